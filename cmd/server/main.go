@@ -1,12 +1,18 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/quckapp/bookmark-service/internal/config"
 	"github.com/quckapp/bookmark-service/internal/handler"
+	"github.com/quckapp/bookmark-service/internal/model"
 	"github.com/quckapp/bookmark-service/internal/repository"
 	"github.com/quckapp/bookmark-service/internal/service"
 	goauth "github.com/quckapp/go-auth"
@@ -33,10 +39,16 @@ func main() {
 
 	// Initialize repositories
 	bookmarkRepo := repository.NewBookmarkRepository(db)
+	if filled, err := repository.BackfillCanonicalURLs(db); err != nil {
+		logger.Error("Failed to backfill canonical URLs", zap.Int("filled", filled), zap.Error(err))
+	} else if filled > 0 {
+		logger.Info("Backfilled canonical URLs", zap.Int("count", filled))
+	}
 	folderRepo := repository.NewFolderRepository(db)
 	tagRepo := repository.NewTagRepository(db)
 	collectionRepo := repository.NewCollectionRepository(db)
 	sharingRepo := repository.NewSharingRepository(db)
+	memberRepo := repository.NewCollectionMemberRepository(db)
 	noteRepo := repository.NewNoteRepository(db)
 	reminderRepo := repository.NewReminderRepository(db)
 	favoriteRepo := repository.NewFavoriteRepository(db)
@@ -48,31 +60,152 @@ func main() {
 	versionRepo := repository.NewVersionRepository(db)
 	expirationRepo := repository.NewExpirationRepository(db)
 	templateRepo := repository.NewTemplateRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	searchRepo := repository.NewSearchRepository(db)
+	backupRepo := repository.NewBackupRepository(db)
+	importJobRepo := repository.NewImportJobRepository(db)
+	bulkRepo := repository.NewBulkRepository(db)
+	smartFolderRepo := repository.NewSmartFolderRepository(db)
+	ruleRepo := repository.NewRuleRepository(db)
+	duplicateRepo := repository.NewDuplicateRepository(db)
+	linkCheckRepo := repository.NewLinkCheckRepository(db)
+	archiveRepo := repository.NewArchiveRepository(db)
+	shareLinkRepo := repository.NewShareLinkRepository(db)
+	transactor := repository.NewTransactor(db)
 
 	// Initialize services
-	bookmarkService := service.NewBookmarkService(bookmarkRepo, folderRepo, redisClient, logger)
-	folderService := service.NewFolderService(folderRepo, logger)
-	tagService := service.NewTagService(tagRepo, logger)
-	collectionService := service.NewCollectionService(collectionRepo, logger)
-	sharingService := service.NewSharingService(sharingRepo, bookmarkRepo, logger)
+	accessService := service.NewAccessService(
+		bookmarkRepo, folderRepo, tagRepo, collectionRepo, noteRepo, commentRepo,
+		reminderRepo, versionRepo, readLaterRepo, templateRepo, sharingRepo, memberRepo, smartFolderRepo, ruleRepo,
+	)
+	previewFetcher := service.NewPreviewFetcher(service.PreviewFetcherConfig{
+		Timeout:      cfg.PreviewFetchTimeout,
+		MaxBodyBytes: cfg.PreviewMaxBodyBytes,
+		MaxRedirects: cfg.PreviewMaxRedirects,
+	})
+	previewQueue := service.NewRedisPreviewQueue(redisClient)
+	previewService := service.NewPreviewService(previewRepo, previewFetcher, previewQueue, accessService, service.PreviewPolicy{
+		MaxAttempts:    cfg.PreviewMaxAttempts,
+		RetryBaseDelay: cfg.PreviewRetryBaseDelay,
+		RefreshTTL:     cfg.PreviewRefreshTTL,
+		Lease:          cfg.PreviewLease,
+	}, logger)
+	activityRecorder := service.NewActivityRecorder(activityRepo, logger)
+	eventBus := service.NewOutboxEventBus(outboxRepo, nil)
+	versionService := service.NewVersionService(versionRepo, bookmarkRepo, cfg.VersionRetention, activityRecorder, transactor, logger)
+	ruleEngine := service.NewRuleEngine(ruleRepo, bulkRepo, folderRepo, logger)
+	bookmarkService := service.NewBookmarkService(
+		bookmarkRepo, folderRepo, previewService, versionService, ruleEngine, activityRecorder, eventBus, transactor, redisClient, logger,
+	)
+	folderService := service.NewFolderService(folderRepo, activityRecorder, eventBus, transactor, redisClient, cfg.FolderMaxDepth, logger)
+	smartFolderService := service.NewSmartFolderService(smartFolderRepo, searchRepo, nil, logger)
+	tagService := service.NewTagService(tagRepo, activityRecorder, logger)
+	collectionService := service.NewCollectionService(collectionRepo, accessService, logger)
+	memberService := service.NewCollectionMemberService(memberRepo, collectionRepo, eventBus, transactor, logger)
+	sharePasswordFailures := service.NewRedisRateLimiter(redisClient, "ratelimit:share-link-password", cfg.ShareLinkPasswordAttempts, cfg.ShareLinkPasswordWindow)
+	shareLinkService := service.NewShareLinkService(shareLinkRepo, collectionRepo, previewRepo, sharePasswordFailures, logger)
+	feedService := service.NewFeedService(collectionRepo, previewRepo, logger)
+	shareLinkLimiter := service.NewRedisRateLimiter(redisClient, "ratelimit:share-link", cfg.ShareLinkRateLimit, cfg.ShareLinkRateWindow)
+	sharingService := service.NewSharingService(
+		sharingRepo, bookmarkRepo, folderRepo, collectionRepo, activityRecorder, eventBus, transactor, logger,
+	)
 	noteService := service.NewNoteService(noteRepo, logger)
-	reminderService := service.NewBookmarkReminderService(reminderRepo, logger)
-	favoriteService := service.NewFavoriteService(favoriteRepo, logger)
+	reminderService := service.NewBookmarkReminderService(reminderRepo, activityRecorder, logger)
+	favoriteService := service.NewFavoriteService(favoriteRepo, activityRecorder, logger)
 	analyticsService := service.NewBookmarkAnalyticsService(
 		analyticsRepo, bookmarkRepo, folderRepo, tagRepo, collectionRepo, activityRepo, logger,
 	)
-	previewService := service.NewPreviewService(previewRepo, logger)
-	readLaterService := service.NewReadLaterService(readLaterRepo, logger)
-	commentService := service.NewCommentService(commentRepo, logger)
-	versionService := service.NewVersionService(versionRepo, bookmarkRepo, logger)
-	expirationService := service.NewExpirationService(expirationRepo, logger)
-	templateService := service.NewTemplateService(templateRepo, bookmarkRepo, logger)
+	readLaterService := service.NewReadLaterService(readLaterRepo, accessService, logger)
+	commentService := service.NewCommentService(commentRepo, activityRecorder, logger)
+	expirationService := service.NewExpirationService(expirationRepo, folderRepo, activityRecorder, logger)
+	templateService := service.NewTemplateService(templateRepo, bookmarkRepo, accessService, logger)
+	searchService := service.NewSearchService(searchRepo, logger)
+	importService := service.NewImportService(
+		bookmarkRepo, folderRepo, tagRepo, noteRepo, favoriteRepo, readLaterRepo,
+		previewService, ruleEngine, activityRecorder, eventBus, transactor, redisClient, logger,
+	)
+	backupService := service.NewBackupService(backupRepo, transactor, nil, logger)
+	bulkService := service.NewBulkService(bulkRepo, folderRepo, accessService, activityRecorder, eventBus, transactor, redisClient, logger)
+	ruleService := service.NewRuleService(ruleRepo, bulkRepo, folderRepo, accessService, activityRecorder, eventBus, transactor, redisClient, logger)
+	duplicateService := service.NewDuplicateService(duplicateRepo, bookmarkRepo, bulkRepo, activityRecorder, eventBus, transactor, redisClient, logger)
+	linkChecker := service.NewLinkChecker(service.LinkCheckerConfig{
+		Timeout:      cfg.LinkCheckTimeout,
+		MaxRedirects: cfg.PreviewMaxRedirects,
+		HostInterval: cfg.LinkCheckHostInterval,
+	})
+	linkCheckService := service.NewLinkCheckService(linkCheckRepo, bulkRepo, bookmarkService, linkChecker, service.LinkCheckPolicy{
+		Workers:          cfg.LinkCheckWorkers,
+		RecheckInterval:  cfg.LinkCheckRecheckInterval,
+		RetryBaseDelay:   cfg.LinkCheckRetryBaseDelay,
+		FailureThreshold: cfg.LinkCheckFailureThreshold,
+	}, logger)
+	var archiveStore service.BlobStore
+	switch cfg.ArchiveStore {
+	case "s3":
+		archiveStore, err = service.NewS3BlobStore(service.S3BlobStoreConfig{
+			Endpoint:        cfg.ArchiveS3Endpoint,
+			Region:          cfg.ArchiveS3Region,
+			Bucket:          cfg.ArchiveS3Bucket,
+			AccessKeyID:     cfg.ArchiveS3AccessKey,
+			SecretAccessKey: cfg.ArchiveS3SecretKey,
+		})
+	default:
+		archiveStore, err = service.NewFileBlobStore(cfg.ArchiveDir)
+	}
+	if err != nil {
+		log.Fatalf("Failed to initialize archive store: %v", err)
+	}
+	archiveFetcher := service.NewArchiveFetcher(service.ArchiveFetcherConfig{
+		Timeout:      cfg.ArchiveFetchTimeout,
+		MaxBodyBytes: cfg.ArchiveMaxBodyBytes,
+		MaxRedirects: cfg.PreviewMaxRedirects,
+	})
+	archiveService := service.NewArchiveService(archiveRepo, bookmarkRepo, archiveFetcher, archiveStore, service.ArchivePolicy{
+		Auto:        cfg.ArchiveAuto,
+		MaxAttempts: cfg.ArchiveMaxAttempts,
+	}, logger)
+
+	// Initialize background jobs
+	var notifier service.Notifier
+	switch cfg.NotifierType {
+	case "webhook":
+		notifier = service.NewWebhookNotifier(cfg.NotifierWebhookURL, 10*time.Second)
+	case "redis":
+		notifier = service.NewRedisNotifier(redisClient, cfg.NotifierChannel)
+	default:
+		notifier = service.NewLogNotifier(logger)
+	}
+	reminderDispatcher := service.NewReminderDispatcher(reminderRepo, notifier, eventBus, service.ReminderRetryPolicy{
+		MaxAttempts:    cfg.ReminderMaxAttempts,
+		RetryBaseDelay: cfg.ReminderRetryBaseDelay,
+	}, cfg.ReminderPollInterval, nil, logger)
+	expirationSweeper := service.NewExpirationSweeper(
+		expirationRepo, bookmarkService, collectionRepo, activityRecorder, notifier, service.ExpirationRetryPolicy{
+			MaxAttempts:    cfg.ExpirationMaxAttempts,
+			RetryBaseDelay: cfg.ExpirationRetryDelay,
+		}, cfg.ExpirationSweepInterval, nil, logger,
+	)
+	previewWorker := service.NewPreviewWorker(
+		previewService, previewQueue, cfg.PreviewWorkers, cfg.PreviewSchedulerInterval, nil, logger,
+	)
+	outboxRelay := service.NewOutboxRelay(
+		outboxRepo, redisClient, cfg.EventStream, cfg.EventStreamMaxLen, cfg.OutboxRelayInterval, cfg.OutboxRetention, nil, logger,
+	)
+	importJobRunner := service.NewImportJobRunner(
+		importService, importJobRepo, folderRepo, cfg.ImportWorkers, cfg.ImportQueueSize, cfg.ImportJobHeartbeat, cfg.ImportTempDir, nil, logger,
+	)
+	linkCheckJob := service.NewLinkCheckJob(linkCheckService, cfg.LinkCheckInterval, nil, logger)
+	archiveJob := service.NewArchiveJob(archiveService, cfg.ArchiveInterval, nil, logger)
 
 	// Initialize handlers
 	bookmarkHandler := handler.NewBookmarkHandler(bookmarkService)
 	folderHandler := handler.NewFolderHandler(folderService)
+	smartFolderHandler := handler.NewSmartFolderHandler(smartFolderService)
 	tagHandler := handler.NewTagHandler(tagService)
 	collectionHandler := handler.NewCollectionHandler(collectionService)
+	memberHandler := handler.NewCollectionMemberHandler(memberService)
+	shareLinkHandler := handler.NewShareLinkHandler(shareLinkService)
+	feedHandler := handler.NewFeedHandler(feedService, shareLinkService)
 	sharingHandler := handler.NewSharingHandler(sharingService)
 	noteHandler := handler.NewNoteHandler(noteService)
 	reminderHandler := handler.NewReminderHandler(reminderService)
@@ -84,12 +217,26 @@ func main() {
 	versionHandler := handler.NewVersionHandler(versionService)
 	expirationHandler := handler.NewExpirationHandler(expirationService)
 	templateHandler := handler.NewTemplateHandler(templateService)
+	searchHandler := handler.NewSearchHandler(searchService)
+	importHandler := handler.NewImportHandler(importService, cfg.ImportMaxBytes)
+	backupHandler := handler.NewBackupHandler(backupService, cfg.RestoreMaxBytes)
+	importJobHandler := handler.NewImportJobHandler(importJobRunner, cfg.ImportMaxBytes)
+	bulkHandler := handler.NewBulkHandler(bulkService)
+	ruleHandler := handler.NewRuleHandler(ruleService)
+	duplicateHandler := handler.NewDuplicateHandler(duplicateService)
+	linkCheckHandler := handler.NewLinkCheckHandler(linkCheckService)
+	archiveHandler := handler.NewArchiveHandler(archiveService)
 
 	// Setup router with shared middleware
 	logrusLogger := logrus.New()
 	logrusLogger.SetFormatter(&logrus.JSONFormatter{})
 
 	router := gin.New()
+	// Client IPs key the rate limits, so X-Forwarded-For is only believed
+	// from known proxies
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 	router.Use(gin.Recovery())
 	router.Use(goauth.RequestID())
 	router.Use(goauth.CORS())
@@ -106,147 +253,226 @@ func main() {
 		c.JSON(200, gin.H{"status": "ready"})
 	})
 
+	// Public share links (no auth required)
+	public := router.Group("/api/v1/public/bookmark-collections", handler.RateLimit(shareLinkLimiter, "view"))
+	{
+		public.GET("/:token", shareLinkHandler.View)
+		public.GET("/:token/feed/:format", feedHandler.SharedCollection)
+	}
+
 	// Auth middleware configuration
 	authCfg := goauth.DefaultConfig(cfg.JWTSecret)
+	authz := handler.NewAuthorizer(accessService)
 
 	// Authenticated API routes
 	authenticated := router.Group("")
-	authenticated.Use(goauth.Auth(authCfg))
+	authenticated.Use(goauth.Auth(authCfg), authz.Identify())
 
 	api := authenticated.Group("/api/v1/bookmarks")
 	{
 		api.POST("", bookmarkHandler.Create)
-		api.GET("/:id", bookmarkHandler.GetByID)
-		api.GET("/user/:userId", bookmarkHandler.GetByUser)
-		api.GET("/user/:userId/workspace/:workspaceId", bookmarkHandler.GetByUserAndWorkspace)
-		api.PUT("/:id", bookmarkHandler.Update)
-		api.DELETE("/:id", bookmarkHandler.Delete)
-		api.POST("/:id/move", bookmarkHandler.MoveToFolder)
-		api.GET("/folder/:folderId", bookmarkHandler.GetByFolder)
+		api.GET("/user/:userId", authz.RequireSelf(), bookmarkHandler.GetByUser)
+		api.GET("/user/:userId/workspace/:workspaceId", authz.RequireSelf(), bookmarkHandler.GetByUserAndWorkspace)
+		api.GET("/folder/:folderId", authz.FolderParamRole("folderId", model.ShareRoleViewer), bookmarkHandler.GetByFolder)
 
 		// Bulk operations
-		api.POST("/bulk-delete", bookmarkHandler.BulkDelete)
-		api.POST("/bulk-move", bookmarkHandler.BulkMove)
-		api.POST("/reorder", bookmarkHandler.Reorder)
+		api.POST("/bulk-delete", bulkHandler.Delete)
+		api.POST("/bulk-move", bulkHandler.Move)
+		api.POST("/bulk-tag", bulkHandler.Tag)
+		api.POST("/bulk-untag", bulkHandler.Untag)
+		api.POST("/bulk-add-to-collection", bulkHandler.AddToCollection)
+		api.POST("/bulk-expiration", bulkHandler.SetExpiration)
+		api.POST("/reorder", bulkHandler.Reorder)
+
+		// Routes open to users the bookmark was shared with
+		api.GET("/:id", authz.BookmarkRole(model.ShareRoleViewer), bookmarkHandler.GetByID)
+		api.PUT("/:id", authz.BookmarkRole(model.ShareRoleEditor), bookmarkHandler.Update)
+		api.GET("/:id/comments", authz.BookmarkRole(model.ShareRoleViewer), commentHandler.GetByBookmark)
+		api.POST("/:id/comments", authz.BookmarkRole(model.ShareRoleCommenter), commentHandler.Create)
+		api.PUT("/:id/comments/:commentId", authz.BookmarkRole(model.ShareRoleCommenter), authz.Comment(), commentHandler.Update)
+		api.DELETE("/:id/comments/:commentId", authz.BookmarkRole(model.ShareRoleCommenter), authz.Comment(), commentHandler.Delete)
+	}
+
+	bookmark := api.Group("/:id", authz.Bookmark())
+	{
+		bookmark.DELETE("", bookmarkHandler.Delete)
+		bookmark.POST("/move", bookmarkHandler.MoveToFolder)
+		bookmark.PUT("/position", bookmarkHandler.MoveNextTo)
 
 		// Tags on bookmarks
-		api.POST("/:id/tags", tagHandler.TagBookmark)
-		api.PUT("/:id/tags", tagHandler.ReplaceBookmarkTags)
-		api.DELETE("/:id/tags/:tagId", tagHandler.UntagBookmark)
-		api.GET("/:id/tags", tagHandler.GetBookmarkTags)
+		bookmark.POST("/tags", tagHandler.TagBookmark)
+		bookmark.PUT("/tags", tagHandler.ReplaceBookmarkTags)
+		bookmark.DELETE("/tags/:tagId", tagHandler.UntagBookmark)
+		bookmark.GET("/tags", tagHandler.GetBookmarkTags)
 
 		// Notes on bookmarks
-		api.POST("/:id/notes/:userId", noteHandler.Create)
-		api.GET("/:id/notes", noteHandler.GetByBookmark)
-		api.PUT("/:id/notes/:noteId", noteHandler.Update)
-		api.DELETE("/:id/notes/:noteId", noteHandler.Delete)
-		api.GET("/:id/notes/pinned", noteHandler.GetPinned)
+		bookmark.POST("/notes", noteHandler.Create)
+		bookmark.GET("/notes", noteHandler.GetByBookmark)
+		bookmark.PUT("/notes/:noteId", authz.Note(), noteHandler.Update)
+		bookmark.DELETE("/notes/:noteId", authz.Note(), noteHandler.Delete)
+		bookmark.GET("/notes/pinned", noteHandler.GetPinned)
 
 		// Reminders on bookmarks
-		api.POST("/:id/reminders/:userId", reminderHandler.Create)
-		api.GET("/:id/reminders", reminderHandler.GetByBookmark)
-		api.POST("/:id/reminders/:reminderId/cancel", reminderHandler.Cancel)
-		api.DELETE("/:id/reminders/:reminderId", reminderHandler.Delete)
+		bookmark.POST("/reminders", reminderHandler.Create)
+		bookmark.GET("/reminders", reminderHandler.GetByBookmark)
+		bookmark.POST("/reminders/:reminderId/cancel", authz.Reminder(), reminderHandler.Cancel)
+		bookmark.DELETE("/reminders/:reminderId", authz.Reminder(), reminderHandler.Delete)
 
 		// Favorites on bookmarks
-		api.POST("/:id/favorite/:userId", favoriteHandler.Add)
-		api.DELETE("/:id/favorite/:userId", favoriteHandler.Remove)
-		api.GET("/:id/favorite/:userId", favoriteHandler.IsFavorite)
+		bookmark.POST("/favorite", favoriteHandler.Add)
+		bookmark.DELETE("/favorite", favoriteHandler.Remove)
+		bookmark.GET("/favorite", favoriteHandler.IsFavorite)
 
 		// Activity on bookmarks
-		api.GET("/:id/activity", analyticsHandler.GetBookmarkActivity)
+		bookmark.GET("/activity", analyticsHandler.GetBookmarkActivity)
 
 		// Link Previews on bookmarks
-		api.POST("/:id/preview", previewHandler.Generate)
-		api.GET("/:id/preview", previewHandler.Get)
-
-		// Comments on bookmarks
-		api.POST("/:id/comments/:userId", commentHandler.Create)
-		api.GET("/:id/comments", commentHandler.GetByBookmark)
-		api.PUT("/:id/comments/:commentId", commentHandler.Update)
-		api.DELETE("/:id/comments/:commentId", commentHandler.Delete)
+		bookmark.POST("/preview", previewHandler.Generate)
+		bookmark.GET("/preview", previewHandler.Get)
 
 		// Version History on bookmarks
-		api.GET("/:id/versions", versionHandler.List)
-		api.GET("/:id/versions/:versionId", versionHandler.Get)
-		api.POST("/:id/versions/:versionId/restore", versionHandler.Restore)
+		bookmark.GET("/versions", versionHandler.List)
+		bookmark.GET("/versions/diff", versionHandler.Diff)
+		bookmark.GET("/versions/:versionId", authz.Version(), versionHandler.Get)
+		bookmark.POST("/versions/:versionId/restore", authz.Version(), versionHandler.Restore)
 
 		// Expiration on bookmarks
-		api.POST("/:id/expiration/:userId", expirationHandler.Set)
-		api.GET("/:id/expiration", expirationHandler.Get)
-		api.DELETE("/:id/expiration", expirationHandler.Remove)
+		bookmark.POST("/expiration", expirationHandler.Set)
+		bookmark.GET("/expiration", expirationHandler.Get)
+		bookmark.DELETE("/expiration", expirationHandler.Remove)
+
+		// Link health on bookmarks
+		bookmark.GET("/link-check", linkCheckHandler.Get)
+		bookmark.POST("/link-check/follow-redirect", linkCheckHandler.FollowRedirect)
+
+		// Page archive
+		bookmark.POST("/archive", archiveHandler.Request)
+		bookmark.GET("/archive", archiveHandler.Get)
 	}
 
 	folders := authenticated.Group("/api/v1/bookmark-folders")
 	{
 		folders.POST("", folderHandler.Create)
-		folders.GET("/:id", folderHandler.GetByID)
-		folders.GET("/user/:userId", folderHandler.GetByUser)
-		folders.GET("/user/:userId/workspace/:workspaceId", folderHandler.GetByUserAndWorkspace)
-		folders.PUT("/:id", folderHandler.Update)
-		folders.DELETE("/:id", folderHandler.Delete)
+		folders.GET("/:id", authz.FolderRole(model.ShareRoleViewer), folderHandler.GetByID)
+		folders.GET("/user/:userId", authz.RequireSelf(), folderHandler.GetByUser)
+		folders.GET("/user/:userId/tree", authz.RequireSelf(), folderHandler.GetTree)
+		folders.GET("/user/:userId/workspace/:workspaceId", authz.RequireSelf(), folderHandler.GetByUserAndWorkspace)
+		folders.PUT("/:id", authz.Folder(), folderHandler.Update)
+		folders.POST("/:id/move", authz.Folder(), folderHandler.Move)
+		folders.PUT("/:id/position", authz.Folder(), folderHandler.MoveNextTo)
+		folders.DELETE("/:id", authz.Folder(), folderHandler.Delete)
 		folders.POST("/reorder", folderHandler.Reorder)
 	}
 
+	// Smart folders
+	smartFolders := authenticated.Group("/api/v1/bookmark-smart-folders")
+	{
+		smartFolders.POST("", smartFolderHandler.Create)
+		smartFolders.GET("/:id", authz.SmartFolder(), smartFolderHandler.GetByID)
+		smartFolders.GET("/:id/bookmarks", authz.SmartFolder(), smartFolderHandler.GetBookmarks)
+		smartFolders.GET("/user/:userId", authz.RequireSelf(), smartFolderHandler.GetByUser)
+		smartFolders.GET("/user/:userId/workspace/:workspaceId", authz.RequireSelf(), smartFolderHandler.GetByUserAndWorkspace)
+		smartFolders.PUT("/:id", authz.SmartFolder(), smartFolderHandler.Update)
+		smartFolders.DELETE("/:id", authz.SmartFolder(), smartFolderHandler.Delete)
+	}
+
+	// Rules
+	rules := authenticated.Group("/api/v1/bookmark-rules")
+	{
+		rules.POST("", ruleHandler.Create)
+		rules.GET("/user/:userId", authz.RequireSelf(), ruleHandler.GetByUser)
+		rules.POST("/reorder", ruleHandler.Reorder)
+		rules.POST("/dry-run", ruleHandler.DryRun)
+		rules.POST("/reapply", ruleHandler.Reapply)
+		rules.GET("/:id", authz.Rule(), ruleHandler.GetByID)
+		rules.PUT("/:id", authz.Rule(), ruleHandler.Update)
+		rules.DELETE("/:id", authz.Rule(), ruleHandler.Delete)
+	}
+
 	// Tags
 	tags := authenticated.Group("/api/v1/bookmark-tags")
 	{
 		tags.POST("", tagHandler.Create)
-		tags.GET("/:id", tagHandler.GetByID)
-		tags.GET("/user/:userId", tagHandler.GetByUser)
-		tags.GET("/user/:userId/workspace/:workspaceId", tagHandler.GetByUserAndWorkspace)
-		tags.PUT("/:id", tagHandler.Update)
-		tags.DELETE("/:id", tagHandler.Delete)
-		tags.GET("/:id/bookmarks", tagHandler.GetBookmarksByTag)
+		tags.GET("/:id", authz.Tag(), tagHandler.GetByID)
+		tags.GET("/user/:userId", authz.RequireSelf(), tagHandler.GetByUser)
+		tags.GET("/user/:userId/workspace/:workspaceId", authz.RequireSelf(), tagHandler.GetByUserAndWorkspace)
+		tags.PUT("/:id", authz.Tag(), tagHandler.Update)
+		tags.DELETE("/:id", authz.Tag(), tagHandler.Delete)
+		tags.GET("/:id/bookmarks", authz.Tag(), tagHandler.GetBookmarksByTag)
 	}
 
 	// Collections
 	collections := authenticated.Group("/api/v1/bookmark-collections")
 	{
 		collections.POST("", collectionHandler.Create)
-		collections.GET("/:id", collectionHandler.GetByID)
-		collections.GET("/user/:userId", collectionHandler.GetByUser)
-		collections.GET("/user/:userId/workspace/:workspaceId", collectionHandler.GetByUserAndWorkspace)
+		collections.GET("/:id", authz.CollectionRead(), collectionHandler.GetByID)
+		collections.GET("/user/:userId", authz.RequireSelf(), collectionHandler.GetByUser)
+		collections.GET("/user/:userId/workspace/:workspaceId", authz.RequireSelf(), collectionHandler.GetByUserAndWorkspace)
 		collections.GET("/public/workspace/:workspaceId", collectionHandler.GetPublic)
-		collections.PUT("/:id", collectionHandler.Update)
-		collections.DELETE("/:id", collectionHandler.Delete)
-		collections.POST("/:id/bookmarks", collectionHandler.AddBookmarks)
-		collections.DELETE("/:id/bookmarks/:bookmarkId", collectionHandler.RemoveBookmark)
+		collections.PUT("/:id", authz.CollectionRole(model.ShareRoleEditor), collectionHandler.Update)
+		collections.DELETE("/:id", authz.Collection(), collectionHandler.Delete)
+		collections.POST("/:id/bookmarks", authz.CollectionRole(model.ShareRoleEditor), collectionHandler.AddBookmarks)
+		collections.DELETE("/:id/bookmarks/:bookmarkId", authz.CollectionRole(model.ShareRoleEditor), collectionHandler.RemoveBookmark)
+		collections.PUT("/:id/bookmarks/:bookmarkId/position", authz.CollectionRole(model.ShareRoleEditor), collectionHandler.MoveBookmark)
+		// Access is checked by the service, which also admits shared users
 		collections.GET("/:id/bookmarks", collectionHandler.GetBookmarks)
+		collections.GET("/:id/feed/:format", authz.CollectionRead(), feedHandler.Collection)
+		collections.GET("/invitations/user/:userId", authz.RequireSelf(), memberHandler.GetPendingInvitations)
+		collections.POST("/:id/members", authz.Collection(), memberHandler.Invite)
+		collections.GET("/:id/members", authz.CollectionRole(model.ShareRoleViewer), memberHandler.GetMembers)
+		collections.PUT("/:id/members/:memberId", authz.Collection(), authz.CollectionMember(), memberHandler.UpdateRole)
+		collections.DELETE("/:id/members/:memberId", authz.CollectionMember(), memberHandler.Remove)
+		collections.POST("/:id/members/:memberId/accept", authz.CollectionInvitee(), memberHandler.Accept)
+		collections.POST("/:id/members/:memberId/decline", authz.CollectionInvitee(), memberHandler.Decline)
+		collections.POST("/:id/share-links", authz.Collection(), shareLinkHandler.Create)
+		collections.GET("/:id/share-links", authz.Collection(), shareLinkHandler.List)
+		collections.POST("/:id/share-links/:linkId/revoke", authz.Collection(), shareLinkHandler.Revoke)
 	}
 
 	// Sharing
 	sharing := authenticated.Group("/api/v1/bookmark-shares")
 	{
-		sharing.POST("/user/:userId", sharingHandler.Share)
-		sharing.GET("/received/:userId", sharingHandler.GetSharedWithUser)
-		sharing.GET("/sent/:userId", sharingHandler.GetSharedByUser)
-		sharing.POST("/:id/accept", sharingHandler.Accept)
-		sharing.POST("/:id/decline", sharingHandler.Decline)
-		sharing.GET("/pending/:userId/count", sharingHandler.GetPendingCount)
+		sharing.POST("/user/:userId", authz.RequireSelf(), sharingHandler.Share)
+		sharing.GET("/received/:userId", authz.RequireSelf(), sharingHandler.GetSharedWithUser)
+		sharing.GET("/sent/:userId", authz.RequireSelf(), sharingHandler.GetSharedByUser)
+		sharing.POST("/:id/accept", authz.ShareRecipient(), sharingHandler.Accept)
+		sharing.POST("/:id/decline", authz.ShareRecipient(), sharingHandler.Decline)
+		sharing.PUT("/:id", authz.ShareOwner(), sharingHandler.Update)
+		sharing.POST("/:id/revoke", authz.ShareOwner(), sharingHandler.Revoke)
+		sharing.GET("/pending/:userId/count", authz.RequireSelf(), sharingHandler.GetPendingCount)
 	}
 
 	// User-level resources
-	users := authenticated.Group("/api/v1/bookmark-users")
+	users := authenticated.Group("/api/v1/bookmark-users/:userId", authz.RequireSelf())
 	{
 		// Notes
-		users.GET("/:userId/notes", noteHandler.GetByUser)
+		users.GET("/notes", noteHandler.GetByUser)
 
 		// Reminders
-		users.GET("/:userId/reminders", reminderHandler.GetByUser)
-		users.GET("/:userId/reminders/pending", reminderHandler.GetPending)
+		users.GET("/reminders", reminderHandler.GetByUser)
+		users.GET("/reminders/pending", reminderHandler.GetPending)
 
 		// Favorites
-		users.GET("/:userId/favorites", favoriteHandler.GetFavorites)
+		users.GET("/favorites", favoriteHandler.GetFavorites)
 
 		// Analytics and Stats
-		users.GET("/:userId/stats", analyticsHandler.GetStats)
-		users.GET("/:userId/recent", analyticsHandler.GetRecent)
-		users.GET("/:userId/search", analyticsHandler.Search)
-		users.GET("/:userId/duplicates", analyticsHandler.CheckDuplicate)
-		users.GET("/:userId/export", analyticsHandler.Export)
-		users.POST("/:userId/import/:workspaceId", analyticsHandler.Import)
-		users.GET("/:userId/activity", analyticsHandler.GetActivity)
+		users.GET("/stats", analyticsHandler.GetStats)
+		users.GET("/recent", analyticsHandler.GetRecent)
+		users.GET("/search", searchHandler.Search)
+		users.GET("/duplicates", analyticsHandler.CheckDuplicate)
+		users.GET("/duplicates/report", duplicateHandler.Report)
+		users.POST("/duplicates/merge", duplicateHandler.Merge)
+		users.GET("/export", analyticsHandler.Export)
+		users.POST("/import/:workspaceId", analyticsHandler.Import)
+		users.GET("/export/netscape", importHandler.ExportNetscape)
+		users.POST("/import/:workspaceId/netscape", importHandler.ImportNetscape)
+		users.POST("/import/:workspaceId/jobs", importJobHandler.CreateJob)
+		users.GET("/import-jobs", importJobHandler.ListJobs)
+		users.GET("/import-jobs/:jobId", importJobHandler.GetJob)
+		users.GET("/backup", backupHandler.Backup)
+		users.POST("/restore", backupHandler.Restore)
+		users.GET("/activity", analyticsHandler.GetActivity)
+		users.GET("/broken-links", linkCheckHandler.GetBrokenLinks)
 	}
 
 	// Link Previews
@@ -259,27 +485,27 @@ func main() {
 	// Read Later
 	readLater := authenticated.Group("/api/v1/bookmark-readlater")
 	{
-		readLater.POST("/:userId", readLaterHandler.Add)
-		readLater.GET("/:userId", readLaterHandler.List)
-		readLater.PUT("/:id/status", readLaterHandler.UpdateStatus)
-		readLater.GET("/:userId/stats", readLaterHandler.GetStats)
+		readLater.POST("/:userId", authz.RequireSelf(), readLaterHandler.Add)
+		readLater.GET("/:userId", authz.RequireSelf(), readLaterHandler.List)
+		readLater.PUT("/:id/status", authz.ReadLaterItem(), readLaterHandler.UpdateStatus)
+		readLater.GET("/:userId/stats", authz.RequireSelf(), readLaterHandler.GetStats)
 	}
 
 	// Expirations
 	expirations := authenticated.Group("/api/v1/bookmark-expirations")
 	{
-		expirations.GET("/:userId/expiring", expirationHandler.GetExpiring)
+		expirations.GET("/:userId/expiring", authz.RequireSelf(), expirationHandler.GetExpiring)
 	}
 
 	// Templates
 	templates := authenticated.Group("/api/v1/bookmark-templates")
 	{
 		templates.POST("", templateHandler.Create)
-		templates.GET("/user/:userId", templateHandler.GetByUser)
-		templates.GET("/user/:userId/workspace/:workspaceId", templateHandler.GetByUserAndWorkspace)
-		templates.PUT("/:id", templateHandler.Update)
-		templates.DELETE("/:id", templateHandler.Delete)
-		templates.POST("/:id/apply", templateHandler.Apply)
+		templates.GET("/user/:userId", authz.RequireSelf(), templateHandler.GetByUser)
+		templates.GET("/user/:userId/workspace/:workspaceId", authz.RequireSelf(), templateHandler.GetByUserAndWorkspace)
+		templates.PUT("/:id", authz.Template(), templateHandler.Update)
+		templates.DELETE("/:id", authz.Template(), templateHandler.Delete)
+		templates.POST("/:id/apply", authz.Template(), templateHandler.Apply)
 	}
 
 	// Start server
@@ -288,8 +514,43 @@ func main() {
 		port = "5010"
 	}
 
-	logger.Info("Starting bookmark service", zap.String("port", port))
-	if err := router.Run(":" + port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	reminderDispatcher.Start(ctx)
+	expirationSweeper.Start(ctx)
+	previewWorker.Start(ctx)
+	outboxRelay.Start(ctx)
+	importJobRunner.Start(ctx)
+	linkCheckJob.Start(ctx)
+	archiveJob.Start(ctx)
+
+	srv := &http.Server{
+		Addr:    ":" + port,
+		Handler: router,
 	}
+
+	go func() {
+		logger.Info("Starting bookmark service", zap.String("port", port))
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	logger.Info("Shutting down bookmark service")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Server forced to shutdown", zap.Error(err))
+	}
+
+	reminderDispatcher.Stop()
+	expirationSweeper.Stop()
+	previewWorker.Stop()
+	outboxRelay.Stop()
+	importJobRunner.Stop()
+	linkCheckJob.Stop()
+	archiveJob.Stop()
 }
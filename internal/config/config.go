@@ -3,6 +3,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 	"gorm.io/driver/mysql"
@@ -19,6 +22,76 @@ type Config struct {
 	RedisHost  string
 	RedisPort  string
 	JWTSecret  string
+
+	// TrustedProxies are the addresses or CIDRs of the proxies whose
+	// X-Forwarded-For is believed. Without any, the client IP is always the
+	// connection's peer address.
+	TrustedProxies []string
+
+	ReminderPollInterval    time.Duration
+	ReminderMaxAttempts     int
+	ReminderRetryBaseDelay  time.Duration
+	ExpirationSweepInterval time.Duration
+	ExpirationMaxAttempts   int
+	ExpirationRetryDelay    time.Duration
+	NotifierType            string
+	NotifierWebhookURL      string
+	NotifierChannel         string
+
+	PreviewFetchTimeout time.Duration
+	PreviewMaxBodyBytes int64
+	PreviewMaxRedirects int
+
+	PreviewWorkers           int
+	PreviewMaxAttempts       int
+	PreviewRetryBaseDelay    time.Duration
+	PreviewRefreshTTL        time.Duration
+	PreviewSchedulerInterval time.Duration
+	PreviewLease             time.Duration
+
+	VersionRetention int
+	FolderMaxDepth   int
+
+	EventStream         string
+	EventStreamMaxLen   int64
+	OutboxRelayInterval time.Duration
+	OutboxRetention     time.Duration
+
+	ImportMaxBytes  int64
+	RestoreMaxBytes int64
+
+	ImportWorkers      int
+	ImportQueueSize    int
+	ImportJobHeartbeat time.Duration
+	ImportTempDir      string
+
+	LinkCheckInterval         time.Duration
+	LinkCheckWorkers          int
+	LinkCheckTimeout          time.Duration
+	LinkCheckHostInterval     time.Duration
+	LinkCheckRecheckInterval  time.Duration
+	LinkCheckRetryBaseDelay   time.Duration
+	LinkCheckFailureThreshold int
+
+	ArchiveAuto         bool
+	ArchiveInterval     time.Duration
+	ArchiveFetchTimeout time.Duration
+	ArchiveMaxBodyBytes int64
+	ArchiveMaxAttempts  int
+	ArchiveStore        string
+	ArchiveDir          string
+	ArchiveS3Endpoint   string
+	ArchiveS3Region     string
+	ArchiveS3Bucket     string
+	ArchiveS3AccessKey  string
+	ArchiveS3SecretKey  string
+
+	ShareLinkRateLimit  int
+	ShareLinkRateWindow time.Duration
+	// ShareLinkPasswordAttempts wrong passwords lock a link for the rest of
+	// its ShareLinkPasswordWindow.
+	ShareLinkPasswordAttempts int
+	ShareLinkPasswordWindow   time.Duration
 }
 
 func Load() *Config {
@@ -31,6 +104,72 @@ func Load() *Config {
 		RedisHost:  getEnv("REDIS_HOST", "localhost"),
 		RedisPort:  getEnv("REDIS_PORT", "6379"),
 		JWTSecret:  getEnv("JWT_SECRET", "dev_jwt_secret_change_in_production_min_32_chars"),
+
+		TrustedProxies: getEnvList("TRUSTED_PROXIES"),
+
+		ReminderPollInterval:    getEnvDuration("REMINDER_POLL_INTERVAL", 30*time.Second),
+		ReminderMaxAttempts:     getEnvInt("REMINDER_MAX_ATTEMPTS", 5),
+		ReminderRetryBaseDelay:  getEnvDuration("REMINDER_RETRY_BASE_DELAY", time.Minute),
+		ExpirationSweepInterval: getEnvDuration("EXPIRATION_SWEEP_INTERVAL", time.Minute),
+		ExpirationMaxAttempts:   getEnvInt("EXPIRATION_MAX_ATTEMPTS", 5),
+		ExpirationRetryDelay:    getEnvDuration("EXPIRATION_RETRY_BASE_DELAY", time.Minute),
+		NotifierType:            getEnv("NOTIFIER_TYPE", "log"),
+		NotifierWebhookURL:      getEnv("NOTIFIER_WEBHOOK_URL", ""),
+		NotifierChannel:         getEnv("NOTIFIER_CHANNEL", "bookmark:notifications"),
+
+		PreviewFetchTimeout: getEnvDuration("PREVIEW_FETCH_TIMEOUT", 10*time.Second),
+		PreviewMaxBodyBytes: int64(getEnvInt("PREVIEW_MAX_BODY_BYTES", 2<<20)),
+		PreviewMaxRedirects: getEnvInt("PREVIEW_MAX_REDIRECTS", 5),
+
+		PreviewWorkers:           getEnvInt("PREVIEW_WORKERS", 2),
+		PreviewMaxAttempts:       getEnvInt("PREVIEW_MAX_ATTEMPTS", 5),
+		PreviewRetryBaseDelay:    getEnvDuration("PREVIEW_RETRY_BASE_DELAY", 30*time.Second),
+		PreviewRefreshTTL:        getEnvDuration("PREVIEW_REFRESH_TTL", 7*24*time.Hour),
+		PreviewSchedulerInterval: getEnvDuration("PREVIEW_SCHEDULER_INTERVAL", time.Minute),
+		PreviewLease:             getEnvDuration("PREVIEW_LEASE", 10*time.Minute),
+
+		VersionRetention: getEnvInt("VERSION_RETENTION", 50),
+		FolderMaxDepth:   getEnvInt("FOLDER_MAX_DEPTH", 10),
+
+		EventStream:         getEnv("EVENT_STREAM", "quckapp:bookmark:events"),
+		EventStreamMaxLen:   int64(getEnvInt("EVENT_STREAM_MAXLEN", 100000)),
+		OutboxRelayInterval: getEnvDuration("OUTBOX_RELAY_INTERVAL", time.Second),
+		OutboxRetention:     getEnvDuration("OUTBOX_RETENTION", 24*time.Hour),
+
+		ImportMaxBytes:  int64(getEnvInt("IMPORT_MAX_BYTES", 50<<20)),
+		RestoreMaxBytes: int64(getEnvInt("RESTORE_MAX_BYTES", 512<<20)),
+
+		ImportWorkers:      getEnvInt("IMPORT_WORKERS", 2),
+		ImportQueueSize:    getEnvInt("IMPORT_QUEUE_SIZE", 32),
+		ImportJobHeartbeat: getEnvDuration("IMPORT_JOB_HEARTBEAT", 30*time.Second),
+		ImportTempDir:      getEnv("IMPORT_TEMP_DIR", os.TempDir()),
+
+		LinkCheckInterval:         getEnvDuration("LINK_CHECK_INTERVAL", 5*time.Minute),
+		LinkCheckWorkers:          getEnvInt("LINK_CHECK_WORKERS", 4),
+		LinkCheckTimeout:          getEnvDuration("LINK_CHECK_TIMEOUT", 10*time.Second),
+		LinkCheckHostInterval:     getEnvDuration("LINK_CHECK_HOST_INTERVAL", 2*time.Second),
+		LinkCheckRecheckInterval:  getEnvDuration("LINK_CHECK_RECHECK_INTERVAL", 7*24*time.Hour),
+		LinkCheckRetryBaseDelay:   getEnvDuration("LINK_CHECK_RETRY_BASE_DELAY", time.Hour),
+		LinkCheckFailureThreshold: getEnvInt("LINK_CHECK_FAILURE_THRESHOLD", 3),
+
+		ArchiveAuto:         getEnvBool("ARCHIVE_AUTO", false),
+		ArchiveInterval:     getEnvDuration("ARCHIVE_INTERVAL", time.Minute),
+		ArchiveFetchTimeout: getEnvDuration("ARCHIVE_FETCH_TIMEOUT", 30*time.Second),
+		ArchiveMaxBodyBytes: int64(getEnvInt("ARCHIVE_MAX_BODY_BYTES", 5<<20)),
+		ArchiveMaxAttempts:  getEnvInt("ARCHIVE_MAX_ATTEMPTS", 3),
+		ArchiveStore:        getEnv("ARCHIVE_STORE", "local"),
+		ArchiveDir:          getEnv("ARCHIVE_DIR", "data/archives"),
+		ArchiveS3Endpoint:   getEnv("ARCHIVE_S3_ENDPOINT", "https://s3.amazonaws.com"),
+		ArchiveS3Region:     getEnv("ARCHIVE_S3_REGION", "us-east-1"),
+		ArchiveS3Bucket:     getEnv("ARCHIVE_S3_BUCKET", ""),
+		ArchiveS3AccessKey:  getEnv("ARCHIVE_S3_ACCESS_KEY", ""),
+		ArchiveS3SecretKey:  getEnv("ARCHIVE_S3_SECRET_KEY", ""),
+
+		ShareLinkRateLimit:  getEnvInt("SHARE_LINK_RATE_LIMIT", 60),
+		ShareLinkRateWindow: getEnvDuration("SHARE_LINK_RATE_WINDOW", time.Minute),
+
+		ShareLinkPasswordAttempts: getEnvInt("SHARE_LINK_PASSWORD_ATTEMPTS", 10),
+		ShareLinkPasswordWindow:   getEnvDuration("SHARE_LINK_PASSWORD_WINDOW", 15*time.Minute),
 	}
 }
 
@@ -68,3 +207,41 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	}
+	return defaultValue
+}
+
+// getEnvList splits a comma separated value, ignoring empty entries.
+func getEnvList(key string) []string {
+	var list []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
@@ -1,8 +1,11 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -19,11 +22,7 @@ func NewAnalyticsHandler(service service.BookmarkAnalyticsService) *AnalyticsHan
 }
 
 func (h *AnalyticsHandler) GetStats(c *gin.Context) {
-	userID, err := uuid.Parse(c.Param("userId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
+	userID := callerID(c)
 
 	var workspaceID *uuid.UUID
 	if wsID := c.Query("workspaceId"); wsID != "" {
@@ -41,11 +40,7 @@ func (h *AnalyticsHandler) GetStats(c *gin.Context) {
 }
 
 func (h *AnalyticsHandler) GetRecent(c *gin.Context) {
-	userID, err := uuid.Parse(c.Param("userId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
+	userID := callerID(c)
 
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 
@@ -59,47 +54,30 @@ func (h *AnalyticsHandler) GetRecent(c *gin.Context) {
 }
 
 func (h *AnalyticsHandler) CheckDuplicate(c *gin.Context) {
-	userID, _ := uuid.Parse(c.Param("userId"))
+	userID := callerID(c)
 	targetID, _ := uuid.Parse(c.Query("targetId"))
 	bookmarkType := model.BookmarkType(c.Query("type"))
-
-	result, err := h.service.CheckDuplicate(userID, targetID, bookmarkType)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	targetURL := c.Query("url")
+	if targetURL == "" && targetID == uuid.Nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "url or targetId is required"})
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{"data": result})
-}
-
-func (h *AnalyticsHandler) Search(c *gin.Context) {
-	userID, err := uuid.Parse(c.Param("userId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
-
-	var params model.BookmarkSearchParams
-	if err := c.ShouldBindQuery(&params); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	workspaceID, ok := parseOptionalWorkspaceID(c, c.Query("workspaceId"))
+	if !ok {
 		return
 	}
 
-	bookmarks, total, err := h.service.SearchBookmarks(userID, params)
+	result, err := h.service.CheckDuplicate(userID, workspaceID, targetURL, targetID, bookmarkType)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": bookmarks, "total": total, "page": params.Page, "limit": params.Limit})
+	c.JSON(http.StatusOK, gin.H{"data": result})
 }
 
 func (h *AnalyticsHandler) Export(c *gin.Context) {
-	userID, err := uuid.Parse(c.Param("userId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
+	userID := callerID(c)
 
 	var workspaceID *uuid.UUID
 	if wsID := c.Query("workspaceId"); wsID != "" {
@@ -117,7 +95,7 @@ func (h *AnalyticsHandler) Export(c *gin.Context) {
 }
 
 func (h *AnalyticsHandler) Import(c *gin.Context) {
-	userID, _ := uuid.Parse(c.Param("userId"))
+	userID := callerID(c)
 	workspaceID, _ := uuid.Parse(c.Param("workspaceId"))
 
 	var req model.ImportRequest
@@ -128,7 +106,7 @@ func (h *AnalyticsHandler) Import(c *gin.Context) {
 
 	result, err := h.service.ImportBookmarks(userID, workspaceID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -136,16 +114,17 @@ func (h *AnalyticsHandler) Import(c *gin.Context) {
 }
 
 func (h *AnalyticsHandler) GetActivity(c *gin.Context) {
-	userID, err := uuid.Parse(c.Param("userId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
+	userID := callerID(c)
 
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	filter, err := activityFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	activities, total, err := h.service.GetActivity(userID, page, limit)
+	activities, total, err := h.service.GetActivity(userID, filter, page, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -163,8 +142,13 @@ func (h *AnalyticsHandler) GetBookmarkActivity(c *gin.Context) {
 
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	filter, err := activityFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	activities, total, err := h.service.GetBookmarkActivity(bookmarkID, page, limit)
+	activities, total, err := h.service.GetBookmarkActivity(bookmarkID, filter, page, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -172,3 +156,30 @@ func (h *AnalyticsHandler) GetBookmarkActivity(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"data": activities, "total": total, "page": page, "limit": limit})
 }
+
+// activityFilter reads ?action=a,b&from=&to= with RFC 3339 times; to is exclusive.
+func activityFilter(c *gin.Context) (model.ActivityFilter, error) {
+	var filter model.ActivityFilter
+	if actions := c.Query("action"); actions != "" {
+		for _, action := range strings.Split(actions, ",") {
+			if action = strings.TrimSpace(action); action != "" {
+				filter.Actions = append(filter.Actions, action)
+			}
+		}
+	}
+	for _, bound := range []struct {
+		param string
+		dst   **time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		raw := c.Query(bound.param)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s time, expected RFC 3339", bound.param)
+		}
+		*bound.dst = &t
+	}
+	return filter, nil
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/service"
+)
+
+type ArchiveHandler struct {
+	service service.ArchiveService
+}
+
+func NewArchiveHandler(service service.ArchiveService) *ArchiveHandler {
+	return &ArchiveHandler{service: service}
+}
+
+// Request queues the bookmark's page to be archived.
+func (h *ArchiveHandler) Request(c *gin.Context) {
+	bookmarkID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bookmark ID"})
+		return
+	}
+
+	archive, err := h.service.Request(bookmarkID)
+	if err != nil {
+		c.JSON(archiveErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"data": archive})
+}
+
+// Get returns the archive's status, or with ?format=html|text the archived
+// page itself.
+func (h *ArchiveHandler) Get(c *gin.Context) {
+	bookmarkID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bookmark ID"})
+		return
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		archive, err := h.service.Get(bookmarkID)
+		if err != nil {
+			c.JSON(archiveErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": archive})
+		return
+	}
+	if format != service.ArchiveFormatHTML && format != service.ArchiveFormatText {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be html or text"})
+		return
+	}
+
+	content, err := h.service.GetContent(c.Request.Context(), bookmarkID, format)
+	if err != nil {
+		c.JSON(archiveErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	// The snapshot may be older than a refresh in progress or one that failed
+	c.Header("X-Archive-Status", string(content.Status))
+	c.Header("Last-Modified", content.ArchivedAt.UTC().Format(http.TimeFormat))
+	// The page is sanitized already; the policy keeps anything missed inert
+	c.Header("Content-Security-Policy", "default-src 'none'; img-src http: https:; style-src 'unsafe-inline'; sandbox")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, content.ContentType, content.Data)
+}
+
+func archiveErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrNotArchivable), errors.Is(err, service.ErrURLNotAllowed):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrArchiveNotReady):
+		return http.StatusConflict
+	default:
+		return errorStatus(err, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/service"
+)
+
+const (
+	// AuthUserIDKey is the context key goauth.Auth stores the token subject under.
+	AuthUserIDKey = "userId"
+
+	callerIDKey = "callerId"
+)
+
+// Authorizer provides middleware that resolves the caller from the validated
+// token and checks the caller may act on the resources named in the path.
+type Authorizer struct {
+	access service.AccessService
+}
+
+func NewAuthorizer(access service.AccessService) *Authorizer {
+	return &Authorizer{access: access}
+}
+
+// Identify resolves the caller's user ID. It must run after goauth.Auth.
+func (a *Authorizer) Identify() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var userID uuid.UUID
+		var err error
+		subject, _ := c.Get(AuthUserIDKey)
+		switch v := subject.(type) {
+		case uuid.UUID:
+			userID = v
+		case string:
+			userID, err = uuid.Parse(v)
+		default:
+			err = errors.New("missing subject")
+		}
+		if err != nil || userID == uuid.Nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token subject"})
+			return
+		}
+		c.Set(callerIDKey, userID)
+		c.Next()
+	}
+}
+
+// RequireSelf rejects requests whose :userId path parameter is not the caller.
+func (a *Authorizer) RequireSelf() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(c.Param("userId"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+		if userID != callerID(c) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": service.ErrForbidden.Error()})
+			return
+		}
+		c.Next()
+	}
+}
+
+func (a *Authorizer) Bookmark() gin.HandlerFunc {
+	return a.authorize("id", a.access.AuthorizeBookmark)
+}
+
+// BookmarkRole also admits users the bookmark was shared with in at least role.
+func (a *Authorizer) BookmarkRole(role model.ShareRole) gin.HandlerFunc {
+	return a.authorize("id", func(userID, id uuid.UUID) error {
+		return a.access.AuthorizeBookmarkRole(userID, id, role)
+	})
+}
+
+func (a *Authorizer) Folder() gin.HandlerFunc {
+	return a.authorize("id", a.access.AuthorizeFolder)
+}
+
+// FolderRole also admits users the folder was shared with in at least role.
+func (a *Authorizer) FolderRole(role model.ShareRole) gin.HandlerFunc {
+	return a.FolderParamRole("id", role)
+}
+
+// FolderParam checks a folder named by a path parameter other than :id.
+func (a *Authorizer) FolderParam(param string) gin.HandlerFunc {
+	return a.authorize(param, a.access.AuthorizeFolder)
+}
+
+func (a *Authorizer) FolderParamRole(param string, role model.ShareRole) gin.HandlerFunc {
+	return a.authorize(param, func(userID, id uuid.UUID) error {
+		return a.access.AuthorizeFolderRole(userID, id, role)
+	})
+}
+
+func (a *Authorizer) Tag() gin.HandlerFunc {
+	return a.authorize("id", a.access.AuthorizeTag)
+}
+
+func (a *Authorizer) Collection() gin.HandlerFunc {
+	return a.authorize("id", a.access.AuthorizeCollection)
+}
+
+// CollectionRole also admits users the collection was shared with in at
+// least role.
+func (a *Authorizer) CollectionRole(role model.ShareRole) gin.HandlerFunc {
+	return a.authorize("id", func(userID, id uuid.UUID) error {
+		return a.access.AuthorizeCollectionRole(userID, id, role)
+	})
+}
+
+func (a *Authorizer) CollectionRead() gin.HandlerFunc {
+	return a.authorize("id", a.access.AuthorizeCollectionRead)
+}
+
+func (a *Authorizer) ReadLaterItem() gin.HandlerFunc {
+	return a.authorize("id", a.access.AuthorizeReadLaterItem)
+}
+
+func (a *Authorizer) Template() gin.HandlerFunc {
+	return a.authorize("id", a.access.AuthorizeTemplate)
+}
+
+func (a *Authorizer) SmartFolder() gin.HandlerFunc {
+	return a.authorize("id", a.access.AuthorizeSmartFolder)
+}
+
+func (a *Authorizer) Rule() gin.HandlerFunc {
+	return a.authorize("id", a.access.AuthorizeRule)
+}
+
+func (a *Authorizer) ShareRecipient() gin.HandlerFunc {
+	return a.authorize("id", a.access.AuthorizeShareRecipient)
+}
+
+func (a *Authorizer) ShareOwner() gin.HandlerFunc {
+	return a.authorize("id", a.access.AuthorizeShareOwner)
+}
+
+// Nested resources are checked against the bookmark in :id, so these must
+// run after Bookmark().
+
+func (a *Authorizer) Note() gin.HandlerFunc {
+	return a.authorizeNested("noteId", a.access.AuthorizeNote)
+}
+
+func (a *Authorizer) Comment() gin.HandlerFunc {
+	return a.authorizeNested("commentId", a.access.AuthorizeComment)
+}
+
+func (a *Authorizer) Reminder() gin.HandlerFunc {
+	return a.authorizeNested("reminderId", a.access.AuthorizeReminder)
+}
+
+func (a *Authorizer) Version() gin.HandlerFunc {
+	return a.authorizeNested("versionId", func(_, bookmarkID, versionID uuid.UUID) error {
+		return a.access.AuthorizeVersion(bookmarkID, versionID)
+	})
+}
+
+// Collection members are checked against the collection in :id.
+
+// CollectionMember admits the collection's owner and the member in :memberId.
+func (a *Authorizer) CollectionMember() gin.HandlerFunc {
+	return a.authorizeInCollection("memberId", a.access.AuthorizeCollectionMember)
+}
+
+// CollectionInvitee admits only the user invited by the membership in
+// :memberId.
+func (a *Authorizer) CollectionInvitee() gin.HandlerFunc {
+	return a.authorizeInCollection("memberId", a.access.AuthorizeCollectionInvitee)
+}
+
+func (a *Authorizer) authorize(param string, check func(userID, id uuid.UUID) error) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := uuid.Parse(c.Param(param))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+		if err := check(callerID(c), id); err != nil {
+			c.AbortWithStatusJSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+			return
+		}
+		c.Next()
+	}
+}
+
+func (a *Authorizer) authorizeNested(param string, check func(userID, bookmarkID, id uuid.UUID) error) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bookmarkID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid bookmark ID"})
+			return
+		}
+		a.authorize(param, func(userID, id uuid.UUID) error {
+			return check(userID, bookmarkID, id)
+		})(c)
+	}
+}
+
+func (a *Authorizer) authorizeInCollection(param string, check func(userID, collectionID, id uuid.UUID) error) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		collectionID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+			return
+		}
+		a.authorize(param, func(userID, id uuid.UUID) error {
+			return check(userID, collectionID, id)
+		})(c)
+	}
+}
+
+// callerID returns the user resolved by Authorizer.Identify.
+func callerID(c *gin.Context) uuid.UUID {
+	return c.MustGet(callerIDKey).(uuid.UUID)
+}
+
+// errorStatus maps access errors to 403/404 and anything else to fallback.
+func errorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrNotFound):
+		return http.StatusNotFound
+	default:
+		return fallback
+	}
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/service"
+)
+
+type BackupHandler struct {
+	service  service.BackupService
+	maxBytes int64
+}
+
+// NewBackupHandler limits uploaded backup archives to maxBytes.
+func NewBackupHandler(service service.BackupService, maxBytes int64) *BackupHandler {
+	return &BackupHandler{service: service, maxBytes: maxBytes}
+}
+
+func (h *BackupHandler) Backup(c *gin.Context) {
+	userID := callerID(c)
+
+	var workspaceID *uuid.UUID
+	if wsID := c.Query("workspaceId"); wsID != "" {
+		parsed, err := uuid.Parse(wsID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+			return
+		}
+		workspaceID = &parsed
+	}
+
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="bookmarks-backup.json"`)
+	if err := h.service.Export(userID, workspaceID, c.Writer); err != nil {
+		if !c.Writer.Written() {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+}
+
+// Restore takes an archive produced by Backup. Query parameters:
+// workspaceId restores everything into one workspace, dryRun=true only
+// reports what would be restored, and onConflict is skip or duplicate.
+func (h *BackupHandler) Restore(c *gin.Context) {
+	userID := callerID(c)
+
+	opts := model.RestoreOptions{OnConflict: c.Query("onConflict")}
+	if wsID := c.Query("workspaceId"); wsID != "" {
+		parsed, err := uuid.Parse(wsID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+			return
+		}
+		opts.WorkspaceID = &parsed
+	}
+	if dryRun := c.Query("dryRun"); dryRun != "" {
+		parsed, err := strconv.ParseBool(dryRun)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dryRun value"})
+			return
+		}
+		opts.DryRun = parsed
+	}
+
+	body, err := uploadedFile(c, h.maxBytes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.service.Restore(userID, body, opts)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Backup file too large"})
+		case errors.Is(err, service.ErrInvalidBackup):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
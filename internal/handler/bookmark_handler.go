@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -19,7 +20,6 @@ func NewBookmarkHandler(service service.BookmarkService) *BookmarkHandler {
 }
 
 type CreateBookmarkRequest struct {
-	UserID      string `json:"userId" binding:"required"`
 	WorkspaceID string `json:"workspaceId" binding:"required"`
 	FolderID    string `json:"folderId,omitempty"`
 	Type        string `json:"type" binding:"required"`
@@ -28,6 +28,9 @@ type CreateBookmarkRequest struct {
 	TargetID    string `json:"targetId" binding:"required"`
 	TargetURL   string `json:"targetUrl,omitempty"`
 	Metadata    string `json:"metadata,omitempty"`
+	// RejectDuplicate answers 409 with the existing bookmark instead of
+	// saving the same URL, or the same target and type, twice.
+	RejectDuplicate bool `json:"rejectDuplicate,omitempty"`
 }
 
 func (h *BookmarkHandler) Create(c *gin.Context) {
@@ -37,7 +40,7 @@ func (h *BookmarkHandler) Create(c *gin.Context) {
 		return
 	}
 
-	userID, _ := uuid.Parse(req.UserID)
+	userID := callerID(c)
 	workspaceID, _ := uuid.Parse(req.WorkspaceID)
 	targetID, _ := uuid.Parse(req.TargetID)
 
@@ -57,8 +60,13 @@ func (h *BookmarkHandler) Create(c *gin.Context) {
 		bookmark.FolderID = &folderID
 	}
 
-	if err := h.service.Create(bookmark); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.Create(bookmark, req.RejectDuplicate); err != nil {
+		var duplicate *service.DuplicateBookmarkError
+		if errors.As(err, &duplicate) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "data": duplicate.Existing})
+			return
+		}
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -82,16 +90,16 @@ func (h *BookmarkHandler) GetByID(c *gin.Context) {
 }
 
 func (h *BookmarkHandler) GetByUser(c *gin.Context) {
-	userID, err := uuid.Parse(c.Param("userId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+	userID := callerID(c)
+	archived, ok := bindArchivedFilter(c)
+	if !ok {
 		return
 	}
 
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 
-	bookmarks, total, err := h.service.GetByUser(userID, page, limit)
+	bookmarks, total, err := h.service.GetByUser(userID, archived, page, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -106,13 +114,17 @@ func (h *BookmarkHandler) GetByUser(c *gin.Context) {
 }
 
 func (h *BookmarkHandler) GetByUserAndWorkspace(c *gin.Context) {
-	userID, _ := uuid.Parse(c.Param("userId"))
+	userID := callerID(c)
 	workspaceID, _ := uuid.Parse(c.Param("workspaceId"))
+	archived, ok := bindArchivedFilter(c)
+	if !ok {
+		return
+	}
 
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 
-	bookmarks, total, err := h.service.GetByUserAndWorkspace(userID, workspaceID, page, limit)
+	bookmarks, total, err := h.service.GetByUserAndWorkspace(userID, workspaceID, archived, page, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -133,14 +145,14 @@ func (h *BookmarkHandler) Update(c *gin.Context) {
 		return
 	}
 
-	var bookmark model.Bookmark
-	if err := c.ShouldBindJSON(&bookmark); err != nil {
+	var req model.UpdateBookmarkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	bookmark.ID = id
-	if err := h.service.Update(&bookmark); err != nil {
+	bookmark, err := h.service.Update(id, &req, callerID(c))
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -181,90 +193,100 @@ func (h *BookmarkHandler) MoveToFolder(c *gin.Context) {
 	}
 
 	if err := h.service.MoveToFolder(bookmarkID, folderID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Bookmark moved"})
 }
 
-func (h *BookmarkHandler) GetByFolder(c *gin.Context) {
-	folderID, err := uuid.Parse(c.Param("folderId"))
+// MoveNextTo drags the bookmark to just before or after another bookmark in
+// its folder.
+func (h *BookmarkHandler) MoveNextTo(c *gin.Context) {
+	bookmarkID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid folder ID"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bookmark ID"})
+		return
+	}
+	anchorID, after, ok := bindMoveItem(c)
+	if !ok {
 		return
 	}
 
-	bookmarks, err := h.service.GetByFolder(folderID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.MoveNextTo(bookmarkID, anchorID, after); err != nil {
+		c.JSON(moveErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": bookmarks})
+	c.JSON(http.StatusOK, gin.H{"message": "Bookmark moved"})
 }
 
-func (h *BookmarkHandler) BulkDelete(c *gin.Context) {
-	var req model.BulkDeleteRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+func (h *BookmarkHandler) GetByFolder(c *gin.Context) {
+	folderID, err := uuid.Parse(c.Param("folderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid folder ID"})
 		return
 	}
-
-	var ids []uuid.UUID
-	for _, idStr := range req.IDs {
-		id, _ := uuid.Parse(idStr)
-		ids = append(ids, id)
+	archived, ok := bindArchivedFilter(c)
+	if !ok {
+		return
 	}
 
-	success, failed, err := h.service.BulkDelete(ids)
+	bookmarks, err := h.service.GetByFolder(folderID, archived)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"deleted": success, "failed": failed})
+	c.JSON(http.StatusOK, gin.H{"data": bookmarks})
+}
+
+// bindArchivedFilter reads ?archived=true|false|all. Listings leave archived
+// bookmarks out unless asked for them, the way an expiration that archives a
+// bookmark takes it out of sight.
+func bindArchivedFilter(c *gin.Context) (*bool, bool) {
+	switch c.DefaultQuery("archived", "false") {
+	case "all":
+		return nil, true
+	case "true":
+		archived := true
+		return &archived, true
+	case "false":
+		archived := false
+		return &archived, true
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "archived must be true, false or all"})
+	return nil, false
 }
 
-func (h *BookmarkHandler) BulkMove(c *gin.Context) {
-	var req model.BulkMoveRequest
+// bindMoveItem reads a MoveItemRequest and returns the item to move next to
+// and whether to move after it. It answers the request itself when the body
+// is invalid.
+func bindMoveItem(c *gin.Context) (uuid.UUID, bool, bool) {
+	var req model.MoveItemRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+		return uuid.Nil, false, false
 	}
-
-	var ids []uuid.UUID
-	for _, idStr := range req.IDs {
-		id, _ := uuid.Parse(idStr)
-		ids = append(ids, id)
+	if (req.Before == "") == (req.After == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exactly one of before and after is required"})
+		return uuid.Nil, false, false
 	}
-
-	var folderID *uuid.UUID
-	if req.FolderID != "" {
-		id, _ := uuid.Parse(req.FolderID)
-		folderID = &id
+	anchor, after := req.Before, false
+	if req.After != "" {
+		anchor, after = req.After, true
 	}
-
-	success, failed, err := h.service.BulkMove(ids, folderID)
+	anchorID, err := uuid.Parse(anchor)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID to move next to"})
+		return uuid.Nil, false, false
 	}
-
-	c.JSON(http.StatusOK, gin.H{"moved": success, "failed": failed})
+	return anchorID, after, true
 }
 
-func (h *BookmarkHandler) Reorder(c *gin.Context) {
-	var req model.ReorderRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+func moveErrorStatus(err error) int {
+	if errors.Is(err, service.ErrInvalidMove) {
+		return http.StatusBadRequest
 	}
-
-	if err := h.service.Reorder(req.Items); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Bookmarks reordered"})
+	return errorStatus(err, http.StatusInternalServerError)
 }
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/service"
+)
+
+type BulkHandler struct {
+	service service.BulkService
+}
+
+func NewBulkHandler(service service.BulkService) *BulkHandler {
+	return &BulkHandler{service: service}
+}
+
+func (h *BulkHandler) Delete(c *gin.Context) {
+	var req model.BulkDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.service.Delete(callerID(c), req.IDs, req.Mode)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	respondBulk(c, result)
+}
+
+func (h *BulkHandler) Move(c *gin.Context) {
+	var req model.BulkMoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var folderID *uuid.UUID
+	if req.FolderID != "" {
+		id, err := uuid.Parse(req.FolderID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid folder ID"})
+			return
+		}
+		folderID = &id
+	}
+
+	result, err := h.service.Move(callerID(c), req.IDs, folderID, req.Mode)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	respondBulk(c, result)
+}
+
+func (h *BulkHandler) Tag(c *gin.Context) {
+	h.tags(c, h.service.Tag)
+}
+
+func (h *BulkHandler) Untag(c *gin.Context) {
+	h.tags(c, h.service.Untag)
+}
+
+func (h *BulkHandler) tags(c *gin.Context, apply func(userID uuid.UUID, ids []string, tagIDs []uuid.UUID, mode model.BulkMode) (*model.BulkResult, error)) {
+	var req model.BulkTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tagIDs := make([]uuid.UUID, 0, len(req.TagIDs))
+	seen := map[uuid.UUID]bool{}
+	for _, raw := range req.TagIDs {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+			return
+		}
+		if !seen[id] {
+			seen[id] = true
+			tagIDs = append(tagIDs, id)
+		}
+	}
+
+	result, err := apply(callerID(c), req.IDs, tagIDs, req.Mode)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	respondBulk(c, result)
+}
+
+func (h *BulkHandler) AddToCollection(c *gin.Context) {
+	var req model.BulkCollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	collectionID, err := uuid.Parse(req.CollectionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		return
+	}
+
+	result, err := h.service.AddToCollection(callerID(c), req.IDs, collectionID, req.Mode)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	respondBulk(c, result)
+}
+
+func (h *BulkHandler) SetExpiration(c *gin.Context) {
+	var req model.BulkExpirationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, req.ExpiresAt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expiresAt format, use RFC3339"})
+		return
+	}
+
+	expiration := model.BookmarkExpiration{
+		ExpiresAt: expiresAt,
+		Action:    model.ExpirationAction(req.Action),
+	}
+	if req.TargetFolderID != "" {
+		folderID, err := uuid.Parse(req.TargetFolderID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid target folder ID"})
+			return
+		}
+		expiration.TargetFolderID = &folderID
+	}
+
+	result, err := h.service.SetExpiration(callerID(c), req.IDs, expiration, req.Mode)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	respondBulk(c, result)
+}
+
+func (h *BulkHandler) Reorder(c *gin.Context) {
+	var req model.ReorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.service.Reorder(callerID(c), req.Items, req.Mode)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	respondBulk(c, result)
+}
+
+// respondBulk answers 200 with the per-item results, or 422 when an atomic
+// request was rejected and nothing was written.
+func respondBulk(c *gin.Context, result *model.BulkResult) {
+	if result.Mode == model.BulkModeAtomic && result.Failed > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "No bookmarks were changed because some items failed", "data": result})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
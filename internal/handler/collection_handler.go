@@ -25,7 +25,7 @@ func (h *CollectionHandler) Create(c *gin.Context) {
 		return
 	}
 
-	userID, _ := uuid.Parse(req.UserID)
+	userID := callerID(c)
 	workspaceID, _ := uuid.Parse(req.WorkspaceID)
 
 	collection := &model.BookmarkCollection{
@@ -63,11 +63,7 @@ func (h *CollectionHandler) GetByID(c *gin.Context) {
 }
 
 func (h *CollectionHandler) GetByUser(c *gin.Context) {
-	userID, err := uuid.Parse(c.Param("userId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
+	userID := callerID(c)
 
 	collections, err := h.service.GetByUser(userID)
 	if err != nil {
@@ -79,7 +75,7 @@ func (h *CollectionHandler) GetByUser(c *gin.Context) {
 }
 
 func (h *CollectionHandler) GetByUserAndWorkspace(c *gin.Context) {
-	userID, _ := uuid.Parse(c.Param("userId"))
+	userID := callerID(c)
 	workspaceID, _ := uuid.Parse(c.Param("workspaceId"))
 
 	collections, err := h.service.GetByUserAndWorkspace(userID, workspaceID)
@@ -123,9 +119,9 @@ func (h *CollectionHandler) Update(c *gin.Context) {
 		return
 	}
 
-	collection, err := h.service.Update(id, &req)
+	collection, err := h.service.Update(callerID(c), id, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -166,8 +162,8 @@ func (h *CollectionHandler) AddBookmarks(c *gin.Context) {
 		bookmarkIDs = append(bookmarkIDs, bID)
 	}
 
-	if err := h.service.AddBookmarks(collectionID, bookmarkIDs); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.AddBookmarks(callerID(c), collectionID, bookmarkIDs); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -186,6 +182,32 @@ func (h *CollectionHandler) RemoveBookmark(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Bookmark removed from collection"})
 }
 
+// MoveBookmark drags a bookmark to just before or after another bookmark of
+// the collection.
+func (h *CollectionHandler) MoveBookmark(c *gin.Context) {
+	collectionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		return
+	}
+	bookmarkID, err := uuid.Parse(c.Param("bookmarkId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bookmark ID"})
+		return
+	}
+	anchorID, after, ok := bindMoveItem(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.MoveBookmark(collectionID, bookmarkID, anchorID, after); err != nil {
+		c.JSON(moveErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bookmark moved"})
+}
+
 func (h *CollectionHandler) GetBookmarks(c *gin.Context) {
 	collectionID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -196,9 +218,9 @@ func (h *CollectionHandler) GetBookmarks(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 
-	bookmarks, total, err := h.service.GetBookmarks(collectionID, page, limit)
+	bookmarks, total, err := h.service.GetBookmarks(callerID(c), collectionID, page, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
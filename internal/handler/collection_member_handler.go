@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/service"
+)
+
+type CollectionMemberHandler struct {
+	service service.CollectionMemberService
+}
+
+func NewCollectionMemberHandler(service service.CollectionMemberService) *CollectionMemberHandler {
+	return &CollectionMemberHandler{service: service}
+}
+
+func (h *CollectionMemberHandler) Invite(c *gin.Context) {
+	collectionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		return
+	}
+
+	var req model.InviteCollectionMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	member := &model.CollectionMember{
+		CollectionID: collectionID,
+		UserID:       userID,
+		Role:         model.ShareRole(req.Role),
+		InvitedBy:    callerID(c),
+		Message:      req.Message,
+	}
+
+	if err := h.service.Invite(member); err != nil {
+		c.JSON(memberErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": member})
+}
+
+func (h *CollectionMemberHandler) GetMembers(c *gin.Context) {
+	collectionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		return
+	}
+
+	members, err := h.service.GetMembers(collectionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": members})
+}
+
+func (h *CollectionMemberHandler) GetPendingInvitations(c *gin.Context) {
+	userID := callerID(c)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	invitations, total, err := h.service.GetPendingInvitations(userID, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": invitations, "total": total, "page": page, "limit": limit})
+}
+
+func (h *CollectionMemberHandler) Accept(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("memberId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := h.service.AcceptInvitation(id); err != nil {
+		c.JSON(memberErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation accepted"})
+}
+
+func (h *CollectionMemberHandler) Decline(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("memberId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := h.service.DeclineInvitation(id); err != nil {
+		c.JSON(memberErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation declined"})
+}
+
+func (h *CollectionMemberHandler) UpdateRole(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("memberId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req model.UpdateCollectionMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := h.service.UpdateRole(id, model.ShareRole(req.Role))
+	if err != nil {
+		c.JSON(memberErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": member})
+}
+
+// Remove takes a member out of the collection. Owners remove anyone; members
+// remove themselves to leave.
+func (h *CollectionMemberHandler) Remove(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("memberId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := h.service.Remove(id); err != nil {
+		c.JSON(memberErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed"})
+}
+
+func memberErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidMember):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrAlreadyMember):
+		return http.StatusConflict
+	default:
+		return errorStatus(err, http.StatusInternalServerError)
+	}
+}
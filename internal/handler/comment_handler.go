@@ -25,11 +25,7 @@ func (h *CommentHandler) Create(c *gin.Context) {
 		return
 	}
 
-	userID, err := uuid.Parse(c.Param("userId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
+	userID := callerID(c)
 
 	var req model.CreateCommentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
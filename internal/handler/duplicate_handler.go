@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/service"
+)
+
+type DuplicateHandler struct {
+	service service.DuplicateService
+}
+
+func NewDuplicateHandler(service service.DuplicateService) *DuplicateHandler {
+	return &DuplicateHandler{service: service}
+}
+
+func (h *DuplicateHandler) Report(c *gin.Context) {
+	workspaceID, ok := parseOptionalWorkspaceID(c, c.Query("workspaceId"))
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	groups, total, err := h.service.Report(callerID(c), workspaceID, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": groups, "total": total, "page": page, "limit": limit})
+}
+
+func (h *DuplicateHandler) Merge(c *gin.Context) {
+	var req model.MergeBookmarksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	targetID, err := uuid.Parse(req.TargetID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bookmark ID"})
+		return
+	}
+	sourceIDs := make([]uuid.UUID, 0, len(req.SourceIDs))
+	for _, raw := range req.SourceIDs {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bookmark ID"})
+			return
+		}
+		sourceIDs = append(sourceIDs, id)
+	}
+
+	result, err := h.service.Merge(callerID(c), targetID, sourceIDs)
+	if err != nil {
+		c.JSON(duplicateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+func duplicateErrorStatus(err error) int {
+	if errors.Is(err, service.ErrNotDuplicate) {
+		return http.StatusBadRequest
+	}
+	return errorStatus(err, http.StatusInternalServerError)
+}
@@ -25,11 +25,7 @@ func (h *ExpirationHandler) Set(c *gin.Context) {
 		return
 	}
 
-	userID, err := uuid.Parse(c.Param("userId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
+	userID := callerID(c)
 
 	var req model.SetExpirationRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -43,20 +39,24 @@ func (h *ExpirationHandler) Set(c *gin.Context) {
 		return
 	}
 
-	action := req.Action
-	if action == "" {
-		action = "archive"
-	}
-
 	expiration := &model.BookmarkExpiration{
 		BookmarkID: bookmarkID,
 		UserID:     userID,
 		ExpiresAt:  expiresAt,
-		Action:     action,
+		Action:     model.ExpirationAction(req.Action),
+	}
+
+	if req.TargetFolderID != "" {
+		folderID, err := uuid.Parse(req.TargetFolderID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid target folder ID"})
+			return
+		}
+		expiration.TargetFolderID = &folderID
 	}
 
 	if err := h.service.Set(expiration); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -87,7 +87,7 @@ func (h *ExpirationHandler) Remove(c *gin.Context) {
 	}
 
 	if err := h.service.Remove(bookmarkID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -95,11 +95,7 @@ func (h *ExpirationHandler) Remove(c *gin.Context) {
 }
 
 func (h *ExpirationHandler) GetExpiring(c *gin.Context) {
-	userID, err := uuid.Parse(c.Param("userId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
+	userID := callerID(c)
 
 	expirations, err := h.service.GetExpiring(userID)
 	if err != nil {
@@ -18,7 +18,7 @@ func NewFavoriteHandler(service service.FavoriteService) *FavoriteHandler {
 }
 
 func (h *FavoriteHandler) Add(c *gin.Context) {
-	userID, _ := uuid.Parse(c.Param("userId"))
+	userID := callerID(c)
 	bookmarkID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bookmark ID"})
@@ -34,7 +34,7 @@ func (h *FavoriteHandler) Add(c *gin.Context) {
 }
 
 func (h *FavoriteHandler) Remove(c *gin.Context) {
-	userID, _ := uuid.Parse(c.Param("userId"))
+	userID := callerID(c)
 	bookmarkID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bookmark ID"})
@@ -50,7 +50,7 @@ func (h *FavoriteHandler) Remove(c *gin.Context) {
 }
 
 func (h *FavoriteHandler) IsFavorite(c *gin.Context) {
-	userID, _ := uuid.Parse(c.Param("userId"))
+	userID := callerID(c)
 	bookmarkID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bookmark ID"})
@@ -67,11 +67,7 @@ func (h *FavoriteHandler) IsFavorite(c *gin.Context) {
 }
 
 func (h *FavoriteHandler) GetFavorites(c *gin.Context) {
-	userID, err := uuid.Parse(c.Param("userId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
+	userID := callerID(c)
 
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/service"
+)
+
+type FeedHandler struct {
+	service    service.FeedService
+	shareLinks service.ShareLinkService
+}
+
+func NewFeedHandler(service service.FeedService, shareLinks service.ShareLinkService) *FeedHandler {
+	return &FeedHandler{service: service, shareLinks: shareLinks}
+}
+
+// Collection serves the feed of a collection the caller can read.
+func (h *FeedHandler) Collection(c *gin.Context) {
+	format, ok := bindFeedFormat(c)
+	if !ok {
+		return
+	}
+	collectionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		return
+	}
+
+	feed, err := h.service.CollectionFeed(collectionID, requestURL(c))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+	writeFeed(c, feed, format)
+}
+
+// SharedCollection serves the feed of the collection behind a share link. It
+// needs no sign in, so feed readers can subscribe to it. Opening the feed
+// does not count as a view of the link.
+func (h *FeedHandler) SharedCollection(c *gin.Context) {
+	format, ok := bindFeedFormat(c)
+	if !ok {
+		return
+	}
+
+	link, err := h.shareLinks.Open(c.Param("token"), c.GetHeader(SharePasswordHeader))
+	if err != nil {
+		c.JSON(shareLinkErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	feed, err := h.service.CollectionFeed(link.CollectionID, requestURL(c))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+	writeFeed(c, feed, format)
+}
+
+func bindFeedFormat(c *gin.Context) (service.FeedFormat, bool) {
+	format := service.FeedFormat(c.Param("format"))
+	if !format.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Feed format must be rss, atom or json"})
+		return "", false
+	}
+	return format, true
+}
+
+// writeFeed renders feed with an ETag and Last-Modified, and answers 304
+// instead when the reader already has this version.
+func writeFeed(c *gin.Context, feed *service.Feed, format service.FeedFormat) {
+	var body bytes.Buffer
+	if err := service.WriteFeed(&body, feed, format); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// The ETag covers the rendered feed, so it also changes when a bookmark
+	// is removed, which Last-Modified cannot tell
+	sum := sha256.Sum256(body.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.Header("ETag", etag)
+	if !feed.UpdatedAt.IsZero() {
+		c.Header("Last-Modified", feed.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
+	// Readers may keep the feed but must check back with the validators
+	// above, and shared caches must not keep feeds that need sign in or a
+	// password
+	c.Header("Cache-Control", "private, no-cache")
+
+	if notModified(c.Request, etag, feed.UpdatedAt) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, format.ContentType(), body.Bytes())
+}
+
+// notModified applies If-None-Match, or If-Modified-Since when there is no
+// If-None-Match, as RFC 9110 orders them.
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, tag := range strings.Split(match, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == etag || tag == "*" {
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	return err == nil && !modified.IsZero() && !modified.Truncate(time.Second).After(since)
+}
+
+// requestURL is the absolute URL the request was made to, as the client saw
+// it behind a TLS terminating proxy.
+func requestURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host + c.Request.URL.Path
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -18,7 +19,6 @@ func NewFolderHandler(service service.FolderService) *FolderHandler {
 }
 
 type CreateFolderRequest struct {
-	UserID      string `json:"userId" binding:"required"`
 	WorkspaceID string `json:"workspaceId" binding:"required"`
 	ParentID    string `json:"parentId,omitempty"`
 	Name        string `json:"name" binding:"required"`
@@ -33,7 +33,7 @@ func (h *FolderHandler) Create(c *gin.Context) {
 		return
 	}
 
-	userID, _ := uuid.Parse(req.UserID)
+	userID := callerID(c)
 	workspaceID, _ := uuid.Parse(req.WorkspaceID)
 
 	folder := &model.BookmarkFolder{
@@ -50,7 +50,7 @@ func (h *FolderHandler) Create(c *gin.Context) {
 	}
 
 	if err := h.service.Create(folder); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(folderErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -74,11 +74,7 @@ func (h *FolderHandler) GetByID(c *gin.Context) {
 }
 
 func (h *FolderHandler) GetByUser(c *gin.Context) {
-	userID, err := uuid.Parse(c.Param("userId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
+	userID := callerID(c)
 
 	folders, err := h.service.GetByUser(userID)
 	if err != nil {
@@ -96,15 +92,15 @@ func (h *FolderHandler) Update(c *gin.Context) {
 		return
 	}
 
-	var folder model.BookmarkFolder
-	if err := c.ShouldBindJSON(&folder); err != nil {
+	var req model.UpdateFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	folder.ID = id
-	if err := h.service.Update(&folder); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	folder, err := h.service.Update(id, &req)
+	if err != nil {
+		c.JSON(folderErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -118,16 +114,82 @@ func (h *FolderHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.Delete(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	mode := model.FolderDeleteMode(c.DefaultQuery("mode", string(model.FolderDeleteMoveToParent)))
+	switch mode {
+	case model.FolderDeleteMoveToParent, model.FolderDeleteCascade, model.FolderDeleteRefuse:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mode, use move_to_parent, cascade or refuse"})
+		return
+	}
+
+	if err := h.service.Delete(id, mode); err != nil {
+		c.JSON(folderErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Folder deleted"})
 }
 
+// Move puts the folder under another parent, or at the top level when
+// parentId is empty.
+func (h *FolderHandler) Move(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req model.MoveFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var parentID *uuid.UUID
+	if req.ParentID != "" {
+		parsed, err := uuid.Parse(req.ParentID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent ID"})
+			return
+		}
+		parentID = &parsed
+	}
+
+	folder, err := h.service.Move(id, parentID, req.Position)
+	if err != nil {
+		c.JSON(folderErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": folder})
+}
+
+// GetTree returns the caller's folders as a nested tree with bookmark
+// counts; ?workspaceId= limits it to one workspace.
+func (h *FolderHandler) GetTree(c *gin.Context) {
+	userID := callerID(c)
+
+	var workspaceID *uuid.UUID
+	if wsID := c.Query("workspaceId"); wsID != "" {
+		parsed, err := uuid.Parse(wsID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+			return
+		}
+		workspaceID = &parsed
+	}
+
+	tree, err := h.service.GetTree(userID, workspaceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": tree})
+}
+
 func (h *FolderHandler) GetByUserAndWorkspace(c *gin.Context) {
-	userID, _ := uuid.Parse(c.Param("userId"))
+	userID := callerID(c)
 	workspaceID, _ := uuid.Parse(c.Param("workspaceId"))
 
 	folders, err := h.service.GetByUserAndWorkspace(userID, workspaceID)
@@ -139,6 +201,27 @@ func (h *FolderHandler) GetByUserAndWorkspace(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": folders})
 }
 
+// MoveNextTo drags the folder to just before or after a folder with the same
+// parent.
+func (h *FolderHandler) MoveNextTo(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	anchorID, after, ok := bindMoveItem(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.MoveNextTo(id, anchorID, after); err != nil {
+		c.JSON(moveErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Folder moved"})
+}
+
 func (h *FolderHandler) Reorder(c *gin.Context) {
 	var req model.ReorderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -146,10 +229,21 @@ func (h *FolderHandler) Reorder(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.Reorder(req.Items); err != nil {
+	if err := h.service.Reorder(callerID(c), req.Items); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Folders reordered"})
 }
+
+func folderErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrFolderCycle), errors.Is(err, service.ErrFolderTooDeep):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrFolderNotEmpty):
+		return http.StatusConflict
+	default:
+		return errorStatus(err, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/service"
+)
+
+type ImportHandler struct {
+	service  service.ImportService
+	maxBytes int64
+}
+
+// NewImportHandler limits uploaded import files to maxBytes.
+func NewImportHandler(service service.ImportService, maxBytes int64) *ImportHandler {
+	return &ImportHandler{service: service, maxBytes: maxBytes}
+}
+
+// ImportNetscape accepts a bookmarks.html either as the "file" field of a
+// multipart form or as the raw request body. The file is parsed as it is
+// read, so it is never buffered in full.
+func (h *ImportHandler) ImportNetscape(c *gin.Context) {
+	userID := callerID(c)
+	workspaceID, err := uuid.Parse(c.Param("workspaceId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	var folderID *uuid.UUID
+	if fID := c.Query("folderId"); fID != "" {
+		parsed, err := uuid.Parse(fID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid folder ID"})
+			return
+		}
+		folderID = &parsed
+	}
+
+	body, err := uploadedFile(c, h.maxBytes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.service.ImportNetscape(userID, workspaceID, folderID, body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Import file too large", "data": result})
+			return
+		}
+		if result != nil {
+			// The file was only partly readable; report what was imported
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "data": result})
+			return
+		}
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+func (h *ImportHandler) ExportNetscape(c *gin.Context) {
+	userID := callerID(c)
+
+	var workspaceID *uuid.UUID
+	if wsID := c.Query("workspaceId"); wsID != "" {
+		parsed, err := uuid.Parse(wsID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+			return
+		}
+		workspaceID = &parsed
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="bookmarks.html"`)
+	if err := h.service.ExportNetscape(userID, workspaceID, c.Writer); err != nil {
+		if !c.Writer.Written() {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+}
+
+// uploadedFile returns the "file" part of a multipart upload, or the raw
+// request body, as a stream capped at maxBytes.
+func uploadedFile(c *gin.Context, maxBytes int64) (io.Reader, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+
+	mr, err := c.Request.MultipartReader()
+	if errors.Is(err, http.ErrNotMultipart) {
+		return c.Request.Body, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, errors.New("missing file field")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == "file" {
+			return part, nil
+		}
+	}
+}
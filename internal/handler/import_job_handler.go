@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/service"
+)
+
+type ImportJobHandler struct {
+	service  service.ImportJobService
+	maxBytes int64
+}
+
+// NewImportJobHandler limits uploaded import files to maxBytes.
+func NewImportJobHandler(service service.ImportJobService, maxBytes int64) *ImportJobHandler {
+	return &ImportJobHandler{service: service, maxBytes: maxBytes}
+}
+
+// CreateJob queues an import of the uploaded file and answers with the job
+// to poll. ?format= is one of netscape, pocket, raindrop, pinboard or csv;
+// CSV files may name their columns with ?urlColumn=, ?titleColumn= and so on.
+func (h *ImportJobHandler) CreateJob(c *gin.Context) {
+	userID := callerID(c)
+	workspaceID, err := uuid.Parse(c.Param("workspaceId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	var folderID *uuid.UUID
+	if fID := c.Query("folderId"); fID != "" {
+		parsed, err := uuid.Parse(fID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid folder ID"})
+			return
+		}
+		folderID = &parsed
+	}
+
+	var mapping model.CSVColumnMapping
+	if err := c.ShouldBindQuery(&mapping); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	body, err := uploadedFile(c, h.maxBytes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := h.service.Submit(userID, workspaceID, folderID, c.Query("format"), mapping, body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Import file too large"})
+		case errors.Is(err, service.ErrUnsupportedImportFormat):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrImportQueueFull):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"data": job})
+}
+
+func (h *ImportJobHandler) GetJob(c *gin.Context) {
+	userID := callerID(c)
+	jobID, err := uuid.Parse(c.Param("jobId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import job ID"})
+		return
+	}
+
+	job, err := h.service.Get(userID, jobID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": job})
+}
+
+func (h *ImportJobHandler) ListJobs(c *gin.Context) {
+	userID := callerID(c)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	jobs, total, err := h.service.List(userID, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": jobs, "total": total, "page": page, "limit": limit})
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/service"
+)
+
+type LinkCheckHandler struct {
+	service service.LinkCheckService
+}
+
+func NewLinkCheckHandler(service service.LinkCheckService) *LinkCheckHandler {
+	return &LinkCheckHandler{service: service}
+}
+
+// GetBrokenLinks lists broken links, or with ?status=redirected the links
+// that moved permanently.
+func (h *LinkCheckHandler) GetBrokenLinks(c *gin.Context) {
+	filter := c.DefaultQuery("status", model.LinkCheckFilterBroken)
+	if filter != model.LinkCheckFilterBroken && filter != model.LinkCheckFilterRedirected {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be broken or redirected"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	links, total, err := h.service.GetByUser(callerID(c), filter, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": links, "total": total, "page": page, "limit": limit})
+}
+
+func (h *LinkCheckHandler) Get(c *gin.Context) {
+	bookmarkID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bookmark ID"})
+		return
+	}
+
+	check, err := h.service.GetByBookmark(bookmarkID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": check})
+}
+
+func (h *LinkCheckHandler) FollowRedirect(c *gin.Context) {
+	bookmarkID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bookmark ID"})
+		return
+	}
+
+	bookmark, err := h.service.FollowRedirect(bookmarkID, callerID(c))
+	if err != nil {
+		status := errorStatus(err, http.StatusInternalServerError)
+		if errors.Is(err, service.ErrNoPermanentRedirect) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": bookmark})
+}
@@ -31,7 +31,7 @@ func (h *NoteHandler) Create(c *gin.Context) {
 		return
 	}
 
-	userID, _ := uuid.Parse(c.Param("userId"))
+	userID := callerID(c)
 
 	note := &model.BookmarkNote{
 		BookmarkID: bookmarkID,
@@ -65,11 +65,7 @@ func (h *NoteHandler) GetByBookmark(c *gin.Context) {
 }
 
 func (h *NoteHandler) GetByUser(c *gin.Context) {
-	userID, err := uuid.Parse(c.Param("userId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
+	userID := callerID(c)
 
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -35,11 +36,15 @@ func (h *PreviewHandler) Generate(c *gin.Context) {
 
 	preview, err := h.service.Generate(bookmarkID, req.URL)
 	if err != nil {
+		if errors.Is(err, service.ErrURLNotAllowed) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": preview})
+	c.JSON(http.StatusAccepted, gin.H{"data": preview})
 }
 
 func (h *PreviewHandler) Get(c *gin.Context) {
@@ -72,8 +77,8 @@ func (h *PreviewHandler) Create(c *gin.Context) {
 		FetchedAt:  time.Now(),
 	}
 
-	if err := h.service.Create(preview); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.Create(callerID(c), preview); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/quckapp/bookmark-service/internal/service"
+)
+
+// RateLimit rejects clients that make more requests than limiter allows,
+// counting by client IP under scope. If the limiter cannot be reached the
+// request is let through rather than taking the endpoint down with it.
+func RateLimit(limiter service.RateLimiter, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, retryAfter, err := limiter.Allow(c.Request.Context(), scope+":"+c.ClientIP())
+		if err != nil || allowed {
+			c.Next()
+			return
+		}
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+	}
+}
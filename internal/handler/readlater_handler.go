@@ -19,11 +19,7 @@ func NewReadLaterHandler(service service.ReadLaterService) *ReadLaterHandler {
 }
 
 func (h *ReadLaterHandler) Add(c *gin.Context) {
-	userID, err := uuid.Parse(c.Param("userId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
+	userID := callerID(c)
 
 	var req model.AddReadLaterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -39,7 +35,7 @@ func (h *ReadLaterHandler) Add(c *gin.Context) {
 	}
 
 	if err := h.service.Add(item); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -47,11 +43,7 @@ func (h *ReadLaterHandler) Add(c *gin.Context) {
 }
 
 func (h *ReadLaterHandler) List(c *gin.Context) {
-	userID, err := uuid.Parse(c.Param("userId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
+	userID := callerID(c)
 
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
@@ -89,11 +81,7 @@ func (h *ReadLaterHandler) UpdateStatus(c *gin.Context) {
 }
 
 func (h *ReadLaterHandler) GetStats(c *gin.Context) {
-	userID, err := uuid.Parse(c.Param("userId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
+	userID := callerID(c)
 
 	stats, err := h.service.GetStats(userID)
 	if err != nil {
@@ -38,7 +38,7 @@ func (h *ReminderHandler) Create(c *gin.Context) {
 		return
 	}
 
-	userID, _ := uuid.Parse(c.Param("userId"))
+	userID := callerID(c)
 
 	reminder := &model.BookmarkReminder{
 		BookmarkID: bookmarkID,
@@ -72,11 +72,7 @@ func (h *ReminderHandler) GetByBookmark(c *gin.Context) {
 }
 
 func (h *ReminderHandler) GetByUser(c *gin.Context) {
-	userID, err := uuid.Parse(c.Param("userId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
+	userID := callerID(c)
 
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
@@ -91,11 +87,7 @@ func (h *ReminderHandler) GetByUser(c *gin.Context) {
 }
 
 func (h *ReminderHandler) GetPending(c *gin.Context) {
-	userID, err := uuid.Parse(c.Param("userId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
+	userID := callerID(c)
 
 	reminders, err := h.service.GetPending(userID)
 	if err != nil {
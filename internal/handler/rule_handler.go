@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/service"
+)
+
+type RuleHandler struct {
+	service service.RuleService
+}
+
+func NewRuleHandler(service service.RuleService) *RuleHandler {
+	return &RuleHandler{service: service}
+}
+
+func (h *RuleHandler) Create(c *gin.Context) {
+	var req model.CreateRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule := newRule(callerID(c), &req)
+	if err := h.service.Create(rule); err != nil {
+		c.JSON(ruleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": rule})
+}
+
+func (h *RuleHandler) GetByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		return
+	}
+
+	rule, err := h.service.GetByID(id)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rule})
+}
+
+func (h *RuleHandler) GetByUser(c *gin.Context) {
+	rules, err := h.service.GetByUser(callerID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rules})
+}
+
+func (h *RuleHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		return
+	}
+
+	var req model.UpdateRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.service.Update(id, &req)
+	if err != nil {
+		c.JSON(ruleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rule})
+}
+
+func (h *RuleHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		return
+	}
+
+	if err := h.service.Delete(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Rule deleted"})
+}
+
+func (h *RuleHandler) Reorder(c *gin.Context) {
+	var req model.ReorderRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ids, ok := parseRuleIDs(c, req.IDs)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Reorder(callerID(c), ids); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Rules reordered"})
+}
+
+func (h *RuleHandler) DryRun(c *gin.Context) {
+	var req model.RuleDryRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := callerID(c)
+	ruleIDs, ok := parseRuleIDs(c, req.RuleIDs)
+	if !ok {
+		return
+	}
+	workspaceID, ok := parseOptionalWorkspaceID(c, req.WorkspaceID)
+	if !ok {
+		return
+	}
+	var rule *model.BookmarkRule
+	if req.Rule != nil {
+		rule = newRule(userID, req.Rule)
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	result, err := h.service.DryRun(userID, rule, ruleIDs, workspaceID, page, limit)
+	if err != nil {
+		c.JSON(ruleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result, "page": page, "limit": limit})
+}
+
+func (h *RuleHandler) Reapply(c *gin.Context) {
+	var req model.ReapplyRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ruleIDs, ok := parseRuleIDs(c, req.RuleIDs)
+	if !ok {
+		return
+	}
+	workspaceID, ok := parseOptionalWorkspaceID(c, req.WorkspaceID)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.Reapply(callerID(c), ruleIDs, workspaceID, req.OverwriteExpirations)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+func newRule(userID uuid.UUID, req *model.CreateRuleRequest) *model.BookmarkRule {
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	return &model.BookmarkRule{
+		UserID:         userID,
+		Name:           req.Name,
+		Enabled:        enabled,
+		StopProcessing: req.StopProcessing,
+		Conditions:     req.Conditions,
+		Actions:        req.Actions,
+	}
+}
+
+// parseRuleIDs answers 400 and returns false when an ID is malformed.
+func parseRuleIDs(c *gin.Context, raw []string) ([]uuid.UUID, bool) {
+	ids := make([]uuid.UUID, 0, len(raw))
+	for _, s := range raw {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+			return nil, false
+		}
+		ids = append(ids, id)
+	}
+	return ids, true
+}
+
+func parseOptionalWorkspaceID(c *gin.Context, raw string) (*uuid.UUID, bool) {
+	if raw == "" {
+		return nil, true
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return nil, false
+	}
+	return &id, true
+}
+
+func ruleErrorStatus(err error) int {
+	if errors.Is(err, service.ErrInvalidRule) {
+		return http.StatusBadRequest
+	}
+	return errorStatus(err, http.StatusInternalServerError)
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/service"
+)
+
+// SharePasswordHeader carries the password of a password protected link, so
+// it stays out of URLs and access logs.
+const SharePasswordHeader = "X-Share-Password"
+
+type ShareLinkHandler struct {
+	service service.ShareLinkService
+}
+
+func NewShareLinkHandler(service service.ShareLinkService) *ShareLinkHandler {
+	return &ShareLinkHandler{service: service}
+}
+
+func (h *ShareLinkHandler) Create(c *gin.Context) {
+	collectionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		return
+	}
+
+	var req model.CreateShareLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	link, err := h.service.Create(callerID(c), collectionID, &req)
+	if err != nil {
+		c.JSON(shareLinkErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": link})
+}
+
+func (h *ShareLinkHandler) List(c *gin.Context) {
+	collectionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		return
+	}
+
+	links, err := h.service.List(collectionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": links})
+}
+
+func (h *ShareLinkHandler) Revoke(c *gin.Context) {
+	collectionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		return
+	}
+	linkID, err := uuid.Parse(c.Param("linkId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid share link ID"})
+		return
+	}
+
+	if err := h.service.Revoke(collectionID, linkID); err != nil {
+		c.JSON(shareLinkErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Share link revoked"})
+}
+
+// View shows the collection behind a share link. It needs no sign in.
+func (h *ShareLinkHandler) View(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	collection, err := h.service.View(c.Param("token"), c.GetHeader(SharePasswordHeader), page, limit)
+	if err != nil {
+		c.JSON(shareLinkErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// Shared collections change and may sit behind a password
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"data": collection})
+}
+
+func shareLinkErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidShare):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrPasswordRequired):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrTooManyAttempts):
+		return http.StatusTooManyRequests
+	case errors.Is(err, service.ErrShareLinkGone):
+		return http.StatusGone
+	default:
+		return errorStatus(err, http.StatusInternalServerError)
+	}
+}
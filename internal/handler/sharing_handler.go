@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -25,20 +26,33 @@ func (h *SharingHandler) Share(c *gin.Context) {
 		return
 	}
 
-	bookmarkID, _ := uuid.Parse(req.BookmarkID)
-	sharedWith, _ := uuid.Parse(req.SharedWith)
-	sharedBy, _ := uuid.Parse(c.Param("userId"))
+	rawID := req.ResourceID
+	if rawID == "" {
+		rawID = req.BookmarkID
+	}
+	resourceID, err := uuid.Parse(rawID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid resource ID"})
+		return
+	}
+	sharedWith, err := uuid.Parse(req.SharedWith)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
 
 	shared := &model.SharedBookmark{
-		BookmarkID:  bookmarkID,
-		SharedBy:    sharedBy,
-		SharedWith:  sharedWith,
-		WorkspaceID: uuid.New(), // Will be derived from bookmark in a real implementation
-		Message:     req.Message,
+		ResourceType: model.ShareResourceType(req.ResourceType),
+		ResourceID:   resourceID,
+		SharedBy:     callerID(c),
+		SharedWith:   sharedWith,
+		Role:         model.ShareRole(req.Role),
+		ExpiresAt:    req.ExpiresAt,
+		Message:      req.Message,
 	}
 
-	if err := h.service.ShareBookmark(shared); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := h.service.Share(shared); err != nil {
+		c.JSON(sharingErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -46,11 +60,7 @@ func (h *SharingHandler) Share(c *gin.Context) {
 }
 
 func (h *SharingHandler) GetSharedWithUser(c *gin.Context) {
-	userID, err := uuid.Parse(c.Param("userId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
+	userID := callerID(c)
 
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
@@ -65,11 +75,7 @@ func (h *SharingHandler) GetSharedWithUser(c *gin.Context) {
 }
 
 func (h *SharingHandler) GetSharedByUser(c *gin.Context) {
-	userID, err := uuid.Parse(c.Param("userId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
+	userID := callerID(c)
 
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
@@ -91,7 +97,7 @@ func (h *SharingHandler) Accept(c *gin.Context) {
 	}
 
 	if err := h.service.AcceptShare(id); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(sharingErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -113,13 +119,46 @@ func (h *SharingHandler) Decline(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Share declined"})
 }
 
-func (h *SharingHandler) GetPendingCount(c *gin.Context) {
-	userID, err := uuid.Parse(c.Param("userId"))
+func (h *SharingHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
 		return
 	}
 
+	var req model.UpdateShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	share, err := h.service.UpdateShare(id, &req)
+	if err != nil {
+		c.JSON(sharingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": share})
+}
+
+func (h *SharingHandler) Revoke(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := h.service.RevokeShare(id); err != nil {
+		c.JSON(sharingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Share revoked"})
+}
+
+func (h *SharingHandler) GetPendingCount(c *gin.Context) {
+	userID := callerID(c)
+
 	count, err := h.service.GetPendingCount(userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -128,3 +167,14 @@ func (h *SharingHandler) GetPendingCount(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"count": count})
 }
+
+func sharingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrAlreadyShared):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrShareExpired):
+		return http.StatusGone
+	default:
+		return errorStatus(err, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/service"
+)
+
+type SmartFolderHandler struct {
+	service service.SmartFolderService
+}
+
+func NewSmartFolderHandler(service service.SmartFolderService) *SmartFolderHandler {
+	return &SmartFolderHandler{service: service}
+}
+
+func (h *SmartFolderHandler) Create(c *gin.Context) {
+	var req model.CreateSmartFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	workspaceID, err := uuid.Parse(req.WorkspaceID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	folder := &model.SmartFolder{
+		UserID:      callerID(c),
+		WorkspaceID: workspaceID,
+		Name:        req.Name,
+		Color:       req.Color,
+		Icon:        req.Icon,
+		Filter:      req.Filter,
+		Sort:        req.Sort,
+		Position:    req.Position,
+	}
+
+	if err := h.service.Create(folder); err != nil {
+		c.JSON(smartFolderErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": folder})
+}
+
+func (h *SmartFolderHandler) GetByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid smart folder ID"})
+		return
+	}
+
+	folder, err := h.service.GetByID(id)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": folder})
+}
+
+func (h *SmartFolderHandler) GetByUser(c *gin.Context) {
+	folders, err := h.service.GetByUser(callerID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": folders})
+}
+
+func (h *SmartFolderHandler) GetByUserAndWorkspace(c *gin.Context) {
+	workspaceID, err := uuid.Parse(c.Param("workspaceId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	folders, err := h.service.GetByUserAndWorkspace(callerID(c), workspaceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": folders})
+}
+
+func (h *SmartFolderHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid smart folder ID"})
+		return
+	}
+
+	var req model.UpdateSmartFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	folder, err := h.service.Update(id, &req)
+	if err != nil {
+		c.JSON(smartFolderErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": folder})
+}
+
+func (h *SmartFolderHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid smart folder ID"})
+		return
+	}
+
+	if err := h.service.Delete(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Smart folder deleted"})
+}
+
+func (h *SmartFolderHandler) GetBookmarks(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid smart folder ID"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	bookmarks, total, err := h.service.GetBookmarks(id, page, limit)
+	if err != nil {
+		c.JSON(smartFolderErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": bookmarks, "total": total, "page": page, "limit": limit})
+}
+
+func smartFolderErrorStatus(err error) int {
+	if errors.Is(err, service.ErrInvalidSmartFolderFilter) {
+		return http.StatusBadRequest
+	}
+	return errorStatus(err, http.StatusInternalServerError)
+}
@@ -25,7 +25,7 @@ func (h *TagHandler) Create(c *gin.Context) {
 		return
 	}
 
-	userID, _ := uuid.Parse(req.UserID)
+	userID := callerID(c)
 	workspaceID, _ := uuid.Parse(req.WorkspaceID)
 
 	tag := &model.BookmarkTag{
@@ -60,11 +60,7 @@ func (h *TagHandler) GetByID(c *gin.Context) {
 }
 
 func (h *TagHandler) GetByUser(c *gin.Context) {
-	userID, err := uuid.Parse(c.Param("userId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
+	userID := callerID(c)
 
 	tags, err := h.service.GetUserTags(userID)
 	if err != nil {
@@ -76,7 +72,7 @@ func (h *TagHandler) GetByUser(c *gin.Context) {
 }
 
 func (h *TagHandler) GetByUserAndWorkspace(c *gin.Context) {
-	userID, _ := uuid.Parse(c.Param("userId"))
+	userID := callerID(c)
 	workspaceID, _ := uuid.Parse(c.Param("workspaceId"))
 
 	tags, err := h.service.GetUserTagsInWorkspace(userID, workspaceID)
@@ -144,8 +140,8 @@ func (h *TagHandler) TagBookmark(c *gin.Context) {
 		tagIDs = append(tagIDs, tagID)
 	}
 
-	if err := h.service.TagBookmark(bookmarkID, tagIDs); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.TagBookmark(callerID(c), bookmarkID, tagIDs); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -156,7 +152,7 @@ func (h *TagHandler) UntagBookmark(c *gin.Context) {
 	bookmarkID, _ := uuid.Parse(c.Param("id"))
 	tagID, _ := uuid.Parse(c.Param("tagId"))
 
-	if err := h.service.UntagBookmark(bookmarkID, tagID); err != nil {
+	if err := h.service.UntagBookmark(callerID(c), bookmarkID, tagID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -218,8 +214,8 @@ func (h *TagHandler) ReplaceBookmarkTags(c *gin.Context) {
 		tagIDs = append(tagIDs, tagID)
 	}
 
-	if err := h.service.ReplaceBookmarkTags(bookmarkID, tagIDs); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.ReplaceBookmarkTags(callerID(c), bookmarkID, tagIDs); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -24,7 +24,7 @@ func (h *TemplateHandler) Create(c *gin.Context) {
 		return
 	}
 
-	userID, _ := uuid.Parse(req.UserID)
+	userID := callerID(c)
 	workspaceID, _ := uuid.Parse(req.WorkspaceID)
 
 	template := &model.BookmarkTemplate{
@@ -39,7 +39,7 @@ func (h *TemplateHandler) Create(c *gin.Context) {
 	}
 
 	if err := h.service.Create(template); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -47,11 +47,7 @@ func (h *TemplateHandler) Create(c *gin.Context) {
 }
 
 func (h *TemplateHandler) GetByUser(c *gin.Context) {
-	userID, err := uuid.Parse(c.Param("userId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
+	userID := callerID(c)
 
 	templates, err := h.service.GetByUser(userID)
 	if err != nil {
@@ -63,11 +59,7 @@ func (h *TemplateHandler) GetByUser(c *gin.Context) {
 }
 
 func (h *TemplateHandler) GetByUserAndWorkspace(c *gin.Context) {
-	userID, err := uuid.Parse(c.Param("userId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
+	userID := callerID(c)
 
 	workspaceID, err := uuid.Parse(c.Param("workspaceId"))
 	if err != nil {
@@ -108,7 +100,7 @@ func (h *TemplateHandler) Update(c *gin.Context) {
 
 	updated, err := h.service.Update(id, template)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
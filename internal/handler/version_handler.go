@@ -65,11 +65,37 @@ func (h *VersionHandler) Restore(c *gin.Context) {
 		return
 	}
 
-	bookmark, err := h.service.Restore(bookmarkID, versionID)
+	bookmark, err := h.service.Restore(bookmarkID, versionID, callerID(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"data": bookmark, "message": "Bookmark restored from version"})
 }
+
+func (h *VersionHandler) Diff(c *gin.Context) {
+	bookmarkID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bookmark ID"})
+		return
+	}
+	fromID, err := uuid.Parse(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from version ID"})
+		return
+	}
+	toID, err := uuid.Parse(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to version ID"})
+		return
+	}
+
+	diff, err := h.service.Diff(bookmarkID, fromID, toID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": diff})
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,20 +9,22 @@ import (
 )
 
 type Bookmark struct {
-	ID          uuid.UUID      `gorm:"type:char(36);primary_key" json:"id"`
-	UserID      uuid.UUID      `gorm:"type:char(36);not null;index" json:"userId"`
-	WorkspaceID uuid.UUID      `gorm:"type:char(36);not null;index" json:"workspaceId"`
-	FolderID    *uuid.UUID     `gorm:"type:char(36);index" json:"folderId,omitempty"`
-	Type        BookmarkType   `gorm:"type:varchar(20);not null" json:"type"`
-	Title       string         `gorm:"type:varchar(255);not null" json:"title"`
-	Description string         `gorm:"type:text" json:"description,omitempty"`
-	TargetID    uuid.UUID      `gorm:"type:char(36);not null" json:"targetId"`
-	TargetURL   string         `gorm:"type:varchar(500)" json:"targetUrl,omitempty"`
-	Metadata    string         `gorm:"type:json" json:"metadata,omitempty"`
-	Position    int            `gorm:"default:0" json:"position"`
-	CreatedAt   time.Time      `json:"createdAt"`
-	UpdatedAt   time.Time      `json:"updatedAt"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	ID           uuid.UUID      `gorm:"type:char(36);primary_key" json:"id"`
+	UserID       uuid.UUID      `gorm:"type:char(36);not null;index;index:idx_bookmarks_user_canonical_url,priority:1" json:"userId"`
+	WorkspaceID  uuid.UUID      `gorm:"type:char(36);not null;index;index:idx_bookmarks_user_canonical_url,priority:2" json:"workspaceId"`
+	FolderID     *uuid.UUID     `gorm:"type:char(36);index" json:"folderId,omitempty"`
+	Type         BookmarkType   `gorm:"type:varchar(20);not null" json:"type"`
+	Title        string         `gorm:"type:varchar(255);not null;index:idx_bookmarks_fulltext,class:FULLTEXT" json:"title"`
+	Description  string         `gorm:"type:text;index:idx_bookmarks_fulltext,class:FULLTEXT" json:"description,omitempty"`
+	TargetID     uuid.UUID      `gorm:"type:char(36);not null" json:"targetId"`
+	TargetURL    string         `gorm:"type:varchar(500);index:idx_bookmarks_fulltext,class:FULLTEXT" json:"targetUrl,omitempty"`
+	CanonicalURL string         `gorm:"type:varchar(500);index:idx_bookmarks_user_canonical_url,priority:3" json:"canonicalUrl,omitempty"`
+	Metadata     string         `gorm:"type:json" json:"metadata,omitempty"`
+	Position     int            `gorm:"default:0" json:"position"`
+	IsArchived   bool           `gorm:"default:false;index" json:"isArchived"`
+	CreatedAt    time.Time      `json:"createdAt"`
+	UpdatedAt    time.Time      `json:"updatedAt"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 type BookmarkType string
@@ -41,6 +44,13 @@ func (b *Bookmark) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// BeforeSave keeps CanonicalURL in step with TargetURL so duplicates can be
+// found with an index lookup.
+func (b *Bookmark) BeforeSave(tx *gorm.DB) error {
+	b.CanonicalURL = CanonicalURL(b.TargetURL)
+	return nil
+}
+
 type BookmarkFolder struct {
 	ID          uuid.UUID      `gorm:"type:char(36);primary_key" json:"id"`
 	UserID      uuid.UUID      `gorm:"type:char(36);not null;index" json:"userId"`
@@ -66,7 +76,7 @@ type BookmarkTag struct {
 	ID          uuid.UUID      `gorm:"type:char(36);primary_key" json:"id"`
 	UserID      uuid.UUID      `gorm:"type:char(36);not null;index" json:"userId"`
 	WorkspaceID uuid.UUID      `gorm:"type:char(36);not null;index" json:"workspaceId"`
-	Name        string         `gorm:"type:varchar(50);not null" json:"name"`
+	Name        string         `gorm:"type:varchar(50);not null;index:idx_bookmark_tags_fulltext,class:FULLTEXT" json:"name"`
 	Color       string         `gorm:"type:varchar(20)" json:"color,omitempty"`
 	CreatedAt   time.Time      `json:"createdAt"`
 	UpdatedAt   time.Time      `json:"updatedAt"`
@@ -120,8 +130,10 @@ type CollectionBookmark struct {
 	ID           uuid.UUID `gorm:"type:char(36);primary_key" json:"id"`
 	CollectionID uuid.UUID `gorm:"type:char(36);not null;index" json:"collectionId"`
 	BookmarkID   uuid.UUID `gorm:"type:char(36);not null;index" json:"bookmarkId"`
-	Position     int       `gorm:"default:0" json:"position"`
-	CreatedAt    time.Time `json:"createdAt"`
+	// AddedBy is the owner or member who put the bookmark in the collection.
+	AddedBy   uuid.UUID `gorm:"type:char(36);index" json:"addedBy"`
+	Position  int       `gorm:"default:0" json:"position"`
+	CreatedAt time.Time `json:"createdAt"`
 }
 
 func (cb *CollectionBookmark) BeforeCreate(tx *gorm.DB) error {
@@ -131,17 +143,117 @@ func (cb *CollectionBookmark) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// CollectionMember lets a user besides the owner work on a collection in
+// Role. Members are invited and take part once they accept.
+type CollectionMember struct {
+	ID           uuid.UUID  `gorm:"type:char(36);primary_key" json:"id"`
+	CollectionID uuid.UUID  `gorm:"type:char(36);not null;uniqueIndex:idx_collection_members_user,priority:1" json:"collectionId"`
+	UserID       uuid.UUID  `gorm:"type:char(36);not null;uniqueIndex:idx_collection_members_user,priority:2;index" json:"userId"`
+	WorkspaceID  uuid.UUID  `gorm:"type:char(36);not null;index" json:"workspaceId"`
+	Role         ShareRole  `gorm:"type:varchar(20);not null;default:viewer" json:"role"`
+	InvitedBy    uuid.UUID  `gorm:"type:char(36);not null" json:"invitedBy"`
+	Message      string     `gorm:"type:text" json:"message,omitempty"`
+	IsAccepted   bool       `gorm:"default:false" json:"isAccepted"`
+	AcceptedAt   *time.Time `json:"acceptedAt,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+func (m *CollectionMember) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+// CollectionShareLink lets anyone holding its token read a collection without
+// signing in. Only a hash of the token is stored; the token itself is shown
+// once, when the link is created.
+type CollectionShareLink struct {
+	ID               uuid.UUID  `gorm:"type:char(36);primary_key" json:"id"`
+	CollectionID     uuid.UUID  `gorm:"type:char(36);not null;index" json:"collectionId"`
+	CreatedBy        uuid.UUID  `gorm:"type:char(36);not null" json:"createdBy"`
+	TokenHash        string     `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	Token            string     `gorm:"-" json:"token,omitempty"`
+	PasswordHash     string     `gorm:"type:varchar(100)" json:"-"`
+	RequiresPassword bool       `gorm:"default:false" json:"requiresPassword"`
+	ExpiresAt        *time.Time `json:"expiresAt,omitempty"`
+	ViewCount        int64      `gorm:"default:0" json:"viewCount"`
+	LastViewedAt     *time.Time `json:"lastViewedAt,omitempty"`
+	RevokedAt        *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
+}
+
+func (l *CollectionShareLink) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsActive reports whether the link still opens its collection at now.
+func (l *CollectionShareLink) IsActive(now time.Time) bool {
+	return l.RevokedAt == nil && (l.ExpiresAt == nil || l.ExpiresAt.After(now))
+}
+
+// SharedBookmark grants another user a role on a bookmark, a folder or a
+// collection. Only accepted shares that have not expired grant access.
+// BookmarkID is only set for bookmark shares.
 type SharedBookmark struct {
-	ID          uuid.UUID      `gorm:"type:char(36);primary_key" json:"id"`
-	BookmarkID  uuid.UUID      `gorm:"type:char(36);not null;index" json:"bookmarkId"`
-	SharedBy    uuid.UUID      `gorm:"type:char(36);not null;index" json:"sharedBy"`
-	SharedWith  uuid.UUID      `gorm:"type:char(36);not null;index" json:"sharedWith"`
-	WorkspaceID uuid.UUID      `gorm:"type:char(36);not null" json:"workspaceId"`
-	Message     string         `gorm:"type:text" json:"message,omitempty"`
-	IsAccepted  bool           `gorm:"default:false" json:"isAccepted"`
-	CreatedAt   time.Time      `json:"createdAt"`
-	UpdatedAt   time.Time      `json:"updatedAt"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	ID           uuid.UUID         `gorm:"type:char(36);primary_key" json:"id"`
+	BookmarkID   uuid.UUID         `gorm:"type:char(36);not null;index" json:"bookmarkId"`
+	ResourceType ShareResourceType `gorm:"type:varchar(20);not null;default:bookmark;index:idx_shared_bookmarks_resource" json:"resourceType"`
+	ResourceID   uuid.UUID         `gorm:"type:char(36);index:idx_shared_bookmarks_resource" json:"resourceId"`
+	SharedBy     uuid.UUID         `gorm:"type:char(36);not null;index" json:"sharedBy"`
+	SharedWith   uuid.UUID         `gorm:"type:char(36);not null;index" json:"sharedWith"`
+	WorkspaceID  uuid.UUID         `gorm:"type:char(36);not null" json:"workspaceId"`
+	Role         ShareRole         `gorm:"type:varchar(20);not null;default:viewer" json:"role"`
+	Message      string            `gorm:"type:text" json:"message,omitempty"`
+	IsAccepted   bool              `gorm:"default:false" json:"isAccepted"`
+	ExpiresAt    *time.Time        `json:"expiresAt,omitempty"`
+	CreatedAt    time.Time         `json:"createdAt"`
+	UpdatedAt    time.Time         `json:"updatedAt"`
+	DeletedAt    gorm.DeletedAt    `gorm:"index" json:"-"`
+}
+
+type ShareResourceType string
+
+const (
+	ShareResourceBookmark   ShareResourceType = "bookmark"
+	ShareResourceFolder     ShareResourceType = "folder"
+	ShareResourceCollection ShareResourceType = "collection"
+)
+
+// ShareRole is what a share lets its recipient do. Each role includes the
+// ones before it: viewers read, commenters also comment and editors also
+// change the shared resource.
+type ShareRole string
+
+const (
+	ShareRoleViewer    ShareRole = "viewer"
+	ShareRoleCommenter ShareRole = "commenter"
+	ShareRoleEditor    ShareRole = "editor"
+)
+
+var shareRoleRanks = map[ShareRole]int{
+	ShareRoleViewer:    1,
+	ShareRoleCommenter: 2,
+	ShareRoleEditor:    3,
+}
+
+func (r ShareRole) IsValid() bool {
+	return shareRoleRanks[r] > 0
+}
+
+// Includes reports whether r grants everything required does.
+func (r ShareRole) Includes(required ShareRole) bool {
+	return r.IsValid() && shareRoleRanks[r] >= shareRoleRanks[required]
+}
+
+// IsActive reports whether the share grants access at now.
+func (sb *SharedBookmark) IsActive(now time.Time) bool {
+	return sb.IsAccepted && (sb.ExpiresAt == nil || sb.ExpiresAt.After(now))
 }
 
 func (sb *SharedBookmark) BeforeCreate(tx *gorm.DB) error {
@@ -155,7 +267,7 @@ type BookmarkNote struct {
 	ID         uuid.UUID      `gorm:"type:char(36);primary_key" json:"id"`
 	BookmarkID uuid.UUID      `gorm:"type:char(36);not null;index" json:"bookmarkId"`
 	UserID     uuid.UUID      `gorm:"type:char(36);not null;index" json:"userId"`
-	Content    string         `gorm:"type:text;not null" json:"content"`
+	Content    string         `gorm:"type:text;not null;index:idx_bookmark_notes_fulltext,class:FULLTEXT" json:"content"`
 	Color      string         `gorm:"type:varchar(20)" json:"color,omitempty"`
 	IsPinned   bool           `gorm:"default:false" json:"isPinned"`
 	CreatedAt  time.Time      `json:"createdAt"`
@@ -170,17 +282,23 @@ func (bn *BookmarkNote) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// BookmarkReminder notifies its user about a bookmark at RemindAt. Attempts
+// counts failed deliveries; after one the reminder waits until NextAttemptAt,
+// and once attempts run out its status is failed.
 type BookmarkReminder struct {
-	ID         uuid.UUID      `gorm:"type:char(36);primary_key" json:"id"`
-	BookmarkID uuid.UUID      `gorm:"type:char(36);not null;index" json:"bookmarkId"`
-	UserID     uuid.UUID      `gorm:"type:char(36);not null;index" json:"userId"`
-	RemindAt   time.Time      `gorm:"not null" json:"remindAt"`
-	Message    string         `gorm:"type:text" json:"message,omitempty"`
-	Status     string         `gorm:"type:varchar(20);default:pending" json:"status"`
-	FiredAt    *time.Time     `json:"firedAt,omitempty"`
-	CreatedAt  time.Time      `json:"createdAt"`
-	UpdatedAt  time.Time      `json:"updatedAt"`
-	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+	ID            uuid.UUID      `gorm:"type:char(36);primary_key" json:"id"`
+	BookmarkID    uuid.UUID      `gorm:"type:char(36);not null;index" json:"bookmarkId"`
+	UserID        uuid.UUID      `gorm:"type:char(36);not null;index" json:"userId"`
+	RemindAt      time.Time      `gorm:"not null;index" json:"remindAt"`
+	Message       string         `gorm:"type:text" json:"message,omitempty"`
+	Status        string         `gorm:"type:varchar(20);default:pending" json:"status"`
+	FiredAt       *time.Time     `json:"firedAt,omitempty"`
+	Attempts      int            `gorm:"default:0" json:"attempts"`
+	NextAttemptAt *time.Time     `json:"nextAttemptAt,omitempty"`
+	Error         string         `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt     time.Time      `json:"createdAt"`
+	UpdatedAt     time.Time      `json:"updatedAt"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 func (br *BookmarkReminder) BeforeCreate(tx *gorm.DB) error {
@@ -213,6 +331,35 @@ type BookmarkActivity struct {
 	CreatedAt  time.Time `json:"createdAt"`
 }
 
+const (
+	ActivityCreated           = "created"
+	ActivityUpdated           = "updated"
+	ActivityDeleted           = "deleted"
+	ActivityMoved             = "moved"
+	ActivityRestored          = "restored"
+	ActivityTagged            = "tagged"
+	ActivityUntagged          = "untagged"
+	ActivityTagsReplaced      = "tags_replaced"
+	ActivityShared            = "shared"
+	ActivityShareAccepted     = "share_accepted"
+	ActivityShareDeclined     = "share_declined"
+	ActivityShareRevoked      = "share_revoked"
+	ActivityShareUpdated      = "share_updated"
+	ActivityFavorited         = "favorited"
+	ActivityUnfavorited       = "unfavorited"
+	ActivityCommented         = "commented"
+	ActivityCommentUpdated    = "comment_updated"
+	ActivityCommentDeleted    = "comment_deleted"
+	ActivityReminderSet       = "reminder_set"
+	ActivityReminderUpdated   = "reminder_updated"
+	ActivityReminderCancelled = "reminder_cancelled"
+	ActivityReminderDeleted   = "reminder_deleted"
+	ActivityExpirationSet     = "expiration_set"
+	ActivityExpirationRemoved = "expiration_removed"
+	ActivityExpired           = "expired"
+	ActivityMerged            = "merged"
+)
+
 func (ba *BookmarkActivity) BeforeCreate(tx *gorm.DB) error {
 	if ba.ID == uuid.Nil {
 		ba.ID = uuid.New()
@@ -221,20 +368,33 @@ func (ba *BookmarkActivity) BeforeCreate(tx *gorm.DB) error {
 }
 
 type LinkPreview struct {
-	ID          uuid.UUID      `gorm:"type:char(36);primary_key" json:"id"`
-	BookmarkID  uuid.UUID      `gorm:"type:char(36);not null;uniqueIndex" json:"bookmarkId"`
-	URL         string         `gorm:"type:varchar(500);not null" json:"url"`
-	Title       string         `gorm:"type:varchar(255)" json:"title,omitempty"`
-	Description string         `gorm:"type:text" json:"description,omitempty"`
-	ImageURL    string         `gorm:"type:varchar(500)" json:"imageUrl,omitempty"`
-	FaviconURL  string         `gorm:"type:varchar(500)" json:"faviconUrl,omitempty"`
-	SiteName    string         `gorm:"type:varchar(100)" json:"siteName,omitempty"`
-	ContentType string         `gorm:"type:varchar(50)" json:"contentType,omitempty"`
-	FetchedAt   time.Time      `json:"fetchedAt"`
-	CreatedAt   time.Time      `json:"createdAt"`
-	UpdatedAt   time.Time      `json:"updatedAt"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
-}
+	ID            uuid.UUID      `gorm:"type:char(36);primary_key" json:"id"`
+	BookmarkID    uuid.UUID      `gorm:"type:char(36);not null;uniqueIndex" json:"bookmarkId"`
+	URL           string         `gorm:"type:varchar(500);not null" json:"url"`
+	Title         string         `gorm:"type:varchar(255);index:idx_link_previews_fulltext,class:FULLTEXT" json:"title,omitempty"`
+	Description   string         `gorm:"type:text;index:idx_link_previews_fulltext,class:FULLTEXT" json:"description,omitempty"`
+	ImageURL      string         `gorm:"type:varchar(500)" json:"imageUrl,omitempty"`
+	FaviconURL    string         `gorm:"type:varchar(500)" json:"faviconUrl,omitempty"`
+	SiteName      string         `gorm:"type:varchar(100);index:idx_link_previews_fulltext,class:FULLTEXT" json:"siteName,omitempty"`
+	ContentType   string         `gorm:"type:varchar(50)" json:"contentType,omitempty"`
+	Status        PreviewStatus  `gorm:"type:varchar(20);default:pending;index" json:"status"`
+	Error         string         `gorm:"type:text" json:"error,omitempty"`
+	Attempts      int            `gorm:"default:0" json:"attempts"`
+	NextAttemptAt *time.Time     `json:"nextAttemptAt,omitempty"`
+	FetchedAt     time.Time      `gorm:"index" json:"fetchedAt"`
+	CreatedAt     time.Time      `json:"createdAt"`
+	UpdatedAt     time.Time      `json:"updatedAt"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+type PreviewStatus string
+
+const (
+	PreviewStatusPending  PreviewStatus = "pending"
+	PreviewStatusFetching PreviewStatus = "fetching"
+	PreviewStatusReady    PreviewStatus = "ready"
+	PreviewStatusFailed   PreviewStatus = "failed"
+)
 
 func (lp *LinkPreview) BeforeCreate(tx *gorm.DB) error {
 	if lp.ID == uuid.Nil {
@@ -243,6 +403,74 @@ func (lp *LinkPreview) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// LinkCheck is the health of an external bookmark's URL as last seen by the
+// link checker. URL is the TargetURL that was checked; RedirectURL is where
+// it finally led when that differs.
+type LinkCheck struct {
+	ID                  uuid.UUID  `gorm:"type:char(36);primary_key" json:"id"`
+	BookmarkID          uuid.UUID  `gorm:"type:char(36);not null;uniqueIndex" json:"bookmarkId"`
+	UserID              uuid.UUID  `gorm:"type:char(36);not null;index" json:"userId"`
+	URL                 string     `gorm:"type:varchar(500);not null" json:"url"`
+	StatusCode          int        `json:"statusCode,omitempty"`
+	RedirectURL         string     `gorm:"type:varchar(500)" json:"redirectUrl,omitempty"`
+	PermanentRedirect   bool       `gorm:"default:false;index" json:"permanentRedirect"`
+	Error               string     `gorm:"type:varchar(255)" json:"error,omitempty"`
+	ConsecutiveFailures int        `gorm:"default:0" json:"consecutiveFailures"`
+	IsBroken            bool       `gorm:"default:false;index" json:"isBroken"`
+	LastCheckedAt       *time.Time `json:"lastCheckedAt,omitempty"`
+	NextCheckAt         time.Time  `gorm:"index" json:"nextCheckAt"`
+	CreatedAt           time.Time  `json:"createdAt"`
+	UpdatedAt           time.Time  `json:"updatedAt"`
+}
+
+func (lc *LinkCheck) BeforeCreate(tx *gorm.DB) error {
+	if lc.ID == uuid.Nil {
+		lc.ID = uuid.New()
+	}
+	return nil
+}
+
+// PageArchive is a saved copy of an external bookmark's page: the readable
+// main content as sanitized HTML and as plain text, kept in a blob store
+// under HTMLKey and TextKey. Content holds the text again, cut to a size the
+// search index takes.
+type PageArchive struct {
+	ID            uuid.UUID      `gorm:"type:char(36);primary_key" json:"id"`
+	BookmarkID    uuid.UUID      `gorm:"type:char(36);not null;uniqueIndex" json:"bookmarkId"`
+	UserID        uuid.UUID      `gorm:"type:char(36);not null;index" json:"userId"`
+	URL           string         `gorm:"type:varchar(500);not null" json:"url"`
+	FinalURL      string         `gorm:"type:varchar(500)" json:"finalUrl,omitempty"`
+	Title         string         `gorm:"type:varchar(255)" json:"title,omitempty"`
+	Status        ArchiveStatus  `gorm:"type:varchar(20);default:pending;index" json:"status"`
+	Error         string         `gorm:"type:text" json:"error,omitempty"`
+	Attempts      int            `gorm:"default:0" json:"attempts"`
+	NextAttemptAt *time.Time     `json:"nextAttemptAt,omitempty"`
+	HTMLKey       string         `gorm:"type:varchar(255)" json:"-"`
+	TextKey       string         `gorm:"type:varchar(255)" json:"-"`
+	Size          int64          `json:"size"`
+	Content       string         `gorm:"type:mediumtext;index:idx_page_archives_fulltext,class:FULLTEXT" json:"-"`
+	ArchivedAt    *time.Time     `json:"archivedAt,omitempty"`
+	CreatedAt     time.Time      `json:"createdAt"`
+	UpdatedAt     time.Time      `json:"updatedAt"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+type ArchiveStatus string
+
+const (
+	ArchiveStatusPending  ArchiveStatus = "pending"
+	ArchiveStatusFetching ArchiveStatus = "fetching"
+	ArchiveStatusReady    ArchiveStatus = "ready"
+	ArchiveStatusFailed   ArchiveStatus = "failed"
+)
+
+func (pa *PageArchive) BeforeCreate(tx *gorm.DB) error {
+	if pa.ID == uuid.Nil {
+		pa.ID = uuid.New()
+	}
+	return nil
+}
+
 type ReadLaterStatus string
 
 const (
@@ -275,7 +503,7 @@ type BookmarkComment struct {
 	ID         uuid.UUID      `gorm:"type:char(36);primary_key" json:"id"`
 	BookmarkID uuid.UUID      `gorm:"type:char(36);not null;index" json:"bookmarkId"`
 	UserID     uuid.UUID      `gorm:"type:char(36);not null;index" json:"userId"`
-	Content    string         `gorm:"type:text;not null" json:"content"`
+	Content    string         `gorm:"type:text;not null;index:idx_bookmark_comments_fulltext,class:FULLTEXT" json:"content"`
 	ParentID   *uuid.UUID     `gorm:"type:char(36);index" json:"parentId,omitempty"`
 	CreatedAt  time.Time      `json:"createdAt"`
 	UpdatedAt  time.Time      `json:"updatedAt"`
@@ -291,13 +519,13 @@ func (bc *BookmarkComment) BeforeCreate(tx *gorm.DB) error {
 
 type BookmarkVersion struct {
 	ID          uuid.UUID `gorm:"type:char(36);primary_key" json:"id"`
-	BookmarkID  uuid.UUID `gorm:"type:char(36);not null;index" json:"bookmarkId"`
+	BookmarkID  uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_bookmark_versions_version,priority:1" json:"bookmarkId"`
 	UserID      uuid.UUID `gorm:"type:char(36);not null" json:"userId"`
 	Title       string    `gorm:"type:varchar(255)" json:"title"`
 	Description string    `gorm:"type:text" json:"description,omitempty"`
 	TargetURL   string    `gorm:"type:varchar(500)" json:"targetUrl,omitempty"`
 	Metadata    string    `gorm:"type:json" json:"metadata,omitempty"`
-	Version     int       `gorm:"not null" json:"version"`
+	Version     int       `gorm:"not null;uniqueIndex:idx_bookmark_versions_version,priority:2" json:"version"`
 	ChangeNote  string    `gorm:"type:text" json:"changeNote,omitempty"`
 	CreatedAt   time.Time `json:"createdAt"`
 }
@@ -309,16 +537,42 @@ func (bv *BookmarkVersion) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+type ExpirationAction string
+
+const (
+	ExpirationActionArchive               ExpirationAction = "archive"
+	ExpirationActionDelete                ExpirationAction = "delete"
+	ExpirationActionMove                  ExpirationAction = "move"
+	ExpirationActionRemoveFromCollections ExpirationAction = "remove_from_collections"
+	ExpirationActionNotify                ExpirationAction = "notify"
+)
+
+func (a ExpirationAction) IsValid() bool {
+	switch a {
+	case ExpirationActionArchive, ExpirationActionDelete, ExpirationActionMove,
+		ExpirationActionRemoveFromCollections, ExpirationActionNotify:
+		return true
+	}
+	return false
+}
+
+// BookmarkExpiration applies Action to a bookmark at ExpiresAt. Attempts
+// counts failed tries; after one the sweeper waits until NextAttemptAt, and
+// once attempts run out the expiration stays expired with Error set.
 type BookmarkExpiration struct {
-	ID         uuid.UUID      `gorm:"type:char(36);primary_key" json:"id"`
-	BookmarkID uuid.UUID      `gorm:"type:char(36);not null;uniqueIndex" json:"bookmarkId"`
-	UserID     uuid.UUID      `gorm:"type:char(36);not null;index" json:"userId"`
-	ExpiresAt  time.Time      `gorm:"not null" json:"expiresAt"`
-	Action     string         `gorm:"type:varchar(20);default:archive" json:"action"`
-	IsExpired  bool           `gorm:"default:false" json:"isExpired"`
-	CreatedAt  time.Time      `json:"createdAt"`
-	UpdatedAt  time.Time      `json:"updatedAt"`
-	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+	ID             uuid.UUID        `gorm:"type:char(36);primary_key" json:"id"`
+	BookmarkID     uuid.UUID        `gorm:"type:char(36);not null;uniqueIndex" json:"bookmarkId"`
+	UserID         uuid.UUID        `gorm:"type:char(36);not null;index" json:"userId"`
+	ExpiresAt      time.Time        `gorm:"not null;index" json:"expiresAt"`
+	Action         ExpirationAction `gorm:"type:varchar(30);default:archive" json:"action"`
+	TargetFolderID *uuid.UUID       `gorm:"type:char(36)" json:"targetFolderId,omitempty"`
+	IsExpired      bool             `gorm:"default:false" json:"isExpired"`
+	Attempts       int              `gorm:"default:0" json:"attempts"`
+	NextAttemptAt  *time.Time       `json:"nextAttemptAt,omitempty"`
+	Error          string           `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt      time.Time        `json:"createdAt"`
+	UpdatedAt      time.Time        `json:"updatedAt"`
+	DeletedAt      gorm.DeletedAt   `gorm:"index" json:"-"`
 }
 
 func (be *BookmarkExpiration) BeforeCreate(tx *gorm.DB) error {
@@ -350,6 +604,168 @@ func (bt *BookmarkTemplate) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// OutboxEvent is a domain event waiting to be relayed to the event stream.
+// It is written in the same transaction as the change that produced it.
+type OutboxEvent struct {
+	ID            uuid.UUID  `gorm:"type:char(36);primary_key" json:"id"`
+	EventType     string     `gorm:"type:varchar(100);not null" json:"eventType"`
+	SchemaVersion int        `gorm:"not null" json:"schemaVersion"`
+	AggregateID   uuid.UUID  `gorm:"type:char(36);not null;index" json:"aggregateId"`
+	Payload       string     `gorm:"type:json" json:"payload"`
+	Attempts      int        `gorm:"default:0" json:"attempts"`
+	LastError     string     `gorm:"type:text" json:"lastError,omitempty"`
+	OccurredAt    time.Time  `gorm:"not null;index" json:"occurredAt"`
+	PublishedAt   *time.Time `gorm:"index" json:"publishedAt,omitempty"`
+}
+
+func (oe *OutboxEvent) BeforeCreate(tx *gorm.DB) error {
+	if oe.ID == uuid.Nil {
+		oe.ID = uuid.New()
+	}
+	return nil
+}
+
+type ImportJobStatus string
+
+const (
+	ImportJobQueued    ImportJobStatus = "queued"
+	ImportJobRunning   ImportJobStatus = "running"
+	ImportJobCompleted ImportJobStatus = "completed"
+	ImportJobFailed    ImportJobStatus = "failed"
+)
+
+// ImportJob tracks an import running in the background. Progress is the
+// share of the uploaded file read so far, from 0 to 100.
+type ImportJob struct {
+	ID          uuid.UUID       `gorm:"type:char(36);primary_key" json:"id"`
+	UserID      uuid.UUID       `gorm:"type:char(36);not null;index" json:"userId"`
+	WorkspaceID uuid.UUID       `gorm:"type:char(36);not null" json:"workspaceId"`
+	FolderID    *uuid.UUID      `gorm:"type:char(36)" json:"folderId,omitempty"`
+	Format      string          `gorm:"type:varchar(20);not null" json:"format"`
+	Status      ImportJobStatus `gorm:"type:varchar(20);default:queued;index" json:"status"`
+	Progress    int             `gorm:"default:0" json:"progress"`
+	Processed   int             `gorm:"default:0" json:"processed"`
+	Imported    int             `gorm:"default:0" json:"imported"`
+	Failed      int             `gorm:"default:0" json:"failed"`
+	Result      *ImportResult   `gorm:"type:json;serializer:json" json:"result,omitempty"`
+	Error       string          `gorm:"type:text" json:"error,omitempty"`
+	StartedAt   *time.Time      `json:"startedAt,omitempty"`
+	FinishedAt  *time.Time      `json:"finishedAt,omitempty"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	UpdatedAt   time.Time       `gorm:"index" json:"updatedAt"`
+}
+
+func (ij *ImportJob) BeforeCreate(tx *gorm.DB) error {
+	if ij.ID == uuid.Nil {
+		ij.ID = uuid.New()
+	}
+	return nil
+}
+
+// SmartFolder is a virtual folder: its bookmarks are the ones matching
+// Filter when it is opened, wherever they are filed.
+type SmartFolder struct {
+	ID          uuid.UUID         `gorm:"type:char(36);primary_key" json:"id"`
+	UserID      uuid.UUID         `gorm:"type:char(36);not null;index" json:"userId"`
+	WorkspaceID uuid.UUID         `gorm:"type:char(36);not null;index" json:"workspaceId"`
+	Name        string            `gorm:"type:varchar(100);not null" json:"name"`
+	Color       string            `gorm:"type:varchar(20)" json:"color,omitempty"`
+	Icon        string            `gorm:"type:varchar(50)" json:"icon,omitempty"`
+	Filter      SmartFolderFilter `gorm:"type:json;serializer:json" json:"filter"`
+	Sort        string            `gorm:"type:varchar(20)" json:"sort,omitempty"`
+	Position    int               `gorm:"default:0" json:"position"`
+	CreatedAt   time.Time         `json:"createdAt"`
+	UpdatedAt   time.Time         `json:"updatedAt"`
+	DeletedAt   gorm.DeletedAt    `gorm:"index" json:"-"`
+}
+
+func (sf *SmartFolder) BeforeCreate(tx *gorm.DB) error {
+	if sf.ID == uuid.Nil {
+		sf.ID = uuid.New()
+	}
+	return nil
+}
+
+// SmartFolderFilter is the saved query of a smart folder. Every field that is
+// set must match.
+type SmartFolderFilter struct {
+	// Query is a search expression as accepted by the search endpoint.
+	Query string   `json:"query,omitempty"`
+	Type  string   `json:"type,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+	// Domain also matches its subdomains.
+	Domain string `json:"domain,omitempty"`
+	// CreatedFrom and CreatedTo are inclusive YYYY-MM-DD dates.
+	CreatedFrom string `json:"createdFrom,omitempty"`
+	CreatedTo   string `json:"createdTo,omitempty"`
+	// CreatedWithinDays keeps bookmarks created in the last n days, counted
+	// from when the folder is opened.
+	CreatedWithinDays int   `json:"createdWithinDays,omitempty"`
+	HasReminder       *bool `json:"hasReminder,omitempty"`
+	// ReadLater is a read-later status; only bookmarks on the list match.
+	ReadLater string `json:"readLater,omitempty"`
+}
+
+// BookmarkRule files and tags a user's bookmarks automatically. Enabled
+// rules run in Position order; every rule whose conditions all match
+// applies its actions, and StopProcessing skips the rules after it.
+type BookmarkRule struct {
+	ID             uuid.UUID      `gorm:"type:char(36);primary_key" json:"id"`
+	UserID         uuid.UUID      `gorm:"type:char(36);not null;index" json:"userId"`
+	Name           string         `gorm:"type:varchar(100);not null" json:"name"`
+	Position       int            `gorm:"default:0" json:"position"`
+	Enabled        bool           `gorm:"not null" json:"enabled"`
+	StopProcessing bool           `gorm:"not null" json:"stopProcessing"`
+	Conditions     RuleConditions `gorm:"type:json;serializer:json" json:"conditions"`
+	Actions        RuleActions    `gorm:"type:json;serializer:json" json:"actions"`
+	CreatedAt      time.Time      `json:"createdAt"`
+	UpdatedAt      time.Time      `json:"updatedAt"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (br *BookmarkRule) BeforeCreate(tx *gorm.DB) error {
+	if br.ID == uuid.Nil {
+		br.ID = uuid.New()
+	}
+	return nil
+}
+
+// RuleConditions must all hold for a rule to match; unset ones are ignored,
+// so a rule without conditions matches every bookmark.
+type RuleConditions struct {
+	// Domain matches the target URL's host or any of its subdomains.
+	Domain string `json:"domain,omitempty"`
+	// URLPattern is a regular expression matched against the target URL.
+	URLPattern string       `json:"urlPattern,omitempty"`
+	Type       BookmarkType `json:"type,omitempty"`
+	// TitleKeywords match when any of them appears in the title, ignoring case.
+	TitleKeywords []string   `json:"titleKeywords,omitempty"`
+	WorkspaceID   *uuid.UUID `json:"workspaceId,omitempty"`
+}
+
+type RuleActions struct {
+	// FolderID files bookmarks that are not in a folder yet, as long as the
+	// folder is in the bookmark's workspace.
+	FolderID     *uuid.UUID            `json:"folderId,omitempty"`
+	TagIDs       []uuid.UUID           `json:"tagIds,omitempty"`
+	CollectionID *uuid.UUID            `json:"collectionId,omitempty"`
+	ReadLater    *RuleReadLaterAction  `json:"readLater,omitempty"`
+	Expiration   *RuleExpirationAction `json:"expiration,omitempty"`
+}
+
+type RuleReadLaterAction struct {
+	Priority int `json:"priority"`
+}
+
+// RuleExpirationAction expires bookmarks AfterDays days after the rule is
+// applied to them. Imported and re-applied bookmarks may be much older than
+// that, so their creation date is not used.
+type RuleExpirationAction struct {
+	AfterDays      int              `json:"afterDays"`
+	Action         ExpirationAction `json:"action,omitempty"`
+	TargetFolderID *uuid.UUID       `json:"targetFolderId,omitempty"`
+}
+
 // Analytics & Stats DTOs
 
 type BookmarkStats struct {
@@ -363,17 +779,69 @@ type BookmarkStats struct {
 }
 
 type DuplicateCheckResult struct {
-	IsDuplicate bool      `json:"isDuplicate"`
-	Existing    *Bookmark `json:"existing,omitempty"`
+	IsDuplicate  bool      `json:"isDuplicate"`
+	CanonicalURL string    `json:"canonicalUrl,omitempty"`
+	Existing     *Bookmark `json:"existing,omitempty"`
+}
+
+type ActivityFilter struct {
+	Actions []string
+	From    *time.Time
+	To      *time.Time
 }
 
 type BookmarkSearchParams struct {
-	Query    string `form:"query" json:"query"`
-	Type     string `form:"type" json:"type,omitempty"`
-	FolderID string `form:"folderId" json:"folderId,omitempty"`
-	Sort     string `form:"sort" json:"sort,omitempty"`
-	Page     int    `form:"page" json:"page"`
-	Limit    int    `form:"limit" json:"limit"`
+	Query       string `form:"query" json:"query"`
+	Type        string `form:"type" json:"type,omitempty"`
+	FolderID    string `form:"folderId" json:"folderId,omitempty"`
+	WorkspaceID string `form:"workspaceId" json:"workspaceId,omitempty"`
+	Sort        string `form:"sort" json:"sort,omitempty"`
+	Page        int    `form:"page" json:"page"`
+	Limit       int    `form:"limit" json:"limit"`
+}
+
+// SearchQuery is a parsed search expression: free text for the full-text
+// index plus qualifiers such as tag:design or created:>2026-01-01.
+type SearchQuery struct {
+	Text    string
+	Filters []SearchFilter
+}
+
+// SearchFilter is one qualifier. Date qualifiers are normalised to the
+// half-open range [From, To); either bound may be nil.
+type SearchFilter struct {
+	Field  string
+	Value  string
+	From   *time.Time
+	To     *time.Time
+	Negate bool
+}
+
+const (
+	SearchFieldTag       = "tag"
+	SearchFieldType      = "type"
+	SearchFieldFolder    = "folder"
+	SearchFieldCreated   = "created"
+	SearchFieldUpdated   = "updated"
+	SearchFieldIs        = "is"
+	SearchFieldHas       = "has"
+	SearchFieldDomain    = "domain"
+	SearchFieldReadLater = "readlater"
+	SearchFieldText      = "text"
+)
+
+// SearchResult is a bookmark matched by a search. The *Text fields carry the
+// related content the bookmark was matched against and are only used to
+// build Highlights.
+type SearchResult struct {
+	Bookmark
+	Score       float64           `json:"score"`
+	Highlights  map[string]string `gorm:"-" json:"highlights,omitempty"`
+	PreviewText string            `json:"-"`
+	TagText     string            `json:"-"`
+	NoteText    string            `json:"-"`
+	CommentText string            `json:"-"`
+	ArchiveText string            `json:"-"`
 }
 
 type ExportData struct {
@@ -403,6 +871,132 @@ type ImportResult struct {
 	Errors   []string `json:"errors,omitempty"`
 }
 
+// Import formats understood by the background importers.
+const (
+	ImportFormatNetscape = "netscape"
+	ImportFormatPocket   = "pocket"
+	ImportFormatRaindrop = "raindrop"
+	ImportFormatPinboard = "pinboard"
+	ImportFormatCSV      = "csv"
+)
+
+// CSVColumnMapping names the CSV header used for each bookmark field. Empty
+// names fall back to common header names such as "url", "title" or "tags".
+type CSVColumnMapping struct {
+	URL             string `form:"urlColumn" json:"urlColumn,omitempty"`
+	Title           string `form:"titleColumn" json:"titleColumn,omitempty"`
+	Description     string `form:"descriptionColumn" json:"descriptionColumn,omitempty"`
+	Tags            string `form:"tagsColumn" json:"tagsColumn,omitempty"`
+	Folder          string `form:"folderColumn" json:"folderColumn,omitempty"`
+	Created         string `form:"createdColumn" json:"createdColumn,omitempty"`
+	ReadLater       string `form:"readLaterColumn" json:"readLaterColumn,omitempty"`
+	TagSeparator    string `form:"tagSeparator" json:"tagSeparator,omitempty"`
+	FolderSeparator string `form:"folderSeparator" json:"folderSeparator,omitempty"`
+}
+
+// Backup archives are a single JSON object: the BackupHeader fields followed
+// by one array per section. BackupFormatVersion is bumped whenever the layout
+// changes incompatibly.
+const (
+	BackupFormat        = "quckapp-bookmarks-backup"
+	BackupFormatVersion = 1
+)
+
+const (
+	BackupSectionFolders             = "folders"
+	BackupSectionTags                = "tags"
+	BackupSectionCollections         = "collections"
+	BackupSectionTemplates           = "templates"
+	BackupSectionSmartFolders        = "smartFolders"
+	BackupSectionRules               = "rules"
+	BackupSectionBookmarks           = "bookmarks"
+	BackupSectionTagMappings         = "tagMappings"
+	BackupSectionCollectionBookmarks = "collectionBookmarks"
+	BackupSectionNotes               = "notes"
+	BackupSectionComments            = "comments"
+	BackupSectionReminders           = "reminders"
+	BackupSectionFavorites           = "favorites"
+	BackupSectionReadLater           = "readLater"
+	BackupSectionPreviews            = "previews"
+	BackupSectionVersions            = "versions"
+	BackupSectionExpirations         = "expirations"
+	BackupSectionShares              = "shares"
+	BackupSectionCollectionMembers   = "collectionMembers"
+	BackupSectionActivities          = "activities"
+)
+
+// BackupSections lists the sections in archive order. Each section only
+// refers to sections before it, which lets a restore stream the archive.
+//
+// Page archives and link checks are left out: both are rebuilt by fetching
+// the bookmarks again, and archives keep their content in blob storage.
+// Share links are left out too, as restoring them would publish links again
+// that may have been withdrawn on purpose. Shares and collection members are
+// restored as invitations, which the recipients have to accept again.
+var BackupSections = []string{
+	BackupSectionFolders,
+	BackupSectionTags,
+	BackupSectionCollections,
+	BackupSectionTemplates,
+	BackupSectionSmartFolders,
+	BackupSectionRules,
+	BackupSectionBookmarks,
+	BackupSectionTagMappings,
+	BackupSectionCollectionBookmarks,
+	BackupSectionNotes,
+	BackupSectionComments,
+	BackupSectionReminders,
+	BackupSectionFavorites,
+	BackupSectionReadLater,
+	BackupSectionPreviews,
+	BackupSectionVersions,
+	BackupSectionExpirations,
+	BackupSectionShares,
+	BackupSectionCollectionMembers,
+	BackupSectionActivities,
+}
+
+type BackupHeader struct {
+	Format      string     `json:"format"`
+	Version     int        `json:"version"`
+	ExportedAt  time.Time  `json:"exportedAt"`
+	UserID      uuid.UUID  `json:"userId"`
+	WorkspaceID *uuid.UUID `json:"workspaceId,omitempty"`
+}
+
+const (
+	// RestoreConflictSkip reuses folders, tags and collections with the same
+	// name and skips bookmarks that already exist, along with their notes,
+	// comments and other dependents.
+	RestoreConflictSkip = "skip"
+	// RestoreConflictDuplicate restores everything as new records.
+	RestoreConflictDuplicate = "duplicate"
+)
+
+type RestoreOptions struct {
+	// WorkspaceID, when set, restores every record into this workspace
+	// instead of the one it was exported from.
+	WorkspaceID *uuid.UUID
+	DryRun      bool
+	OnConflict  string
+}
+
+type RestoreConflict struct {
+	Section    string     `json:"section"`
+	SourceID   uuid.UUID  `json:"sourceId"`
+	ExistingID *uuid.UUID `json:"existingId,omitempty"`
+	Reason     string     `json:"reason"`
+	Resolution string     `json:"resolution"`
+}
+
+type RestoreResult struct {
+	DryRun    bool              `json:"dryRun"`
+	Created   map[string]int    `json:"created"`
+	Skipped   map[string]int    `json:"skipped"`
+	Conflicts []RestoreConflict `json:"conflicts,omitempty"`
+	Errors    []string          `json:"errors,omitempty"`
+}
+
 type ReadLaterStats struct {
 	Total     int64 `json:"total"`
 	Unread    int64 `json:"unread"`
@@ -411,10 +1005,96 @@ type ReadLaterStats struct {
 	Archived  int64 `json:"archived"`
 }
 
+// Version DTOs
+
+type VersionDiff struct {
+	BookmarkID  uuid.UUID     `json:"bookmarkId"`
+	FromVersion int           `json:"fromVersion"`
+	ToVersion   int           `json:"toVersion"`
+	Changes     []FieldChange `json:"changes"`
+}
+
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// Event DTOs
+
+// EventSchemaVersion is bumped whenever the shape of an event's Data changes
+// incompatibly, so consumers can tell old and new payloads apart.
+const EventSchemaVersion = 1
+
+const (
+	EventBookmarkCreated         = "bookmark.created"
+	EventBookmarkUpdated         = "bookmark.updated"
+	EventBookmarkDeleted         = "bookmark.deleted"
+	EventBookmarkMoved           = "bookmark.moved"
+	EventShareCreated            = "share.created"
+	EventShareAccepted           = "share.accepted"
+	EventShareUpdated            = "share.updated"
+	EventShareRevoked            = "share.revoked"
+	EventCollectionMemberInvited = "collection.member_invited"
+	EventCollectionMemberJoined  = "collection.member_joined"
+	EventCollectionMemberUpdated = "collection.member_updated"
+	EventCollectionMemberRemoved = "collection.member_removed"
+	EventReminderFired           = "reminder.fired"
+)
+
+// Event is the envelope published to the event stream.
+type Event struct {
+	ID            uuid.UUID       `json:"id"`
+	Type          string          `json:"type"`
+	SchemaVersion int             `json:"schemaVersion"`
+	AggregateID   uuid.UUID       `json:"aggregateId"`
+	OccurredAt    time.Time       `json:"occurredAt"`
+	Data          json.RawMessage `json:"data"`
+}
+
+type BookmarkDeletedEvent struct {
+	BookmarkID  uuid.UUID `json:"bookmarkId"`
+	UserID      uuid.UUID `json:"userId"`
+	WorkspaceID uuid.UUID `json:"workspaceId"`
+}
+
+type BookmarkMovedEvent struct {
+	BookmarkID   uuid.UUID  `json:"bookmarkId"`
+	UserID       uuid.UUID  `json:"userId"`
+	FromFolderID *uuid.UUID `json:"fromFolderId"`
+	ToFolderID   *uuid.UUID `json:"toFolderId"`
+}
+
+// Notification DTOs
+
+type Notification struct {
+	Type       string      `json:"type"`
+	UserID     uuid.UUID   `json:"userId"`
+	BookmarkID uuid.UUID   `json:"bookmarkId"`
+	Message    string      `json:"message,omitempty"`
+	Data       interface{} `json:"data,omitempty"`
+	OccurredAt time.Time   `json:"occurredAt"`
+}
+
+const (
+	NotificationTypeReminder   = "bookmark.reminder"
+	NotificationTypeExpiration = "bookmark.expired"
+)
+
 // Request DTOs
 
+// UpdateBookmarkRequest replaces the bookmark's content. TargetURL and
+// Position are left as they are when unset.
+type UpdateBookmarkRequest struct {
+	Title       string  `json:"title"`
+	Description string  `json:"description,omitempty"`
+	TargetURL   *string `json:"targetUrl,omitempty"`
+	Position    *int    `json:"position,omitempty"`
+	Metadata    string  `json:"metadata,omitempty"`
+	ChangeNote  string  `json:"changeNote,omitempty"`
+}
+
 type CreateTagRequest struct {
-	UserID      string `json:"userId" binding:"required"`
 	WorkspaceID string `json:"workspaceId" binding:"required"`
 	Name        string `json:"name" binding:"required"`
 	Color       string `json:"color,omitempty"`
@@ -430,7 +1110,6 @@ type TagBookmarkRequest struct {
 }
 
 type CreateCollectionRequest struct {
-	UserID      string `json:"userId" binding:"required"`
 	WorkspaceID string `json:"workspaceId" binding:"required"`
 	Name        string `json:"name" binding:"required"`
 	Description string `json:"description,omitempty"`
@@ -452,10 +1131,73 @@ type AddToCollectionRequest struct {
 	BookmarkIDs []string `json:"bookmarkIds" binding:"required"`
 }
 
+// InviteCollectionMemberRequest invites a user into a collection. Role
+// defaults to viewer.
+type InviteCollectionMemberRequest struct {
+	UserID  string `json:"userId" binding:"required"`
+	Role    string `json:"role,omitempty" binding:"omitempty,oneof=viewer commenter editor"`
+	Message string `json:"message,omitempty"`
+}
+
+type UpdateCollectionMemberRequest struct {
+	Role string `json:"role" binding:"required,oneof=viewer commenter editor"`
+}
+
+// CreateShareLinkRequest creates a public link to a collection, optionally
+// behind a password and with an expiry.
+type CreateShareLinkRequest struct {
+	Password  string     `json:"password,omitempty" binding:"omitempty,min=4,max=72"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// PublicCollection is what a share link shows: the collection and a page of
+// its bookmarks, without owners, workspaces or anything private.
+type PublicCollection struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Color       string           `json:"color,omitempty"`
+	Icon        string           `json:"icon,omitempty"`
+	Bookmarks   []PublicBookmark `json:"bookmarks"`
+	Total       int64            `json:"total"`
+	Page        int              `json:"page"`
+	Limit       int              `json:"limit"`
+}
+
+type PublicBookmark struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	URL         string         `json:"url,omitempty"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	Preview     *PublicPreview `json:"preview,omitempty"`
+}
+
+type PublicPreview struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"imageUrl,omitempty"`
+	FaviconURL  string `json:"faviconUrl,omitempty"`
+	SiteName    string `json:"siteName,omitempty"`
+}
+
+// ShareBookmarkRequest shares a resource. ResourceType defaults to bookmark,
+// in which case BookmarkID may be given instead of ResourceID; Role defaults
+// to viewer.
 type ShareBookmarkRequest struct {
-	BookmarkID string `json:"bookmarkId" binding:"required"`
-	SharedWith string `json:"sharedWith" binding:"required"`
-	Message    string `json:"message,omitempty"`
+	ResourceType string     `json:"resourceType,omitempty" binding:"omitempty,oneof=bookmark folder collection"`
+	ResourceID   string     `json:"resourceId,omitempty"`
+	BookmarkID   string     `json:"bookmarkId,omitempty"`
+	SharedWith   string     `json:"sharedWith" binding:"required"`
+	Role         string     `json:"role,omitempty" binding:"omitempty,oneof=viewer commenter editor"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+	Message      string     `json:"message,omitempty"`
+}
+
+// UpdateShareRequest changes a share's role or expiry; omitted fields are
+// left alone and ClearExpiry makes the share permanent.
+type UpdateShareRequest struct {
+	Role        string     `json:"role,omitempty" binding:"omitempty,oneof=viewer commenter editor"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	ClearExpiry bool       `json:"clearExpiry,omitempty"`
 }
 
 type CreateNoteRequest struct {
@@ -494,12 +1236,12 @@ type UpdateCommentRequest struct {
 }
 
 type SetExpirationRequest struct {
-	ExpiresAt string `json:"expiresAt" binding:"required"`
-	Action    string `json:"action,omitempty"`
+	ExpiresAt      string `json:"expiresAt" binding:"required"`
+	Action         string `json:"action,omitempty" binding:"omitempty,oneof=archive delete move remove_from_collections notify"`
+	TargetFolderID string `json:"targetFolderId,omitempty"`
 }
 
 type CreateTemplateRequest struct {
-	UserID      string `json:"userId" binding:"required"`
 	WorkspaceID string `json:"workspaceId" binding:"required"`
 	Name        string `json:"name" binding:"required"`
 	Description string `json:"description,omitempty"`
@@ -516,27 +1258,269 @@ type ApplyTemplateRequest struct {
 	TargetURL   string `json:"targetUrl,omitempty"`
 }
 
+// Folder Tree DTOs
+
+// FolderNode is a folder with its subfolders. BookmarkCount counts the
+// bookmarks directly in the folder, TotalBookmarkCount those in its whole
+// subtree.
+type FolderNode struct {
+	BookmarkFolder
+	Depth              int           `json:"depth"`
+	BookmarkCount      int64         `json:"bookmarkCount"`
+	TotalBookmarkCount int64         `json:"totalBookmarkCount"`
+	Children           []*FolderNode `json:"children"`
+}
+
+// FolderDeleteMode says what happens to a folder's contents when it is deleted.
+type FolderDeleteMode string
+
+const (
+	// FolderDeleteMoveToParent hands subfolders and bookmarks to the
+	// folder's parent, or to the top level.
+	FolderDeleteMoveToParent FolderDeleteMode = "move_to_parent"
+	// FolderDeleteCascade deletes every subfolder and bookmark with it.
+	FolderDeleteCascade FolderDeleteMode = "cascade"
+	// FolderDeleteRefuse only deletes empty folders.
+	FolderDeleteRefuse FolderDeleteMode = "refuse"
+)
+
+// UpdateFolderRequest replaces the folder's fields. A nil ParentID puts the
+// folder at the top level; Position is left as it is when unset.
+type UpdateFolderRequest struct {
+	Name     string     `json:"name"`
+	Color    string     `json:"color,omitempty"`
+	Icon     string     `json:"icon,omitempty"`
+	ParentID *uuid.UUID `json:"parentId,omitempty"`
+	Position *int       `json:"position,omitempty"`
+}
+
+type MoveFolderRequest struct {
+	// ParentID is the new parent; empty moves the folder to the top level.
+	ParentID string `json:"parentId,omitempty"`
+	Position *int   `json:"position,omitempty"`
+}
+
 // Bulk Operation DTOs
 
+// BulkMode selects what a bulk operation does when some of its items fail.
+type BulkMode string
+
+const (
+	// BulkModeBestEffort applies the items that can be applied and reports
+	// the rest.
+	BulkModeBestEffort BulkMode = "best_effort"
+	// BulkModeAtomic applies every item or, if any item fails, none.
+	BulkModeAtomic BulkMode = "atomic"
+)
+
 type BulkDeleteRequest struct {
-	IDs []string `json:"ids" binding:"required"`
+	IDs  []string `json:"ids" binding:"required,max=1000"`
+	Mode BulkMode `json:"mode,omitempty" binding:"omitempty,oneof=best_effort atomic"`
 }
 
 type BulkMoveRequest struct {
-	IDs      []string `json:"ids" binding:"required"`
+	IDs      []string `json:"ids" binding:"required,max=1000"`
 	FolderID string   `json:"folderId,omitempty"`
+	Mode     BulkMode `json:"mode,omitempty" binding:"omitempty,oneof=best_effort atomic"`
 }
 
 type BulkTagRequest struct {
-	IDs    []string `json:"ids" binding:"required"`
-	TagIDs []string `json:"tagIds" binding:"required"`
+	IDs    []string `json:"ids" binding:"required,max=1000"`
+	TagIDs []string `json:"tagIds" binding:"required,min=1"`
+	Mode   BulkMode `json:"mode,omitempty" binding:"omitempty,oneof=best_effort atomic"`
+}
+
+type BulkCollectionRequest struct {
+	IDs          []string `json:"ids" binding:"required,max=1000"`
+	CollectionID string   `json:"collectionId" binding:"required"`
+	Mode         BulkMode `json:"mode,omitempty" binding:"omitempty,oneof=best_effort atomic"`
+}
+
+type BulkExpirationRequest struct {
+	IDs            []string `json:"ids" binding:"required,max=1000"`
+	ExpiresAt      string   `json:"expiresAt" binding:"required"`
+	Action         string   `json:"action,omitempty" binding:"omitempty,oneof=archive delete move remove_from_collections notify"`
+	TargetFolderID string   `json:"targetFolderId,omitempty"`
+	Mode           BulkMode `json:"mode,omitempty" binding:"omitempty,oneof=best_effort atomic"`
 }
 
 type ReorderRequest struct {
-	Items []ReorderItem `json:"items" binding:"required"`
+	Items []ReorderItem `json:"items" binding:"required,max=1000"`
+	Mode  BulkMode      `json:"mode,omitempty" binding:"omitempty,oneof=best_effort atomic"`
 }
 
 type ReorderItem struct {
 	ID       string `json:"id" binding:"required"`
 	Position int    `json:"position"`
 }
+
+// MoveItemRequest drags an item to just before or just after another item
+// of the same list. Exactly one of Before and After is given.
+type MoveItemRequest struct {
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+type BulkItemStatus string
+
+const (
+	BulkItemSucceeded BulkItemStatus = "succeeded"
+	BulkItemFailed    BulkItemStatus = "failed"
+	// BulkItemSkipped marks items of an atomic operation that could have been
+	// applied but were not because another item failed.
+	BulkItemSkipped BulkItemStatus = "skipped"
+)
+
+// BulkItemResult is the outcome for one ID of a bulk request.
+type BulkItemResult struct {
+	ID     string         `json:"id"`
+	Status BulkItemStatus `json:"status"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// BulkResult reports a bulk operation item by item, in request order.
+type BulkResult struct {
+	Mode      BulkMode         `json:"mode"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Skipped   int              `json:"skipped"`
+	Results   []BulkItemResult `json:"results"`
+}
+
+// Smart Folder DTOs
+
+type CreateSmartFolderRequest struct {
+	WorkspaceID string            `json:"workspaceId" binding:"required"`
+	Name        string            `json:"name" binding:"required,max=100"`
+	Color       string            `json:"color,omitempty"`
+	Icon        string            `json:"icon,omitempty"`
+	Filter      SmartFolderFilter `json:"filter"`
+	Sort        string            `json:"sort,omitempty" binding:"omitempty,oneof=newest oldest title position"`
+	Position    int               `json:"position"`
+}
+
+// UpdateSmartFolderRequest replaces the fields that are set; Filter is
+// replaced as a whole.
+type UpdateSmartFolderRequest struct {
+	Name     string             `json:"name,omitempty" binding:"max=100"`
+	Color    string             `json:"color,omitempty"`
+	Icon     string             `json:"icon,omitempty"`
+	Filter   *SmartFolderFilter `json:"filter,omitempty"`
+	Sort     string             `json:"sort,omitempty" binding:"omitempty,oneof=newest oldest title position"`
+	Position *int               `json:"position,omitempty"`
+}
+
+// Rule DTOs
+
+type CreateRuleRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+	// Enabled defaults to true.
+	Enabled        *bool          `json:"enabled,omitempty"`
+	StopProcessing bool           `json:"stopProcessing"`
+	Conditions     RuleConditions `json:"conditions"`
+	Actions        RuleActions    `json:"actions"`
+}
+
+type UpdateRuleRequest struct {
+	Name           string          `json:"name,omitempty" binding:"max=100"`
+	Enabled        *bool           `json:"enabled,omitempty"`
+	StopProcessing *bool           `json:"stopProcessing,omitempty"`
+	Conditions     *RuleConditions `json:"conditions,omitempty"`
+	Actions        *RuleActions    `json:"actions,omitempty"`
+}
+
+type ReorderRulesRequest struct {
+	IDs []string `json:"ids" binding:"required,max=1000"`
+}
+
+// RuleDryRunRequest picks the rules to try: an unsaved Rule, the saved rules
+// in RuleIDs whether enabled or not, or by default every enabled rule.
+type RuleDryRunRequest struct {
+	Rule        *CreateRuleRequest `json:"rule,omitempty"`
+	RuleIDs     []string           `json:"ruleIds,omitempty" binding:"max=100"`
+	WorkspaceID string             `json:"workspaceId,omitempty"`
+}
+
+// ReapplyRulesRequest runs the saved rules in RuleIDs, or every enabled
+// rule, over existing bookmarks. Expirations bookmarks already have are only
+// replaced with OverwriteExpirations.
+type ReapplyRulesRequest struct {
+	RuleIDs              []string `json:"ruleIds,omitempty" binding:"max=100"`
+	WorkspaceID          string   `json:"workspaceId,omitempty"`
+	OverwriteExpirations bool     `json:"overwriteExpirations,omitempty"`
+}
+
+// RuleOutcome is what the matching rules do to one bookmark. The first
+// matching rule to set a folder, read-later priority or expiration wins;
+// tags and collections add up.
+type RuleOutcome struct {
+	RuleIDs       []uuid.UUID           `json:"ruleIds"`
+	FolderID      *uuid.UUID            `json:"folderId,omitempty"`
+	TagIDs        []uuid.UUID           `json:"tagIds,omitempty"`
+	CollectionIDs []uuid.UUID           `json:"collectionIds,omitempty"`
+	ReadLater     *RuleReadLaterAction  `json:"readLater,omitempty"`
+	Expiration    *RuleExpirationAction `json:"expiration,omitempty"`
+}
+
+type RuleMatch struct {
+	Bookmark Bookmark    `json:"bookmark"`
+	Outcome  RuleOutcome `json:"outcome"`
+}
+
+// RuleDryRunResult holds one page of the bookmarks the rules would change.
+type RuleDryRunResult struct {
+	Scanned int         `json:"scanned"`
+	Matched int         `json:"matched"`
+	Matches []RuleMatch `json:"matches"`
+}
+
+// RuleReapplyResult counts what re-applying rules changed. Actions that were
+// already in effect, such as a tag the bookmark had, are not counted.
+type RuleReapplyResult struct {
+	Scanned            int `json:"scanned"`
+	Matched            int `json:"matched"`
+	Filed              int `json:"filed"`
+	Tagged             int `json:"tagged"`
+	AddedToCollections int `json:"addedToCollections"`
+	AddedToReadLater   int `json:"addedToReadLater"`
+	ExpirationsSet     int `json:"expirationsSet"`
+}
+
+// Duplicate DTOs
+
+// DuplicateGroup is a set of bookmarks in one workspace that share a
+// canonical URL, oldest first.
+type DuplicateGroup struct {
+	WorkspaceID  uuid.UUID  `json:"workspaceId"`
+	CanonicalURL string     `json:"canonicalUrl"`
+	Bookmarks    []Bookmark `json:"bookmarks"`
+}
+
+type MergeBookmarksRequest struct {
+	TargetID  string   `json:"targetId" binding:"required"`
+	SourceIDs []string `json:"sourceIds" binding:"required,min=1,max=100"`
+}
+
+// MergeResult counts what the sources added to the target. Tags and
+// collections the target already had are not counted.
+type MergeResult struct {
+	Bookmark         Bookmark `json:"bookmark"`
+	Merged           int      `json:"merged"`
+	TagsAdded        int      `json:"tagsAdded"`
+	NotesMoved       int64    `json:"notesMoved"`
+	CollectionsAdded int      `json:"collectionsAdded"`
+}
+
+// Link Check DTOs
+
+// LinkCheckFilterBroken and LinkCheckFilterRedirected select which checks
+// the broken-links report lists.
+const (
+	LinkCheckFilterBroken     = "broken"
+	LinkCheckFilterRedirected = "redirected"
+)
+
+type BrokenLink struct {
+	Bookmark Bookmark  `json:"bookmark"`
+	Check    LinkCheck `json:"check"`
+}
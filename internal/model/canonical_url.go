@@ -0,0 +1,64 @@
+package model
+
+import (
+	"net/url"
+	"strings"
+)
+
+// maxCanonicalURLLength is the size of the canonical_url column.
+const maxCanonicalURLLength = 500
+
+// trackingParams are query parameters that only identify where a link was
+// shared from. Parameters starting with utm_ are dropped as well.
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"msclkid": true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+}
+
+// CanonicalURL normalises a URL so that links to the same page compare
+// equal: the scheme and host are lowercased, default ports, fragments,
+// tracking parameters and trailing slashes are removed and the remaining
+// query parameters are sorted. Values that do not parse as absolute URLs, or
+// that re-encode longer than the column holding them, are only trimmed.
+func CanonicalURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return raw
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port := u.Port(); port != "" && !isDefaultPort(u.Scheme, port) {
+		host += ":" + port
+	}
+	u.Host = host
+	u.Fragment, u.RawFragment = "", ""
+
+	query := u.Query()
+	for key := range query {
+		if trackingParams[strings.ToLower(key)] || strings.HasPrefix(strings.ToLower(key), "utm_") {
+			query.Del(key)
+		}
+	}
+	// Encode sorts by key
+	u.RawQuery = query.Encode()
+	u.ForceQuery = false
+
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = strings.TrimRight(u.RawPath, "/")
+	if canonical := u.String(); len(canonical) <= maxCanonicalURLLength {
+		return canonical
+	}
+	return raw
+}
+
+func isDefaultPort(scheme, port string) bool {
+	return (scheme == "http" && port == "80") || (scheme == "https" && port == "443")
+}
@@ -8,8 +8,8 @@ import (
 
 type ActivityRepository interface {
 	Create(activity *model.BookmarkActivity) error
-	GetByBookmark(bookmarkID uuid.UUID, limit, offset int) ([]model.BookmarkActivity, int64, error)
-	GetByUser(userID uuid.UUID, limit, offset int) ([]model.BookmarkActivity, int64, error)
+	GetByBookmark(bookmarkID uuid.UUID, filter model.ActivityFilter, limit, offset int) ([]model.BookmarkActivity, int64, error)
+	GetByUser(userID uuid.UUID, filter model.ActivityFilter, limit, offset int) ([]model.BookmarkActivity, int64, error)
 	GetRecent(userID uuid.UUID, limit int) ([]model.BookmarkActivity, error)
 }
 
@@ -26,25 +26,30 @@ func (r *activityRepository) Create(activity *model.BookmarkActivity) error {
 	return r.db.Create(activity).Error
 }
 
-func (r *activityRepository) GetByBookmark(bookmarkID uuid.UUID, limit, offset int) ([]model.BookmarkActivity, int64, error) {
-	var activities []model.BookmarkActivity
-	var total int64
+func (r *activityRepository) GetByBookmark(bookmarkID uuid.UUID, filter model.ActivityFilter, limit, offset int) ([]model.BookmarkActivity, int64, error) {
+	return r.list(r.db.Model(&model.BookmarkActivity{}).Where("bookmark_id = ?", bookmarkID), filter, limit, offset)
+}
 
-	r.db.Model(&model.BookmarkActivity{}).Where("bookmark_id = ?", bookmarkID).Count(&total)
-	err := r.db.Where("bookmark_id = ?", bookmarkID).
-		Order("created_at DESC").
-		Limit(limit).Offset(offset).
-		Find(&activities).Error
-	return activities, total, err
+func (r *activityRepository) GetByUser(userID uuid.UUID, filter model.ActivityFilter, limit, offset int) ([]model.BookmarkActivity, int64, error) {
+	return r.list(r.db.Model(&model.BookmarkActivity{}).Where("user_id = ?", userID), filter, limit, offset)
 }
 
-func (r *activityRepository) GetByUser(userID uuid.UUID, limit, offset int) ([]model.BookmarkActivity, int64, error) {
+func (r *activityRepository) list(query *gorm.DB, filter model.ActivityFilter, limit, offset int) ([]model.BookmarkActivity, int64, error) {
 	var activities []model.BookmarkActivity
 	var total int64
 
-	r.db.Model(&model.BookmarkActivity{}).Where("user_id = ?", userID).Count(&total)
-	err := r.db.Where("user_id = ?", userID).
-		Order("created_at DESC").
+	if len(filter.Actions) > 0 {
+		query = query.Where("action IN ?", filter.Actions)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	query.Count(&total)
+	err := query.Order("created_at DESC").
 		Limit(limit).Offset(offset).
 		Find(&activities).Error
 	return activities, total, err
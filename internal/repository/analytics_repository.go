@@ -12,8 +12,6 @@ type AnalyticsRepository interface {
 	GetStats(userID uuid.UUID, workspaceID *uuid.UUID) (*model.BookmarkStats, error)
 	GetRecentBookmarks(userID uuid.UUID, limit int) ([]model.Bookmark, error)
 	GetBookmarkCountByType(userID uuid.UUID) (map[string]int64, error)
-	CheckDuplicate(userID uuid.UUID, targetID uuid.UUID, bookmarkType model.BookmarkType) (*model.Bookmark, error)
-	SearchBookmarks(userID uuid.UUID, params model.BookmarkSearchParams) ([]model.Bookmark, int64, error)
 }
 
 type analyticsRepository struct {
@@ -115,53 +113,3 @@ func (r *analyticsRepository) GetBookmarkCountByType(userID uuid.UUID) (map[stri
 	}
 	return result, nil
 }
-
-func (r *analyticsRepository) CheckDuplicate(userID uuid.UUID, targetID uuid.UUID, bookmarkType model.BookmarkType) (*model.Bookmark, error) {
-	var bookmark model.Bookmark
-	err := r.db.Where("user_id = ? AND target_id = ? AND type = ?", userID, targetID, bookmarkType).
-		First(&bookmark).Error
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, nil
-		}
-		return nil, err
-	}
-	return &bookmark, nil
-}
-
-func (r *analyticsRepository) SearchBookmarks(userID uuid.UUID, params model.BookmarkSearchParams) ([]model.Bookmark, int64, error) {
-	var bookmarks []model.Bookmark
-	var total int64
-
-	query := r.db.Model(&model.Bookmark{}).Where("user_id = ?", userID)
-
-	if params.Query != "" {
-		searchTerm := "%" + params.Query + "%"
-		query = query.Where("(title LIKE ? OR description LIKE ?)", searchTerm, searchTerm)
-	}
-	if params.Type != "" {
-		query = query.Where("type = ?", params.Type)
-	}
-	if params.FolderID != "" {
-		folderID, _ := uuid.Parse(params.FolderID)
-		query = query.Where("folder_id = ?", folderID)
-	}
-
-	query.Count(&total)
-
-	// Sorting
-	switch params.Sort {
-	case "oldest":
-		query = query.Order("created_at ASC")
-	case "title":
-		query = query.Order("title ASC")
-	case "position":
-		query = query.Order("position ASC")
-	default:
-		query = query.Order("created_at DESC")
-	}
-
-	offset := params.Page * params.Limit
-	err := query.Limit(params.Limit).Offset(offset).Find(&bookmarks).Error
-	return bookmarks, total, err
-}
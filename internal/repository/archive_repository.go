@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ArchiveRepository interface {
+	GetByBookmark(bookmarkID uuid.UUID) (*model.PageArchive, error)
+	// Request creates a pending archive for the bookmark or puts its existing
+	// one back to pending.
+	Request(archive *model.PageArchive) error
+	Update(archive *model.PageArchive) error
+	// CreateMissing queues archives for up to limit external bookmarks that
+	// have none and returns how many were queued.
+	CreateMissing(limit int) (int, error)
+	// GetDue returns pending archives whose next attempt is due and archives
+	// stuck fetching since before stuckBefore.
+	GetDue(now, stuckBefore time.Time, limit int) ([]model.PageArchive, error)
+	// Claim marks the archive as fetching only if it has not changed since it
+	// was read, so an archive is fetched once even with several instances.
+	Claim(archive *model.PageArchive) (bool, error)
+	// GetOrphaned returns archives whose bookmark was deleted, leaving out
+	// those still being fetched since stuckBefore.
+	GetOrphaned(stuckBefore time.Time, limit int) ([]model.PageArchive, error)
+	// Delete removes the archive for good, once its content is gone.
+	Delete(id uuid.UUID) error
+}
+
+type archiveRepository struct {
+	db *gorm.DB
+}
+
+func NewArchiveRepository(db *gorm.DB) ArchiveRepository {
+	db.AutoMigrate(&model.PageArchive{})
+	return &archiveRepository{db: db}
+}
+
+func (r *archiveRepository) GetByBookmark(bookmarkID uuid.UUID) (*model.PageArchive, error) {
+	var archive model.PageArchive
+	err := r.db.Where("bookmark_id = ?", bookmarkID).First(&archive).Error
+	if err != nil {
+		return nil, err
+	}
+	return &archive, nil
+}
+
+func (r *archiveRepository) Request(archive *model.PageArchive) error {
+	archive.Status = model.ArchiveStatusPending
+	archive.Attempts = 0
+	archive.Error = ""
+	archive.NextAttemptAt = nil
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "bookmark_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"url", "status", "attempts", "error", "next_attempt_at", "updated_at", "deleted_at"}),
+	}).Create(archive).Error
+}
+
+func (r *archiveRepository) Update(archive *model.PageArchive) error {
+	return r.db.Save(archive).Error
+}
+
+func (r *archiveRepository) CreateMissing(limit int) (int, error) {
+	var bookmarks []model.Bookmark
+	err := r.db.Select("bookmarks.id", "bookmarks.user_id", "bookmarks.target_url").
+		Joins("LEFT JOIN page_archives ON page_archives.bookmark_id = bookmarks.id").
+		Where("bookmarks.type = ? AND bookmarks.target_url <> '' AND page_archives.id IS NULL", model.BookmarkTypeExternal).
+		Order("bookmarks.created_at ASC").
+		Limit(limit).
+		Find(&bookmarks).Error
+	if err != nil || len(bookmarks) == 0 {
+		return 0, err
+	}
+
+	archives := make([]model.PageArchive, len(bookmarks))
+	for i, bookmark := range bookmarks {
+		archives[i] = model.PageArchive{
+			BookmarkID: bookmark.ID,
+			UserID:     bookmark.UserID,
+			URL:        bookmark.TargetURL,
+			Status:     model.ArchiveStatusPending,
+		}
+	}
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&archives)
+	return int(result.RowsAffected), result.Error
+}
+
+func (r *archiveRepository) GetDue(now, stuckBefore time.Time, limit int) ([]model.PageArchive, error) {
+	var archives []model.PageArchive
+	err := r.db.Where("(status = ? AND (next_attempt_at IS NULL OR next_attempt_at <= ?)) OR (status = ? AND updated_at < ?)",
+		model.ArchiveStatusPending, now, model.ArchiveStatusFetching, stuckBefore).
+		Where("bookmark_id IN (SELECT id FROM bookmarks WHERE deleted_at IS NULL)").
+		Order("updated_at ASC").
+		Limit(limit).
+		Find(&archives).Error
+	return archives, err
+}
+
+func (r *archiveRepository) Claim(archive *model.PageArchive) (bool, error) {
+	result := r.db.Model(&model.PageArchive{}).
+		Where("id = ? AND status = ? AND updated_at = ?", archive.ID, archive.Status, archive.UpdatedAt).
+		Update("status", model.ArchiveStatusFetching)
+	if result.RowsAffected == 1 {
+		archive.Status = model.ArchiveStatusFetching
+	}
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *archiveRepository) GetOrphaned(stuckBefore time.Time, limit int) ([]model.PageArchive, error) {
+	var archives []model.PageArchive
+	err := r.db.Where("bookmark_id NOT IN (SELECT id FROM bookmarks WHERE deleted_at IS NULL)").
+		Where("status <> ? OR updated_at < ?", model.ArchiveStatusFetching, stuckBefore).
+		Order("updated_at ASC").
+		Limit(limit).
+		Find(&archives).Error
+	return archives, err
+}
+
+func (r *archiveRepository) Delete(id uuid.UUID) error {
+	return r.db.Unscoped().Delete(&model.PageArchive{}, "id = ?", id).Error
+}
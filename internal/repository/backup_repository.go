@@ -0,0 +1,253 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"gorm.io/gorm"
+)
+
+// BackupRepository reads and writes whole backup sections across every
+// bookmark table.
+type BackupRepository interface {
+	WithTx(tx *gorm.DB) BackupRepository
+	// Stream hands fn the section's rows batchSize at a time, as a pointer to
+	// a slice of the section's model. Only the user's records are included,
+	// limited to workspaceID when it is set.
+	Stream(userID uuid.UUID, workspaceID *uuid.UUID, section string, batchSize int, fn func(rows interface{}) error) error
+	Create(row interface{}) error
+	SetParent(row interface{}, id uuid.UUID, parentID uuid.UUID) error
+	FindFolder(userID, workspaceID uuid.UUID, parentID *uuid.UUID, name string) (*model.BookmarkFolder, error)
+	FindTag(userID, workspaceID uuid.UUID, name string) (*model.BookmarkTag, error)
+	FindCollection(userID, workspaceID uuid.UUID, name string) (*model.BookmarkCollection, error)
+	FindTemplate(userID, workspaceID uuid.UUID, name string) (*model.BookmarkTemplate, error)
+	FindSmartFolder(userID, workspaceID uuid.UUID, name string) (*model.SmartFolder, error)
+	FindRule(userID uuid.UUID, name string) (*model.BookmarkRule, error)
+	// IsShared reports whether the resource is already shared with
+	// sharedWith.
+	IsShared(resourceType model.ShareResourceType, resourceID, sharedWith uuid.UUID) (bool, error)
+	// IsCollectionMember reports whether userID is already a member of, or
+	// invited to, the collection.
+	IsCollectionMember(collectionID, userID uuid.UUID) (bool, error)
+	FindBookmark(userID, workspaceID uuid.UUID, bookmarkType model.BookmarkType, targetID uuid.UUID, targetURL string) (*model.Bookmark, error)
+}
+
+type backupRepository struct {
+	db *gorm.DB
+}
+
+func NewBackupRepository(db *gorm.DB) BackupRepository {
+	return &backupRepository{db: db}
+}
+
+func (r *backupRepository) WithTx(tx *gorm.DB) BackupRepository {
+	return &backupRepository{db: tx}
+}
+
+func (r *backupRepository) Stream(userID uuid.UUID, workspaceID *uuid.UUID, section string, batchSize int, fn func(rows interface{}) error) error {
+	owned := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("user_id = ?", userID)
+		if workspaceID != nil {
+			db = db.Where("workspace_id = ?", *workspaceID)
+		}
+		return db
+	}
+	bookmarks := r.db.Model(&model.Bookmark{}).Select("id").Scopes(owned)
+	folders := r.db.Model(&model.BookmarkFolder{}).Select("id").Scopes(owned)
+	collections := r.db.Model(&model.BookmarkCollection{}).Select("id").Scopes(owned)
+	onBookmarks := func(db *gorm.DB) *gorm.DB {
+		return db.Where("bookmark_id IN (?)", bookmarks)
+	}
+	personal := func(db *gorm.DB) *gorm.DB {
+		return db.Where("user_id = ? AND bookmark_id IN (?)", userID, bookmarks)
+	}
+
+	var rows interface{}
+	var scope func(db *gorm.DB) *gorm.DB
+	switch section {
+	case model.BackupSectionFolders:
+		rows, scope = &[]model.BookmarkFolder{}, owned
+	case model.BackupSectionTags:
+		rows, scope = &[]model.BookmarkTag{}, owned
+	case model.BackupSectionCollections:
+		rows, scope = &[]model.BookmarkCollection{}, owned
+	case model.BackupSectionTemplates:
+		rows, scope = &[]model.BookmarkTemplate{}, owned
+	case model.BackupSectionSmartFolders:
+		rows, scope = &[]model.SmartFolder{}, owned
+	case model.BackupSectionRules:
+		// Rules belong to the user rather than a workspace
+		rows, scope = &[]model.BookmarkRule{}, func(db *gorm.DB) *gorm.DB {
+			return db.Where("user_id = ?", userID)
+		}
+	case model.BackupSectionBookmarks:
+		rows, scope = &[]model.Bookmark{}, owned
+	case model.BackupSectionTagMappings:
+		tags := r.db.Model(&model.BookmarkTag{}).Select("id").Scopes(owned)
+		rows, scope = &[]model.BookmarkTagMapping{}, func(db *gorm.DB) *gorm.DB {
+			return db.Scopes(onBookmarks).Where("tag_id IN (?)", tags)
+		}
+	case model.BackupSectionCollectionBookmarks:
+		rows, scope = &[]model.CollectionBookmark{}, func(db *gorm.DB) *gorm.DB {
+			return db.Scopes(onBookmarks).Where("collection_id IN (?)", collections)
+		}
+	case model.BackupSectionNotes:
+		rows, scope = &[]model.BookmarkNote{}, personal
+	case model.BackupSectionComments:
+		rows, scope = &[]model.BookmarkComment{}, onBookmarks
+	case model.BackupSectionReminders:
+		rows, scope = &[]model.BookmarkReminder{}, personal
+	case model.BackupSectionFavorites:
+		rows, scope = &[]model.BookmarkFavorite{}, personal
+	case model.BackupSectionReadLater:
+		rows, scope = &[]model.ReadLaterItem{}, personal
+	case model.BackupSectionPreviews:
+		rows, scope = &[]model.LinkPreview{}, onBookmarks
+	case model.BackupSectionVersions:
+		rows, scope = &[]model.BookmarkVersion{}, onBookmarks
+	case model.BackupSectionExpirations:
+		rows, scope = &[]model.BookmarkExpiration{}, onBookmarks
+	case model.BackupSectionShares:
+		rows, scope = &[]model.SharedBookmark{}, func(db *gorm.DB) *gorm.DB {
+			return db.Where("shared_by = ?", userID).Where(
+				r.db.Where("resource_type = ? AND resource_id IN (?)", model.ShareResourceBookmark, bookmarks).
+					Or("resource_type = ? AND resource_id IN (?)", model.ShareResourceFolder, folders).
+					Or("resource_type = ? AND resource_id IN (?)", model.ShareResourceCollection, collections))
+		}
+	case model.BackupSectionCollectionMembers:
+		rows, scope = &[]model.CollectionMember{}, func(db *gorm.DB) *gorm.DB {
+			return db.Where("collection_id IN (?)", collections)
+		}
+	case model.BackupSectionActivities:
+		rows, scope = &[]model.BookmarkActivity{}, onBookmarks
+	default:
+		return fmt.Errorf("unknown backup section %q", section)
+	}
+
+	return r.db.Scopes(scope).FindInBatches(rows, batchSize, func(tx *gorm.DB, batch int) error {
+		return fn(rows)
+	}).Error
+}
+
+func (r *backupRepository) Create(row interface{}) error {
+	return r.db.Create(row).Error
+}
+
+// SetParent points a restored folder or comment at its parent once the
+// parent has been restored too.
+func (r *backupRepository) SetParent(row interface{}, id uuid.UUID, parentID uuid.UUID) error {
+	return r.db.Model(row).Where("id = ?", id).Update("parent_id", parentID).Error
+}
+
+func (r *backupRepository) FindFolder(userID, workspaceID uuid.UUID, parentID *uuid.UUID, name string) (*model.BookmarkFolder, error) {
+	query := r.db.Where("user_id = ? AND workspace_id = ? AND name = ?", userID, workspaceID, name)
+	if parentID != nil {
+		query = query.Where("parent_id = ?", *parentID)
+	} else {
+		query = query.Where("parent_id IS NULL")
+	}
+	var folder model.BookmarkFolder
+	if err := query.First(&folder).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &folder, nil
+}
+
+func (r *backupRepository) FindTag(userID, workspaceID uuid.UUID, name string) (*model.BookmarkTag, error) {
+	var tag model.BookmarkTag
+	err := r.db.Where("user_id = ? AND workspace_id = ? AND name = ?", userID, workspaceID, name).First(&tag).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (r *backupRepository) FindCollection(userID, workspaceID uuid.UUID, name string) (*model.BookmarkCollection, error) {
+	var collection model.BookmarkCollection
+	err := r.db.Where("user_id = ? AND workspace_id = ? AND name = ?", userID, workspaceID, name).First(&collection).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &collection, nil
+}
+
+func (r *backupRepository) FindTemplate(userID, workspaceID uuid.UUID, name string) (*model.BookmarkTemplate, error) {
+	var template model.BookmarkTemplate
+	err := r.db.Where("user_id = ? AND workspace_id = ? AND name = ?", userID, workspaceID, name).First(&template).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *backupRepository) FindSmartFolder(userID, workspaceID uuid.UUID, name string) (*model.SmartFolder, error) {
+	var folder model.SmartFolder
+	err := r.db.Where("user_id = ? AND workspace_id = ? AND name = ?", userID, workspaceID, name).First(&folder).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &folder, nil
+}
+
+func (r *backupRepository) FindRule(userID uuid.UUID, name string) (*model.BookmarkRule, error) {
+	var rule model.BookmarkRule
+	err := r.db.Where("user_id = ? AND name = ?", userID, name).First(&rule).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *backupRepository) IsShared(resourceType model.ShareResourceType, resourceID, sharedWith uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.SharedBookmark{}).
+		Where("resource_type = ? AND resource_id = ? AND shared_with = ?", resourceType, resourceID, sharedWith).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *backupRepository) IsCollectionMember(collectionID, userID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.CollectionMember{}).
+		Where("collection_id = ? AND user_id = ?", collectionID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// FindBookmark finds the user's bookmark of the same target: the same URL for
+// external bookmarks, the same target ID for everything else.
+func (r *backupRepository) FindBookmark(userID, workspaceID uuid.UUID, bookmarkType model.BookmarkType, targetID uuid.UUID, targetURL string) (*model.Bookmark, error) {
+	query := r.db.Where("user_id = ? AND workspace_id = ? AND type = ?", userID, workspaceID, bookmarkType)
+	if bookmarkType == model.BookmarkTypeExternal && targetURL != "" {
+		query = query.Where("target_url = ?", targetURL)
+	} else {
+		query = query.Where("target_id = ?", targetID)
+	}
+	var bookmark model.Bookmark
+	if err := query.First(&bookmark).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &bookmark, nil
+}
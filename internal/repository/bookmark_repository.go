@@ -4,17 +4,39 @@ import (
 	"github.com/google/uuid"
 	"github.com/quckapp/bookmark-service/internal/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type BookmarkRepository interface {
+	WithTx(tx *gorm.DB) BookmarkRepository
 	Create(bookmark *model.Bookmark) error
 	GetByID(id uuid.UUID) (*model.Bookmark, error)
-	GetByUser(userID uuid.UUID, limit, offset int) ([]model.Bookmark, int64, error)
-	GetByUserAndWorkspace(userID, workspaceID uuid.UUID, limit, offset int) ([]model.Bookmark, int64, error)
-	GetByFolder(folderID uuid.UUID) ([]model.Bookmark, error)
+	// GetForUpdate reads the bookmark and locks its row until the transaction
+	// ends, so a read-modify-save cannot revert concurrent writes. Call it
+	// through WithTx.
+	GetForUpdate(id uuid.UUID) (*model.Bookmark, error)
+	// GetByUser, GetByUserAndWorkspace and GetByFolder list only archived or
+	// only unarchived bookmarks according to archived, or both when it is nil.
+	GetByUser(userID uuid.UUID, archived *bool, limit, offset int) ([]model.Bookmark, int64, error)
+	GetByUserAndWorkspace(userID, workspaceID uuid.UUID, archived *bool, limit, offset int) ([]model.Bookmark, int64, error)
+	GetByFolder(folderID uuid.UUID, archived *bool) ([]model.Bookmark, error)
 	Update(bookmark *model.Bookmark) error
 	Delete(id uuid.UUID) error
 	MoveToFolder(bookmarkID uuid.UUID, folderID *uuid.UUID) error
+	// MoveNextTo places the bookmark directly before or after anchorID among
+	// the bookmarks in its folder, or at the top level of its workspace.
+	MoveNextTo(bookmark *model.Bookmark, anchorID uuid.UUID, after bool) error
+	SetArchived(id uuid.UUID, archived bool) error
+	// StreamForExport calls fn with batches of the user's bookmarks that have
+	// a URL and sit in folderID, in position order. A nil folderID stands for
+	// the top level, which also takes bookmarks whose folder is not among the
+	// user's folders in the workspace.
+	StreamForExport(userID uuid.UUID, workspaceID, folderID *uuid.UUID, batchSize int, fn func(bookmarks []model.Bookmark) error) error
+	// FindDuplicate returns the user's oldest bookmark in the workspace with
+	// the same canonical URL, or the same target and type, or nil if there is
+	// none. A nil workspaceID looks in every workspace. Empty criteria are
+	// ignored.
+	FindDuplicate(userID uuid.UUID, workspaceID *uuid.UUID, canonicalURL string, targetID uuid.UUID, bookmarkType model.BookmarkType) (*model.Bookmark, error)
 }
 
 type bookmarkRepository struct {
@@ -26,6 +48,45 @@ func NewBookmarkRepository(db *gorm.DB) BookmarkRepository {
 	return &bookmarkRepository{db: db}
 }
 
+// BackfillCanonicalURLs fills canonical_url for bookmarks saved before the
+// column existed and returns how many it filled. Every bookmark it reads gets
+// a value, so later runs only cost the check for a bookmark without.
+func BackfillCanonicalURLs(db *gorm.DB) (int, error) {
+	missing := db.Unscoped().Model(&model.Bookmark{}).
+		Where("(canonical_url IS NULL OR canonical_url = '') AND TRIM(target_url) <> ''")
+	var pending []uuid.UUID
+	if err := missing.Session(&gorm.Session{}).Limit(1).Pluck("id", &pending).Error; err != nil || len(pending) == 0 {
+		return 0, err
+	}
+
+	filled := 0
+	var bookmarks []model.Bookmark
+	err := missing.Session(&gorm.Session{}).Select("id", "target_url").
+		FindInBatches(&bookmarks, bulkBatchSize, func(tx *gorm.DB, batch int) error {
+			for _, b := range bookmarks {
+				canonical := model.CanonicalURL(b.TargetURL)
+				if canonical == "" {
+					// Only whitespace that TRIM keeps, such as tabs. Storing it
+					// as is keeps the row from being picked up on every boot;
+					// duplicate lookups ignore an empty canonical URL anyway.
+					canonical = b.TargetURL
+				}
+				err := db.Unscoped().Model(&model.Bookmark{}).Where("id = ?", b.ID).
+					UpdateColumn("canonical_url", canonical).Error
+				if err != nil {
+					return err
+				}
+				filled++
+			}
+			return nil
+		}).Error
+	return filled, err
+}
+
+func (r *bookmarkRepository) WithTx(tx *gorm.DB) BookmarkRepository {
+	return &bookmarkRepository{db: tx}
+}
+
 func (r *bookmarkRepository) Create(bookmark *model.Bookmark) error {
 	return r.db.Create(bookmark).Error
 }
@@ -39,12 +100,21 @@ func (r *bookmarkRepository) GetByID(id uuid.UUID) (*model.Bookmark, error) {
 	return &bookmark, nil
 }
 
-func (r *bookmarkRepository) GetByUser(userID uuid.UUID, limit, offset int) ([]model.Bookmark, int64, error) {
+func (r *bookmarkRepository) GetForUpdate(id uuid.UUID) (*model.Bookmark, error) {
+	var bookmark model.Bookmark
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&bookmark, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &bookmark, nil
+}
+
+func (r *bookmarkRepository) GetByUser(userID uuid.UUID, archived *bool, limit, offset int) ([]model.Bookmark, int64, error) {
 	var bookmarks []model.Bookmark
 	var total int64
 
-	r.db.Model(&model.Bookmark{}).Where("user_id = ?", userID).Count(&total)
-	err := r.db.Where("user_id = ?", userID).
+	r.db.Model(&model.Bookmark{}).Where("user_id = ?", userID).Scopes(archivedScope(archived)).Count(&total)
+	err := r.db.Where("user_id = ?", userID).Scopes(archivedScope(archived)).
 		Order("position ASC, created_at DESC").
 		Limit(limit).Offset(offset).
 		Find(&bookmarks).Error
@@ -52,12 +122,12 @@ func (r *bookmarkRepository) GetByUser(userID uuid.UUID, limit, offset int) ([]m
 	return bookmarks, total, err
 }
 
-func (r *bookmarkRepository) GetByUserAndWorkspace(userID, workspaceID uuid.UUID, limit, offset int) ([]model.Bookmark, int64, error) {
+func (r *bookmarkRepository) GetByUserAndWorkspace(userID, workspaceID uuid.UUID, archived *bool, limit, offset int) ([]model.Bookmark, int64, error) {
 	var bookmarks []model.Bookmark
 	var total int64
 
-	r.db.Model(&model.Bookmark{}).Where("user_id = ? AND workspace_id = ?", userID, workspaceID).Count(&total)
-	err := r.db.Where("user_id = ? AND workspace_id = ?", userID, workspaceID).
+	r.db.Model(&model.Bookmark{}).Where("user_id = ? AND workspace_id = ?", userID, workspaceID).Scopes(archivedScope(archived)).Count(&total)
+	err := r.db.Where("user_id = ? AND workspace_id = ?", userID, workspaceID).Scopes(archivedScope(archived)).
 		Order("position ASC, created_at DESC").
 		Limit(limit).Offset(offset).
 		Find(&bookmarks).Error
@@ -65,14 +135,23 @@ func (r *bookmarkRepository) GetByUserAndWorkspace(userID, workspaceID uuid.UUID
 	return bookmarks, total, err
 }
 
-func (r *bookmarkRepository) GetByFolder(folderID uuid.UUID) ([]model.Bookmark, error) {
+func (r *bookmarkRepository) GetByFolder(folderID uuid.UUID, archived *bool) ([]model.Bookmark, error) {
 	var bookmarks []model.Bookmark
-	err := r.db.Where("folder_id = ?", folderID).
-		Order("position ASC").
+	err := r.db.Where("folder_id = ?", folderID).Scopes(archivedScope(archived)).
+		Order("position ASC, created_at DESC").
 		Find(&bookmarks).Error
 	return bookmarks, err
 }
 
+func archivedScope(archived *bool) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if archived == nil {
+			return db
+		}
+		return db.Where("is_archived = ?", *archived)
+	}
+}
+
 func (r *bookmarkRepository) Update(bookmark *model.Bookmark) error {
 	return r.db.Save(bookmark).Error
 }
@@ -84,3 +163,92 @@ func (r *bookmarkRepository) Delete(id uuid.UUID) error {
 func (r *bookmarkRepository) MoveToFolder(bookmarkID uuid.UUID, folderID *uuid.UUID) error {
 	return r.db.Model(&model.Bookmark{}).Where("id = ?", bookmarkID).Update("folder_id", folderID).Error
 }
+
+func (r *bookmarkRepository) MoveNextTo(bookmark *model.Bookmark, anchorID uuid.UUID, after bool) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return rankedList{
+			db:    tx,
+			model: &model.Bookmark{},
+			key:   "id",
+			order: "position ASC, created_at DESC",
+			scope: func(db *gorm.DB) *gorm.DB {
+				db = db.Where("user_id = ? AND workspace_id = ?", bookmark.UserID, bookmark.WorkspaceID)
+				if bookmark.FolderID == nil {
+					return db.Where("folder_id IS NULL")
+				}
+				return db.Where("folder_id = ?", *bookmark.FolderID)
+			},
+		}.move(bookmark.ID, anchorID, after)
+	})
+}
+
+func (r *bookmarkRepository) SetArchived(id uuid.UUID, archived bool) error {
+	return r.db.Model(&model.Bookmark{}).Where("id = ?", id).Update("is_archived", archived).Error
+}
+
+func (r *bookmarkRepository) StreamForExport(userID uuid.UUID, workspaceID, folderID *uuid.UUID, batchSize int, fn func(bookmarks []model.Bookmark) error) error {
+	scope := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("user_id = ?", userID)
+		if workspaceID != nil {
+			db = db.Where("workspace_id = ?", *workspaceID)
+		}
+		return db
+	}
+	query := r.db.Scopes(scope).Where("target_url <> ''")
+	if folderID != nil {
+		query = query.Where("folder_id = ?", *folderID)
+	} else {
+		folders := r.db.Model(&model.BookmarkFolder{}).Select("id").Scopes(scope)
+		query = query.Where("folder_id IS NULL OR folder_id NOT IN (?)", folders)
+	}
+
+	// Paged by (position, id) rather than offset, so each page is one index
+	// range however far into the folder it is
+	var last *model.Bookmark
+	for {
+		page := query.Session(&gorm.Session{})
+		if last != nil {
+			page = page.Where("position > ? OR (position = ? AND id > ?)", last.Position, last.Position, last.ID)
+		}
+		var bookmarks []model.Bookmark
+		if err := page.Order("position ASC, id ASC").Limit(batchSize).Find(&bookmarks).Error; err != nil {
+			return err
+		}
+		if len(bookmarks) == 0 {
+			return nil
+		}
+		if err := fn(bookmarks); err != nil {
+			return err
+		}
+		if len(bookmarks) < batchSize {
+			return nil
+		}
+		last = &bookmarks[len(bookmarks)-1]
+	}
+}
+
+func (r *bookmarkRepository) FindDuplicate(userID uuid.UUID, workspaceID *uuid.UUID, canonicalURL string, targetID uuid.UUID, bookmarkType model.BookmarkType) (*model.Bookmark, error) {
+	byURL := canonicalURL != ""
+	byTarget := targetID != uuid.Nil && bookmarkType != ""
+	query := r.db.Where("user_id = ?", userID)
+	if workspaceID != nil {
+		query = query.Where("workspace_id = ?", *workspaceID)
+	}
+	switch {
+	case byURL && byTarget:
+		query = query.Where("canonical_url = ? OR (target_id = ? AND type = ?)", canonicalURL, targetID, bookmarkType)
+	case byURL:
+		query = query.Where("canonical_url = ?", canonicalURL)
+	case byTarget:
+		query = query.Where("target_id = ? AND type = ?", targetID, bookmarkType)
+	default:
+		return nil, nil
+	}
+
+	var bookmarks []model.Bookmark
+	err := query.Order("created_at ASC").Limit(1).Find(&bookmarks).Error
+	if err != nil || len(bookmarks) == 0 {
+		return nil, err
+	}
+	return &bookmarks[0], nil
+}
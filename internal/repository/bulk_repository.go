@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// bulkBatchSize bounds the rows written by one INSERT.
+const bulkBatchSize = 500
+
+// BulkRepository reads and writes many bookmarks with one statement per
+// operation rather than one per bookmark.
+type BulkRepository interface {
+	WithTx(tx *gorm.DB) BulkRepository
+	GetBookmarks(ids []uuid.UUID) ([]model.Bookmark, error)
+	GetTags(ids []uuid.UUID) ([]model.BookmarkTag, error)
+	DeleteBookmarks(ids []uuid.UUID) error
+	MoveBookmarks(ids []uuid.UUID, folderID *uuid.UUID) error
+	SetPositions(positions map[uuid.UUID]int) error
+	AddTags(bookmarkIDs, tagIDs []uuid.UUID) (int, error)
+	RemoveTags(bookmarkIDs, tagIDs []uuid.UUID) (int64, error)
+	AddToCollection(collectionID, addedBy uuid.UUID, bookmarkIDs []uuid.UUID) (int, error)
+	AddToReadLater(userID uuid.UUID, bookmarkIDs []uuid.UUID, priority int) (int, error)
+	SetExpirations(expirations []model.BookmarkExpiration) error
+	// AddExpirations creates the expirations of bookmarks that have none and
+	// leaves existing ones, including removed ones, alone. It returns how
+	// many were created.
+	AddExpirations(expirations []model.BookmarkExpiration) (int, error)
+}
+
+type bulkRepository struct {
+	db *gorm.DB
+}
+
+func NewBulkRepository(db *gorm.DB) BulkRepository {
+	return &bulkRepository{db: db}
+}
+
+func (r *bulkRepository) WithTx(tx *gorm.DB) BulkRepository {
+	return &bulkRepository{db: tx}
+}
+
+func (r *bulkRepository) GetBookmarks(ids []uuid.UUID) ([]model.Bookmark, error) {
+	var bookmarks []model.Bookmark
+	err := r.db.Where("id IN ?", ids).Find(&bookmarks).Error
+	return bookmarks, err
+}
+
+func (r *bulkRepository) GetTags(ids []uuid.UUID) ([]model.BookmarkTag, error) {
+	var tags []model.BookmarkTag
+	err := r.db.Where("id IN ?", ids).Find(&tags).Error
+	return tags, err
+}
+
+func (r *bulkRepository) DeleteBookmarks(ids []uuid.UUID) error {
+	return r.db.Where("id IN ?", ids).Delete(&model.Bookmark{}).Error
+}
+
+func (r *bulkRepository) MoveBookmarks(ids []uuid.UUID, folderID *uuid.UUID) error {
+	return r.db.Model(&model.Bookmark{}).Where("id IN ?", ids).Update("folder_id", folderID).Error
+}
+
+// SetPositions updates every bookmark's position in one statement.
+func (r *bulkRepository) SetPositions(positions map[uuid.UUID]int) error {
+	return setPositions(r.db.Model(&model.Bookmark{}), "id", positions)
+}
+
+// AddTags maps every tag to every bookmark, skipping pairs already mapped,
+// and returns the number of mappings created.
+func (r *bulkRepository) AddTags(bookmarkIDs, tagIDs []uuid.UUID) (int, error) {
+	var existing []model.BookmarkTagMapping
+	err := r.db.Where("bookmark_id IN ? AND tag_id IN ?", bookmarkIDs, tagIDs).Find(&existing).Error
+	if err != nil {
+		return 0, err
+	}
+	mapped := make(map[[2]uuid.UUID]bool, len(existing))
+	for _, m := range existing {
+		mapped[[2]uuid.UUID{m.BookmarkID, m.TagID}] = true
+	}
+
+	var mappings []model.BookmarkTagMapping
+	for _, bookmarkID := range bookmarkIDs {
+		for _, tagID := range tagIDs {
+			if !mapped[[2]uuid.UUID{bookmarkID, tagID}] {
+				mappings = append(mappings, model.BookmarkTagMapping{BookmarkID: bookmarkID, TagID: tagID})
+			}
+		}
+	}
+	if len(mappings) == 0 {
+		return 0, nil
+	}
+	return len(mappings), r.db.CreateInBatches(&mappings, bulkBatchSize).Error
+}
+
+func (r *bulkRepository) RemoveTags(bookmarkIDs, tagIDs []uuid.UUID) (int64, error) {
+	result := r.db.Where("bookmark_id IN ? AND tag_id IN ?", bookmarkIDs, tagIDs).Delete(&model.BookmarkTagMapping{})
+	return result.RowsAffected, result.Error
+}
+
+// AddToCollection appends the bookmarks that are not yet in the collection
+// after its last entry, credited to addedBy, and returns how many were added.
+func (r *bulkRepository) AddToCollection(collectionID, addedBy uuid.UUID, bookmarkIDs []uuid.UUID) (int, error) {
+	var present []uuid.UUID
+	err := r.db.Model(&model.CollectionBookmark{}).
+		Where("collection_id = ? AND bookmark_id IN ?", collectionID, bookmarkIDs).
+		Pluck("bookmark_id", &present).Error
+	if err != nil {
+		return 0, err
+	}
+	skip := make(map[uuid.UUID]bool, len(present))
+	for _, id := range present {
+		skip[id] = true
+	}
+
+	var last int
+	err = r.db.Model(&model.CollectionBookmark{}).
+		Where("collection_id = ?", collectionID).
+		Select("COALESCE(MAX(position), 0)").
+		Scan(&last).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var entries []model.CollectionBookmark
+	for _, id := range bookmarkIDs {
+		if skip[id] {
+			continue
+		}
+		last += RankGap
+		entries = append(entries, model.CollectionBookmark{CollectionID: collectionID, BookmarkID: id, AddedBy: addedBy, Position: last})
+	}
+	if len(entries) == 0 {
+		return 0, nil
+	}
+	return len(entries), r.db.CreateInBatches(&entries, bulkBatchSize).Error
+}
+
+// AddToReadLater puts the bookmarks that are not yet on the user's
+// read-later list on it as unread and returns how many were added.
+func (r *bulkRepository) AddToReadLater(userID uuid.UUID, bookmarkIDs []uuid.UUID, priority int) (int, error) {
+	var present []uuid.UUID
+	err := r.db.Model(&model.ReadLaterItem{}).
+		Where("user_id = ? AND bookmark_id IN ?", userID, bookmarkIDs).
+		Pluck("bookmark_id", &present).Error
+	if err != nil {
+		return 0, err
+	}
+	skip := make(map[uuid.UUID]bool, len(present))
+	for _, id := range present {
+		skip[id] = true
+	}
+
+	var items []model.ReadLaterItem
+	for _, id := range bookmarkIDs {
+		if skip[id] {
+			continue
+		}
+		skip[id] = true
+		items = append(items, model.ReadLaterItem{
+			UserID:     userID,
+			BookmarkID: id,
+			Status:     model.ReadLaterStatusUnread,
+			Priority:   priority,
+		})
+	}
+	if len(items) == 0 {
+		return 0, nil
+	}
+	return len(items), r.db.CreateInBatches(&items, bulkBatchSize).Error
+}
+
+// SetExpirations creates or replaces each bookmark's expiration, reviving
+// ones that were removed, in batched upserts keyed on bookmark_id.
+func (r *bulkRepository) SetExpirations(expirations []model.BookmarkExpiration) error {
+	if len(expirations) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "bookmark_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"expires_at", "action", "target_folder_id", "is_expired", "attempts", "next_attempt_at", "error", "updated_at", "deleted_at"}),
+	}).CreateInBatches(&expirations, bulkBatchSize).Error
+}
+
+func (r *bulkRepository) AddExpirations(expirations []model.BookmarkExpiration) (int, error) {
+	if len(expirations) == 0 {
+		return 0, nil
+	}
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "bookmark_id"}},
+		DoNothing: true,
+	}).CreateInBatches(&expirations, bulkBatchSize)
+	return int(result.RowsAffected), result.Error
+}
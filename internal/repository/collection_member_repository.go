@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"gorm.io/gorm"
+)
+
+type CollectionMemberRepository interface {
+	WithTx(tx *gorm.DB) CollectionMemberRepository
+	Create(member *model.CollectionMember) error
+	GetByID(id uuid.UUID) (*model.CollectionMember, error)
+	// GetByCollection lists a collection's members, invited ones included.
+	GetByCollection(collectionID uuid.UUID) ([]model.CollectionMember, error)
+	GetPendingByUser(userID uuid.UUID, limit, offset int) ([]model.CollectionMember, int64, error)
+	Update(member *model.CollectionMember) error
+	// Accept marks the invitation accepted and reports whether it was still
+	// pending.
+	Accept(id uuid.UUID, at time.Time) (bool, error)
+	Delete(id uuid.UUID) error
+	// IsInvited reports whether userID is a member of the collection or has
+	// an open invitation to it.
+	IsInvited(collectionID, userID uuid.UUID) (bool, error)
+	// GetAccepted returns userID's accepted memberships of any of
+	// collectionIDs.
+	GetAccepted(userID uuid.UUID, collectionIDs []uuid.UUID) ([]model.CollectionMember, error)
+}
+
+type collectionMemberRepository struct {
+	db *gorm.DB
+}
+
+func NewCollectionMemberRepository(db *gorm.DB) CollectionMemberRepository {
+	db.AutoMigrate(&model.CollectionMember{})
+	return &collectionMemberRepository{db: db}
+}
+
+func (r *collectionMemberRepository) WithTx(tx *gorm.DB) CollectionMemberRepository {
+	return &collectionMemberRepository{db: tx}
+}
+
+func (r *collectionMemberRepository) Create(member *model.CollectionMember) error {
+	return r.db.Create(member).Error
+}
+
+func (r *collectionMemberRepository) GetByID(id uuid.UUID) (*model.CollectionMember, error) {
+	var member model.CollectionMember
+	err := r.db.First(&member, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *collectionMemberRepository) GetByCollection(collectionID uuid.UUID) ([]model.CollectionMember, error) {
+	var members []model.CollectionMember
+	err := r.db.Where("collection_id = ?", collectionID).Order("created_at ASC").Find(&members).Error
+	return members, err
+}
+
+func (r *collectionMemberRepository) GetPendingByUser(userID uuid.UUID, limit, offset int) ([]model.CollectionMember, int64, error) {
+	var members []model.CollectionMember
+	var total int64
+
+	r.db.Model(&model.CollectionMember{}).Where("user_id = ? AND is_accepted = ?", userID, false).Count(&total)
+	err := r.db.Where("user_id = ? AND is_accepted = ?", userID, false).
+		Order("created_at DESC").
+		Limit(limit).Offset(offset).
+		Find(&members).Error
+	return members, total, err
+}
+
+func (r *collectionMemberRepository) Update(member *model.CollectionMember) error {
+	return r.db.Save(member).Error
+}
+
+func (r *collectionMemberRepository) Accept(id uuid.UUID, at time.Time) (bool, error) {
+	result := r.db.Model(&model.CollectionMember{}).
+		Where("id = ? AND is_accepted = ?", id, false).
+		Updates(map[string]interface{}{"is_accepted": true, "accepted_at": at})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *collectionMemberRepository) Delete(id uuid.UUID) error {
+	return r.db.Delete(&model.CollectionMember{}, "id = ?", id).Error
+}
+
+func (r *collectionMemberRepository) IsInvited(collectionID, userID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.CollectionMember{}).
+		Where("collection_id = ? AND user_id = ?", collectionID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *collectionMemberRepository) GetAccepted(userID uuid.UUID, collectionIDs []uuid.UUID) ([]model.CollectionMember, error) {
+	var members []model.CollectionMember
+	if len(collectionIDs) == 0 {
+		return members, nil
+	}
+	err := r.db.Where("user_id = ? AND collection_id IN ? AND is_accepted = ?", userID, collectionIDs, true).
+		Find(&members).Error
+	return members, err
+}
@@ -1,23 +1,47 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/quckapp/bookmark-service/internal/model"
 	"gorm.io/gorm"
 )
 
+// CollectionEntry is a bookmark together with when it was put in a
+// collection.
+type CollectionEntry struct {
+	model.Bookmark
+	AddedAt time.Time
+}
+
 type CollectionRepository interface {
 	Create(collection *model.BookmarkCollection) error
 	GetByID(id uuid.UUID) (*model.BookmarkCollection, error)
+	GetByIDs(ids []uuid.UUID) ([]model.BookmarkCollection, error)
 	GetByUser(userID uuid.UUID) ([]model.BookmarkCollection, error)
+	// GetByUserAndWorkspace lists the collections userID owns or is an
+	// accepted member of.
 	GetByUserAndWorkspace(userID, workspaceID uuid.UUID) ([]model.BookmarkCollection, error)
 	GetPublicByWorkspace(workspaceID uuid.UUID, limit, offset int) ([]model.BookmarkCollection, int64, error)
 	Update(collection *model.BookmarkCollection) error
 	Delete(id uuid.UUID) error
+	// AddBookmark appends the entry after the collection's last one.
 	AddBookmark(cb *model.CollectionBookmark) error
 	RemoveBookmark(collectionID, bookmarkID uuid.UUID) error
+	RemoveBookmarkFromAll(bookmarkID uuid.UUID) error
+	// MoveBookmark places the bookmark directly before or after anchorID
+	// within the collection.
+	MoveBookmark(collectionID, bookmarkID, anchorID uuid.UUID, after bool) error
 	GetBookmarks(collectionID uuid.UUID, limit, offset int) ([]model.Bookmark, int64, error)
+	// GetExternalBookmarks is GetBookmarks limited to links to the web, which
+	// are all a collection shows outside the workspace.
+	GetExternalBookmarks(collectionID uuid.UUID, limit, offset int) ([]model.Bookmark, int64, error)
+	// GetLatestExternalEntries returns the web bookmarks most recently added
+	// to the collection, newest first.
+	GetLatestExternalEntries(collectionID uuid.UUID, limit int) ([]CollectionEntry, error)
 	IsBookmarkInCollection(collectionID, bookmarkID uuid.UUID) (bool, error)
+	GetCollectionIDsByBookmark(bookmarkID uuid.UUID) ([]uuid.UUID, error)
 	CountBookmarks(collectionID uuid.UUID) (int64, error)
 }
 
@@ -27,9 +51,20 @@ type collectionRepository struct {
 
 func NewCollectionRepository(db *gorm.DB) CollectionRepository {
 	db.AutoMigrate(&model.BookmarkCollection{}, &model.CollectionBookmark{})
+	backfillCollectionBookmarkAdders(db)
 	return &collectionRepository{db: db}
 }
 
+// backfillCollectionBookmarkAdders credits entries made before collections
+// had members to the collection's owner, the only one who could add them.
+func backfillCollectionBookmarkAdders(db *gorm.DB) error {
+	return db.Model(&model.CollectionBookmark{}).
+		Where("added_by IS NULL").
+		UpdateColumn("added_by", gorm.Expr(
+			"(SELECT user_id FROM bookmark_collections WHERE bookmark_collections.id = collection_bookmarks.collection_id)",
+		)).Error
+}
+
 func (r *collectionRepository) Create(collection *model.BookmarkCollection) error {
 	return r.db.Create(collection).Error
 }
@@ -43,6 +78,15 @@ func (r *collectionRepository) GetByID(id uuid.UUID) (*model.BookmarkCollection,
 	return &collection, nil
 }
 
+func (r *collectionRepository) GetByIDs(ids []uuid.UUID) ([]model.BookmarkCollection, error) {
+	var collections []model.BookmarkCollection
+	if len(ids) == 0 {
+		return collections, nil
+	}
+	err := r.db.Where("id IN ?", ids).Find(&collections).Error
+	return collections, err
+}
+
 func (r *collectionRepository) GetByUser(userID uuid.UUID) ([]model.BookmarkCollection, error) {
 	var collections []model.BookmarkCollection
 	err := r.db.Where("user_id = ?", userID).Order("position ASC, name ASC").Find(&collections).Error
@@ -51,7 +95,11 @@ func (r *collectionRepository) GetByUser(userID uuid.UUID) ([]model.BookmarkColl
 
 func (r *collectionRepository) GetByUserAndWorkspace(userID, workspaceID uuid.UUID) ([]model.BookmarkCollection, error) {
 	var collections []model.BookmarkCollection
-	err := r.db.Where("user_id = ? AND workspace_id = ?", userID, workspaceID).
+	memberOf := r.db.Model(&model.CollectionMember{}).
+		Select("collection_id").
+		Where("user_id = ? AND is_accepted = ?", userID, true)
+	err := r.db.Where("workspace_id = ?", workspaceID).
+		Where(r.db.Where("user_id = ?", userID).Or("id IN (?)", memberOf)).
 		Order("position ASC, name ASC").Find(&collections).Error
 	return collections, err
 }
@@ -74,10 +122,21 @@ func (r *collectionRepository) Update(collection *model.BookmarkCollection) erro
 
 func (r *collectionRepository) Delete(id uuid.UUID) error {
 	r.db.Where("collection_id = ?", id).Delete(&model.CollectionBookmark{})
+	r.db.Where("collection_id = ?", id).Delete(&model.CollectionShareLink{})
+	r.db.Where("collection_id = ?", id).Delete(&model.CollectionMember{})
 	return r.db.Delete(&model.BookmarkCollection{}, "id = ?", id).Error
 }
 
 func (r *collectionRepository) AddBookmark(cb *model.CollectionBookmark) error {
+	var last int
+	err := r.db.Model(&model.CollectionBookmark{}).
+		Where("collection_id = ?", cb.CollectionID).
+		Select("COALESCE(MAX(position), 0)").
+		Scan(&last).Error
+	if err != nil {
+		return err
+	}
+	cb.Position = last + RankGap
 	return r.db.Create(cb).Error
 }
 
@@ -86,6 +145,24 @@ func (r *collectionRepository) RemoveBookmark(collectionID, bookmarkID uuid.UUID
 		Delete(&model.CollectionBookmark{}).Error
 }
 
+func (r *collectionRepository) RemoveBookmarkFromAll(bookmarkID uuid.UUID) error {
+	return r.db.Where("bookmark_id = ?", bookmarkID).Delete(&model.CollectionBookmark{}).Error
+}
+
+func (r *collectionRepository) MoveBookmark(collectionID, bookmarkID, anchorID uuid.UUID, after bool) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return rankedList{
+			db:    tx,
+			model: &model.CollectionBookmark{},
+			key:   "bookmark_id",
+			order: "position ASC, created_at ASC",
+			scope: func(db *gorm.DB) *gorm.DB {
+				return db.Where("collection_id = ?", collectionID)
+			},
+		}.move(bookmarkID, anchorID, after)
+	})
+}
+
 func (r *collectionRepository) GetBookmarks(collectionID uuid.UUID, limit, offset int) ([]model.Bookmark, int64, error) {
 	var bookmarks []model.Bookmark
 	var total int64
@@ -100,6 +177,35 @@ func (r *collectionRepository) GetBookmarks(collectionID uuid.UUID, limit, offse
 	return bookmarks, total, err
 }
 
+func (r *collectionRepository) GetExternalBookmarks(collectionID uuid.UUID, limit, offset int) ([]model.Bookmark, int64, error) {
+	var bookmarks []model.Bookmark
+	var total int64
+
+	r.db.Model(&model.Bookmark{}).
+		Joins("JOIN collection_bookmarks ON collection_bookmarks.bookmark_id = bookmarks.id").
+		Where("collection_bookmarks.collection_id = ? AND bookmarks.type = ?", collectionID, model.BookmarkTypeExternal).
+		Count(&total)
+	err := r.db.Joins("JOIN collection_bookmarks ON collection_bookmarks.bookmark_id = bookmarks.id").
+		Where("collection_bookmarks.collection_id = ? AND bookmarks.type = ?", collectionID, model.BookmarkTypeExternal).
+		Order("collection_bookmarks.position ASC").
+		Limit(limit).Offset(offset).
+		Find(&bookmarks).Error
+
+	return bookmarks, total, err
+}
+
+func (r *collectionRepository) GetLatestExternalEntries(collectionID uuid.UUID, limit int) ([]CollectionEntry, error) {
+	var entries []CollectionEntry
+	err := r.db.Model(&model.Bookmark{}).
+		Select("bookmarks.*, collection_bookmarks.created_at AS added_at").
+		Joins("JOIN collection_bookmarks ON collection_bookmarks.bookmark_id = bookmarks.id").
+		Where("collection_bookmarks.collection_id = ? AND bookmarks.type = ?", collectionID, model.BookmarkTypeExternal).
+		Order("collection_bookmarks.created_at DESC").
+		Limit(limit).
+		Scan(&entries).Error
+	return entries, err
+}
+
 func (r *collectionRepository) IsBookmarkInCollection(collectionID, bookmarkID uuid.UUID) (bool, error) {
 	var count int64
 	err := r.db.Model(&model.CollectionBookmark{}).
@@ -108,6 +214,12 @@ func (r *collectionRepository) IsBookmarkInCollection(collectionID, bookmarkID u
 	return count > 0, err
 }
 
+func (r *collectionRepository) GetCollectionIDsByBookmark(bookmarkID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.Model(&model.CollectionBookmark{}).Where("bookmark_id = ?", bookmarkID).Pluck("collection_id", &ids).Error
+	return ids, err
+}
+
 func (r *collectionRepository) CountBookmarks(collectionID uuid.UUID) (int64, error) {
 	var count int64
 	err := r.db.Model(&model.CollectionBookmark{}).Where("collection_id = ?", collectionID).Count(&count).Error
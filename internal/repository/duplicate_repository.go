@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"gorm.io/gorm"
+)
+
+// DuplicateKey identifies a group of duplicate bookmarks.
+type DuplicateKey struct {
+	WorkspaceID  uuid.UUID
+	CanonicalURL string
+}
+
+type DuplicateRepository interface {
+	WithTx(tx *gorm.DB) DuplicateRepository
+	// GetKeys returns a page of the user's canonical URLs saved more than
+	// once in the same workspace, most recently saved first.
+	GetKeys(userID uuid.UUID, workspaceID *uuid.UUID, limit, offset int) ([]DuplicateKey, int64, error)
+	GetByKeys(userID uuid.UUID, keys []DuplicateKey) ([]model.Bookmark, error)
+	GetTagIDs(bookmarkIDs []uuid.UUID) ([]uuid.UUID, error)
+	GetCollectionIDs(bookmarkIDs []uuid.UUID) ([]uuid.UUID, error)
+	MoveNotes(fromIDs []uuid.UUID, toID uuid.UUID) (int64, error)
+}
+
+type duplicateRepository struct {
+	db *gorm.DB
+}
+
+func NewDuplicateRepository(db *gorm.DB) DuplicateRepository {
+	return &duplicateRepository{db: db}
+}
+
+func (r *duplicateRepository) WithTx(tx *gorm.DB) DuplicateRepository {
+	return &duplicateRepository{db: tx}
+}
+
+func (r *duplicateRepository) GetKeys(userID uuid.UUID, workspaceID *uuid.UUID, limit, offset int) ([]DuplicateKey, int64, error) {
+	groups := r.db.Model(&model.Bookmark{}).
+		Select("workspace_id, canonical_url").
+		Where("user_id = ? AND canonical_url <> ''", userID)
+	if workspaceID != nil {
+		groups = groups.Where("workspace_id = ?", *workspaceID)
+	}
+	groups = groups.Group("workspace_id, canonical_url").Having("COUNT(*) > 1")
+
+	var total int64
+	if err := r.db.Table("(?) AS duplicate_groups", groups).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var keys []DuplicateKey
+	err := groups.Order("MAX(created_at) DESC, canonical_url ASC").
+		Limit(limit).Offset(offset).
+		Scan(&keys).Error
+	return keys, total, err
+}
+
+func (r *duplicateRepository) GetByKeys(userID uuid.UUID, keys []DuplicateKey) ([]model.Bookmark, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	pairs := make([][]interface{}, len(keys))
+	for i, key := range keys {
+		pairs[i] = []interface{}{key.WorkspaceID, key.CanonicalURL}
+	}
+
+	var bookmarks []model.Bookmark
+	err := r.db.Where("user_id = ? AND (workspace_id, canonical_url) IN ?", userID, pairs).
+		Order("created_at ASC").
+		Find(&bookmarks).Error
+	return bookmarks, err
+}
+
+func (r *duplicateRepository) GetTagIDs(bookmarkIDs []uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.Model(&model.BookmarkTagMapping{}).
+		Where("bookmark_id IN ?", bookmarkIDs).
+		Distinct().
+		Pluck("tag_id", &ids).Error
+	return ids, err
+}
+
+func (r *duplicateRepository) GetCollectionIDs(bookmarkIDs []uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.Model(&model.CollectionBookmark{}).
+		Where("bookmark_id IN ?", bookmarkIDs).
+		Distinct().
+		Pluck("collection_id", &ids).Error
+	return ids, err
+}
+
+// MoveNotes reattaches the notes of the bookmarks in fromIDs to toID.
+func (r *duplicateRepository) MoveNotes(fromIDs []uuid.UUID, toID uuid.UUID) (int64, error) {
+	result := r.db.Model(&model.BookmarkNote{}).
+		Where("bookmark_id IN ?", fromIDs).
+		Update("bookmark_id", toID)
+	return result.RowsAffected, result.Error
+}
@@ -13,7 +13,12 @@ type ExpirationRepository interface {
 	GetByBookmarkID(bookmarkID uuid.UUID) (*model.BookmarkExpiration, error)
 	Delete(bookmarkID uuid.UUID) error
 	GetExpiring(userID uuid.UUID, before time.Time) ([]model.BookmarkExpiration, error)
-	MarkExpired(id uuid.UUID) error
+	GetDue(before time.Time, limit int) ([]model.BookmarkExpiration, error)
+	MarkExpired(id uuid.UUID) (bool, error)
+	// RecordFailure stores a failed attempt at an expiration: whether it is
+	// expired, which is false while it will be retried, its attempts, next
+	// attempt and error.
+	RecordFailure(expiration *model.BookmarkExpiration) error
 	Update(expiration *model.BookmarkExpiration) error
 }
 
@@ -51,8 +56,32 @@ func (r *expirationRepository) GetExpiring(userID uuid.UUID, before time.Time) (
 	return expirations, err
 }
 
-func (r *expirationRepository) MarkExpired(id uuid.UUID) error {
-	return r.db.Model(&model.BookmarkExpiration{}).Where("id = ?", id).Update("is_expired", true).Error
+func (r *expirationRepository) GetDue(before time.Time, limit int) ([]model.BookmarkExpiration, error) {
+	var expirations []model.BookmarkExpiration
+	err := r.db.Where("expires_at <= ? AND is_expired = ?", before, false).
+		Where("next_attempt_at IS NULL OR next_attempt_at <= ?", before).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&expirations).Error
+	return expirations, err
+}
+
+// MarkExpired only flips rows that are not yet expired, so concurrent sweepers
+// can use the result to decide which of them owns the row.
+func (r *expirationRepository) MarkExpired(id uuid.UUID) (bool, error) {
+	result := r.db.Model(&model.BookmarkExpiration{}).Where("id = ? AND is_expired = ?", id, false).
+		Update("is_expired", true)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *expirationRepository) RecordFailure(expiration *model.BookmarkExpiration) error {
+	return r.db.Model(&model.BookmarkExpiration{}).Where("id = ?", expiration.ID).
+		Updates(map[string]interface{}{
+			"is_expired":      expiration.IsExpired,
+			"attempts":        expiration.Attempts,
+			"next_attempt_at": expiration.NextAttemptAt,
+			"error":           expiration.Error,
+		}).Error
 }
 
 func (r *expirationRepository) Update(expiration *model.BookmarkExpiration) error {
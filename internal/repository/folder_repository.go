@@ -4,15 +4,33 @@ import (
 	"github.com/google/uuid"
 	"github.com/quckapp/bookmark-service/internal/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type FolderRepository interface {
+	WithTx(tx *gorm.DB) FolderRepository
 	Create(folder *model.BookmarkFolder) error
 	GetByID(id uuid.UUID) (*model.BookmarkFolder, error)
 	GetByUser(userID uuid.UUID) ([]model.BookmarkFolder, error)
 	GetByUserAndWorkspace(userID, workspaceID uuid.UUID) ([]model.BookmarkFolder, error)
+	// LockByUser returns the user's folders and locks them until the
+	// transaction ends, so a placement checked against them stays valid.
+	// Call it through WithTx.
+	LockByUser(userID uuid.UUID) ([]model.BookmarkFolder, error)
 	Update(folder *model.BookmarkFolder) error
 	Delete(id uuid.UUID) error
+	CountBookmarks(userID uuid.UUID) (map[uuid.UUID]int64, error)
+	// LockBookmarks returns the bookmarks in folderIDs and locks them, and
+	// the folders' index range, so none can be moved in or out until the
+	// transaction ends. Call it through WithTx.
+	LockBookmarks(folderIDs []uuid.UUID) ([]model.Bookmark, error)
+	SetParent(id uuid.UUID, parentID *uuid.UUID, position int) error
+	SetPositions(positions map[uuid.UUID]int) error
+	// MoveNextTo places the folder directly before or after anchorID among
+	// the folders sharing its parent.
+	MoveNextTo(folder *model.BookmarkFolder, anchorID uuid.UUID, after bool) error
+	MoveContents(folderID uuid.UUID, parentID *uuid.UUID) error
+	DeleteTree(folderIDs []uuid.UUID) error
 }
 
 type folderRepository struct {
@@ -24,6 +42,10 @@ func NewFolderRepository(db *gorm.DB) FolderRepository {
 	return &folderRepository{db: db}
 }
 
+func (r *folderRepository) WithTx(tx *gorm.DB) FolderRepository {
+	return &folderRepository{db: tx}
+}
+
 func (r *folderRepository) Create(folder *model.BookmarkFolder) error {
 	return r.db.Create(folder).Error
 }
@@ -45,6 +67,16 @@ func (r *folderRepository) GetByUser(userID uuid.UUID) ([]model.BookmarkFolder,
 	return folders, err
 }
 
+func (r *folderRepository) LockByUser(userID uuid.UUID) ([]model.BookmarkFolder, error) {
+	var folders []model.BookmarkFolder
+	// A fixed order keeps concurrent moves from locking in opposite orders
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", userID).
+		Order("id ASC").
+		Find(&folders).Error
+	return folders, err
+}
+
 func (r *folderRepository) GetByUserAndWorkspace(userID, workspaceID uuid.UUID) ([]model.BookmarkFolder, error) {
 	var folders []model.BookmarkFolder
 	err := r.db.Where("user_id = ? AND workspace_id = ?", userID, workspaceID).
@@ -60,3 +92,80 @@ func (r *folderRepository) Update(folder *model.BookmarkFolder) error {
 func (r *folderRepository) Delete(id uuid.UUID) error {
 	return r.db.Delete(&model.BookmarkFolder{}, "id = ?", id).Error
 }
+
+// CountBookmarks returns the number of bookmarks directly in each of the
+// user's folders that has any.
+func (r *folderRepository) CountBookmarks(userID uuid.UUID) (map[uuid.UUID]int64, error) {
+	var rows []struct {
+		FolderID uuid.UUID
+		Count    int64
+	}
+	err := r.db.Model(&model.Bookmark{}).
+		Select("folder_id, COUNT(*) AS count").
+		Where("user_id = ? AND folder_id IS NOT NULL", userID).
+		Group("folder_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[uuid.UUID]int64, len(rows))
+	for _, row := range rows {
+		counts[row.FolderID] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *folderRepository) LockBookmarks(folderIDs []uuid.UUID) ([]model.Bookmark, error) {
+	var bookmarks []model.Bookmark
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("folder_id IN ?", folderIDs).
+		Find(&bookmarks).Error
+	return bookmarks, err
+}
+
+func (r *folderRepository) SetParent(id uuid.UUID, parentID *uuid.UUID, position int) error {
+	return r.db.Model(&model.BookmarkFolder{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"parent_id": parentID, "position": position}).Error
+}
+
+func (r *folderRepository) SetPositions(positions map[uuid.UUID]int) error {
+	return setPositions(r.db.Model(&model.BookmarkFolder{}), "id", positions)
+}
+
+func (r *folderRepository) MoveNextTo(folder *model.BookmarkFolder, anchorID uuid.UUID, after bool) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return rankedList{
+			db:    tx,
+			model: &model.BookmarkFolder{},
+			key:   "id",
+			order: "position ASC, name ASC",
+			scope: func(db *gorm.DB) *gorm.DB {
+				db = db.Where("user_id = ? AND workspace_id = ?", folder.UserID, folder.WorkspaceID)
+				if folder.ParentID == nil {
+					return db.Where("parent_id IS NULL")
+				}
+				return db.Where("parent_id = ?", *folder.ParentID)
+			},
+		}.move(folder.ID, anchorID, after)
+	})
+}
+
+// MoveContents moves the folder's subfolders and bookmarks to parentID,
+// or to the top level when parentID is nil.
+func (r *folderRepository) MoveContents(folderID uuid.UUID, parentID *uuid.UUID) error {
+	err := r.db.Model(&model.BookmarkFolder{}).Where("parent_id = ?", folderID).
+		Update("parent_id", parentID).Error
+	if err != nil {
+		return err
+	}
+	return r.db.Model(&model.Bookmark{}).Where("folder_id = ?", folderID).
+		Update("folder_id", parentID).Error
+}
+
+// DeleteTree deletes the folders and every bookmark in them.
+func (r *folderRepository) DeleteTree(folderIDs []uuid.UUID) error {
+	if err := r.db.Where("folder_id IN ?", folderIDs).Delete(&model.Bookmark{}).Error; err != nil {
+		return err
+	}
+	return r.db.Where("id IN ?", folderIDs).Delete(&model.BookmarkFolder{}).Error
+}
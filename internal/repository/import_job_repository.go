@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"gorm.io/gorm"
+)
+
+type ImportJobRepository interface {
+	Create(job *model.ImportJob) error
+	GetByID(id uuid.UUID) (*model.ImportJob, error)
+	GetByUser(userID uuid.UUID, limit, offset int) ([]model.ImportJob, int64, error)
+	Update(job *model.ImportJob) error
+	Touch(ids []uuid.UUID, at time.Time) error
+	FailStale(before time.Time, reason string) (int64, error)
+}
+
+type importJobRepository struct {
+	db *gorm.DB
+}
+
+func NewImportJobRepository(db *gorm.DB) ImportJobRepository {
+	db.AutoMigrate(&model.ImportJob{})
+	return &importJobRepository{db: db}
+}
+
+func (r *importJobRepository) Create(job *model.ImportJob) error {
+	return r.db.Create(job).Error
+}
+
+func (r *importJobRepository) GetByID(id uuid.UUID) (*model.ImportJob, error) {
+	var job model.ImportJob
+	err := r.db.First(&job, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *importJobRepository) GetByUser(userID uuid.UUID, limit, offset int) ([]model.ImportJob, int64, error) {
+	var jobs []model.ImportJob
+	var total int64
+
+	query := r.db.Model(&model.ImportJob{}).Where("user_id = ?", userID)
+	query.Count(&total)
+	err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&jobs).Error
+	return jobs, total, err
+}
+
+func (r *importJobRepository) Update(job *model.ImportJob) error {
+	return r.db.Save(job).Error
+}
+
+// Touch marks jobs as still alive so FailStale leaves them alone.
+func (r *importJobRepository) Touch(ids []uuid.UUID, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&model.ImportJob{}).Where("id IN ?", ids).Update("updated_at", at).Error
+}
+
+// FailStale fails queued and running jobs that have not been touched since
+// before, which happens when the instance running them went away.
+func (r *importJobRepository) FailStale(before time.Time, reason string) (int64, error) {
+	result := r.db.Model(&model.ImportJob{}).
+		Where("status IN ? AND updated_at < ?", []model.ImportJobStatus{model.ImportJobQueued, model.ImportJobRunning}, before).
+		Updates(map[string]interface{}{
+			"status":      model.ImportJobFailed,
+			"error":       reason,
+			"finished_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
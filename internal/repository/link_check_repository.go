@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LinkCheckRepository interface {
+	// GetDue returns external bookmarks that were never checked or whose
+	// next check is due, never-checked ones first.
+	GetDue(now time.Time, limit int) ([]model.Bookmark, error)
+	GetByBookmark(bookmarkID uuid.UUID) (*model.LinkCheck, error)
+	GetByBookmarks(bookmarkIDs []uuid.UUID) ([]model.LinkCheck, error)
+	GetByUser(userID uuid.UUID, filter string, limit, offset int) ([]model.LinkCheck, int64, error)
+	// Claim reserves check until leaseUntil so it is checked once even with
+	// several instances running. A check without an ID is created.
+	Claim(check *model.LinkCheck, now, leaseUntil time.Time) (bool, error)
+	Update(check *model.LinkCheck) error
+}
+
+type linkCheckRepository struct {
+	db *gorm.DB
+}
+
+func NewLinkCheckRepository(db *gorm.DB) LinkCheckRepository {
+	db.AutoMigrate(&model.LinkCheck{})
+	return &linkCheckRepository{db: db}
+}
+
+func (r *linkCheckRepository) GetDue(now time.Time, limit int) ([]model.Bookmark, error) {
+	var bookmarks []model.Bookmark
+	err := r.db.Joins("LEFT JOIN link_checks ON link_checks.bookmark_id = bookmarks.id").
+		Where("bookmarks.type = ? AND bookmarks.target_url <> ''", model.BookmarkTypeExternal).
+		Where("link_checks.id IS NULL OR link_checks.next_check_at <= ?", now).
+		Order("link_checks.next_check_at IS NOT NULL, link_checks.next_check_at ASC").
+		Limit(limit).
+		Find(&bookmarks).Error
+	return bookmarks, err
+}
+
+func (r *linkCheckRepository) GetByBookmark(bookmarkID uuid.UUID) (*model.LinkCheck, error) {
+	var check model.LinkCheck
+	err := r.db.Where("bookmark_id = ?", bookmarkID).First(&check).Error
+	if err != nil {
+		return nil, err
+	}
+	return &check, nil
+}
+
+func (r *linkCheckRepository) GetByBookmarks(bookmarkIDs []uuid.UUID) ([]model.LinkCheck, error) {
+	var checks []model.LinkCheck
+	err := r.db.Where("bookmark_id IN ?", bookmarkIDs).Find(&checks).Error
+	return checks, err
+}
+
+// GetByUser lists the user's broken or permanently redirected links whose
+// bookmark still exists, most recently checked first.
+func (r *linkCheckRepository) GetByUser(userID uuid.UUID, filter string, limit, offset int) ([]model.LinkCheck, int64, error) {
+	query := r.db.Model(&model.LinkCheck{}).
+		Joins("JOIN bookmarks ON bookmarks.id = link_checks.bookmark_id AND bookmarks.deleted_at IS NULL").
+		Where("link_checks.user_id = ?", userID)
+	if filter == model.LinkCheckFilterRedirected {
+		query = query.Where("link_checks.permanent_redirect = ?", true)
+	} else {
+		query = query.Where("link_checks.is_broken = ?", true)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var checks []model.LinkCheck
+	err := query.Select("link_checks.*").
+		Order("link_checks.last_checked_at DESC").
+		Limit(limit).Offset(offset).
+		Find(&checks).Error
+	return checks, total, err
+}
+
+func (r *linkCheckRepository) Claim(check *model.LinkCheck, now, leaseUntil time.Time) (bool, error) {
+	if check.ID == uuid.Nil {
+		check.NextCheckAt = leaseUntil
+		result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(check)
+		return result.RowsAffected == 1, result.Error
+	}
+	result := r.db.Model(&model.LinkCheck{}).Where("id = ? AND next_check_at <= ?", check.ID, now).
+		Update("next_check_at", leaseUntil)
+	if result.RowsAffected == 1 {
+		check.NextCheckAt = leaseUntil
+	}
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *linkCheckRepository) Update(check *model.LinkCheck) error {
+	return r.db.Save(check).Error
+}
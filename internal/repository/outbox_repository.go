@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/quckapp/bookmark-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository interface {
+	WithTx(tx *gorm.DB) OutboxRepository
+	Create(event *model.OutboxEvent) error
+	Relay(limit int, publish func(event *model.OutboxEvent) error) (int, error)
+	DeletePublishedBefore(t time.Time) (int64, error)
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	db.AutoMigrate(&model.OutboxEvent{})
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) WithTx(tx *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: tx}
+}
+
+func (r *outboxRepository) Create(event *model.OutboxEvent) error {
+	return r.db.Create(event).Error
+}
+
+// Relay locks up to limit unpublished events, oldest first, and hands them to
+// publish in order. Rows locked by another replica are skipped. Relaying stops
+// at the first failure so events are never published out of order; the
+// failure is recorded on the event and it is retried on the next call.
+func (r *outboxRepository) Relay(limit int, publish func(event *model.OutboxEvent) error) (int, error) {
+	published := 0
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var events []model.OutboxEvent
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("published_at IS NULL").
+			Order("occurred_at ASC, id ASC").
+			Limit(limit).
+			Find(&events).Error
+		if err != nil {
+			return err
+		}
+
+		for i := range events {
+			event := &events[i]
+			if err := publish(event); err != nil {
+				return tx.Model(event).Updates(map[string]interface{}{
+					"attempts":   gorm.Expr("attempts + 1"),
+					"last_error": err.Error(),
+				}).Error
+			}
+			if err := tx.Model(event).Update("published_at", time.Now()).Error; err != nil {
+				return err
+			}
+			published++
+		}
+		return nil
+	})
+	return published, err
+}
+
+func (r *outboxRepository) DeletePublishedBefore(t time.Time) (int64, error) {
+	result := r.db.Where("published_at IS NOT NULL AND published_at < ?", t).Delete(&model.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/quckapp/bookmark-service/internal/model"
 	"gorm.io/gorm"
@@ -9,9 +11,25 @@ import (
 type PreviewRepository interface {
 	Create(preview *model.LinkPreview) error
 	GetByBookmarkID(bookmarkID uuid.UUID) (*model.LinkPreview, error)
+	GetByBookmarkIDs(bookmarkIDs []uuid.UUID) ([]model.LinkPreview, error)
 	GetByURL(url string) (*model.LinkPreview, error)
 	Update(preview *model.LinkPreview) error
 	Delete(bookmarkID uuid.UUID) error
+	GetStale(fetchedBefore time.Time, limit int) ([]model.LinkPreview, error)
+	MarkPending(id uuid.UUID, from model.PreviewStatus) (bool, error)
+	// GetStuck returns previews that have been fetching since before
+	// leaseStart, or pending with no retry due since then, whose job was
+	// most likely lost.
+	GetStuck(leaseStart time.Time, limit int) ([]model.LinkPreview, error)
+	// Reclaim saves status and attempts only if the preview was not touched
+	// since it was read, so a stuck preview is requeued once.
+	Reclaim(preview *model.LinkPreview, status model.PreviewStatus, attempts int) (bool, error)
+	// Claim moves the bookmark's preview from pending to fetching only if it
+	// is still pending for url, so one worker fetches it.
+	Claim(bookmarkID uuid.UUID, url string) (bool, error)
+	// SaveResult writes the outcome of a fetch only while the preview is
+	// still fetching url, so a fetch superseded by a new URL is dropped.
+	SaveResult(preview *model.LinkPreview, url string) (bool, error)
 }
 
 type previewRepository struct {
@@ -36,6 +54,15 @@ func (r *previewRepository) GetByBookmarkID(bookmarkID uuid.UUID) (*model.LinkPr
 	return &preview, nil
 }
 
+func (r *previewRepository) GetByBookmarkIDs(bookmarkIDs []uuid.UUID) ([]model.LinkPreview, error) {
+	var previews []model.LinkPreview
+	if len(bookmarkIDs) == 0 {
+		return previews, nil
+	}
+	err := r.db.Where("bookmark_id IN ?", bookmarkIDs).Find(&previews).Error
+	return previews, err
+}
+
 func (r *previewRepository) GetByURL(url string) (*model.LinkPreview, error) {
 	var preview model.LinkPreview
 	err := r.db.Where("url = ?", url).First(&preview).Error
@@ -52,3 +79,64 @@ func (r *previewRepository) Update(preview *model.LinkPreview) error {
 func (r *previewRepository) Delete(bookmarkID uuid.UUID) error {
 	return r.db.Where("bookmark_id = ?", bookmarkID).Delete(&model.LinkPreview{}).Error
 }
+
+func (r *previewRepository) GetStale(fetchedBefore time.Time, limit int) ([]model.LinkPreview, error) {
+	var previews []model.LinkPreview
+	err := r.db.Where("status = ? AND fetched_at < ?", model.PreviewStatusReady, fetchedBefore).
+		Order("fetched_at ASC").
+		Limit(limit).
+		Find(&previews).Error
+	return previews, err
+}
+
+func (r *previewRepository) GetStuck(leaseStart time.Time, limit int) ([]model.LinkPreview, error) {
+	var previews []model.LinkPreview
+	err := r.db.Where("updated_at < ?", leaseStart).
+		Where(r.db.Where("status = ?", model.PreviewStatusFetching).
+			Or("status = ? AND (next_attempt_at IS NULL OR next_attempt_at < ?)", model.PreviewStatusPending, leaseStart)).
+		Order("updated_at ASC").
+		Limit(limit).
+		Find(&previews).Error
+	return previews, err
+}
+
+func (r *previewRepository) Reclaim(preview *model.LinkPreview, status model.PreviewStatus, attempts int) (bool, error) {
+	result := r.db.Model(&model.LinkPreview{}).
+		Where("id = ? AND status = ? AND updated_at = ?", preview.ID, preview.Status, preview.UpdatedAt).
+		Updates(map[string]interface{}{"status": status, "attempts": attempts, "next_attempt_at": nil})
+	return result.RowsAffected == 1, result.Error
+}
+
+// MarkPending moves a preview back to pending only if it is still in the
+// expected status, so a preview is queued once even with several schedulers.
+func (r *previewRepository) MarkPending(id uuid.UUID, from model.PreviewStatus) (bool, error) {
+	result := r.db.Model(&model.LinkPreview{}).Where("id = ? AND status = ?", id, from).
+		Updates(map[string]interface{}{"status": model.PreviewStatusPending, "attempts": 0, "error": ""})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *previewRepository) Claim(bookmarkID uuid.UUID, url string) (bool, error) {
+	result := r.db.Model(&model.LinkPreview{}).
+		Where("bookmark_id = ? AND url = ? AND status = ?", bookmarkID, url, model.PreviewStatusPending).
+		Updates(map[string]interface{}{"status": model.PreviewStatusFetching, "next_attempt_at": nil})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *previewRepository) SaveResult(preview *model.LinkPreview, url string) (bool, error) {
+	result := r.db.Model(&model.LinkPreview{}).
+		Where("bookmark_id = ? AND url = ? AND status = ?", preview.BookmarkID, url, model.PreviewStatusFetching).
+		Updates(map[string]interface{}{
+			"title":           preview.Title,
+			"description":     preview.Description,
+			"image_url":       preview.ImageURL,
+			"favicon_url":     preview.FaviconURL,
+			"site_name":       preview.SiteName,
+			"content_type":    preview.ContentType,
+			"status":          preview.Status,
+			"error":           preview.Error,
+			"attempts":        preview.Attempts,
+			"next_attempt_at": preview.NextAttemptAt,
+			"fetched_at":      preview.FetchedAt,
+		})
+	return result.RowsAffected == 1, result.Error
+}
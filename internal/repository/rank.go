@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RankGap is how far apart items are placed when a list is ranked afresh.
+// Moving an item takes the midpoint between its new neighbours, so about ten
+// moves fit into the same spot before the list has to be ranked again.
+const RankGap = 1024
+
+// ErrItemNotRanked is returned when moving next to an item that is not in
+// the list.
+var ErrItemNotRanked = errors.New("item is not in the list")
+
+// rankedList is a list of rows ordered by their position column: the
+// entries of a collection, the bookmarks in a folder or the folders under a
+// parent.
+type rankedList struct {
+	db    *gorm.DB
+	model interface{}
+	// key is the column naming an item of the list.
+	key string
+	// order is how the list is shown, which breaks ties between equal
+	// positions.
+	order string
+	scope func(db *gorm.DB) *gorm.DB
+}
+
+func (l rankedList) rows() *gorm.DB {
+	return l.db.Model(l.model).Scopes(l.scope)
+}
+
+// move places item directly before or after anchor by changing only item's
+// position. When the two neighbours leave no room, the list is ranked afresh
+// first.
+func (l rankedList) move(item, anchor uuid.UUID, after bool) error {
+	position, ok, err := l.slot(item, anchor, after)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if err := l.rerank(); err != nil {
+			return err
+		}
+		if position, ok, err = l.slot(item, anchor, after); err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("no room to rank item after reranking")
+		}
+	}
+	return l.rows().Where(l.key+" = ?", item).Update("position", position).Error
+}
+
+// slot returns a free position next to anchor, or false if anchor shares its
+// position with another item or sits right next to its neighbour.
+func (l rankedList) slot(item, anchor uuid.UUID, after bool) (int, bool, error) {
+	var positions []int
+	if err := l.rows().Where(l.key+" = ?", anchor).Pluck("position", &positions).Error; err != nil {
+		return 0, false, err
+	}
+	if len(positions) == 0 {
+		return 0, false, ErrItemNotRanked
+	}
+	at := positions[0]
+
+	var ties int64
+	err := l.rows().Where(l.key+" <> ? AND "+l.key+" <> ? AND position = ?", item, anchor, at).Count(&ties).Error
+	if err != nil || ties > 0 {
+		return 0, false, err
+	}
+
+	var neighbour sql.NullInt64
+	query := l.rows().Where(l.key+" <> ?", item)
+	if after {
+		query = query.Where("position > ?", at).Select("MIN(position)")
+	} else {
+		query = query.Where("position < ?", at).Select("MAX(position)")
+	}
+	if err := query.Row().Scan(&neighbour); err != nil {
+		return 0, false, err
+	}
+
+	switch {
+	case !neighbour.Valid && after:
+		return at + RankGap, true, nil
+	case !neighbour.Valid:
+		return at - RankGap, true, nil
+	}
+	next := int(neighbour.Int64)
+	if next-at < 2 && at-next < 2 {
+		return 0, false, nil
+	}
+	return at + (next-at)/2, true, nil
+}
+
+// rerank spaces the whole list RankGap apart, keeping its order.
+func (l rankedList) rerank() error {
+	var keys []uuid.UUID
+	if err := l.rows().Order(l.order).Pluck(l.key, &keys).Error; err != nil {
+		return err
+	}
+	for start := 0; start < len(keys); start += bulkBatchSize {
+		end := min(start+bulkBatchSize, len(keys))
+		positions := make(map[uuid.UUID]int, end-start)
+		for i := start; i < end; i++ {
+			positions[keys[i]] = (i + 1) * RankGap
+		}
+		if err := setPositions(l.rows(), l.key, positions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setPositions updates the position of every row named in positions with
+// one statement.
+func setPositions(db *gorm.DB, key string, positions map[uuid.UUID]int) error {
+	if len(positions) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, 0, len(positions))
+	args := make([]interface{}, 0, 2*len(positions))
+	var expr strings.Builder
+	expr.WriteString("CASE " + key)
+	for id, position := range positions {
+		ids = append(ids, id)
+		args = append(args, id, position)
+		expr.WriteString(" WHEN ? THEN ?")
+	}
+	expr.WriteString(" END")
+	return db.Where(key+" IN ?", ids).Update("position", gorm.Expr(expr.String(), args...)).Error
+}
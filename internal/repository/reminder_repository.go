@@ -16,8 +16,14 @@ type ReminderRepository interface {
 	GetPending(userID uuid.UUID) ([]model.BookmarkReminder, error)
 	Update(reminder *model.BookmarkReminder) error
 	Delete(id uuid.UUID) error
-	MarkFired(id uuid.UUID) error
-	GetDueBefore(t time.Time) ([]model.BookmarkReminder, error)
+	MarkFired(id uuid.UUID, firedAt time.Time) (bool, error)
+	// RecordFailure stores a failed delivery of a fired reminder: its status,
+	// which puts it back to pending for a retry or marks it failed, attempts,
+	// next attempt and error.
+	RecordFailure(reminder *model.BookmarkReminder) error
+	// GetDueBefore returns pending reminders due by t whose next attempt, if
+	// one was scheduled, is due as well.
+	GetDueBefore(t time.Time, limit int) ([]model.BookmarkReminder, error)
 	CancelByBookmark(bookmarkID uuid.UUID) error
 }
 
@@ -77,15 +83,31 @@ func (r *reminderRepository) Delete(id uuid.UUID) error {
 	return r.db.Delete(&model.BookmarkReminder{}, "id = ?", id).Error
 }
 
-func (r *reminderRepository) MarkFired(id uuid.UUID) error {
-	now := time.Now()
-	return r.db.Model(&model.BookmarkReminder{}).Where("id = ?", id).
-		Updates(map[string]interface{}{"status": "fired", "fired_at": now}).Error
+// MarkFired only transitions pending reminders, so when several replicas race
+// for the same row exactly one of them sees claimed == true.
+func (r *reminderRepository) MarkFired(id uuid.UUID, firedAt time.Time) (bool, error) {
+	result := r.db.Model(&model.BookmarkReminder{}).Where("id = ? AND status = ?", id, "pending").
+		Updates(map[string]interface{}{"status": "fired", "fired_at": firedAt})
+	return result.RowsAffected == 1, result.Error
 }
 
-func (r *reminderRepository) GetDueBefore(t time.Time) ([]model.BookmarkReminder, error) {
+func (r *reminderRepository) RecordFailure(reminder *model.BookmarkReminder) error {
+	return r.db.Model(&model.BookmarkReminder{}).Where("id = ? AND status = ?", reminder.ID, "fired").
+		Updates(map[string]interface{}{
+			"status":          reminder.Status,
+			"fired_at":        reminder.FiredAt,
+			"attempts":        reminder.Attempts,
+			"next_attempt_at": reminder.NextAttemptAt,
+			"error":           reminder.Error,
+		}).Error
+}
+
+func (r *reminderRepository) GetDueBefore(t time.Time, limit int) ([]model.BookmarkReminder, error) {
 	var reminders []model.BookmarkReminder
-	err := r.db.Where("status = ? AND remind_at <= ?", "pending", t).Find(&reminders).Error
+	err := r.db.Where("status = ? AND remind_at <= ? AND (next_attempt_at IS NULL OR next_attempt_at <= ?)", "pending", t, t).
+		Order("remind_at ASC").
+		Limit(limit).
+		Find(&reminders).Error
 	return reminders, err
 }
 
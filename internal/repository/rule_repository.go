@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"gorm.io/gorm"
+)
+
+type RuleRepository interface {
+	Create(rule *model.BookmarkRule) error
+	GetByID(id uuid.UUID) (*model.BookmarkRule, error)
+	// GetByUser and GetEnabled return rules in the order they run.
+	GetByUser(userID uuid.UUID) ([]model.BookmarkRule, error)
+	GetEnabled(userID uuid.UUID) ([]model.BookmarkRule, error)
+	NextPosition(userID uuid.UUID) (int, error)
+	Update(rule *model.BookmarkRule) error
+	Delete(id uuid.UUID) error
+	SetPositions(positions map[uuid.UUID]int) error
+	// FindBookmarks calls fn with the user's bookmarks, batchSize at a time,
+	// optionally limited to one workspace.
+	FindBookmarks(userID uuid.UUID, workspaceID *uuid.UUID, batchSize int, fn func(bookmarks []model.Bookmark) error) error
+}
+
+type ruleRepository struct {
+	db *gorm.DB
+}
+
+func NewRuleRepository(db *gorm.DB) RuleRepository {
+	db.AutoMigrate(&model.BookmarkRule{})
+	return &ruleRepository{db: db}
+}
+
+func (r *ruleRepository) Create(rule *model.BookmarkRule) error {
+	return r.db.Create(rule).Error
+}
+
+func (r *ruleRepository) GetByID(id uuid.UUID) (*model.BookmarkRule, error) {
+	var rule model.BookmarkRule
+	err := r.db.First(&rule, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *ruleRepository) GetByUser(userID uuid.UUID) ([]model.BookmarkRule, error) {
+	var rules []model.BookmarkRule
+	err := r.db.Where("user_id = ?", userID).Order("position ASC, created_at ASC").Find(&rules).Error
+	return rules, err
+}
+
+func (r *ruleRepository) GetEnabled(userID uuid.UUID) ([]model.BookmarkRule, error) {
+	var rules []model.BookmarkRule
+	err := r.db.Where("user_id = ? AND enabled = ?", userID, true).
+		Order("position ASC, created_at ASC").Find(&rules).Error
+	return rules, err
+}
+
+// NextPosition returns the position after the user's last rule.
+func (r *ruleRepository) NextPosition(userID uuid.UUID) (int, error) {
+	var last int
+	err := r.db.Model(&model.BookmarkRule{}).
+		Where("user_id = ?", userID).
+		Select("COALESCE(MAX(position), -1)").
+		Scan(&last).Error
+	return last + 1, err
+}
+
+func (r *ruleRepository) Update(rule *model.BookmarkRule) error {
+	return r.db.Save(rule).Error
+}
+
+func (r *ruleRepository) Delete(id uuid.UUID) error {
+	return r.db.Delete(&model.BookmarkRule{}, "id = ?", id).Error
+}
+
+func (r *ruleRepository) SetPositions(positions map[uuid.UUID]int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for id, position := range positions {
+			err := tx.Model(&model.BookmarkRule{}).Where("id = ?", id).Update("position", position).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *ruleRepository) FindBookmarks(userID uuid.UUID, workspaceID *uuid.UUID, batchSize int, fn func(bookmarks []model.Bookmark) error) error {
+	query := r.db.Where("user_id = ?", userID)
+	if workspaceID != nil {
+		query = query.Where("workspace_id = ?", *workspaceID)
+	}
+	var batch []model.Bookmark
+	return query.FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+		return fn(batch)
+	}).Error
+}
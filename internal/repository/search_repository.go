@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"gorm.io/gorm"
+)
+
+// minFullTextQueryLen mirrors InnoDB's default innodb_ft_min_token_size;
+// shorter queries never match a FULLTEXT index, so they fall back to LIKE.
+const minFullTextQueryLen = 3
+
+const (
+	bookmarkMatch = "MATCH(b.title, b.description, b.target_url) AGAINST (? IN NATURAL LANGUAGE MODE)"
+	previewMatch  = "MATCH(p.title, p.description, p.site_name) AGAINST (? IN NATURAL LANGUAGE MODE)"
+	tagMatch      = "MATCH(t.name) AGAINST (? IN NATURAL LANGUAGE MODE)"
+	noteMatch     = "MATCH(n.content) AGAINST (? IN NATURAL LANGUAGE MODE)"
+	commentMatch  = "MATCH(c.content) AGAINST (? IN NATURAL LANGUAGE MODE)"
+	archiveMatch  = "MATCH(a.content) AGAINST (? IN NATURAL LANGUAGE MODE)"
+)
+
+// Relevance weights: a hit in the bookmark itself counts most, then its tags,
+// its preview, the owner's notes and finally comments and the archived page.
+const searchScore = "(" + bookmarkMatch + " * 3" +
+	" + COALESCE((SELECT " + previewMatch + " FROM link_previews p" +
+	" WHERE p.bookmark_id = b.id AND p.deleted_at IS NULL LIMIT 1), 0) * 1.5" +
+	" + COALESCE((SELECT SUM(" + tagMatch + ") FROM bookmark_tag_mappings m" +
+	" JOIN bookmark_tags t ON t.id = m.tag_id AND t.deleted_at IS NULL WHERE m.bookmark_id = b.id), 0) * 2" +
+	" + COALESCE((SELECT SUM(" + noteMatch + ") FROM bookmark_notes n" +
+	" WHERE n.bookmark_id = b.id AND n.user_id = b.user_id AND n.deleted_at IS NULL), 0)" +
+	" + COALESCE((SELECT SUM(" + commentMatch + ") FROM bookmark_comments c" +
+	" WHERE c.bookmark_id = b.id AND c.deleted_at IS NULL), 0) * 0.5" +
+	" + COALESCE((SELECT " + archiveMatch + " FROM page_archives a" +
+	" WHERE a.bookmark_id = b.id AND a.deleted_at IS NULL LIMIT 1), 0) * 0.5) AS score"
+
+const searchMatches = "(" + bookmarkMatch +
+	" OR b.id IN (SELECT p.bookmark_id FROM link_previews p WHERE p.deleted_at IS NULL AND " + previewMatch + ")" +
+	" OR b.id IN (SELECT m.bookmark_id FROM bookmark_tag_mappings m JOIN bookmark_tags t ON t.id = m.tag_id" +
+	" WHERE t.deleted_at IS NULL AND t.user_id = b.user_id AND " + tagMatch + ")" +
+	" OR b.id IN (SELECT n.bookmark_id FROM bookmark_notes n" +
+	" WHERE n.deleted_at IS NULL AND n.user_id = b.user_id AND " + noteMatch + ")" +
+	" OR b.id IN (SELECT c.bookmark_id FROM bookmark_comments c WHERE c.deleted_at IS NULL AND " + commentMatch + ")" +
+	" OR b.id IN (SELECT a.bookmark_id FROM page_archives a WHERE a.deleted_at IS NULL AND " + archiveMatch + "))"
+
+const searchText = "COALESCE((SELECT CONCAT_WS(' ', p.title, p.description, p.site_name) FROM link_previews p" +
+	" WHERE p.bookmark_id = b.id AND p.deleted_at IS NULL LIMIT 1), '') AS preview_text," +
+	" COALESCE((SELECT GROUP_CONCAT(t.name SEPARATOR ', ') FROM bookmark_tag_mappings m" +
+	" JOIN bookmark_tags t ON t.id = m.tag_id AND t.deleted_at IS NULL WHERE m.bookmark_id = b.id), '') AS tag_text," +
+	" COALESCE((SELECT GROUP_CONCAT(n.content SEPARATOR '\\n') FROM bookmark_notes n" +
+	" WHERE n.bookmark_id = b.id AND n.user_id = b.user_id AND n.deleted_at IS NULL), '') AS note_text," +
+	" COALESCE((SELECT GROUP_CONCAT(c.content SEPARATOR '\\n') FROM bookmark_comments c" +
+	" WHERE c.bookmark_id = b.id AND c.deleted_at IS NULL), '') AS comment_text," +
+	" COALESCE((SELECT SUBSTRING(a.content, 1, 2000) FROM page_archives a" +
+	" WHERE a.bookmark_id = b.id AND a.deleted_at IS NULL LIMIT 1), '') AS archive_text"
+
+type SearchRepository interface {
+	Search(userID uuid.UUID, query *model.SearchQuery, params model.BookmarkSearchParams) ([]model.SearchResult, int64, error)
+}
+
+type searchRepository struct {
+	db *gorm.DB
+}
+
+// NewSearchRepository expects the FULLTEXT indexes declared on the models to
+// exist; they are created by the AutoMigrate calls of the owning repositories.
+func NewSearchRepository(db *gorm.DB) SearchRepository {
+	return &searchRepository{db: db}
+}
+
+func (r *searchRepository) Search(userID uuid.UUID, query *model.SearchQuery, params model.BookmarkSearchParams) ([]model.SearchResult, int64, error) {
+	var results []model.SearchResult
+	var total int64
+
+	text := query.Text
+	fullText := len([]rune(text)) >= minFullTextQueryLen
+	filter := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("b.user_id = ? AND b.deleted_at IS NULL", userID)
+		if text != "" {
+			sql, args := textCondition(text)
+			db = db.Where(sql, args...)
+		}
+		for _, f := range query.Filters {
+			sql, args := filterCondition(f)
+			if f.Negate {
+				sql = "NOT " + sql
+			}
+			db = db.Where(sql, args...)
+		}
+		if params.Type != "" {
+			db = db.Where("b.type = ?", params.Type)
+		}
+		if params.FolderID != "" {
+			folderID, _ := uuid.Parse(params.FolderID)
+			db = db.Where("b.folder_id = ?", folderID)
+		}
+		if params.WorkspaceID != "" {
+			workspaceID, _ := uuid.Parse(params.WorkspaceID)
+			db = db.Where("b.workspace_id = ?", workspaceID)
+		}
+		return db
+	}
+
+	if err := r.db.Table("bookmarks AS b").Scopes(filter).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	stmt := r.db.Table("bookmarks AS b").Scopes(filter)
+	if fullText {
+		stmt = stmt.Select("b.*, "+searchScore+", "+searchText, text, text, text, text, text, text)
+	} else {
+		stmt = stmt.Select("b.*, 0 AS score, " + searchText)
+	}
+
+	switch params.Sort {
+	case "relevance":
+		stmt = stmt.Order("score DESC").Order("b.created_at DESC")
+	case "oldest":
+		stmt = stmt.Order("b.created_at ASC")
+	case "title":
+		stmt = stmt.Order("b.title ASC")
+	case "position":
+		stmt = stmt.Order("b.position ASC")
+	default:
+		stmt = stmt.Order("b.created_at DESC")
+	}
+
+	offset := params.Page * params.Limit
+	err := stmt.Limit(params.Limit).Offset(offset).Scan(&results).Error
+	return results, total, err
+}
+
+// textCondition matches text against the full-text indexes, or with LIKE on
+// the bookmark itself when text is too short to be indexed.
+func textCondition(text string) (string, []interface{}) {
+	if len([]rune(text)) >= minFullTextQueryLen {
+		return searchMatches, []interface{}{text, text, text, text, text, text}
+	}
+	term := "%" + likeEscaper.Replace(text) + "%"
+	return `(b.title LIKE ? ESCAPE '\\' OR b.description LIKE ? ESCAPE '\\' OR b.target_url LIKE ? ESCAPE '\\')`,
+		[]interface{}{term, term, term}
+}
+
+// likeEscaper makes LIKE match its input literally, so a search for "_" or
+// "%" does not match every bookmark.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// filterCondition compiles one qualifier into a parenthesised condition that
+// can be negated with a leading NOT.
+func filterCondition(f model.SearchFilter) (string, []interface{}) {
+	switch f.Field {
+	case model.SearchFieldText:
+		return textCondition(f.Value)
+	case model.SearchFieldTag:
+		return "(b.id IN (SELECT m.bookmark_id FROM bookmark_tag_mappings m JOIN bookmark_tags t ON t.id = m.tag_id" +
+			" WHERE t.deleted_at IS NULL AND t.user_id = b.user_id AND t.name = ?))", []interface{}{f.Value}
+	case model.SearchFieldType:
+		return "(b.type = ?)", []interface{}{f.Value}
+	case model.SearchFieldFolder:
+		if folderID, err := uuid.Parse(f.Value); err == nil {
+			return "(b.folder_id IS NOT NULL AND b.folder_id = ?)", []interface{}{folderID}
+		}
+		return "(b.folder_id IS NOT NULL AND b.folder_id IN (SELECT f.id FROM bookmark_folders f" +
+			" WHERE f.deleted_at IS NULL AND f.user_id = b.user_id AND f.name = ?))", []interface{}{f.Value}
+	case model.SearchFieldCreated, model.SearchFieldUpdated:
+		return dateCondition("b."+f.Field+"_at", f)
+	case model.SearchFieldDomain:
+		// Host is the domain or one of its subdomains, with or without a port
+		pattern := `^[a-z][a-z0-9+.-]*://([^/?#@]*@)?([^/?#:@]*\.)?` + regexp.QuoteMeta(f.Value) + `(:[0-9]+)?([/?#]|$)`
+		return "(b.target_url REGEXP ?)", []interface{}{pattern}
+	case model.SearchFieldReadLater:
+		return "(EXISTS (SELECT 1 FROM read_later_items rl WHERE rl.bookmark_id = b.id AND rl.user_id = b.user_id" +
+			" AND rl.status = ? AND rl.deleted_at IS NULL))", []interface{}{f.Value}
+	case model.SearchFieldIs:
+		switch f.Value {
+		case "favorite":
+			return "(EXISTS (SELECT 1 FROM bookmark_favorites fv WHERE fv.bookmark_id = b.id AND fv.user_id = b.user_id))", nil
+		case "archived":
+			return "(b.is_archived = ?)", []interface{}{true}
+		case "shared":
+			return "(EXISTS (SELECT 1 FROM shared_bookmarks s WHERE s.bookmark_id = b.id AND s.deleted_at IS NULL))", nil
+		}
+	case model.SearchFieldHas:
+		switch f.Value {
+		case "note":
+			return "(EXISTS (SELECT 1 FROM bookmark_notes n WHERE n.bookmark_id = b.id AND n.user_id = b.user_id AND n.deleted_at IS NULL))", nil
+		case "comment":
+			return "(EXISTS (SELECT 1 FROM bookmark_comments c WHERE c.bookmark_id = b.id AND c.deleted_at IS NULL))", nil
+		case "reminder":
+			return "(EXISTS (SELECT 1 FROM bookmark_reminders r WHERE r.bookmark_id = b.id AND r.status = ? AND r.deleted_at IS NULL))", []interface{}{"pending"}
+		case "preview":
+			return "(EXISTS (SELECT 1 FROM link_previews p WHERE p.bookmark_id = b.id AND p.status = ? AND p.deleted_at IS NULL))", []interface{}{model.PreviewStatusReady}
+		case "tag":
+			return "(EXISTS (SELECT 1 FROM bookmark_tag_mappings m JOIN bookmark_tags t ON t.id = m.tag_id" +
+				" WHERE m.bookmark_id = b.id AND t.deleted_at IS NULL))", nil
+		case "archive":
+			return "(EXISTS (SELECT 1 FROM page_archives a WHERE a.bookmark_id = b.id AND a.status = ? AND a.deleted_at IS NULL))", []interface{}{model.ArchiveStatusReady}
+		case "expiration":
+			return "(EXISTS (SELECT 1 FROM bookmark_expirations e WHERE e.bookmark_id = b.id AND e.is_expired = ? AND e.deleted_at IS NULL))", []interface{}{false}
+		}
+	}
+	// The parser only produces the fields above; match nothing rather than everything
+	return "(1 = 0)", nil
+}
+
+func dateCondition(column string, f model.SearchFilter) (string, []interface{}) {
+	switch {
+	case f.From != nil && f.To != nil:
+		return fmt.Sprintf("(%s >= ? AND %s < ?)", column, column), []interface{}{*f.From, *f.To}
+	case f.From != nil:
+		return fmt.Sprintf("(%s >= ?)", column), []interface{}{*f.From}
+	case f.To != nil:
+		return fmt.Sprintf("(%s < ?)", column), []interface{}{*f.To}
+	}
+	return "(1 = 1)", nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"gorm.io/gorm"
+)
+
+type ShareLinkRepository interface {
+	Create(link *model.CollectionShareLink) error
+	GetByID(id uuid.UUID) (*model.CollectionShareLink, error)
+	GetByTokenHash(tokenHash string) (*model.CollectionShareLink, error)
+	GetByCollection(collectionID uuid.UUID) ([]model.CollectionShareLink, error)
+	// Revoke marks the link revoked and reports whether it was still active.
+	Revoke(id uuid.UUID, at time.Time) (bool, error)
+	RecordView(id uuid.UUID, at time.Time) error
+}
+
+type shareLinkRepository struct {
+	db *gorm.DB
+}
+
+func NewShareLinkRepository(db *gorm.DB) ShareLinkRepository {
+	db.AutoMigrate(&model.CollectionShareLink{})
+	return &shareLinkRepository{db: db}
+}
+
+func (r *shareLinkRepository) Create(link *model.CollectionShareLink) error {
+	return r.db.Create(link).Error
+}
+
+func (r *shareLinkRepository) GetByID(id uuid.UUID) (*model.CollectionShareLink, error) {
+	var link model.CollectionShareLink
+	err := r.db.First(&link, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *shareLinkRepository) GetByTokenHash(tokenHash string) (*model.CollectionShareLink, error) {
+	var link model.CollectionShareLink
+	err := r.db.First(&link, "token_hash = ?", tokenHash).Error
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *shareLinkRepository) GetByCollection(collectionID uuid.UUID) ([]model.CollectionShareLink, error) {
+	var links []model.CollectionShareLink
+	err := r.db.Where("collection_id = ?", collectionID).Order("created_at DESC").Find(&links).Error
+	return links, err
+}
+
+func (r *shareLinkRepository) Revoke(id uuid.UUID, at time.Time) (bool, error) {
+	result := r.db.Model(&model.CollectionShareLink{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at)
+	return result.RowsAffected == 1, result.Error
+}
+
+// RecordView counts a view in the database, so concurrent views of the same
+// link are not lost.
+func (r *shareLinkRepository) RecordView(id uuid.UUID, at time.Time) error {
+	return r.db.Model(&model.CollectionShareLink{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"view_count":     gorm.Expr("view_count + 1"),
+			"last_viewed_at": at,
+		}).Error
+}
@@ -1,20 +1,27 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/quckapp/bookmark-service/internal/model"
 	"gorm.io/gorm"
 )
 
 type SharingRepository interface {
+	WithTx(tx *gorm.DB) SharingRepository
 	Create(shared *model.SharedBookmark) error
 	GetByID(id uuid.UUID) (*model.SharedBookmark, error)
 	GetSharedWithUser(userID uuid.UUID, limit, offset int) ([]model.SharedBookmark, int64, error)
 	GetSharedByUser(userID uuid.UUID, limit, offset int) ([]model.SharedBookmark, int64, error)
+	Update(shared *model.SharedBookmark) error
 	Accept(id uuid.UUID) error
 	Delete(id uuid.UUID) error
-	IsAlreadyShared(bookmarkID, sharedWith uuid.UUID) (bool, error)
+	IsAlreadyShared(resourceType model.ShareResourceType, resourceID, sharedWith uuid.UUID) (bool, error)
 	GetPendingCount(userID uuid.UUID) (int64, error)
+	// GetActive returns the accepted, unexpired shares that give userID
+	// access to any of resourceIDs.
+	GetActive(userID uuid.UUID, resourceType model.ShareResourceType, resourceIDs []uuid.UUID, now time.Time) ([]model.SharedBookmark, error)
 }
 
 type sharingRepository struct {
@@ -23,9 +30,25 @@ type sharingRepository struct {
 
 func NewSharingRepository(db *gorm.DB) SharingRepository {
 	db.AutoMigrate(&model.SharedBookmark{})
+	backfillShareResources(db)
 	return &sharingRepository{db: db}
 }
 
+// backfillShareResources points bookmark shares made before shares had a
+// resource at their bookmark.
+func backfillShareResources(db *gorm.DB) error {
+	return db.Unscoped().Model(&model.SharedBookmark{}).
+		Where("resource_id IS NULL").
+		UpdateColumns(map[string]interface{}{
+			"resource_type": model.ShareResourceBookmark,
+			"resource_id":   gorm.Expr("bookmark_id"),
+		}).Error
+}
+
+func (r *sharingRepository) WithTx(tx *gorm.DB) SharingRepository {
+	return &sharingRepository{db: tx}
+}
+
 func (r *sharingRepository) Create(shared *model.SharedBookmark) error {
 	return r.db.Create(shared).Error
 }
@@ -63,6 +86,10 @@ func (r *sharingRepository) GetSharedByUser(userID uuid.UUID, limit, offset int)
 	return shares, total, err
 }
 
+func (r *sharingRepository) Update(shared *model.SharedBookmark) error {
+	return r.db.Save(shared).Error
+}
+
 func (r *sharingRepository) Accept(id uuid.UUID) error {
 	return r.db.Model(&model.SharedBookmark{}).Where("id = ?", id).Update("is_accepted", true).Error
 }
@@ -71,10 +98,10 @@ func (r *sharingRepository) Delete(id uuid.UUID) error {
 	return r.db.Delete(&model.SharedBookmark{}, "id = ?", id).Error
 }
 
-func (r *sharingRepository) IsAlreadyShared(bookmarkID, sharedWith uuid.UUID) (bool, error) {
+func (r *sharingRepository) IsAlreadyShared(resourceType model.ShareResourceType, resourceID, sharedWith uuid.UUID) (bool, error) {
 	var count int64
 	err := r.db.Model(&model.SharedBookmark{}).
-		Where("bookmark_id = ? AND shared_with = ?", bookmarkID, sharedWith).
+		Where("resource_type = ? AND resource_id = ? AND shared_with = ?", resourceType, resourceID, sharedWith).
 		Count(&count).Error
 	return count > 0, err
 }
@@ -86,3 +113,15 @@ func (r *sharingRepository) GetPendingCount(userID uuid.UUID) (int64, error) {
 		Count(&count).Error
 	return count, err
 }
+
+func (r *sharingRepository) GetActive(userID uuid.UUID, resourceType model.ShareResourceType, resourceIDs []uuid.UUID, now time.Time) ([]model.SharedBookmark, error) {
+	var shares []model.SharedBookmark
+	if len(resourceIDs) == 0 {
+		return shares, nil
+	}
+	err := r.db.Where("shared_with = ? AND resource_type = ? AND resource_id IN ? AND is_accepted = ?",
+		userID, resourceType, resourceIDs, true).
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Find(&shares).Error
+	return shares, err
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"gorm.io/gorm"
+)
+
+type SmartFolderRepository interface {
+	Create(folder *model.SmartFolder) error
+	GetByID(id uuid.UUID) (*model.SmartFolder, error)
+	GetByUser(userID uuid.UUID) ([]model.SmartFolder, error)
+	GetByUserAndWorkspace(userID, workspaceID uuid.UUID) ([]model.SmartFolder, error)
+	Update(folder *model.SmartFolder) error
+	Delete(id uuid.UUID) error
+}
+
+type smartFolderRepository struct {
+	db *gorm.DB
+}
+
+func NewSmartFolderRepository(db *gorm.DB) SmartFolderRepository {
+	db.AutoMigrate(&model.SmartFolder{})
+	return &smartFolderRepository{db: db}
+}
+
+func (r *smartFolderRepository) Create(folder *model.SmartFolder) error {
+	return r.db.Create(folder).Error
+}
+
+func (r *smartFolderRepository) GetByID(id uuid.UUID) (*model.SmartFolder, error) {
+	var folder model.SmartFolder
+	err := r.db.First(&folder, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+func (r *smartFolderRepository) GetByUser(userID uuid.UUID) ([]model.SmartFolder, error) {
+	var folders []model.SmartFolder
+	err := r.db.Where("user_id = ?", userID).Order("position ASC, name ASC").Find(&folders).Error
+	return folders, err
+}
+
+func (r *smartFolderRepository) GetByUserAndWorkspace(userID, workspaceID uuid.UUID) ([]model.SmartFolder, error) {
+	var folders []model.SmartFolder
+	err := r.db.Where("user_id = ? AND workspace_id = ?", userID, workspaceID).
+		Order("position ASC, name ASC").Find(&folders).Error
+	return folders, err
+}
+
+func (r *smartFolderRepository) Update(folder *model.SmartFolder) error {
+	return r.db.Save(folder).Error
+}
+
+func (r *smartFolderRepository) Delete(id uuid.UUID) error {
+	return r.db.Delete(&model.SmartFolder{}, "id = ?", id).Error
+}
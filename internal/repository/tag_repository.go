@@ -18,7 +18,7 @@ type TagRepository interface {
 	GetTagsByBookmark(bookmarkID uuid.UUID) ([]model.BookmarkTag, error)
 	GetBookmarksByTag(tagID uuid.UUID, limit, offset int) ([]model.Bookmark, int64, error)
 	RemoveAllTagsFromBookmark(bookmarkID uuid.UUID) error
-	BulkAddTagToBookmarks(bookmarkIDs []uuid.UUID, tagID uuid.UUID) error
+	GetMappingsByBookmarks(bookmarkIDs []uuid.UUID) ([]model.BookmarkTagMapping, error)
 }
 
 type tagRepository struct {
@@ -99,14 +99,12 @@ func (r *tagRepository) RemoveAllTagsFromBookmark(bookmarkID uuid.UUID) error {
 	return r.db.Where("bookmark_id = ?", bookmarkID).Delete(&model.BookmarkTagMapping{}).Error
 }
 
-func (r *tagRepository) BulkAddTagToBookmarks(bookmarkIDs []uuid.UUID, tagID uuid.UUID) error {
-	for _, bID := range bookmarkIDs {
-		mapping := &model.BookmarkTagMapping{
-			BookmarkID: bID,
-			TagID:      tagID,
-		}
-		// Ignore duplicate errors
-		r.db.Where("bookmark_id = ? AND tag_id = ?", bID, tagID).FirstOrCreate(mapping)
+// GetMappingsByBookmarks returns the tag mappings of the given bookmarks.
+func (r *tagRepository) GetMappingsByBookmarks(bookmarkIDs []uuid.UUID) ([]model.BookmarkTagMapping, error) {
+	var mappings []model.BookmarkTagMapping
+	if len(bookmarkIDs) == 0 {
+		return mappings, nil
 	}
-	return nil
+	err := r.db.Where("bookmark_id IN ?", bookmarkIDs).Find(&mappings).Error
+	return mappings, err
 }
@@ -0,0 +1,21 @@
+package repository
+
+import "gorm.io/gorm"
+
+// Transactor runs work that spans several repositories in one database
+// transaction. Repositories join it through their WithTx method.
+type Transactor interface {
+	Transaction(fn func(tx *gorm.DB) error) error
+}
+
+type transactor struct {
+	db *gorm.DB
+}
+
+func NewTransactor(db *gorm.DB) Transactor {
+	return &transactor{db: db}
+}
+
+func (t *transactor) Transaction(fn func(tx *gorm.DB) error) error {
+	return t.db.Transaction(fn)
+}
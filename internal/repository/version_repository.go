@@ -4,13 +4,19 @@ import (
 	"github.com/google/uuid"
 	"github.com/quckapp/bookmark-service/internal/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type VersionRepository interface {
+	WithTx(tx *gorm.DB) VersionRepository
 	Create(version *model.BookmarkVersion) error
 	GetByID(id uuid.UUID) (*model.BookmarkVersion, error)
 	GetByBookmark(bookmarkID uuid.UUID, limit, offset int) ([]model.BookmarkVersion, int64, error)
-	GetLatestVersion(bookmarkID uuid.UUID) (int, error)
+	// LockLatestVersion returns the bookmark's highest version number, or 0
+	// if it has none, and locks the bookmark row so no other transaction can
+	// number a version for it until this one ends. Call it through WithTx.
+	LockLatestVersion(bookmarkID uuid.UUID) (int, error)
+	DeleteUpTo(bookmarkID uuid.UUID, version int) error
 }
 
 type versionRepository struct {
@@ -22,6 +28,10 @@ func NewVersionRepository(db *gorm.DB) VersionRepository {
 	return &versionRepository{db: db}
 }
 
+func (r *versionRepository) WithTx(tx *gorm.DB) VersionRepository {
+	return &versionRepository{db: tx}
+}
+
 func (r *versionRepository) Create(version *model.BookmarkVersion) error {
 	return r.db.Create(version).Error
 }
@@ -47,14 +57,26 @@ func (r *versionRepository) GetByBookmark(bookmarkID uuid.UUID, limit, offset in
 	return versions, total, err
 }
 
-func (r *versionRepository) GetLatestVersion(bookmarkID uuid.UUID) (int, error) {
-	var version model.BookmarkVersion
-	err := r.db.Where("bookmark_id = ?", bookmarkID).Order("version DESC").First(&version).Error
+func (r *versionRepository) LockLatestVersion(bookmarkID uuid.UUID) (int, error) {
+	// Locking the bookmark rather than its versions also serializes the first
+	// version, when there are no version rows to lock yet.
+	var ids []uuid.UUID
+	err := r.db.Model(&model.Bookmark{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", bookmarkID).
+		Pluck("id", &ids).Error
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return 0, nil
-		}
 		return 0, err
 	}
-	return version.Version, nil
+
+	var latest int
+	err = r.db.Model(&model.BookmarkVersion{}).
+		Where("bookmark_id = ?", bookmarkID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&latest).Error
+	return latest, err
+}
+
+func (r *versionRepository) DeleteUpTo(bookmarkID uuid.UUID, version int) error {
+	return r.db.Where("bookmark_id = ? AND version <= ?", bookmarkID, version).Delete(&model.BookmarkVersion{}).Error
 }
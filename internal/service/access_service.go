@@ -0,0 +1,405 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/repository"
+	"gorm.io/gorm"
+)
+
+// maxShareFolderDepth bounds the walk up the folder tree when looking for a
+// shared ancestor, in case the tree holds a cycle.
+const maxShareFolderDepth = 64
+
+var (
+	ErrForbidden = errors.New("forbidden")
+	ErrNotFound  = errors.New("not found")
+)
+
+// AccessService decides whether a caller may act on a resource. A resource
+// that does not exist, or that belongs to a different parent than the one in
+// the path, is reported as ErrNotFound; one owned by someone else as ErrForbidden.
+//
+// The Authorize*Role methods also admit users the resource was shared with
+// in a role that includes role. A bookmark is shared through a share of the
+// bookmark, of its folder or a folder above it, or of a collection holding
+// it; a folder through a share of itself or a folder above it. Accepted
+// members of a collection count as if it was shared with them in their role,
+// and its owner as a viewer of the bookmarks members put in it.
+type AccessService interface {
+	AuthorizeBookmark(userID, bookmarkID uuid.UUID) error
+	AuthorizeBookmarkRole(userID, bookmarkID uuid.UUID, role model.ShareRole) error
+	AuthorizeFolder(userID, folderID uuid.UUID) error
+	AuthorizeFolderRole(userID, folderID uuid.UUID, role model.ShareRole) error
+	AuthorizeTag(userID, tagID uuid.UUID) error
+	AuthorizeCollection(userID, collectionID uuid.UUID) error
+	AuthorizeCollectionRole(userID, collectionID uuid.UUID, role model.ShareRole) error
+	// AuthorizeCollectionRead admits public collections and anyone the
+	// collection was shared with.
+	AuthorizeCollectionRead(userID, collectionID uuid.UUID) error
+	AuthorizeNote(userID, bookmarkID, noteID uuid.UUID) error
+	AuthorizeComment(userID, bookmarkID, commentID uuid.UUID) error
+	AuthorizeReminder(userID, bookmarkID, reminderID uuid.UUID) error
+	AuthorizeVersion(bookmarkID, versionID uuid.UUID) error
+	AuthorizeReadLaterItem(userID, itemID uuid.UUID) error
+	AuthorizeTemplate(userID, templateID uuid.UUID) error
+	AuthorizeSmartFolder(userID, smartFolderID uuid.UUID) error
+	AuthorizeRule(userID, ruleID uuid.UUID) error
+	AuthorizeShareRecipient(userID, shareID uuid.UUID) error
+	AuthorizeShareOwner(userID, shareID uuid.UUID) error
+	// AuthorizeCollectionMember admits the collection's owner and the member
+	// themselves.
+	AuthorizeCollectionMember(userID, collectionID, memberID uuid.UUID) error
+	AuthorizeCollectionInvitee(userID, collectionID, memberID uuid.UUID) error
+}
+
+type accessService struct {
+	bookmarkRepo    repository.BookmarkRepository
+	folderRepo      repository.FolderRepository
+	tagRepo         repository.TagRepository
+	collectionRepo  repository.CollectionRepository
+	noteRepo        repository.NoteRepository
+	commentRepo     repository.CommentRepository
+	reminderRepo    repository.ReminderRepository
+	versionRepo     repository.VersionRepository
+	readLaterRepo   repository.ReadLaterRepository
+	templateRepo    repository.TemplateRepository
+	sharingRepo     repository.SharingRepository
+	memberRepo      repository.CollectionMemberRepository
+	smartFolderRepo repository.SmartFolderRepository
+	ruleRepo        repository.RuleRepository
+}
+
+func NewAccessService(
+	bookmarkRepo repository.BookmarkRepository,
+	folderRepo repository.FolderRepository,
+	tagRepo repository.TagRepository,
+	collectionRepo repository.CollectionRepository,
+	noteRepo repository.NoteRepository,
+	commentRepo repository.CommentRepository,
+	reminderRepo repository.ReminderRepository,
+	versionRepo repository.VersionRepository,
+	readLaterRepo repository.ReadLaterRepository,
+	templateRepo repository.TemplateRepository,
+	sharingRepo repository.SharingRepository,
+	memberRepo repository.CollectionMemberRepository,
+	smartFolderRepo repository.SmartFolderRepository,
+	ruleRepo repository.RuleRepository,
+) AccessService {
+	return &accessService{
+		bookmarkRepo:    bookmarkRepo,
+		folderRepo:      folderRepo,
+		tagRepo:         tagRepo,
+		collectionRepo:  collectionRepo,
+		noteRepo:        noteRepo,
+		commentRepo:     commentRepo,
+		reminderRepo:    reminderRepo,
+		versionRepo:     versionRepo,
+		readLaterRepo:   readLaterRepo,
+		templateRepo:    templateRepo,
+		sharingRepo:     sharingRepo,
+		memberRepo:      memberRepo,
+		smartFolderRepo: smartFolderRepo,
+		ruleRepo:        ruleRepo,
+	}
+}
+
+func (s *accessService) AuthorizeBookmark(userID, bookmarkID uuid.UUID) error {
+	bookmark, err := s.bookmarkRepo.GetByID(bookmarkID)
+	if err != nil {
+		return lookupError("bookmark", err)
+	}
+	return checkOwner(userID, bookmark.UserID)
+}
+
+func (s *accessService) AuthorizeBookmarkRole(userID, bookmarkID uuid.UUID, role model.ShareRole) error {
+	bookmark, err := s.bookmarkRepo.GetByID(bookmarkID)
+	if err != nil {
+		return lookupError("bookmark", err)
+	}
+	if bookmark.UserID == userID {
+		return nil
+	}
+
+	if ok, err := s.hasShare(userID, model.ShareResourceBookmark, []uuid.UUID{bookmarkID}, role); ok || err != nil {
+		return err
+	}
+	if bookmark.FolderID != nil {
+		if ok, err := s.hasFolderShare(userID, *bookmark.FolderID, role); ok || err != nil {
+			return err
+		}
+	}
+	collectionIDs, err := s.collectionRepo.GetCollectionIDsByBookmark(bookmarkID)
+	if err != nil {
+		return err
+	}
+	if ok, err := s.hasCollectionRole(userID, collectionIDs, role); ok || err != nil {
+		return err
+	}
+	// Putting a bookmark in someone's collection lets them read it, not
+	// change it
+	if !model.ShareRoleViewer.Includes(role) {
+		return ErrForbidden
+	}
+	collections, err := s.collectionRepo.GetByIDs(collectionIDs)
+	if err != nil {
+		return err
+	}
+	for _, collection := range collections {
+		if collection.UserID == userID {
+			return nil
+		}
+	}
+	return ErrForbidden
+}
+
+func (s *accessService) AuthorizeFolder(userID, folderID uuid.UUID) error {
+	folder, err := s.folderRepo.GetByID(folderID)
+	if err != nil {
+		return lookupError("folder", err)
+	}
+	return checkOwner(userID, folder.UserID)
+}
+
+func (s *accessService) AuthorizeFolderRole(userID, folderID uuid.UUID, role model.ShareRole) error {
+	folder, err := s.folderRepo.GetByID(folderID)
+	if err != nil {
+		return lookupError("folder", err)
+	}
+	if folder.UserID == userID {
+		return nil
+	}
+	if ok, err := s.hasFolderShare(userID, folderID, role); ok || err != nil {
+		return err
+	}
+	return ErrForbidden
+}
+
+func (s *accessService) AuthorizeTag(userID, tagID uuid.UUID) error {
+	tag, err := s.tagRepo.GetByID(tagID)
+	if err != nil {
+		return lookupError("tag", err)
+	}
+	return checkOwner(userID, tag.UserID)
+}
+
+func (s *accessService) AuthorizeCollection(userID, collectionID uuid.UUID) error {
+	collection, err := s.collectionRepo.GetByID(collectionID)
+	if err != nil {
+		return lookupError("collection", err)
+	}
+	return checkOwner(userID, collection.UserID)
+}
+
+func (s *accessService) AuthorizeCollectionRole(userID, collectionID uuid.UUID, role model.ShareRole) error {
+	collection, err := s.collectionRepo.GetByID(collectionID)
+	if err != nil {
+		return lookupError("collection", err)
+	}
+	if collection.UserID == userID {
+		return nil
+	}
+	if ok, err := s.hasCollectionRole(userID, []uuid.UUID{collectionID}, role); ok || err != nil {
+		return err
+	}
+	return ErrForbidden
+}
+
+func (s *accessService) AuthorizeCollectionRead(userID, collectionID uuid.UUID) error {
+	collection, err := s.collectionRepo.GetByID(collectionID)
+	if err != nil {
+		return lookupError("collection", err)
+	}
+	if collection.IsPublic || collection.UserID == userID {
+		return nil
+	}
+	if ok, err := s.hasCollectionRole(userID, []uuid.UUID{collectionID}, model.ShareRoleViewer); ok || err != nil {
+		return err
+	}
+	return ErrForbidden
+}
+
+func (s *accessService) AuthorizeNote(userID, bookmarkID, noteID uuid.UUID) error {
+	note, err := s.noteRepo.GetByID(noteID)
+	if err != nil {
+		return lookupError("note", err)
+	}
+	if note.BookmarkID != bookmarkID {
+		return fmt.Errorf("note %w", ErrNotFound)
+	}
+	return checkOwner(userID, note.UserID)
+}
+
+func (s *accessService) AuthorizeComment(userID, bookmarkID, commentID uuid.UUID) error {
+	comment, err := s.commentRepo.GetByID(commentID)
+	if err != nil {
+		return lookupError("comment", err)
+	}
+	if comment.BookmarkID != bookmarkID {
+		return fmt.Errorf("comment %w", ErrNotFound)
+	}
+	return checkOwner(userID, comment.UserID)
+}
+
+func (s *accessService) AuthorizeReminder(userID, bookmarkID, reminderID uuid.UUID) error {
+	reminder, err := s.reminderRepo.GetByID(reminderID)
+	if err != nil {
+		return lookupError("reminder", err)
+	}
+	if reminder.BookmarkID != bookmarkID {
+		return fmt.Errorf("reminder %w", ErrNotFound)
+	}
+	return checkOwner(userID, reminder.UserID)
+}
+
+// AuthorizeVersion only checks the version belongs to the bookmark; access to
+// the bookmark itself is checked separately.
+func (s *accessService) AuthorizeVersion(bookmarkID, versionID uuid.UUID) error {
+	version, err := s.versionRepo.GetByID(versionID)
+	if err != nil {
+		return lookupError("version", err)
+	}
+	if version.BookmarkID != bookmarkID {
+		return fmt.Errorf("version %w", ErrNotFound)
+	}
+	return nil
+}
+
+func (s *accessService) AuthorizeReadLaterItem(userID, itemID uuid.UUID) error {
+	item, err := s.readLaterRepo.GetByID(itemID)
+	if err != nil {
+		return lookupError("read later item", err)
+	}
+	return checkOwner(userID, item.UserID)
+}
+
+func (s *accessService) AuthorizeTemplate(userID, templateID uuid.UUID) error {
+	template, err := s.templateRepo.GetByID(templateID)
+	if err != nil {
+		return lookupError("template", err)
+	}
+	return checkOwner(userID, template.UserID)
+}
+
+func (s *accessService) AuthorizeSmartFolder(userID, smartFolderID uuid.UUID) error {
+	folder, err := s.smartFolderRepo.GetByID(smartFolderID)
+	if err != nil {
+		return lookupError("smart folder", err)
+	}
+	return checkOwner(userID, folder.UserID)
+}
+
+func (s *accessService) AuthorizeRule(userID, ruleID uuid.UUID) error {
+	rule, err := s.ruleRepo.GetByID(ruleID)
+	if err != nil {
+		return lookupError("rule", err)
+	}
+	return checkOwner(userID, rule.UserID)
+}
+
+func (s *accessService) AuthorizeShareRecipient(userID, shareID uuid.UUID) error {
+	share, err := s.sharingRepo.GetByID(shareID)
+	if err != nil {
+		return lookupError("shared bookmark", err)
+	}
+	return checkOwner(userID, share.SharedWith)
+}
+
+func (s *accessService) AuthorizeShareOwner(userID, shareID uuid.UUID) error {
+	share, err := s.sharingRepo.GetByID(shareID)
+	if err != nil {
+		return lookupError("shared bookmark", err)
+	}
+	return checkOwner(userID, share.SharedBy)
+}
+
+func (s *accessService) AuthorizeCollectionMember(userID, collectionID, memberID uuid.UUID) error {
+	member, err := s.memberRepo.GetByID(memberID)
+	if err != nil {
+		return lookupError("collection member", err)
+	}
+	if member.CollectionID != collectionID {
+		return fmt.Errorf("collection member %w", ErrNotFound)
+	}
+	if member.UserID == userID {
+		return nil
+	}
+	return s.AuthorizeCollection(userID, collectionID)
+}
+
+func (s *accessService) AuthorizeCollectionInvitee(userID, collectionID, memberID uuid.UUID) error {
+	member, err := s.memberRepo.GetByID(memberID)
+	if err != nil {
+		return lookupError("collection member", err)
+	}
+	if member.CollectionID != collectionID {
+		return fmt.Errorf("collection member %w", ErrNotFound)
+	}
+	return checkOwner(userID, member.UserID)
+}
+
+// hasCollectionRole reports whether any of collectionIDs is shared with
+// userID, or has userID as an accepted member, in at least role.
+func (s *accessService) hasCollectionRole(userID uuid.UUID, collectionIDs []uuid.UUID, role model.ShareRole) (bool, error) {
+	if ok, err := s.hasShare(userID, model.ShareResourceCollection, collectionIDs, role); ok || err != nil {
+		return ok, err
+	}
+	members, err := s.memberRepo.GetAccepted(userID, collectionIDs)
+	if err != nil {
+		return false, err
+	}
+	for _, member := range members {
+		if member.Role.Includes(role) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// hasShare reports whether userID holds an active share in at least role on
+// any of resourceIDs.
+func (s *accessService) hasShare(userID uuid.UUID, resourceType model.ShareResourceType, resourceIDs []uuid.UUID, role model.ShareRole) (bool, error) {
+	shares, err := s.sharingRepo.GetActive(userID, resourceType, resourceIDs, time.Now())
+	if err != nil {
+		return false, err
+	}
+	for _, share := range shares {
+		if share.Role.Includes(role) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// hasFolderShare reports whether folderID or a folder above it is shared
+// with userID in at least role.
+func (s *accessService) hasFolderShare(userID, folderID uuid.UUID, role model.ShareRole) (bool, error) {
+	ids := []uuid.UUID{folderID}
+	for id := folderID; len(ids) < maxShareFolderDepth; {
+		folder, err := s.folderRepo.GetByID(id)
+		if err != nil || folder.ParentID == nil || containsID(ids, *folder.ParentID) {
+			break
+		}
+		id = *folder.ParentID
+		ids = append(ids, id)
+	}
+	return s.hasShare(userID, model.ShareResourceFolder, ids, role)
+}
+
+func checkOwner(userID, ownerID uuid.UUID) error {
+	if userID != ownerID {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func lookupError(resource string, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%s %w", resource, ErrNotFound)
+	}
+	return err
+}
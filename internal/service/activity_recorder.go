@@ -0,0 +1,48 @@
+package service
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+// ActivityRecorder writes the activity log for mutating operations. Recording
+// is best effort: failures are logged and never fail the operation itself.
+type ActivityRecorder interface {
+	Record(userID, bookmarkID uuid.UUID, action string, details interface{})
+}
+
+type activityRecorder struct {
+	repo   repository.ActivityRepository
+	logger *zap.Logger
+}
+
+func NewActivityRecorder(repo repository.ActivityRepository, logger *zap.Logger) ActivityRecorder {
+	return &activityRecorder{repo: repo, logger: logger}
+}
+
+func (r *activityRecorder) Record(userID, bookmarkID uuid.UUID, action string, details interface{}) {
+	activity := &model.BookmarkActivity{
+		BookmarkID: bookmarkID,
+		UserID:     userID,
+		Action:     action,
+	}
+	if details != nil {
+		data, err := json.Marshal(details)
+		if err != nil {
+			r.logger.Warn("Failed to encode activity details", zap.String("action", action), zap.Error(err))
+		} else {
+			activity.Details = string(data)
+		}
+	}
+
+	if err := r.repo.Create(activity); err != nil {
+		r.logger.Warn("Failed to record activity",
+			zap.String("bookmarkId", bookmarkID.String()),
+			zap.String("action", action),
+			zap.Error(err))
+	}
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,13 +13,14 @@ import (
 type BookmarkAnalyticsService interface {
 	GetStats(userID uuid.UUID, workspaceID *uuid.UUID) (*model.BookmarkStats, error)
 	GetRecentBookmarks(userID uuid.UUID, limit int) ([]model.Bookmark, error)
-	CheckDuplicate(userID uuid.UUID, targetID uuid.UUID, bookmarkType model.BookmarkType) (*model.DuplicateCheckResult, error)
-	SearchBookmarks(userID uuid.UUID, params model.BookmarkSearchParams) ([]model.Bookmark, int64, error)
+	// CheckDuplicate looks for a bookmark of the user's in the workspace, or
+	// in any workspace if workspaceID is nil, with the same canonical URL as
+	// targetURL, of any type, or with the same target and type.
+	CheckDuplicate(userID uuid.UUID, workspaceID *uuid.UUID, targetURL string, targetID uuid.UUID, bookmarkType model.BookmarkType) (*model.DuplicateCheckResult, error)
 	ExportBookmarks(userID uuid.UUID, workspaceID *uuid.UUID) (*model.ExportData, error)
 	ImportBookmarks(userID, workspaceID uuid.UUID, req model.ImportRequest) (*model.ImportResult, error)
-	GetActivity(userID uuid.UUID, page, limit int) ([]model.BookmarkActivity, int64, error)
-	GetBookmarkActivity(bookmarkID uuid.UUID, page, limit int) ([]model.BookmarkActivity, int64, error)
-	LogActivity(bookmarkID, userID uuid.UUID, action, details string) error
+	GetActivity(userID uuid.UUID, filter model.ActivityFilter, page, limit int) ([]model.BookmarkActivity, int64, error)
+	GetBookmarkActivity(bookmarkID uuid.UUID, filter model.ActivityFilter, page, limit int) ([]model.BookmarkActivity, int64, error)
 }
 
 type bookmarkAnalyticsService struct {
@@ -59,35 +61,27 @@ func (s *bookmarkAnalyticsService) GetRecentBookmarks(userID uuid.UUID, limit in
 	return s.analyticsRepo.GetRecentBookmarks(userID, limit)
 }
 
-func (s *bookmarkAnalyticsService) CheckDuplicate(userID uuid.UUID, targetID uuid.UUID, bookmarkType model.BookmarkType) (*model.DuplicateCheckResult, error) {
-	existing, err := s.analyticsRepo.CheckDuplicate(userID, targetID, bookmarkType)
+func (s *bookmarkAnalyticsService) CheckDuplicate(userID uuid.UUID, workspaceID *uuid.UUID, targetURL string, targetID uuid.UUID, bookmarkType model.BookmarkType) (*model.DuplicateCheckResult, error) {
+	canonicalURL := model.CanonicalURL(targetURL)
+	existing, err := s.bookmarkRepo.FindDuplicate(userID, workspaceID, canonicalURL, targetID, bookmarkType)
 	if err != nil {
 		return nil, err
 	}
 	result := &model.DuplicateCheckResult{
-		IsDuplicate: existing != nil,
-		Existing:    existing,
+		IsDuplicate:  existing != nil,
+		CanonicalURL: canonicalURL,
+		Existing:     existing,
 	}
 	return result, nil
 }
 
-func (s *bookmarkAnalyticsService) SearchBookmarks(userID uuid.UUID, params model.BookmarkSearchParams) ([]model.Bookmark, int64, error) {
-	if params.Limit <= 0 {
-		params.Limit = 20
-	}
-	if params.Limit > 100 {
-		params.Limit = 100
-	}
-	return s.analyticsRepo.SearchBookmarks(userID, params)
-}
-
 func (s *bookmarkAnalyticsService) ExportBookmarks(userID uuid.UUID, workspaceID *uuid.UUID) (*model.ExportData, error) {
 	export := &model.ExportData{
 		ExportedAt: time.Now(),
 	}
 
 	// Get bookmarks
-	bookmarks, _, err := s.bookmarkRepo.GetByUser(userID, 10000, 0)
+	bookmarks, _, err := s.bookmarkRepo.GetByUser(userID, nil, 10000, 0)
 	if err != nil {
 		return nil, err
 	}
@@ -123,6 +117,10 @@ func (s *bookmarkAnalyticsService) ImportBookmarks(userID, workspaceID uuid.UUID
 	var folderID *uuid.UUID
 	if req.FolderID != "" {
 		fID, _ := uuid.Parse(req.FolderID)
+		folder, err := s.folderRepo.GetByID(fID)
+		if err != nil || folder.UserID != userID {
+			return nil, fmt.Errorf("folder %w", ErrNotFound)
+		}
 		folderID = &fID
 	}
 
@@ -150,22 +148,12 @@ func (s *bookmarkAnalyticsService) ImportBookmarks(userID, workspaceID uuid.UUID
 	return result, nil
 }
 
-func (s *bookmarkAnalyticsService) GetActivity(userID uuid.UUID, page, limit int) ([]model.BookmarkActivity, int64, error) {
+func (s *bookmarkAnalyticsService) GetActivity(userID uuid.UUID, filter model.ActivityFilter, page, limit int) ([]model.BookmarkActivity, int64, error) {
 	offset := page * limit
-	return s.activityRepo.GetByUser(userID, limit, offset)
+	return s.activityRepo.GetByUser(userID, filter, limit, offset)
 }
 
-func (s *bookmarkAnalyticsService) GetBookmarkActivity(bookmarkID uuid.UUID, page, limit int) ([]model.BookmarkActivity, int64, error) {
+func (s *bookmarkAnalyticsService) GetBookmarkActivity(bookmarkID uuid.UUID, filter model.ActivityFilter, page, limit int) ([]model.BookmarkActivity, int64, error) {
 	offset := page * limit
-	return s.activityRepo.GetByBookmark(bookmarkID, limit, offset)
-}
-
-func (s *bookmarkAnalyticsService) LogActivity(bookmarkID, userID uuid.UUID, action, details string) error {
-	activity := &model.BookmarkActivity{
-		BookmarkID: bookmarkID,
-		UserID:     userID,
-		Action:     action,
-		Details:    details,
-	}
-	return s.activityRepo.Create(activity)
+	return s.activityRepo.GetByBookmark(bookmarkID, filter, limit, offset)
 }
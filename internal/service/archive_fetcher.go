@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// ErrNotHTML is returned when a page to archive is not an HTML document.
+var ErrNotHTML = errors.New("page is not an HTML document")
+
+// ArchivedPage is the readable content of a fetched page.
+type ArchivedPage struct {
+	FinalURL string
+	ReadableContent
+}
+
+type ArchiveFetcher interface {
+	Fetch(ctx context.Context, rawURL string) (*ArchivedPage, error)
+}
+
+type ArchiveFetcherConfig struct {
+	Timeout      time.Duration
+	MaxBodyBytes int64
+	MaxRedirects int
+	UserAgent    string
+	// AllowPrivateNetworks disables the SSRF guard. Only tests pointing at a
+	// local httptest server should set it.
+	AllowPrivateNetworks bool
+}
+
+type httpArchiveFetcher struct {
+	client *http.Client
+	cfg    ArchiveFetcherConfig
+}
+
+func NewArchiveFetcher(cfg ArchiveFetcherConfig) ArchiveFetcher {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = 5 << 20
+	}
+	if cfg.MaxRedirects <= 0 {
+		cfg.MaxRedirects = 5
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = "QuckAppBookmarkBot/1.0"
+	}
+
+	client := &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: newGuardedTransport(cfg.Timeout, cfg.AllowPrivateNetworks),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= cfg.MaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", cfg.MaxRedirects)
+			}
+			return checkScheme(req.URL)
+		},
+	}
+	return &httpArchiveFetcher{client: client, cfg: cfg}
+}
+
+func (f *httpArchiveFetcher) Fetch(ctx context.Context, rawURL string) (*ArchivedPage, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid url", ErrURLNotAllowed)
+	}
+	if err := checkScheme(target); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.cfg.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "" && mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, fmt.Errorf("%w: %s", ErrNotHTML, mediaType)
+	}
+
+	body, err := charset.NewReader(io.LimitReader(resp.Body, f.cfg.MaxBodyBytes), resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+	doc, err := html.Parse(body)
+	if err != nil {
+		return nil, err
+	}
+
+	finalURL := resp.Request.URL
+	return &ArchivedPage{
+		FinalURL:        finalURL.String(),
+		ReadableContent: *ExtractReadable(doc, finalURL),
+	}, nil
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ArchiveJob periodically archives the pages that were requested or, with
+// automatic archiving, every external bookmark that has no archive yet, and
+// removes the snapshots of deleted bookmarks.
+type ArchiveJob struct {
+	periodicJob
+	service  ArchiveService
+	interval time.Duration
+	now      Clock
+	logger   *zap.Logger
+}
+
+func NewArchiveJob(service ArchiveService, interval time.Duration, clock Clock, logger *zap.Logger) *ArchiveJob {
+	if clock == nil {
+		clock = time.Now
+	}
+	return &ArchiveJob{
+		service:  service,
+		interval: interval,
+		now:      clock,
+		logger:   logger,
+	}
+}
+
+func (j *ArchiveJob) Start(ctx context.Context) {
+	j.logger.Info("Starting page archiver", zap.Duration("interval", j.interval))
+	j.start(ctx, j.interval, j.run)
+}
+
+func (j *ArchiveJob) Stop() {
+	j.stop()
+	j.logger.Info("Stopped page archiver")
+}
+
+func (j *ArchiveJob) run(ctx context.Context) {
+	processed, err := j.service.ProcessDue(ctx, j.now())
+	if err != nil {
+		j.logger.Error("Failed to archive pages", zap.Error(err))
+		return
+	}
+	if processed > 0 {
+		j.logger.Info("Archived pages", zap.Int("count", processed))
+	}
+
+	purged, err := j.service.PurgeDeleted(ctx, j.now())
+	if err != nil {
+		j.logger.Error("Failed to purge page archives", zap.Error(err))
+		return
+	}
+	if purged > 0 {
+		j.logger.Info("Purged page archives of deleted bookmarks", zap.Int("count", purged))
+	}
+}
@@ -0,0 +1,337 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	archiveBatchSize = 20
+	// archiveFetchLease is how long an archive may stay fetching before
+	// another instance takes it over.
+	archiveFetchLease    = 15 * time.Minute
+	archiveMaxRetryDelay = 6 * time.Hour
+	// archiveIndexedBytes is how much of a page's text is kept in the
+	// database for full-text search.
+	archiveIndexedBytes = 64 << 10
+)
+
+const (
+	ArchiveFormatHTML = "html"
+	ArchiveFormatText = "text"
+)
+
+var (
+	// ErrNotArchivable is returned when archiving a bookmark that does not
+	// point at a web page.
+	ErrNotArchivable = errors.New("only external bookmarks with a URL can be archived")
+	// ErrArchiveNotReady is returned when reading an archive that has no
+	// snapshot yet.
+	ErrArchiveNotReady = errors.New("archive is not ready")
+)
+
+// ArchiveContent is the latest snapshot of a page. Status is that of the
+// archive, which may be fetching a newer snapshot or have failed to.
+type ArchiveContent struct {
+	Data        []byte
+	ContentType string
+	Status      model.ArchiveStatus
+	ArchivedAt  time.Time
+}
+
+type ArchiveService interface {
+	// Request queues the bookmark's page to be archived, replacing any
+	// earlier snapshot once fetched.
+	Request(bookmarkID uuid.UUID) (*model.PageArchive, error)
+	Get(bookmarkID uuid.UUID) (*model.PageArchive, error)
+	// GetContent returns the latest snapshot of the page as sanitized HTML
+	// or plain text, according to format.
+	GetContent(ctx context.Context, bookmarkID uuid.UUID, format string) (*ArchiveContent, error)
+	// ProcessDue archives a batch of pages that are due and returns how many
+	// were attempted.
+	ProcessDue(ctx context.Context, now time.Time) (int, error)
+	// PurgeDeleted removes the snapshots of a batch of deleted bookmarks and
+	// returns how many were removed.
+	PurgeDeleted(ctx context.Context, now time.Time) (int, error)
+}
+
+type ArchivePolicy struct {
+	// Auto archives every external bookmark, not only those requested.
+	Auto           bool
+	MaxAttempts    int
+	RetryBaseDelay time.Duration
+}
+
+type archiveService struct {
+	repo         repository.ArchiveRepository
+	bookmarkRepo repository.BookmarkRepository
+	fetcher      ArchiveFetcher
+	store        BlobStore
+	policy       ArchivePolicy
+	logger       *zap.Logger
+}
+
+func NewArchiveService(
+	repo repository.ArchiveRepository,
+	bookmarkRepo repository.BookmarkRepository,
+	fetcher ArchiveFetcher,
+	store BlobStore,
+	policy ArchivePolicy,
+	logger *zap.Logger,
+) ArchiveService {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 3
+	}
+	if policy.RetryBaseDelay <= 0 {
+		policy.RetryBaseDelay = 5 * time.Minute
+	}
+	return &archiveService{
+		repo:         repo,
+		bookmarkRepo: bookmarkRepo,
+		fetcher:      fetcher,
+		store:        store,
+		policy:       policy,
+		logger:       logger,
+	}
+}
+
+func (s *archiveService) Request(bookmarkID uuid.UUID) (*model.PageArchive, error) {
+	bookmark, err := s.bookmarkRepo.GetByID(bookmarkID)
+	if err != nil {
+		return nil, lookupError("bookmark", err)
+	}
+	if bookmark.Type != model.BookmarkTypeExternal || bookmark.TargetURL == "" {
+		return nil, ErrNotArchivable
+	}
+	target, err := url.Parse(bookmark.TargetURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid url", ErrURLNotAllowed)
+	}
+	if err := checkScheme(target); err != nil {
+		return nil, err
+	}
+
+	err = s.repo.Request(&model.PageArchive{
+		BookmarkID: bookmark.ID,
+		UserID:     bookmark.UserID,
+		URL:        bookmark.TargetURL,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("Queued page archive", zap.String("bookmarkId", bookmarkID.String()))
+	return s.Get(bookmarkID)
+}
+
+func (s *archiveService) Get(bookmarkID uuid.UUID) (*model.PageArchive, error) {
+	archive, err := s.repo.GetByBookmark(bookmarkID)
+	if err != nil {
+		return nil, lookupError("archive", err)
+	}
+	return archive, nil
+}
+
+func (s *archiveService) GetContent(ctx context.Context, bookmarkID uuid.UUID, format string) (*ArchiveContent, error) {
+	archive, err := s.Get(bookmarkID)
+	if err != nil {
+		return nil, err
+	}
+	// A page being archived again, or failing to, still has its earlier
+	// snapshot
+	if archive.ArchivedAt == nil || archive.HTMLKey == "" {
+		return nil, fmt.Errorf("%w: %s", ErrArchiveNotReady, archive.Status)
+	}
+
+	key, contentType := archive.HTMLKey, "text/html; charset=utf-8"
+	if format == ArchiveFormatText {
+		key, contentType = archive.TextKey, "text/plain; charset=utf-8"
+	}
+	data, err := s.store.Get(ctx, key)
+	if errors.Is(err, ErrBlobNotFound) {
+		return nil, fmt.Errorf("archive content %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ArchiveContent{
+		Data:        data,
+		ContentType: contentType,
+		Status:      archive.Status,
+		ArchivedAt:  *archive.ArchivedAt,
+	}, nil
+}
+
+func (s *archiveService) ProcessDue(ctx context.Context, now time.Time) (int, error) {
+	if s.policy.Auto {
+		queued, err := s.repo.CreateMissing(archiveBatchSize)
+		if err != nil {
+			s.logger.Error("Failed to queue page archives", zap.Error(err))
+		} else if queued > 0 {
+			s.logger.Info("Queued page archives", zap.Int("count", queued))
+		}
+	}
+
+	archives, err := s.repo.GetDue(now, now.Add(-archiveFetchLease), archiveBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for i := range archives {
+		if ctx.Err() != nil {
+			break
+		}
+		archive := &archives[i]
+		claimed, err := s.repo.Claim(archive)
+		if err != nil {
+			s.logger.Error("Failed to claim page archive", zap.String("bookmarkId", archive.BookmarkID.String()), zap.Error(err))
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		if s.archive(ctx, archive) {
+			processed++
+		}
+	}
+	return processed, nil
+}
+
+func (s *archiveService) PurgeDeleted(ctx context.Context, now time.Time) (int, error) {
+	archives, err := s.repo.GetOrphaned(now.Add(-archiveFetchLease), archiveBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for i := range archives {
+		if ctx.Err() != nil {
+			break
+		}
+		archive := &archives[i]
+		if err := s.deleteBlobs(ctx, archive); err != nil {
+			s.logger.Error("Failed to delete page archive content", zap.String("bookmarkId", archive.BookmarkID.String()), zap.Error(err))
+			continue
+		}
+		if err := s.repo.Delete(archive.ID); err != nil {
+			s.logger.Error("Failed to delete page archive", zap.String("bookmarkId", archive.BookmarkID.String()), zap.Error(err))
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// deleteBlobs removes both forms of the archive's snapshot. The keys are
+// derived from the bookmark, so a snapshot stored by an attempt that failed
+// before recording it is removed too.
+func (s *archiveService) deleteBlobs(ctx context.Context, archive *model.PageArchive) error {
+	for _, key := range []string{archiveKey(archive.BookmarkID, "html"), archiveKey(archive.BookmarkID, "txt")} {
+		if err := s.store.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archive fetches and stores one claimed archive and reports whether the
+// attempt was recorded.
+func (s *archiveService) archive(ctx context.Context, archive *model.PageArchive) bool {
+	page, err := s.fetcher.Fetch(ctx, archive.URL)
+	if err == nil {
+		err = s.save(ctx, archive, page)
+	}
+	if ctx.Err() != nil {
+		// Shutting down; the lease expires and the page is archived later
+		return false
+	}
+
+	if err == nil {
+		s.logger.Info("Archived page", zap.String("bookmarkId", archive.BookmarkID.String()), zap.Int64("size", archive.Size))
+	} else {
+		s.fail(archive, err)
+	}
+	if err := s.repo.Update(archive); err != nil {
+		s.logger.Error("Failed to save page archive", zap.String("bookmarkId", archive.BookmarkID.String()), zap.Error(err))
+		return false
+	}
+	return true
+}
+
+func (s *archiveService) save(ctx context.Context, archive *model.PageArchive, page *ArchivedPage) error {
+	htmlKey := archiveKey(archive.BookmarkID, "html")
+	textKey := archiveKey(archive.BookmarkID, "txt")
+	document := []byte("<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>" + html.EscapeString(page.Title) +
+		"</title></head><body><article>" + page.HTML + "</article></body></html>\n")
+	text := []byte(page.Text)
+
+	if err := s.store.Put(ctx, htmlKey, "text/html; charset=utf-8", document); err != nil {
+		return fmt.Errorf("failed to store archive: %w", err)
+	}
+	if err := s.store.Put(ctx, textKey, "text/plain; charset=utf-8", text); err != nil {
+		return fmt.Errorf("failed to store archive: %w", err)
+	}
+
+	now := time.Now()
+	archive.FinalURL = truncate(page.FinalURL, 500)
+	archive.Title = truncate(page.Title, 255)
+	archive.HTMLKey = htmlKey
+	archive.TextKey = textKey
+	archive.Size = int64(len(document) + len(text))
+	archive.Content = truncate(page.Text, archiveIndexedBytes)
+	archive.ArchivedAt = &now
+	archive.Status = model.ArchiveStatusReady
+	archive.Error = ""
+	archive.Attempts = 0
+	archive.NextAttemptAt = nil
+	return nil
+}
+
+// fail records a failed attempt and schedules a retry, unless the page can
+// never be archived or attempts ran out. An earlier snapshot is kept.
+func (s *archiveService) fail(archive *model.PageArchive, err error) {
+	archive.Attempts++
+	archive.Error = truncate(err.Error(), 1000)
+	if errors.Is(err, ErrURLNotAllowed) || errors.Is(err, ErrNotHTML) || archive.Attempts >= s.policy.MaxAttempts {
+		archive.Status = model.ArchiveStatusFailed
+		archive.NextAttemptAt = nil
+		s.logger.Warn("Page archive failed",
+			zap.String("bookmarkId", archive.BookmarkID.String()),
+			zap.Int("attempts", archive.Attempts),
+			zap.Error(err))
+		return
+	}
+
+	next := time.Now().Add(s.retryDelay(archive.Attempts))
+	archive.Status = model.ArchiveStatusPending
+	archive.NextAttemptAt = &next
+	s.logger.Warn("Page archive failed, will retry",
+		zap.String("bookmarkId", archive.BookmarkID.String()),
+		zap.Int("attempts", archive.Attempts),
+		zap.Time("nextAttemptAt", next),
+		zap.Error(err))
+}
+
+func (s *archiveService) retryDelay(attempts int) time.Duration {
+	delay := s.policy.RetryBaseDelay
+	for i := 1; i < attempts && delay < archiveMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > archiveMaxRetryDelay {
+		delay = archiveMaxRetryDelay
+	}
+	return delay
+}
+
+func archiveKey(bookmarkID uuid.UUID, ext string) string {
+	return "archives/" + bookmarkID.String() + "." + ext
+}
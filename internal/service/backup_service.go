@@ -0,0 +1,916 @@
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/repository"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// backupBatchSize is how many rows are loaded at a time while exporting.
+const backupBatchSize = 500
+
+// ErrInvalidBackup is returned when a restore is given something that is
+// not a readable backup archive.
+var ErrInvalidBackup = errors.New("invalid backup")
+
+// errRestoreDryRun rolls back a dry-run restore once it has been checked.
+var errRestoreDryRun = errors.New("restore dry run")
+
+// restoreReferenced are the sections other sections refer to. Only their ID
+// mappings are kept while restoring.
+var restoreReferenced = []string{
+	model.BackupSectionFolders,
+	model.BackupSectionTags,
+	model.BackupSectionCollections,
+	model.BackupSectionBookmarks,
+	model.BackupSectionComments,
+}
+
+// BackupService writes and restores complete backups: every bookmark with
+// its folders, tags, collections and everything attached to it.
+type BackupService interface {
+	// Export streams a backup archive to w without loading it into memory.
+	Export(userID uuid.UUID, workspaceID *uuid.UUID, w io.Writer) error
+	// Restore reads an archive written by Export and recreates it for userID
+	// under new IDs. It runs in one transaction; a dry run reports what would
+	// happen and rolls it back.
+	Restore(userID uuid.UUID, r io.Reader, opts model.RestoreOptions) (*model.RestoreResult, error)
+}
+
+type backupService struct {
+	repo   repository.BackupRepository
+	tx     repository.Transactor
+	clock  Clock
+	logger *zap.Logger
+}
+
+func NewBackupService(repo repository.BackupRepository, tx repository.Transactor, clock Clock, logger *zap.Logger) BackupService {
+	if clock == nil {
+		clock = time.Now
+	}
+	return &backupService{repo: repo, tx: tx, clock: clock, logger: logger}
+}
+
+func (s *backupService) Export(userID uuid.UUID, workspaceID *uuid.UUID, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	header, err := json.Marshal(model.BackupHeader{
+		Format:      model.BackupFormat,
+		Version:     model.BackupFormatVersion,
+		ExportedAt:  s.clock(),
+		UserID:      userID,
+		WorkspaceID: workspaceID,
+	})
+	if err != nil {
+		return err
+	}
+	// Leave the header object open so the sections can follow it
+	bw.Write(header[:len(header)-1])
+
+	for _, section := range model.BackupSections {
+		fmt.Fprintf(bw, ",%q:[", section)
+		empty := true
+		err := s.repo.Stream(userID, workspaceID, section, backupBatchSize, func(rows interface{}) error {
+			batch, err := json.Marshal(rows)
+			if err != nil {
+				return err
+			}
+			// Splice the batch's elements into the section's array
+			if len(batch) <= 2 {
+				return nil
+			}
+			if !empty {
+				bw.WriteByte(',')
+			}
+			empty = false
+			_, err = bw.Write(batch[1 : len(batch)-1])
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("export %s: %w", section, err)
+		}
+		bw.WriteByte(']')
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+func (s *backupService) Restore(userID uuid.UUID, r io.Reader, opts model.RestoreOptions) (*model.RestoreResult, error) {
+	switch opts.OnConflict {
+	case "":
+		opts.OnConflict = model.RestoreConflictSkip
+	case model.RestoreConflictSkip, model.RestoreConflictDuplicate:
+	default:
+		return nil, fmt.Errorf("%w: onConflict must be %s or %s",
+			ErrInvalidBackup, model.RestoreConflictSkip, model.RestoreConflictDuplicate)
+	}
+
+	var rs *restorer
+	err := s.tx.Transaction(func(tx *gorm.DB) error {
+		rs = newRestorer(s.repo.WithTx(tx), userID, opts)
+		if err := rs.run(json.NewDecoder(r)); err != nil {
+			return err
+		}
+		if opts.DryRun {
+			return errRestoreDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errRestoreDryRun) {
+		return nil, err
+	}
+
+	s.logger.Info("Restored backup",
+		zap.String("userId", userID.String()),
+		zap.Bool("dryRun", opts.DryRun),
+		zap.Any("created", rs.result.Created))
+	return rs.result, nil
+}
+
+type parentLink struct {
+	id     uuid.UUID
+	parent uuid.UUID
+}
+
+// restorer applies one archive. ids maps the archived IDs of the referenced
+// sections to the IDs they were restored as; uuid.Nil marks a record that
+// was skipped.
+type restorer struct {
+	repo    repository.BackupRepository
+	userID  uuid.UUID
+	opts    model.RestoreOptions
+	header  *model.BackupHeader
+	ids     map[string]map[uuid.UUID]uuid.UUID
+	folders []model.BookmarkFolder
+	replies []parentLink
+	result  *model.RestoreResult
+}
+
+func newRestorer(repo repository.BackupRepository, userID uuid.UUID, opts model.RestoreOptions) *restorer {
+	rs := &restorer{
+		repo:   repo,
+		userID: userID,
+		opts:   opts,
+		ids:    map[string]map[uuid.UUID]uuid.UUID{},
+		result: &model.RestoreResult{
+			DryRun:  opts.DryRun,
+			Created: map[string]int{},
+			Skipped: map[string]int{},
+		},
+	}
+	for _, section := range restoreReferenced {
+		rs.ids[section] = map[uuid.UUID]uuid.UUID{}
+	}
+	return rs
+}
+
+func (rs *restorer) run(dec *json.Decoder) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+
+	var header model.BackupHeader
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return invalidBackup(err)
+		}
+		key, _ := tok.(string)
+
+		var value interface{}
+		switch key {
+		case "format":
+			value = &header.Format
+		case "version":
+			value = &header.Version
+		case "exportedAt":
+			value = &header.ExportedAt
+		case "userId":
+			value = &header.UserID
+		case "workspaceId":
+			value = &header.WorkspaceID
+		}
+		if value != nil {
+			if err := dec.Decode(value); err != nil {
+				return invalidBackup(fmt.Errorf("%s: %w", key, err))
+			}
+			continue
+		}
+
+		if !contains(model.BackupSections, key) {
+			// Sections added by newer versions of the same format are skipped
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return invalidBackup(err)
+			}
+			continue
+		}
+		if rs.header == nil {
+			if err := checkBackupHeader(&header); err != nil {
+				return err
+			}
+			rs.header = &header
+		}
+		if key != model.BackupSectionFolders {
+			if err := rs.flushFolders(); err != nil {
+				return err
+			}
+		}
+		if err := rs.section(dec, key); err != nil {
+			return err
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return err
+	}
+	if rs.header == nil {
+		if err := checkBackupHeader(&header); err != nil {
+			return err
+		}
+		rs.header = &header
+	}
+	if err := rs.flushFolders(); err != nil {
+		return err
+	}
+	return rs.linkReplies()
+}
+
+func checkBackupHeader(header *model.BackupHeader) error {
+	if header.Format != model.BackupFormat {
+		return fmt.Errorf("%w: not a bookmark backup (format %q); the header must precede the data", ErrInvalidBackup, header.Format)
+	}
+	if header.Version < 1 || header.Version > model.BackupFormatVersion {
+		return fmt.Errorf("%w: unsupported backup version %d", ErrInvalidBackup, header.Version)
+	}
+	return nil
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return invalidBackup(err)
+	}
+	if d, ok := tok.(json.Delim); !ok || d != delim {
+		return invalidBackup(fmt.Errorf("expected %q", delim))
+	}
+	return nil
+}
+
+func invalidBackup(err error) error {
+	return fmt.Errorf("%w: %w", ErrInvalidBackup, err)
+}
+
+// section restores one section's array, decoding a record at a time.
+func (rs *restorer) section(dec *json.Decoder, section string) error {
+	if err := expectDelim(dec, '['); err != nil {
+		return err
+	}
+	for dec.More() {
+		if err := rs.record(dec, section); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, ']')
+}
+
+func (rs *restorer) record(dec *json.Decoder, section string) error {
+	decode := func(v interface{}) error {
+		if err := dec.Decode(v); err != nil {
+			return invalidBackup(fmt.Errorf("%s: %w", section, err))
+		}
+		return nil
+	}
+
+	switch section {
+	case model.BackupSectionFolders:
+		var f model.BookmarkFolder
+		if err := decode(&f); err != nil {
+			return err
+		}
+		// Folders are restored together once the section ends, parents first
+		rs.folders = append(rs.folders, f)
+		return nil
+
+	case model.BackupSectionTags:
+		var t model.BookmarkTag
+		if err := decode(&t); err != nil {
+			return err
+		}
+		oldID := t.ID
+		t.ID, t.UserID, t.WorkspaceID = uuid.New(), rs.userID, rs.workspace(t.WorkspaceID)
+		if rs.opts.OnConflict == model.RestoreConflictSkip {
+			existing, err := rs.repo.FindTag(t.UserID, t.WorkspaceID, t.Name)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				rs.merge(section, oldID, existing.ID, "a tag with the same name exists")
+				return nil
+			}
+		}
+		return rs.create(section, oldID, t.ID, &t)
+
+	case model.BackupSectionCollections:
+		var c model.BookmarkCollection
+		if err := decode(&c); err != nil {
+			return err
+		}
+		oldID := c.ID
+		c.ID, c.UserID, c.WorkspaceID = uuid.New(), rs.userID, rs.workspace(c.WorkspaceID)
+		if rs.opts.OnConflict == model.RestoreConflictSkip {
+			existing, err := rs.repo.FindCollection(c.UserID, c.WorkspaceID, c.Name)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				rs.merge(section, oldID, existing.ID, "a collection with the same name exists")
+				return nil
+			}
+		}
+		return rs.create(section, oldID, c.ID, &c)
+
+	case model.BackupSectionTemplates:
+		var t model.BookmarkTemplate
+		if err := decode(&t); err != nil {
+			return err
+		}
+		oldID := t.ID
+		t.ID, t.UserID, t.WorkspaceID = uuid.New(), rs.userID, rs.workspace(t.WorkspaceID)
+		if rs.opts.OnConflict == model.RestoreConflictSkip {
+			existing, err := rs.repo.FindTemplate(t.UserID, t.WorkspaceID, t.Name)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				rs.skip(section, oldID, &existing.ID, "a template with the same name exists")
+				return nil
+			}
+		}
+		t.FolderID = rs.remapString(model.BackupSectionFolders, t.FolderID)
+		t.TagIDs = rs.remapTagIDs(t.TagIDs)
+		return rs.create(section, oldID, t.ID, &t)
+
+	case model.BackupSectionSmartFolders:
+		var f model.SmartFolder
+		if err := decode(&f); err != nil {
+			return err
+		}
+		oldID := f.ID
+		f.ID, f.UserID, f.WorkspaceID = uuid.New(), rs.userID, rs.workspace(f.WorkspaceID)
+		if rs.opts.OnConflict == model.RestoreConflictSkip {
+			existing, err := rs.repo.FindSmartFolder(f.UserID, f.WorkspaceID, f.Name)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				rs.skip(section, oldID, &existing.ID, "a smart folder with the same name exists")
+				return nil
+			}
+		}
+		return rs.create(section, oldID, f.ID, &f)
+
+	case model.BackupSectionRules:
+		var rule model.BookmarkRule
+		if err := decode(&rule); err != nil {
+			return err
+		}
+		oldID := rule.ID
+		rule.ID, rule.UserID = uuid.New(), rs.userID
+		if rs.opts.OnConflict == model.RestoreConflictSkip {
+			existing, err := rs.repo.FindRule(rule.UserID, rule.Name)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				rs.skip(section, oldID, &existing.ID, "a rule with the same name exists")
+				return nil
+			}
+		}
+		rs.remapRule(&rule)
+		return rs.create(section, oldID, rule.ID, &rule)
+
+	case model.BackupSectionBookmarks:
+		var b model.Bookmark
+		if err := decode(&b); err != nil {
+			return err
+		}
+		oldID := b.ID
+		b.ID, b.UserID, b.WorkspaceID = uuid.New(), rs.userID, rs.workspace(b.WorkspaceID)
+		if rs.opts.OnConflict == model.RestoreConflictSkip {
+			existing, err := rs.repo.FindBookmark(b.UserID, b.WorkspaceID, b.Type, b.TargetID, b.TargetURL)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				rs.skip(section, oldID, &existing.ID, "a bookmark of the same target exists")
+				return nil
+			}
+		}
+		b.FolderID = rs.remapOptional(model.BackupSectionFolders, b.FolderID)
+		return rs.create(section, oldID, b.ID, &b)
+
+	case model.BackupSectionTagMappings:
+		var m model.BookmarkTagMapping
+		if err := decode(&m); err != nil {
+			return err
+		}
+		oldID := m.ID
+		var ok bool
+		if m.BookmarkID, ok = rs.bookmark(section, oldID, m.BookmarkID); !ok {
+			return nil
+		}
+		if m.TagID, ok = rs.reference(section, oldID, model.BackupSectionTags, m.TagID); !ok {
+			return nil
+		}
+		m.ID = uuid.New()
+		return rs.create(section, oldID, m.ID, &m)
+
+	case model.BackupSectionCollectionBookmarks:
+		var cb model.CollectionBookmark
+		if err := decode(&cb); err != nil {
+			return err
+		}
+		oldID := cb.ID
+		var ok bool
+		if cb.BookmarkID, ok = rs.bookmark(section, oldID, cb.BookmarkID); !ok {
+			return nil
+		}
+		if cb.CollectionID, ok = rs.reference(section, oldID, model.BackupSectionCollections, cb.CollectionID); !ok {
+			return nil
+		}
+		cb.ID, cb.AddedBy = uuid.New(), rs.userID
+		return rs.create(section, oldID, cb.ID, &cb)
+
+	case model.BackupSectionNotes:
+		var n model.BookmarkNote
+		if err := decode(&n); err != nil {
+			return err
+		}
+		oldID := n.ID
+		var ok bool
+		if n.BookmarkID, ok = rs.bookmark(section, oldID, n.BookmarkID); !ok {
+			return nil
+		}
+		if !rs.owns(section, oldID, n.UserID) {
+			return nil
+		}
+		n.ID, n.UserID = uuid.New(), rs.userID
+		return rs.create(section, oldID, n.ID, &n)
+
+	case model.BackupSectionComments:
+		var c model.BookmarkComment
+		if err := decode(&c); err != nil {
+			return err
+		}
+		oldID := c.ID
+		var ok bool
+		if c.BookmarkID, ok = rs.bookmark(section, oldID, c.BookmarkID); !ok {
+			return nil
+		}
+		// Other people's comments are left out rather than restored in
+		// their name; replies to them become top-level comments
+		if !rs.owns(section, oldID, c.UserID) {
+			return nil
+		}
+		c.ID, c.UserID = uuid.New(), rs.userID
+		if c.ParentID != nil {
+			// Replies may come before the comment they answer; link them at the end
+			rs.replies = append(rs.replies, parentLink{id: c.ID, parent: *c.ParentID})
+			c.ParentID = nil
+		}
+		return rs.create(section, oldID, c.ID, &c)
+
+	case model.BackupSectionReminders:
+		var rm model.BookmarkReminder
+		if err := decode(&rm); err != nil {
+			return err
+		}
+		oldID := rm.ID
+		var ok bool
+		if rm.BookmarkID, ok = rs.bookmark(section, oldID, rm.BookmarkID); !ok {
+			return nil
+		}
+		if !rs.owns(section, oldID, rm.UserID) {
+			return nil
+		}
+		rm.ID, rm.UserID = uuid.New(), rs.userID
+		return rs.create(section, oldID, rm.ID, &rm)
+
+	case model.BackupSectionFavorites:
+		var f model.BookmarkFavorite
+		if err := decode(&f); err != nil {
+			return err
+		}
+		oldID := f.ID
+		var ok bool
+		if f.BookmarkID, ok = rs.bookmark(section, oldID, f.BookmarkID); !ok {
+			return nil
+		}
+		if !rs.owns(section, oldID, f.UserID) {
+			return nil
+		}
+		f.ID, f.UserID = uuid.New(), rs.userID
+		return rs.create(section, oldID, f.ID, &f)
+
+	case model.BackupSectionReadLater:
+		var item model.ReadLaterItem
+		if err := decode(&item); err != nil {
+			return err
+		}
+		oldID := item.ID
+		var ok bool
+		if item.BookmarkID, ok = rs.bookmark(section, oldID, item.BookmarkID); !ok {
+			return nil
+		}
+		if !rs.owns(section, oldID, item.UserID) {
+			return nil
+		}
+		item.ID, item.UserID = uuid.New(), rs.userID
+		return rs.create(section, oldID, item.ID, &item)
+
+	case model.BackupSectionPreviews:
+		var p model.LinkPreview
+		if err := decode(&p); err != nil {
+			return err
+		}
+		oldID := p.ID
+		var ok bool
+		if p.BookmarkID, ok = rs.bookmark(section, oldID, p.BookmarkID); !ok {
+			return nil
+		}
+		p.ID = uuid.New()
+		return rs.create(section, oldID, p.ID, &p)
+
+	case model.BackupSectionVersions:
+		var v model.BookmarkVersion
+		if err := decode(&v); err != nil {
+			return err
+		}
+		oldID := v.ID
+		var ok bool
+		if v.BookmarkID, ok = rs.bookmark(section, oldID, v.BookmarkID); !ok {
+			return nil
+		}
+		// Versions saved by editors the bookmark was shared with are kept
+		// as history, credited to the restoring user
+		v.ID, v.UserID = uuid.New(), rs.userID
+		return rs.create(section, oldID, v.ID, &v)
+
+	case model.BackupSectionExpirations:
+		var e model.BookmarkExpiration
+		if err := decode(&e); err != nil {
+			return err
+		}
+		oldID := e.ID
+		var ok bool
+		if e.BookmarkID, ok = rs.bookmark(section, oldID, e.BookmarkID); !ok {
+			return nil
+		}
+		if !rs.owns(section, oldID, e.UserID) {
+			return nil
+		}
+		e.ID, e.UserID = uuid.New(), rs.userID
+		e.TargetFolderID = rs.remapOptional(model.BackupSectionFolders, e.TargetFolderID)
+		return rs.create(section, oldID, e.ID, &e)
+
+	case model.BackupSectionShares:
+		var sh model.SharedBookmark
+		if err := decode(&sh); err != nil {
+			return err
+		}
+		oldID := sh.ID
+		if sh.ResourceType == "" {
+			sh.ResourceType, sh.ResourceID = model.ShareResourceBookmark, sh.BookmarkID
+		}
+		var ok bool
+		switch sh.ResourceType {
+		case model.ShareResourceBookmark:
+			sh.ResourceID, ok = rs.bookmark(section, oldID, sh.ResourceID)
+			sh.BookmarkID = sh.ResourceID
+		case model.ShareResourceFolder:
+			sh.ResourceID, ok = rs.reference(section, oldID, model.BackupSectionFolders, sh.ResourceID)
+			sh.BookmarkID = uuid.Nil
+		case model.ShareResourceCollection:
+			sh.ResourceID, ok = rs.reference(section, oldID, model.BackupSectionCollections, sh.ResourceID)
+			sh.BookmarkID = uuid.Nil
+		default:
+			rs.fail(section, oldID, fmt.Sprintf("unknown resource type %q", sh.ResourceType))
+		}
+		if !ok {
+			return nil
+		}
+		if sh.SharedWith == uuid.Nil || sh.SharedWith == rs.userID {
+			rs.skip(section, oldID, nil, "the resource was shared with the restoring user")
+			return nil
+		}
+		if rs.opts.OnConflict == model.RestoreConflictSkip {
+			shared, err := rs.repo.IsShared(sh.ResourceType, sh.ResourceID, sh.SharedWith)
+			if err != nil {
+				return err
+			}
+			if shared {
+				rs.skip(section, oldID, nil, "the resource is already shared with this user")
+				return nil
+			}
+		}
+		if !sh.Role.IsValid() {
+			sh.Role = model.ShareRoleViewer
+		}
+		// Restored shares are invitations again: the archive cannot prove
+		// the recipient ever accepted, nor who shared them
+		sh.ID, sh.SharedBy, sh.WorkspaceID = uuid.New(), rs.userID, rs.workspace(sh.WorkspaceID)
+		sh.IsAccepted = false
+		return rs.create(section, oldID, sh.ID, &sh)
+
+	case model.BackupSectionCollectionMembers:
+		var m model.CollectionMember
+		if err := decode(&m); err != nil {
+			return err
+		}
+		oldID := m.ID
+		var ok bool
+		if m.CollectionID, ok = rs.reference(section, oldID, model.BackupSectionCollections, m.CollectionID); !ok {
+			return nil
+		}
+		if m.UserID == uuid.Nil || m.UserID == rs.userID {
+			rs.skip(section, oldID, nil, "the restoring user owns the collection")
+			return nil
+		}
+		if rs.opts.OnConflict == model.RestoreConflictSkip {
+			member, err := rs.repo.IsCollectionMember(m.CollectionID, m.UserID)
+			if err != nil {
+				return err
+			}
+			if member {
+				rs.skip(section, oldID, nil, "the user is already a member of the collection")
+				return nil
+			}
+		}
+		if !m.Role.IsValid() {
+			m.Role = model.ShareRoleViewer
+		}
+		// Like shares, members are invited again rather than given access
+		m.ID, m.InvitedBy, m.WorkspaceID = uuid.New(), rs.userID, rs.workspace(m.WorkspaceID)
+		m.IsAccepted, m.AcceptedAt = false, nil
+		return rs.create(section, oldID, m.ID, &m)
+
+	case model.BackupSectionActivities:
+		var a model.BookmarkActivity
+		if err := decode(&a); err != nil {
+			return err
+		}
+		oldID := a.ID
+		var ok bool
+		if a.BookmarkID, ok = rs.bookmark(section, oldID, a.BookmarkID); !ok {
+			return nil
+		}
+		// Like versions, activity by others is credited to the restoring user
+		a.ID, a.UserID = uuid.New(), rs.userID
+		return rs.create(section, oldID, a.ID, &a)
+	}
+	return nil
+}
+
+// flushFolders restores the buffered folders parents first, so each folder
+// can be matched by name under its restored parent. Folders whose parent is
+// missing from the archive, or that form a cycle, are restored at the top.
+func (rs *restorer) flushFolders() error {
+	pending := rs.folders
+	rs.folders = nil
+	section := model.BackupSectionFolders
+
+	for len(pending) > 0 {
+		var waiting []model.BookmarkFolder
+		for _, f := range pending {
+			var parent *uuid.UUID
+			if f.ParentID != nil {
+				newParent, seen := rs.ids[section][*f.ParentID]
+				if !seen && containsFolder(pending, *f.ParentID) {
+					waiting = append(waiting, f)
+					continue
+				}
+				if seen {
+					parent = &newParent
+				}
+			}
+			if err := rs.folder(f, parent); err != nil {
+				return err
+			}
+		}
+		if len(waiting) == len(pending) {
+			// Nothing could be placed: the rest form a cycle
+			for _, f := range waiting {
+				rs.conflict(section, f.ID, nil, "the folder's parents form a cycle", "restored at the top level")
+				if err := rs.folder(f, nil); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		pending = waiting
+	}
+	return nil
+}
+
+func (rs *restorer) folder(f model.BookmarkFolder, parent *uuid.UUID) error {
+	section := model.BackupSectionFolders
+	oldID := f.ID
+	f.ID, f.UserID, f.WorkspaceID, f.ParentID = uuid.New(), rs.userID, rs.workspace(f.WorkspaceID), parent
+	if rs.opts.OnConflict == model.RestoreConflictSkip {
+		existing, err := rs.repo.FindFolder(f.UserID, f.WorkspaceID, f.ParentID, f.Name)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			rs.merge(section, oldID, existing.ID, "a folder with the same name exists")
+			return nil
+		}
+	}
+	return rs.create(section, oldID, f.ID, &f)
+}
+
+func containsFolder(folders []model.BookmarkFolder, id uuid.UUID) bool {
+	for _, f := range folders {
+		if f.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (rs *restorer) linkReplies() error {
+	for _, link := range rs.replies {
+		parent, ok := rs.ids[model.BackupSectionComments][link.parent]
+		if !ok || parent == uuid.Nil {
+			// The reply stays, as a top-level comment
+			continue
+		}
+		if err := rs.repo.SetParent(&model.BookmarkComment{}, link.id, parent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rs *restorer) create(section string, oldID, newID uuid.UUID, row interface{}) error {
+	if err := rs.repo.Create(row); err != nil {
+		return fmt.Errorf("restore %s %s: %w", section, oldID, err)
+	}
+	rs.remember(section, oldID, newID)
+	rs.result.Created[section]++
+	return nil
+}
+
+// merge maps an archived record onto an existing one, so the records that
+// refer to it are attached to the existing one.
+func (rs *restorer) merge(section string, oldID, existingID uuid.UUID, reason string) {
+	rs.remember(section, oldID, existingID)
+	rs.result.Skipped[section]++
+	rs.conflict(section, oldID, &existingID, reason, "merged into the existing record")
+}
+
+// skip leaves an archived record out along with everything attached to it.
+func (rs *restorer) skip(section string, oldID uuid.UUID, existingID *uuid.UUID, reason string) {
+	rs.remember(section, oldID, uuid.Nil)
+	rs.result.Skipped[section]++
+	rs.conflict(section, oldID, existingID, reason, "skipped")
+}
+
+func (rs *restorer) remember(section string, oldID, newID uuid.UUID) {
+	if ids, ok := rs.ids[section]; ok {
+		ids[oldID] = newID
+	}
+}
+
+func (rs *restorer) conflict(section string, oldID uuid.UUID, existingID *uuid.UUID, reason, resolution string) {
+	if len(rs.result.Conflicts) < maxImportErrors {
+		rs.result.Conflicts = append(rs.result.Conflicts, model.RestoreConflict{
+			Section:    section,
+			SourceID:   oldID,
+			ExistingID: existingID,
+			Reason:     reason,
+			Resolution: resolution,
+		})
+	}
+}
+
+func (rs *restorer) fail(section string, oldID uuid.UUID, msg string) {
+	rs.result.Skipped[section]++
+	if len(rs.result.Errors) < maxImportErrors {
+		rs.result.Errors = append(rs.result.Errors, fmt.Sprintf("%s %s: %s", section, oldID, msg))
+	}
+}
+
+// bookmark resolves a record's bookmark, reporting why the record is left
+// out when the bookmark was skipped or is not in the archive.
+func (rs *restorer) bookmark(section string, oldID, bookmarkID uuid.UUID) (uuid.UUID, bool) {
+	return rs.reference(section, oldID, model.BackupSectionBookmarks, bookmarkID)
+}
+
+func (rs *restorer) reference(section string, oldID uuid.UUID, target string, id uuid.UUID) (uuid.UUID, bool) {
+	newID, ok := rs.ids[target][id]
+	switch {
+	case !ok:
+		rs.fail(section, oldID, fmt.Sprintf("refers to %s %s, which is not in the backup", strings.TrimSuffix(target, "s"), id))
+		return uuid.Nil, false
+	case newID == uuid.Nil:
+		rs.result.Skipped[section]++
+		return uuid.Nil, false
+	}
+	return newID, true
+}
+
+func (rs *restorer) remapOptional(section string, id *uuid.UUID) *uuid.UUID {
+	if id == nil {
+		return nil
+	}
+	if newID, ok := rs.ids[section][*id]; ok && newID != uuid.Nil {
+		return &newID
+	}
+	return nil
+}
+
+func (rs *restorer) remapString(section, id string) string {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return ""
+	}
+	if newID := rs.remapOptional(section, &parsed); newID != nil {
+		return newID.String()
+	}
+	return ""
+}
+
+// remapTagIDs rewrites a template's JSON array of tag IDs, dropping tags
+// that were not restored.
+func (rs *restorer) remapTagIDs(tagIDs string) string {
+	if tagIDs == "" {
+		return tagIDs
+	}
+	var ids []string
+	if err := json.Unmarshal([]byte(tagIDs), &ids); err != nil {
+		return ""
+	}
+	remapped := []string{}
+	for _, id := range ids {
+		if newID := rs.remapString(model.BackupSectionTags, id); newID != "" {
+			remapped = append(remapped, newID)
+		}
+	}
+	data, _ := json.Marshal(remapped)
+	return string(data)
+}
+
+// owns reports whether a personal record belongs to the archive's owner.
+// Anyone else's records are skipped, so a restore never writes in another
+// user's name.
+func (rs *restorer) owns(section string, oldID, userID uuid.UUID) bool {
+	if userID == rs.header.UserID || userID == rs.userID {
+		return true
+	}
+	rs.skip(section, oldID, nil, "the record belongs to another user")
+	return false
+}
+
+// remapRule points a rule's conditions and actions at the restored
+// workspace, folders, tags and collection, dropping references to records
+// that were not restored.
+func (rs *restorer) remapRule(rule *model.BookmarkRule) {
+	if id := rule.Conditions.WorkspaceID; id != nil {
+		workspaceID := rs.workspace(*id)
+		rule.Conditions.WorkspaceID = &workspaceID
+	}
+	actions := &rule.Actions
+	actions.FolderID = rs.remapOptional(model.BackupSectionFolders, actions.FolderID)
+	actions.CollectionID = rs.remapOptional(model.BackupSectionCollections, actions.CollectionID)
+	var tagIDs []uuid.UUID
+	for _, id := range actions.TagIDs {
+		id := id
+		if newID := rs.remapOptional(model.BackupSectionTags, &id); newID != nil {
+			tagIDs = append(tagIDs, *newID)
+		}
+	}
+	actions.TagIDs = tagIDs
+	if exp := actions.Expiration; exp != nil {
+		exp.TargetFolderID = rs.remapOptional(model.BackupSectionFolders, exp.TargetFolderID)
+		if exp.Action == model.ExpirationActionMove && exp.TargetFolderID == nil {
+			actions.Expiration = nil
+		}
+	}
+}
+
+func (rs *restorer) workspace(id uuid.UUID) uuid.UUID {
+	if rs.opts.WorkspaceID != nil {
+		return *rs.opts.WorkspaceID
+	}
+	return id
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -11,59 +12,147 @@ import (
 	"github.com/quckapp/bookmark-service/internal/repository"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
+// ErrDuplicateBookmark is wrapped by a DuplicateBookmarkError.
+var ErrDuplicateBookmark = errors.New("bookmark already exists")
+
+// ErrInvalidMove is wrapped when an item is moved next to itself or next to
+// an item of another list.
+var ErrInvalidMove = errors.New("invalid move")
+
+// DuplicateBookmarkError is returned by Create when duplicates are rejected
+// and the user already has the bookmark.
+type DuplicateBookmarkError struct {
+	Existing *model.Bookmark
+}
+
+func (e *DuplicateBookmarkError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrDuplicateBookmark, e.Existing.ID)
+}
+
+func (e *DuplicateBookmarkError) Unwrap() error {
+	return ErrDuplicateBookmark
+}
+
 type BookmarkService interface {
-	Create(bookmark *model.Bookmark) error
+	// Create saves bookmark. With rejectDuplicate it fails with a
+	// DuplicateBookmarkError if the user has a bookmark with the same
+	// canonical URL, or the same target and type.
+	Create(bookmark *model.Bookmark, rejectDuplicate bool) error
 	GetByID(id uuid.UUID) (*model.Bookmark, error)
-	GetByUser(userID uuid.UUID, page, limit int) ([]model.Bookmark, int64, error)
-	GetByUserAndWorkspace(userID, workspaceID uuid.UUID, page, limit int) ([]model.Bookmark, int64, error)
-	GetByFolder(folderID uuid.UUID) ([]model.Bookmark, error)
-	Update(bookmark *model.Bookmark) error
+	// GetByUser, GetByUserAndWorkspace and GetByFolder list archived or
+	// unarchived bookmarks according to archived, or all when it is nil.
+	GetByUser(userID uuid.UUID, archived *bool, page, limit int) ([]model.Bookmark, int64, error)
+	GetByUserAndWorkspace(userID, workspaceID uuid.UUID, archived *bool, page, limit int) ([]model.Bookmark, int64, error)
+	GetByFolder(folderID uuid.UUID, archived *bool) ([]model.Bookmark, error)
+	Update(id uuid.UUID, req *model.UpdateBookmarkRequest, userID uuid.UUID) (*model.Bookmark, error)
 	Delete(id uuid.UUID) error
 	MoveToFolder(bookmarkID uuid.UUID, folderID *uuid.UUID) error
-	BulkDelete(ids []uuid.UUID) (int, int, error)
-	BulkMove(ids []uuid.UUID, folderID *uuid.UUID) (int, int, error)
-	Reorder(items []model.ReorderItem) error
+	// MoveNextTo places the bookmark directly before or after anchorID,
+	// which must be in the same folder.
+	MoveNextTo(bookmarkID, anchorID uuid.UUID, after bool) error
+	SetArchived(id uuid.UUID, archived bool) error
 }
 
 type bookmarkService struct {
-	repo       repository.BookmarkRepository
-	folderRepo repository.FolderRepository
-	redis      *redis.Client
-	logger     *zap.Logger
+	repo           repository.BookmarkRepository
+	folderRepo     repository.FolderRepository
+	previewService PreviewService
+	versionService VersionService
+	rules          RuleEngine
+	activity       ActivityRecorder
+	events         EventBus
+	tx             repository.Transactor
+	redis          *redis.Client
+	logger         *zap.Logger
 }
 
 func NewBookmarkService(
 	repo repository.BookmarkRepository,
 	folderRepo repository.FolderRepository,
+	previewService PreviewService,
+	versionService VersionService,
+	rules RuleEngine,
+	activity ActivityRecorder,
+	events EventBus,
+	tx repository.Transactor,
 	redis *redis.Client,
 	logger *zap.Logger,
 ) BookmarkService {
 	return &bookmarkService{
-		repo:       repo,
-		folderRepo: folderRepo,
-		redis:      redis,
-		logger:     logger,
+		repo:           repo,
+		folderRepo:     folderRepo,
+		previewService: previewService,
+		versionService: versionService,
+		rules:          rules,
+		activity:       activity,
+		events:         events,
+		tx:             tx,
+		redis:          redis,
+		logger:         logger,
 	}
 }
 
-func (s *bookmarkService) Create(bookmark *model.Bookmark) error {
-	// Validate folder exists if provided
+func (s *bookmarkService) Create(bookmark *model.Bookmark, rejectDuplicate bool) error {
+	// Validate folder exists and belongs to the same user if provided
 	if bookmark.FolderID != nil {
-		_, err := s.folderRepo.GetByID(*bookmark.FolderID)
+		if err := s.checkFolder(bookmark.UserID, *bookmark.FolderID); err != nil {
+			return err
+		}
+	}
+	if rejectDuplicate {
+		existing, err := s.repo.FindDuplicate(
+			bookmark.UserID, &bookmark.WorkspaceID, model.CanonicalURL(bookmark.TargetURL), bookmark.TargetID, bookmark.Type,
+		)
 		if err != nil {
-			return fmt.Errorf("folder not found")
+			return err
+		}
+		if existing != nil {
+			return &DuplicateBookmarkError{Existing: existing}
 		}
 	}
 
-	err := s.repo.Create(bookmark)
+	// The user's rules may file the bookmark and tag it along with the insert
+	rules, err := s.rules.Load(bookmark.UserID)
+	if err != nil {
+		return err
+	}
+	outcome := s.rules.Prepare(rules, bookmark)
+
+	err = s.inTx(func(repo repository.BookmarkRepository, tx *gorm.DB) error {
+		if err := repo.Create(bookmark); err != nil {
+			return err
+		}
+		if err := s.rules.Apply(tx, bookmark, outcome); err != nil {
+			return err
+		}
+		return s.events.Publish(tx, model.EventBookmarkCreated, bookmark.ID, bookmark)
+	})
 	if err != nil {
 		return err
 	}
 
 	// Invalidate cache
 	s.invalidateUserCache(bookmark.UserID)
+
+	// Previews are fetched in the background; a failure to queue must not fail the create
+	if bookmark.Type == model.BookmarkTypeExternal && bookmark.TargetURL != "" {
+		if _, err := s.previewService.Generate(bookmark.ID, bookmark.TargetURL); err != nil {
+			s.logger.Warn("Failed to queue preview", zap.String("id", bookmark.ID.String()), zap.Error(err))
+		}
+	}
+
+	details := map[string]interface{}{
+		"type":     bookmark.Type,
+		"title":    bookmark.Title,
+		"folderId": bookmark.FolderID,
+	}
+	if outcome != nil {
+		details["ruleIds"] = outcome.RuleIDs
+	}
+	s.activity.Record(bookmark.UserID, bookmark.ID, model.ActivityCreated, details)
 	s.logger.Info("Created bookmark", zap.String("id", bookmark.ID.String()))
 	return nil
 }
@@ -71,7 +160,7 @@ func (s *bookmarkService) Create(bookmark *model.Bookmark) error {
 func (s *bookmarkService) GetByID(id uuid.UUID) (*model.Bookmark, error) {
 	// Try cache first
 	ctx := context.Background()
-	cacheKey := fmt.Sprintf("bookmark:%s", id.String())
+	cacheKey := bookmarkCacheKey(id)
 	cached, err := s.redis.Get(ctx, cacheKey).Result()
 	if err == nil {
 		var bookmark model.Bookmark
@@ -93,36 +182,60 @@ func (s *bookmarkService) GetByID(id uuid.UUID) (*model.Bookmark, error) {
 	return bookmark, nil
 }
 
-func (s *bookmarkService) GetByUser(userID uuid.UUID, page, limit int) ([]model.Bookmark, int64, error) {
+func (s *bookmarkService) GetByUser(userID uuid.UUID, archived *bool, page, limit int) ([]model.Bookmark, int64, error) {
 	offset := page * limit
-	return s.repo.GetByUser(userID, limit, offset)
+	return s.repo.GetByUser(userID, archived, limit, offset)
 }
 
-func (s *bookmarkService) GetByUserAndWorkspace(userID, workspaceID uuid.UUID, page, limit int) ([]model.Bookmark, int64, error) {
+func (s *bookmarkService) GetByUserAndWorkspace(userID, workspaceID uuid.UUID, archived *bool, page, limit int) ([]model.Bookmark, int64, error) {
 	offset := page * limit
-	return s.repo.GetByUserAndWorkspace(userID, workspaceID, limit, offset)
+	return s.repo.GetByUserAndWorkspace(userID, workspaceID, archived, limit, offset)
 }
 
-func (s *bookmarkService) Update(bookmark *model.Bookmark) error {
-	existing, err := s.repo.GetByID(bookmark.ID)
-	if err != nil {
-		return err
-	}
+// Update records a version when the content changed; userID is the user
+// making the change.
+func (s *bookmarkService) Update(id uuid.UUID, req *model.UpdateBookmarkRequest, userID uuid.UUID) (*model.Bookmark, error) {
+	var existing *model.Bookmark
+	var before model.Bookmark
+	err := s.inTx(func(repo repository.BookmarkRepository, tx *gorm.DB) error {
+		// Applied to a locked copy so writes since the caller read the
+		// bookmark, such as a move or archiving, are not reverted
+		var err error
+		if existing, err = repo.GetForUpdate(id); err != nil {
+			return err
+		}
+		before = *existing
 
-	existing.Title = bookmark.Title
-	existing.Description = bookmark.Description
-	existing.Position = bookmark.Position
-	existing.Metadata = bookmark.Metadata
+		existing.Title = req.Title
+		existing.Description = req.Description
+		if req.Position != nil {
+			existing.Position = *req.Position
+		}
+		existing.Metadata = req.Metadata
+		if req.TargetURL != nil {
+			existing.TargetURL = *req.TargetURL
+		}
 
-	err = s.repo.Update(existing)
+		if err := repo.Update(existing); err != nil {
+			return err
+		}
+		if err := s.versionService.RecordChange(tx, &before, existing, userID, req.ChangeNote); err != nil {
+			return err
+		}
+		return s.events.Publish(tx, model.EventBookmarkUpdated, id, existing)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	s.activity.Record(userID, id, model.ActivityUpdated, map[string]interface{}{
+		"changes": diffFields(versionOf(&before), versionOf(existing)),
+	})
+
 	// Invalidate cache
-	s.invalidateBookmarkCache(bookmark.ID)
+	s.invalidateBookmarkCache(id)
 	s.invalidateUserCache(existing.UserID)
-	return nil
+	return existing, nil
 }
 
 func (s *bookmarkService) Delete(id uuid.UUID) error {
@@ -131,88 +244,143 @@ func (s *bookmarkService) Delete(id uuid.UUID) error {
 		return err
 	}
 
-	err = s.repo.Delete(id)
+	err = s.delete(bookmark)
 	if err != nil {
 		return err
 	}
 
 	s.invalidateBookmarkCache(id)
 	s.invalidateUserCache(bookmark.UserID)
+	s.activity.Record(bookmark.UserID, id, model.ActivityDeleted, map[string]interface{}{"title": bookmark.Title})
 	s.logger.Info("Deleted bookmark", zap.String("id", id.String()))
 	return nil
 }
 
 func (s *bookmarkService) MoveToFolder(bookmarkID uuid.UUID, folderID *uuid.UUID) error {
+	bookmark, err := s.repo.GetByID(bookmarkID)
+	if err != nil {
+		return err
+	}
 	if folderID != nil {
-		_, err := s.folderRepo.GetByID(*folderID)
-		if err != nil {
-			return fmt.Errorf("folder not found")
+		if err := s.checkFolder(bookmark.UserID, *folderID); err != nil {
+			return err
 		}
 	}
 
-	return s.repo.MoveToFolder(bookmarkID, folderID)
-}
+	err = s.move(bookmark, folderID)
+	if err != nil {
+		return err
+	}
 
-func (s *bookmarkService) GetByFolder(folderID uuid.UUID) ([]model.Bookmark, error) {
-	return s.repo.GetByFolder(folderID)
+	s.invalidateBookmarkCache(bookmarkID)
+	s.invalidateUserCache(bookmark.UserID)
+	s.activity.Record(bookmark.UserID, bookmarkID, model.ActivityMoved, map[string]interface{}{
+		"fromFolderId": bookmark.FolderID,
+		"toFolderId":   folderID,
+	})
+	return nil
 }
 
-func (s *bookmarkService) BulkDelete(ids []uuid.UUID) (int, int, error) {
-	success := 0
-	failed := 0
-	for _, id := range ids {
-		if err := s.repo.Delete(id); err != nil {
-			failed++
-		} else {
-			s.invalidateBookmarkCache(id)
-			success++
-		}
+func (s *bookmarkService) MoveNextTo(bookmarkID, anchorID uuid.UUID, after bool) error {
+	if bookmarkID == anchorID {
+		return fmt.Errorf("%w: a bookmark cannot be moved next to itself", ErrInvalidMove)
+	}
+	bookmark, err := s.repo.GetByID(bookmarkID)
+	if err != nil {
+		return lookupError("bookmark", err)
+	}
+	anchor, err := s.repo.GetByID(anchorID)
+	if err != nil {
+		return lookupError("bookmark", err)
+	}
+	if anchor.UserID != bookmark.UserID || anchor.WorkspaceID != bookmark.WorkspaceID || !sameParent(anchor.FolderID, bookmark.FolderID) {
+		return fmt.Errorf("%w: the bookmarks are in different folders", ErrInvalidMove)
+	}
+
+	if err := s.repo.MoveNextTo(bookmark, anchorID, after); err != nil {
+		return err
 	}
-	return success, failed, nil
+	s.invalidateBookmarkCache(bookmarkID)
+	s.invalidateUserCache(bookmark.UserID)
+	return nil
 }
 
-func (s *bookmarkService) BulkMove(ids []uuid.UUID, folderID *uuid.UUID) (int, int, error) {
-	if folderID != nil {
-		_, err := s.folderRepo.GetByID(*folderID)
-		if err != nil {
-			return 0, len(ids), fmt.Errorf("folder not found")
-		}
+func (s *bookmarkService) SetArchived(id uuid.UUID, archived bool) error {
+	bookmark, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
 	}
 
-	success := 0
-	failed := 0
-	for _, id := range ids {
-		if err := s.repo.MoveToFolder(id, folderID); err != nil {
-			failed++
-		} else {
-			success++
-		}
+	err = s.repo.SetArchived(id, archived)
+	if err != nil {
+		return err
 	}
-	return success, failed, nil
+
+	s.invalidateBookmarkCache(id)
+	s.invalidateUserCache(bookmark.UserID)
+	return nil
 }
 
-func (s *bookmarkService) Reorder(items []model.ReorderItem) error {
-	for _, item := range items {
-		id, err := uuid.Parse(item.ID)
-		if err != nil {
-			continue
-		}
-		bookmark, err := s.repo.GetByID(id)
-		if err != nil {
-			continue
-		}
-		bookmark.Position = item.Position
-		s.repo.Update(bookmark)
+func (s *bookmarkService) GetByFolder(folderID uuid.UUID, archived *bool) ([]model.Bookmark, error) {
+	return s.repo.GetByFolder(folderID, archived)
+}
+
+func (s *bookmarkService) checkFolder(userID, folderID uuid.UUID) error {
+	folder, err := s.folderRepo.GetByID(folderID)
+	if err != nil || folder.UserID != userID {
+		return fmt.Errorf("folder %w", ErrNotFound)
 	}
 	return nil
 }
 
+func (s *bookmarkService) delete(bookmark *model.Bookmark) error {
+	return s.inTx(func(repo repository.BookmarkRepository, tx *gorm.DB) error {
+		if err := repo.Delete(bookmark.ID); err != nil {
+			return err
+		}
+		return s.events.Publish(tx, model.EventBookmarkDeleted, bookmark.ID, model.BookmarkDeletedEvent{
+			BookmarkID:  bookmark.ID,
+			UserID:      bookmark.UserID,
+			WorkspaceID: bookmark.WorkspaceID,
+		})
+	})
+}
+
+func (s *bookmarkService) move(bookmark *model.Bookmark, folderID *uuid.UUID) error {
+	return s.inTx(func(repo repository.BookmarkRepository, tx *gorm.DB) error {
+		if err := repo.MoveToFolder(bookmark.ID, folderID); err != nil {
+			return err
+		}
+		return s.events.Publish(tx, model.EventBookmarkMoved, bookmark.ID, model.BookmarkMovedEvent{
+			BookmarkID:   bookmark.ID,
+			UserID:       bookmark.UserID,
+			FromFolderID: bookmark.FolderID,
+			ToFolderID:   folderID,
+		})
+	})
+}
+
+// inTx runs fn in a transaction so the events it publishes commit with the change.
+func (s *bookmarkService) inTx(fn func(repo repository.BookmarkRepository, tx *gorm.DB) error) error {
+	return s.tx.Transaction(func(tx *gorm.DB) error {
+		return fn(s.repo.WithTx(tx), tx)
+	})
+}
+
 func (s *bookmarkService) invalidateBookmarkCache(id uuid.UUID) {
 	ctx := context.Background()
-	s.redis.Del(ctx, fmt.Sprintf("bookmark:%s", id.String()))
+	s.redis.Del(ctx, bookmarkCacheKey(id))
 }
 
 func (s *bookmarkService) invalidateUserCache(userID uuid.UUID) {
 	ctx := context.Background()
-	s.redis.Del(ctx, fmt.Sprintf("user_bookmarks:%s", userID.String()))
+	s.redis.Del(ctx, userBookmarksCacheKey(userID))
+}
+
+func bookmarkCacheKey(id uuid.UUID) string {
+	return fmt.Sprintf("bookmark:%s", id.String())
+}
+
+func userBookmarksCacheKey(userID uuid.UUID) string {
+	return fmt.Sprintf("user_bookmarks:%s", userID.String())
 }
@@ -0,0 +1,448 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/repository"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// BulkService applies one change to many of the caller's bookmarks. Each
+// operation checks every ID, writes the ones that pass with batched
+// statements in a single transaction, and reports an outcome per ID. IDs
+// that are malformed, unknown or owned by someone else fail on their own in
+// best-effort mode and fail the whole request in atomic mode.
+type BulkService interface {
+	Delete(userID uuid.UUID, ids []string, mode model.BulkMode) (*model.BulkResult, error)
+	Move(userID uuid.UUID, ids []string, folderID *uuid.UUID, mode model.BulkMode) (*model.BulkResult, error)
+	Tag(userID uuid.UUID, ids []string, tagIDs []uuid.UUID, mode model.BulkMode) (*model.BulkResult, error)
+	Untag(userID uuid.UUID, ids []string, tagIDs []uuid.UUID, mode model.BulkMode) (*model.BulkResult, error)
+	AddToCollection(userID uuid.UUID, ids []string, collectionID uuid.UUID, mode model.BulkMode) (*model.BulkResult, error)
+	// SetExpiration gives every bookmark a copy of expiration, replacing any
+	// expiration it already has.
+	SetExpiration(userID uuid.UUID, ids []string, expiration model.BookmarkExpiration, mode model.BulkMode) (*model.BulkResult, error)
+	Reorder(userID uuid.UUID, items []model.ReorderItem, mode model.BulkMode) (*model.BulkResult, error)
+}
+
+type bulkService struct {
+	repo       repository.BulkRepository
+	folderRepo repository.FolderRepository
+	access     AccessService
+	activity   ActivityRecorder
+	events     EventBus
+	tx         repository.Transactor
+	redis      *redis.Client
+	logger     *zap.Logger
+}
+
+func NewBulkService(
+	repo repository.BulkRepository,
+	folderRepo repository.FolderRepository,
+	access AccessService,
+	activity ActivityRecorder,
+	events EventBus,
+	tx repository.Transactor,
+	redis *redis.Client,
+	logger *zap.Logger,
+) BulkService {
+	return &bulkService{
+		repo:       repo,
+		folderRepo: folderRepo,
+		access:     access,
+		activity:   activity,
+		events:     events,
+		tx:         tx,
+		redis:      redis,
+		logger:     logger,
+	}
+}
+
+func (s *bulkService) Delete(userID uuid.UUID, ids []string, mode model.BulkMode) (*model.BulkResult, error) {
+	op, err := s.begin(userID, ids, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.apply(op, func(repo repository.BulkRepository, tx *gorm.DB, ids []uuid.UUID) error {
+		if err := repo.DeleteBookmarks(ids); err != nil {
+			return err
+		}
+		for _, id := range ids {
+			bookmark := op.bookmarks[id]
+			err := s.events.Publish(tx, model.EventBookmarkDeleted, id, model.BookmarkDeletedEvent{
+				BookmarkID:  id,
+				UserID:      bookmark.UserID,
+				WorkspaceID: bookmark.WorkspaceID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateCaches(op)
+	for _, id := range op.succeeded() {
+		s.activity.Record(userID, id, model.ActivityDeleted, map[string]interface{}{
+			"title": op.bookmarks[id].Title,
+			"bulk":  true,
+		})
+	}
+	return s.finish(op, "Bulk deleted bookmarks"), nil
+}
+
+func (s *bulkService) Move(userID uuid.UUID, ids []string, folderID *uuid.UUID, mode model.BulkMode) (*model.BulkResult, error) {
+	if folderID != nil {
+		if err := s.access.AuthorizeFolder(userID, *folderID); err != nil {
+			return nil, err
+		}
+	}
+	op, err := s.begin(userID, ids, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.apply(op, func(repo repository.BulkRepository, tx *gorm.DB, ids []uuid.UUID) error {
+		if err := repo.MoveBookmarks(ids, folderID); err != nil {
+			return err
+		}
+		for _, id := range ids {
+			err := s.events.Publish(tx, model.EventBookmarkMoved, id, model.BookmarkMovedEvent{
+				BookmarkID:   id,
+				UserID:       userID,
+				FromFolderID: op.bookmarks[id].FolderID,
+				ToFolderID:   folderID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateCaches(op)
+	for _, id := range op.succeeded() {
+		s.activity.Record(userID, id, model.ActivityMoved, map[string]interface{}{
+			"fromFolderId": op.bookmarks[id].FolderID,
+			"toFolderId":   folderID,
+			"bulk":         true,
+		})
+	}
+	return s.finish(op, "Bulk moved bookmarks"), nil
+}
+
+func (s *bulkService) Tag(userID uuid.UUID, ids []string, tagIDs []uuid.UUID, mode model.BulkMode) (*model.BulkResult, error) {
+	if err := s.checkTags(userID, tagIDs); err != nil {
+		return nil, err
+	}
+	op, err := s.begin(userID, ids, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.apply(op, func(repo repository.BulkRepository, tx *gorm.DB, ids []uuid.UUID) error {
+		_, err := repo.AddTags(ids, tagIDs)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range op.succeeded() {
+		s.activity.Record(userID, id, model.ActivityTagged, map[string]interface{}{"tagIds": tagIDs, "bulk": true})
+	}
+	return s.finish(op, "Bulk tagged bookmarks"), nil
+}
+
+func (s *bulkService) Untag(userID uuid.UUID, ids []string, tagIDs []uuid.UUID, mode model.BulkMode) (*model.BulkResult, error) {
+	if err := s.checkTags(userID, tagIDs); err != nil {
+		return nil, err
+	}
+	op, err := s.begin(userID, ids, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.apply(op, func(repo repository.BulkRepository, tx *gorm.DB, ids []uuid.UUID) error {
+		_, err := repo.RemoveTags(ids, tagIDs)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range op.succeeded() {
+		s.activity.Record(userID, id, model.ActivityUntagged, map[string]interface{}{"tagIds": tagIDs, "bulk": true})
+	}
+	return s.finish(op, "Bulk untagged bookmarks"), nil
+}
+
+func (s *bulkService) AddToCollection(userID uuid.UUID, ids []string, collectionID uuid.UUID, mode model.BulkMode) (*model.BulkResult, error) {
+	if err := s.access.AuthorizeCollectionRole(userID, collectionID, model.ShareRoleEditor); err != nil {
+		return nil, err
+	}
+	op, err := s.begin(userID, ids, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.apply(op, func(repo repository.BulkRepository, tx *gorm.DB, ids []uuid.UUID) error {
+		_, err := repo.AddToCollection(collectionID, userID, ids)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.finish(op, "Bulk added bookmarks to collection"), nil
+}
+
+func (s *bulkService) SetExpiration(userID uuid.UUID, ids []string, expiration model.BookmarkExpiration, mode model.BulkMode) (*model.BulkResult, error) {
+	expiration.UserID = userID
+	if err := checkExpiration(s.folderRepo, &expiration); err != nil {
+		return nil, err
+	}
+	op, err := s.begin(userID, ids, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.apply(op, func(repo repository.BulkRepository, tx *gorm.DB, ids []uuid.UUID) error {
+		expirations := make([]model.BookmarkExpiration, len(ids))
+		for i, id := range ids {
+			expirations[i] = expiration
+			expirations[i].BookmarkID = id
+		}
+		return repo.SetExpirations(expirations)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	details := expirationDetails(&expiration)
+	details["bulk"] = true
+	for _, id := range op.succeeded() {
+		s.activity.Record(userID, id, model.ActivityExpirationSet, details)
+	}
+	return s.finish(op, "Bulk set bookmark expirations"), nil
+}
+
+func (s *bulkService) Reorder(userID uuid.UUID, items []model.ReorderItem, mode model.BulkMode) (*model.BulkResult, error) {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	op, err := s.begin(userID, ids, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.apply(op, func(repo repository.BulkRepository, tx *gorm.DB, ids []uuid.UUID) error {
+		positions := make(map[uuid.UUID]int, len(ids))
+		for _, item := range items {
+			// A repeated ID takes its last position
+			if id, err := uuid.Parse(item.ID); err == nil && op.bookmarks[id] != nil {
+				positions[id] = item.Position
+			}
+		}
+		return repo.SetPositions(positions)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateCaches(op)
+	return s.finish(op, "Reordered bookmarks"), nil
+}
+
+// checkTags ensures every tag exists and belongs to the user.
+func (s *bulkService) checkTags(userID uuid.UUID, tagIDs []uuid.UUID) error {
+	tags, err := s.repo.GetTags(tagIDs)
+	if err != nil {
+		return err
+	}
+	owned := map[uuid.UUID]bool{}
+	for _, tag := range tags {
+		if tag.UserID == userID {
+			owned[tag.ID] = true
+		}
+	}
+	for _, tagID := range tagIDs {
+		if !owned[tagID] {
+			return fmt.Errorf("tag %w", ErrNotFound)
+		}
+	}
+	return nil
+}
+
+// begin resolves the requested IDs with one query and fails the ones that
+// are malformed, missing or not the user's. Repeated IDs are reported once.
+func (s *bulkService) begin(userID uuid.UUID, ids []string, mode model.BulkMode) (*bulkOp, error) {
+	if mode == "" {
+		mode = model.BulkModeBestEffort
+	}
+	op := &bulkOp{
+		mode:      mode,
+		index:     map[uuid.UUID]int{},
+		bookmarks: map[uuid.UUID]*model.Bookmark{},
+	}
+
+	var parsed []uuid.UUID
+	for _, raw := range ids {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			op.results = append(op.results, model.BulkItemResult{
+				ID:     raw,
+				Status: model.BulkItemFailed,
+				Error:  "invalid bookmark ID",
+			})
+			continue
+		}
+		if _, seen := op.index[id]; seen {
+			continue
+		}
+		op.index[id] = len(op.results)
+		op.results = append(op.results, model.BulkItemResult{ID: id.String()})
+		parsed = append(parsed, id)
+	}
+	if len(parsed) == 0 {
+		return op, nil
+	}
+
+	bookmarks, err := s.repo.GetBookmarks(parsed)
+	if err != nil {
+		return nil, err
+	}
+	for i := range bookmarks {
+		op.bookmarks[bookmarks[i].ID] = &bookmarks[i]
+	}
+	for _, id := range parsed {
+		bookmark, ok := op.bookmarks[id]
+		switch {
+		case !ok:
+			op.fail(id, fmt.Errorf("bookmark %w", ErrNotFound))
+		case bookmark.UserID != userID:
+			op.fail(id, ErrForbidden)
+		}
+	}
+	return op, nil
+}
+
+// apply runs write over the items still pending in one transaction. In
+// atomic mode nothing is written once any item has failed. A failed write
+// rolls back every item and is returned as the error.
+func (s *bulkService) apply(op *bulkOp, write func(repo repository.BulkRepository, tx *gorm.DB, ids []uuid.UUID) error) error {
+	ids := op.pending()
+	if len(ids) == 0 {
+		return nil
+	}
+	if op.mode == model.BulkModeAtomic && len(ids) < len(op.results) {
+		op.settle(model.BulkItemSkipped, "not applied because other items failed")
+		return nil
+	}
+
+	err := s.tx.Transaction(func(tx *gorm.DB) error {
+		return write(s.repo.WithTx(tx), tx, ids)
+	})
+	if err != nil {
+		return err
+	}
+	op.settle(model.BulkItemSucceeded, "")
+	return nil
+}
+
+func (s *bulkService) finish(op *bulkOp, msg string) *model.BulkResult {
+	result := &model.BulkResult{Mode: op.mode, Results: op.results}
+	for _, item := range op.results {
+		switch item.Status {
+		case model.BulkItemSucceeded:
+			result.Succeeded++
+		case model.BulkItemFailed:
+			result.Failed++
+		case model.BulkItemSkipped:
+			result.Skipped++
+		}
+	}
+	s.logger.Info(msg,
+		zap.String("mode", string(op.mode)),
+		zap.Int("succeeded", result.Succeeded),
+		zap.Int("failed", result.Failed),
+		zap.Int("skipped", result.Skipped))
+	return result
+}
+
+// invalidateCaches drops the cached copies of the bookmarks that changed.
+func (s *bulkService) invalidateCaches(op *bulkOp) {
+	ids := op.succeeded()
+	if len(ids) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(ids)+1)
+	users := map[uuid.UUID]bool{}
+	for _, id := range ids {
+		keys = append(keys, bookmarkCacheKey(id))
+		users[op.bookmarks[id].UserID] = true
+	}
+	for userID := range users {
+		keys = append(keys, userBookmarksCacheKey(userID))
+	}
+	s.redis.Del(context.Background(), keys...)
+}
+
+// bulkOp tracks the outcome of every item of one bulk request. An item with
+// an empty status is still pending.
+type bulkOp struct {
+	mode    model.BulkMode
+	results []model.BulkItemResult
+	index   map[uuid.UUID]int
+	// bookmarks holds the bookmarks loaded for the request, by ID.
+	bookmarks map[uuid.UUID]*model.Bookmark
+}
+
+func (op *bulkOp) fail(id uuid.UUID, err error) {
+	item := &op.results[op.index[id]]
+	item.Status = model.BulkItemFailed
+	item.Error = err.Error()
+}
+
+// pending returns the IDs still to be written, in request order.
+func (op *bulkOp) pending() []uuid.UUID {
+	return op.withStatus("")
+}
+
+func (op *bulkOp) succeeded() []uuid.UUID {
+	return op.withStatus(model.BulkItemSucceeded)
+}
+
+func (op *bulkOp) withStatus(status model.BulkItemStatus) []uuid.UUID {
+	var ids []uuid.UUID
+	for _, item := range op.results {
+		if item.Status != status {
+			continue
+		}
+		if id, err := uuid.Parse(item.ID); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// settle gives every pending item status.
+func (op *bulkOp) settle(status model.BulkItemStatus, reason string) {
+	for i := range op.results {
+		if op.results[i].Status == "" {
+			op.results[i].Status = status
+			op.results[i].Error = reason
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/repository"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidMember is wrapped when an invitation or membership change is
+	// malformed.
+	ErrInvalidMember = errors.New("invalid collection member")
+	ErrAlreadyMember = errors.New("already a member of this collection")
+)
+
+type CollectionMemberService interface {
+	// Invite invites member.UserID into member.CollectionID on behalf of
+	// member.InvitedBy, who must own the collection.
+	Invite(member *model.CollectionMember) error
+	// GetMembers lists a collection's members, invited ones included.
+	GetMembers(collectionID uuid.UUID) ([]model.CollectionMember, error)
+	GetPendingInvitations(userID uuid.UUID, page, limit int) ([]model.CollectionMember, int64, error)
+	AcceptInvitation(id uuid.UUID) error
+	DeclineInvitation(id uuid.UUID) error
+	UpdateRole(id uuid.UUID, role model.ShareRole) (*model.CollectionMember, error)
+	// Remove ends a membership or withdraws an invitation.
+	Remove(id uuid.UUID) error
+}
+
+type collectionMemberService struct {
+	repo           repository.CollectionMemberRepository
+	collectionRepo repository.CollectionRepository
+	events         EventBus
+	tx             repository.Transactor
+	logger         *zap.Logger
+}
+
+func NewCollectionMemberService(
+	repo repository.CollectionMemberRepository,
+	collectionRepo repository.CollectionRepository,
+	events EventBus,
+	tx repository.Transactor,
+	logger *zap.Logger,
+) CollectionMemberService {
+	return &collectionMemberService{
+		repo:           repo,
+		collectionRepo: collectionRepo,
+		events:         events,
+		tx:             tx,
+		logger:         logger,
+	}
+}
+
+func (s *collectionMemberService) Invite(member *model.CollectionMember) error {
+	if member.Role == "" {
+		member.Role = model.ShareRoleViewer
+	}
+	if !member.Role.IsValid() {
+		return fmt.Errorf("%w: unknown role %q", ErrInvalidMember, member.Role)
+	}
+
+	collection, err := s.collectionRepo.GetByID(member.CollectionID)
+	if err != nil {
+		return lookupError("collection", err)
+	}
+	if collection.UserID != member.InvitedBy {
+		return ErrForbidden
+	}
+	if member.UserID == uuid.Nil || member.UserID == collection.UserID {
+		return fmt.Errorf("%w: the owner cannot be invited", ErrInvalidMember)
+	}
+	invited, err := s.repo.IsInvited(member.CollectionID, member.UserID)
+	if err != nil {
+		return err
+	}
+	if invited {
+		return ErrAlreadyMember
+	}
+	member.WorkspaceID = collection.WorkspaceID
+	member.IsAccepted = false
+	member.AcceptedAt = nil
+
+	err = s.tx.Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).Create(member); err != nil {
+			return err
+		}
+		return s.events.Publish(tx, model.EventCollectionMemberInvited, member.CollectionID, member)
+	})
+	if err != nil {
+		return err
+	}
+	s.logger.Info("Invited collection member",
+		zap.String("collectionID", member.CollectionID.String()),
+		zap.String("userID", member.UserID.String()),
+		zap.String("role", string(member.Role)))
+	return nil
+}
+
+func (s *collectionMemberService) GetMembers(collectionID uuid.UUID) ([]model.CollectionMember, error) {
+	return s.repo.GetByCollection(collectionID)
+}
+
+func (s *collectionMemberService) GetPendingInvitations(userID uuid.UUID, page, limit int) ([]model.CollectionMember, int64, error) {
+	offset := page * limit
+	return s.repo.GetPendingByUser(userID, limit, offset)
+}
+
+func (s *collectionMemberService) AcceptInvitation(id uuid.UUID) error {
+	member, err := s.repo.GetByID(id)
+	if err != nil {
+		return lookupError("invitation", err)
+	}
+	if member.IsAccepted {
+		return fmt.Errorf("%w: invitation already accepted", ErrInvalidMember)
+	}
+
+	now := time.Now()
+	err = s.tx.Transaction(func(tx *gorm.DB) error {
+		accepted, err := s.repo.WithTx(tx).Accept(id, now)
+		if err != nil {
+			return err
+		}
+		if !accepted {
+			return fmt.Errorf("%w: invitation already accepted", ErrInvalidMember)
+		}
+		member.IsAccepted = true
+		member.AcceptedAt = &now
+		return s.events.Publish(tx, model.EventCollectionMemberJoined, member.CollectionID, member)
+	})
+	if err != nil {
+		return err
+	}
+	s.logger.Info("Collection member joined",
+		zap.String("collectionID", member.CollectionID.String()),
+		zap.String("userID", member.UserID.String()))
+	return nil
+}
+
+func (s *collectionMemberService) DeclineInvitation(id uuid.UUID) error {
+	member, err := s.repo.GetByID(id)
+	if err != nil {
+		return lookupError("invitation", err)
+	}
+	if member.IsAccepted {
+		return fmt.Errorf("%w: invitation already accepted", ErrInvalidMember)
+	}
+	return s.repo.Delete(id)
+}
+
+func (s *collectionMemberService) UpdateRole(id uuid.UUID, role model.ShareRole) (*model.CollectionMember, error) {
+	if !role.IsValid() {
+		return nil, fmt.Errorf("%w: unknown role %q", ErrInvalidMember, role)
+	}
+	member, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, lookupError("collection member", err)
+	}
+	member.Role = role
+
+	err = s.tx.Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).Update(member); err != nil {
+			return err
+		}
+		return s.events.Publish(tx, model.EventCollectionMemberUpdated, member.CollectionID, member)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+func (s *collectionMemberService) Remove(id uuid.UUID) error {
+	member, err := s.repo.GetByID(id)
+	if err != nil {
+		return lookupError("collection member", err)
+	}
+	err = s.tx.Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).Delete(id); err != nil {
+			return err
+		}
+		return s.events.Publish(tx, model.EventCollectionMemberRemoved, member.CollectionID, member)
+	})
+	if err != nil {
+		return err
+	}
+	s.logger.Info("Removed collection member",
+		zap.String("collectionID", member.CollectionID.String()),
+		zap.String("userID", member.UserID.String()))
+	return nil
+}
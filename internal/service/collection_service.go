@@ -15,21 +15,31 @@ type CollectionService interface {
 	GetByUser(userID uuid.UUID) ([]model.BookmarkCollection, error)
 	GetByUserAndWorkspace(userID, workspaceID uuid.UUID) ([]model.BookmarkCollection, error)
 	GetPublicByWorkspace(workspaceID uuid.UUID, page, limit int) ([]model.BookmarkCollection, int64, error)
-	Update(id uuid.UUID, req *model.UpdateCollectionRequest) (*model.BookmarkCollection, error)
+	// Update changes the collection for userID. Editors may change it too,
+	// but only the owner decides whether it is public.
+	Update(userID, id uuid.UUID, req *model.UpdateCollectionRequest) (*model.BookmarkCollection, error)
 	Delete(id uuid.UUID) error
-	AddBookmarks(collectionID uuid.UUID, bookmarkIDs []uuid.UUID) error
+	// AddBookmarks adds bookmarks owned by userID to the collection and
+	// credits them to userID.
+	AddBookmarks(userID, collectionID uuid.UUID, bookmarkIDs []uuid.UUID) error
 	RemoveBookmark(collectionID, bookmarkID uuid.UUID) error
-	GetBookmarks(collectionID uuid.UUID, page, limit int) ([]model.Bookmark, int64, error)
+	// MoveBookmark places the bookmark directly before or after anchorID,
+	// both being in the collection.
+	MoveBookmark(collectionID, bookmarkID, anchorID uuid.UUID, after bool) error
+	// GetBookmarks lists the collection's bookmarks for userID, who must own
+	// the collection, have it shared with them or find it public.
+	GetBookmarks(userID, collectionID uuid.UUID, page, limit int) ([]model.Bookmark, int64, error)
 	CountBookmarks(collectionID uuid.UUID) (int64, error)
 }
 
 type collectionService struct {
 	repo   repository.CollectionRepository
+	access AccessService
 	logger *zap.Logger
 }
 
-func NewCollectionService(repo repository.CollectionRepository, logger *zap.Logger) CollectionService {
-	return &collectionService{repo: repo, logger: logger}
+func NewCollectionService(repo repository.CollectionRepository, access AccessService, logger *zap.Logger) CollectionService {
+	return &collectionService{repo: repo, access: access, logger: logger}
 }
 
 func (s *collectionService) Create(collection *model.BookmarkCollection) error {
@@ -58,11 +68,14 @@ func (s *collectionService) GetPublicByWorkspace(workspaceID uuid.UUID, page, li
 	return s.repo.GetPublicByWorkspace(workspaceID, limit, offset)
 }
 
-func (s *collectionService) Update(id uuid.UUID, req *model.UpdateCollectionRequest) (*model.BookmarkCollection, error) {
+func (s *collectionService) Update(userID, id uuid.UUID, req *model.UpdateCollectionRequest) (*model.BookmarkCollection, error) {
 	collection, err := s.repo.GetByID(id)
 	if err != nil {
 		return nil, fmt.Errorf("collection not found")
 	}
+	if req.IsPublic != nil && *req.IsPublic != collection.IsPublic && collection.UserID != userID {
+		return nil, ErrForbidden
+	}
 
 	if req.Name != "" {
 		collection.Name = req.Name
@@ -99,7 +112,18 @@ func (s *collectionService) Delete(id uuid.UUID) error {
 	return nil
 }
 
-func (s *collectionService) AddBookmarks(collectionID uuid.UUID, bookmarkIDs []uuid.UUID) error {
+func (s *collectionService) AddBookmarks(userID, collectionID uuid.UUID, bookmarkIDs []uuid.UUID) error {
+	if _, err := s.repo.GetByID(collectionID); err != nil {
+		return fmt.Errorf("collection %w", ErrNotFound)
+	}
+	// Members only add their own bookmarks, so nobody can expose someone
+	// else's bookmark to the collection
+	for _, bID := range bookmarkIDs {
+		if err := s.access.AuthorizeBookmark(userID, bID); err != nil {
+			return err
+		}
+	}
+
 	for _, bID := range bookmarkIDs {
 		exists, _ := s.repo.IsBookmarkInCollection(collectionID, bID)
 		if exists {
@@ -108,6 +132,7 @@ func (s *collectionService) AddBookmarks(collectionID uuid.UUID, bookmarkIDs []u
 		cb := &model.CollectionBookmark{
 			CollectionID: collectionID,
 			BookmarkID:   bID,
+			AddedBy:      userID,
 		}
 		if err := s.repo.AddBookmark(cb); err != nil {
 			s.logger.Warn("Failed to add bookmark to collection",
@@ -123,7 +148,26 @@ func (s *collectionService) RemoveBookmark(collectionID, bookmarkID uuid.UUID) e
 	return s.repo.RemoveBookmark(collectionID, bookmarkID)
 }
 
-func (s *collectionService) GetBookmarks(collectionID uuid.UUID, page, limit int) ([]model.Bookmark, int64, error) {
+func (s *collectionService) MoveBookmark(collectionID, bookmarkID, anchorID uuid.UUID, after bool) error {
+	if bookmarkID == anchorID {
+		return fmt.Errorf("%w: a bookmark cannot be moved next to itself", ErrInvalidMove)
+	}
+	for _, id := range []uuid.UUID{bookmarkID, anchorID} {
+		in, err := s.repo.IsBookmarkInCollection(collectionID, id)
+		if err != nil {
+			return err
+		}
+		if !in {
+			return fmt.Errorf("bookmark %w in collection", ErrNotFound)
+		}
+	}
+	return s.repo.MoveBookmark(collectionID, bookmarkID, anchorID, after)
+}
+
+func (s *collectionService) GetBookmarks(userID, collectionID uuid.UUID, page, limit int) ([]model.Bookmark, int64, error) {
+	if err := s.access.AuthorizeCollectionRead(userID, collectionID); err != nil {
+		return nil, 0, err
+	}
 	offset := page * limit
 	return s.repo.GetBookmarks(collectionID, limit, offset)
 }
@@ -15,12 +15,13 @@ type CommentService interface {
 }
 
 type commentService struct {
-	repo   repository.CommentRepository
-	logger *zap.Logger
+	repo     repository.CommentRepository
+	activity ActivityRecorder
+	logger   *zap.Logger
 }
 
-func NewCommentService(repo repository.CommentRepository, logger *zap.Logger) CommentService {
-	return &commentService{repo: repo, logger: logger}
+func NewCommentService(repo repository.CommentRepository, activity ActivityRecorder, logger *zap.Logger) CommentService {
+	return &commentService{repo: repo, activity: activity, logger: logger}
 }
 
 func (s *commentService) Create(comment *model.BookmarkComment) error {
@@ -28,6 +29,10 @@ func (s *commentService) Create(comment *model.BookmarkComment) error {
 	if err != nil {
 		return err
 	}
+	s.activity.Record(comment.UserID, comment.BookmarkID, model.ActivityCommented, map[string]interface{}{
+		"commentId": comment.ID,
+		"parentId":  comment.ParentID,
+	})
 	s.logger.Info("Created comment", zap.String("bookmarkId", comment.BookmarkID.String()))
 	return nil
 }
@@ -47,9 +52,18 @@ func (s *commentService) Update(id uuid.UUID, content string) (*model.BookmarkCo
 	if err != nil {
 		return nil, err
 	}
+	s.activity.Record(comment.UserID, comment.BookmarkID, model.ActivityCommentUpdated, map[string]interface{}{"commentId": comment.ID})
 	return comment, nil
 }
 
 func (s *commentService) Delete(id uuid.UUID) error {
-	return s.repo.Delete(id)
+	comment, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	s.activity.Record(comment.UserID, comment.BookmarkID, model.ActivityCommentDeleted, map[string]interface{}{"commentId": comment.ID})
+	return nil
 }
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/quckapp/bookmark-service/internal/model"
+)
+
+// csvColumnNames are the header names tried, case-insensitively, for fields
+// the column mapping leaves empty.
+var csvColumnNames = map[string][]string{
+	"url":         {"url", "link", "href", "address"},
+	"title":       {"title", "name"},
+	"description": {"description", "excerpt", "extended", "summary"},
+	"note":        {"note", "notes", "comment"},
+	"tags":        {"tags", "labels", "keywords"},
+	"folder":      {"folder", "collection", "category", "path"},
+	"created":     {"created", "created_at", "date", "added", "time_added", "time"},
+	"readLater":   {"read_later", "toread", "unread"},
+	"favorite":    {"favorite", "starred", "important"},
+}
+
+// csvImporter reads a CSV file with a header row. Each bookmark field is
+// taken from the column named in the mapping, or from a column with a
+// common name for that field. Only the URL column is required.
+type csvImporter struct {
+	mapping model.CSVColumnMapping
+	// skipFolders are folder names that mean "no folder", such as
+	// Raindrop's "Unsorted".
+	skipFolders []string
+}
+
+func newRaindropImporter() *csvImporter {
+	return &csvImporter{
+		mapping: model.CSVColumnMapping{
+			URL:             "url",
+			Title:           "title",
+			Description:     "excerpt",
+			Tags:            "tags",
+			Folder:          "folder",
+			Created:         "created",
+			TagSeparator:    ",",
+			FolderSeparator: "/",
+		},
+		skipFolders: []string{"Unsorted"},
+	}
+}
+
+func (c *csvImporter) Parse(r io.Reader, emit func(b ImportedBookmark) error) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, seen := columns[name]; !seen {
+			columns[name] = i
+		}
+	}
+
+	m := c.mapping
+	col := func(field, mapped string) (int, error) {
+		if mapped != "" {
+			i, ok := columns[strings.ToLower(mapped)]
+			if !ok {
+				return -1, fmt.Errorf("column %q not found", mapped)
+			}
+			return i, nil
+		}
+		for _, name := range csvColumnNames[field] {
+			if i, ok := columns[name]; ok {
+				return i, nil
+			}
+		}
+		return -1, nil
+	}
+	var idx struct{ url, title, description, note, tags, folder, created, readLater, favorite int }
+	for _, f := range []struct {
+		field, mapped string
+		dest          *int
+	}{
+		{"url", m.URL, &idx.url},
+		{"title", m.Title, &idx.title},
+		{"description", m.Description, &idx.description},
+		{"note", "", &idx.note},
+		{"tags", m.Tags, &idx.tags},
+		{"folder", m.Folder, &idx.folder},
+		{"created", m.Created, &idx.created},
+		{"readLater", m.ReadLater, &idx.readLater},
+		{"favorite", "", &idx.favorite},
+	} {
+		if *f.dest, err = col(f.field, f.mapped); err != nil {
+			return err
+		}
+	}
+	if idx.url < 0 {
+		return errors.New("no URL column; set urlColumn to the header that holds the links")
+	}
+
+	tagSep := m.TagSeparator
+	if tagSep == "" {
+		tagSep = ","
+	}
+	folderSep := m.FolderSeparator
+	if folderSep == "" {
+		folderSep = "/"
+	}
+
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		value := func(i int) string {
+			if i < 0 || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		b := ImportedBookmark{
+			URL:         value(idx.url),
+			Title:       value(idx.title),
+			Description: value(idx.description),
+			Note:        value(idx.note),
+			Tags:        splitImportList(value(idx.tags), tagSep),
+			CreatedAt:   parseImportTime(value(idx.created)),
+			ReadLater:   parseImportBool(value(idx.readLater)),
+			Favorite:    parseImportBool(value(idx.favorite)),
+		}
+		if folder := value(idx.folder); folder != "" && !contains(c.skipFolders, folder) {
+			b.Folders = splitImportList(folder, folderSep)
+		}
+		if err := emit(b); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/repository"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// maxDuplicatePageSize caps how many groups one page of the report holds.
+const maxDuplicatePageSize = 100
+
+// ErrNotDuplicate is wrapped when a bookmark cannot be merged because it is
+// not a duplicate of the target.
+var ErrNotDuplicate = errors.New("bookmark is not a duplicate")
+
+// DuplicateService finds bookmarks a user saved more than once and merges
+// them. Bookmarks are duplicates when they belong to the same user and
+// workspace and share a canonical URL or a target and type.
+type DuplicateService interface {
+	// Report returns a page of the groups of bookmarks that share a canonical
+	// URL, optionally limited to one workspace.
+	Report(userID uuid.UUID, workspaceID *uuid.UUID, page, limit int) ([]model.DuplicateGroup, int64, error)
+	// Merge moves the tags, notes and collection memberships of sourceIDs to
+	// targetID and deletes the sources.
+	Merge(userID, targetID uuid.UUID, sourceIDs []uuid.UUID) (*model.MergeResult, error)
+}
+
+type duplicateService struct {
+	repo         repository.DuplicateRepository
+	bookmarkRepo repository.BookmarkRepository
+	bulkRepo     repository.BulkRepository
+	activity     ActivityRecorder
+	events       EventBus
+	tx           repository.Transactor
+	redis        *redis.Client
+	logger       *zap.Logger
+}
+
+func NewDuplicateService(
+	repo repository.DuplicateRepository,
+	bookmarkRepo repository.BookmarkRepository,
+	bulkRepo repository.BulkRepository,
+	activity ActivityRecorder,
+	events EventBus,
+	tx repository.Transactor,
+	redis *redis.Client,
+	logger *zap.Logger,
+) DuplicateService {
+	return &duplicateService{
+		repo:         repo,
+		bookmarkRepo: bookmarkRepo,
+		bulkRepo:     bulkRepo,
+		activity:     activity,
+		events:       events,
+		tx:           tx,
+		redis:        redis,
+		logger:       logger,
+	}
+}
+
+func (s *duplicateService) Report(userID uuid.UUID, workspaceID *uuid.UUID, page, limit int) ([]model.DuplicateGroup, int64, error) {
+	if page < 0 {
+		page = 0
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > maxDuplicatePageSize {
+		limit = maxDuplicatePageSize
+	}
+
+	keys, total, err := s.repo.GetKeys(userID, workspaceID, limit, page*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	bookmarks, err := s.repo.GetByKeys(userID, keys)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	groups := make([]model.DuplicateGroup, len(keys))
+	index := make(map[repository.DuplicateKey]int, len(keys))
+	for i, key := range keys {
+		groups[i] = model.DuplicateGroup{WorkspaceID: key.WorkspaceID, CanonicalURL: key.CanonicalURL}
+		index[key] = i
+	}
+	for _, bookmark := range bookmarks {
+		i, ok := index[repository.DuplicateKey{WorkspaceID: bookmark.WorkspaceID, CanonicalURL: bookmark.CanonicalURL}]
+		if ok {
+			groups[i].Bookmarks = append(groups[i].Bookmarks, bookmark)
+		}
+	}
+	return groups, total, nil
+}
+
+func (s *duplicateService) Merge(userID, targetID uuid.UUID, sourceIDs []uuid.UUID) (*model.MergeResult, error) {
+	target, err := s.bookmarkRepo.GetByID(targetID)
+	if err != nil {
+		return nil, lookupError("bookmark", err)
+	}
+	if err := checkOwner(userID, target.UserID); err != nil {
+		return nil, err
+	}
+	sources, err := s.sources(userID, target, sourceIDs)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uuid.UUID, len(sources))
+	for i, source := range sources {
+		ids[i] = source.ID
+	}
+
+	result := &model.MergeResult{Merged: len(sources)}
+	err = s.tx.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		bulkRepo := s.bulkRepo.WithTx(tx)
+
+		tagIDs, err := repo.GetTagIDs(ids)
+		if err != nil {
+			return err
+		}
+		if len(tagIDs) > 0 {
+			if result.TagsAdded, err = bulkRepo.AddTags([]uuid.UUID{targetID}, tagIDs); err != nil {
+				return err
+			}
+		}
+
+		collectionIDs, err := repo.GetCollectionIDs(ids)
+		if err != nil {
+			return err
+		}
+		for _, collectionID := range collectionIDs {
+			added, err := bulkRepo.AddToCollection(collectionID, userID, []uuid.UUID{targetID})
+			if err != nil {
+				return err
+			}
+			result.CollectionsAdded += added
+		}
+
+		if result.NotesMoved, err = repo.MoveNotes(ids, targetID); err != nil {
+			return err
+		}
+
+		if err := bulkRepo.DeleteBookmarks(ids); err != nil {
+			return err
+		}
+		for _, source := range sources {
+			err := s.events.Publish(tx, model.EventBookmarkDeleted, source.ID, model.BookmarkDeletedEvent{
+				BookmarkID:  source.ID,
+				UserID:      source.UserID,
+				WorkspaceID: source.WorkspaceID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Bookmark = *target
+
+	keys := []string{bookmarkCacheKey(targetID), userBookmarksCacheKey(userID)}
+	for _, id := range ids {
+		keys = append(keys, bookmarkCacheKey(id))
+	}
+	s.redis.Del(context.Background(), keys...)
+
+	for _, source := range sources {
+		s.activity.Record(userID, source.ID, model.ActivityDeleted, map[string]interface{}{
+			"title":      source.Title,
+			"mergedInto": targetID,
+		})
+	}
+	s.activity.Record(userID, targetID, model.ActivityMerged, map[string]interface{}{
+		"sourceIds":        ids,
+		"tagsAdded":        result.TagsAdded,
+		"notesMoved":       result.NotesMoved,
+		"collectionsAdded": result.CollectionsAdded,
+	})
+	s.logger.Info("Merged duplicate bookmarks", zap.String("id", targetID.String()), zap.Int("merged", len(ids)))
+	return result, nil
+}
+
+// sources loads the bookmarks to merge into target and checks that each is
+// the user's and a duplicate of target.
+func (s *duplicateService) sources(userID uuid.UUID, target *model.Bookmark, ids []uuid.UUID) ([]model.Bookmark, error) {
+	var unique []uuid.UUID
+	for _, id := range ids {
+		if id == target.ID {
+			return nil, fmt.Errorf("%w: a bookmark cannot be merged into itself", ErrNotDuplicate)
+		}
+		if !containsID(unique, id) {
+			unique = append(unique, id)
+		}
+	}
+
+	sources, err := s.bulkRepo.GetBookmarks(unique)
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) != len(unique) {
+		return nil, fmt.Errorf("bookmark %w", ErrNotFound)
+	}
+	for i := range sources {
+		source := &sources[i]
+		if err := checkOwner(userID, source.UserID); err != nil {
+			return nil, err
+		}
+		if !isDuplicate(target, source) {
+			return nil, fmt.Errorf("%w: %s", ErrNotDuplicate, source.ID)
+		}
+	}
+	return sources, nil
+}
+
+func isDuplicate(a, b *model.Bookmark) bool {
+	if a.UserID != b.UserID || a.WorkspaceID != b.WorkspaceID {
+		return false
+	}
+	if a.CanonicalURL != "" && a.CanonicalURL == b.CanonicalURL {
+		return true
+	}
+	return a.TargetID != uuid.Nil && a.TargetID == b.TargetID && a.Type == b.Type
+}
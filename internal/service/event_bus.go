@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/repository"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const outboxBatchSize = 100
+
+// EventBus publishes domain events for other services. Events are staged in
+// the outbox table and relayed to a Redis stream by OutboxRelay, so an event
+// is never lost once the change that produced it has committed.
+type EventBus interface {
+	// Publish stages an event. Pass the transaction making the change so the
+	// event commits or rolls back with it; a nil tx stages it on its own.
+	Publish(tx *gorm.DB, eventType string, aggregateID uuid.UUID, data interface{}) error
+}
+
+type outboxEventBus struct {
+	repo repository.OutboxRepository
+	now  Clock
+}
+
+func NewOutboxEventBus(repo repository.OutboxRepository, clock Clock) EventBus {
+	if clock == nil {
+		clock = time.Now
+	}
+	return &outboxEventBus{repo: repo, now: clock}
+}
+
+func (b *outboxEventBus) Publish(tx *gorm.DB, eventType string, aggregateID uuid.UUID, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	repo := b.repo
+	if tx != nil {
+		repo = repo.WithTx(tx)
+	}
+	return repo.Create(&model.OutboxEvent{
+		EventType:     eventType,
+		SchemaVersion: model.EventSchemaVersion,
+		AggregateID:   aggregateID,
+		Payload:       string(payload),
+		OccurredAt:    b.now(),
+	})
+}
+
+// OutboxRelay moves staged events from the outbox to the event stream and
+// prunes events that were published longer than retention ago.
+type OutboxRelay struct {
+	periodicJob
+	repo      repository.OutboxRepository
+	redis     *redis.Client
+	stream    string
+	maxLen    int64
+	interval  time.Duration
+	retention time.Duration
+	now       Clock
+	logger    *zap.Logger
+}
+
+func NewOutboxRelay(
+	repo repository.OutboxRepository,
+	redis *redis.Client,
+	stream string,
+	maxLen int64,
+	interval time.Duration,
+	retention time.Duration,
+	clock Clock,
+	logger *zap.Logger,
+) *OutboxRelay {
+	if clock == nil {
+		clock = time.Now
+	}
+	return &OutboxRelay{
+		repo:      repo,
+		redis:     redis,
+		stream:    stream,
+		maxLen:    maxLen,
+		interval:  interval,
+		retention: retention,
+		now:       clock,
+		logger:    logger,
+	}
+}
+
+func (r *OutboxRelay) Start(ctx context.Context) {
+	r.logger.Info("Starting outbox relay", zap.String("stream", r.stream), zap.Duration("interval", r.interval))
+	r.start(ctx, r.interval, func(ctx context.Context) { r.Relay(ctx) })
+}
+
+func (r *OutboxRelay) Stop() {
+	r.stop()
+	r.logger.Info("Stopped outbox relay")
+}
+
+// Relay publishes pending events until the outbox is drained or publishing
+// fails, and returns the number of events published.
+func (r *OutboxRelay) Relay(ctx context.Context) int {
+	total := 0
+	for ctx.Err() == nil {
+		published, err := r.repo.Relay(outboxBatchSize, func(event *model.OutboxEvent) error {
+			return r.publish(ctx, event)
+		})
+		total += published
+		if err != nil {
+			r.logger.Error("Failed to relay outbox events", zap.Error(err))
+			break
+		}
+		if published < outboxBatchSize {
+			break
+		}
+	}
+
+	if r.retention > 0 {
+		if _, err := r.repo.DeletePublishedBefore(r.now().Add(-r.retention)); err != nil {
+			r.logger.Warn("Failed to prune outbox", zap.Error(err))
+		}
+	}
+	if total > 0 {
+		r.logger.Debug("Relayed outbox events", zap.Int("count", total))
+	}
+	return total
+}
+
+func (r *OutboxRelay) publish(ctx context.Context, event *model.OutboxEvent) error {
+	envelope, err := json.Marshal(model.Event{
+		ID:            event.ID,
+		Type:          event.EventType,
+		SchemaVersion: event.SchemaVersion,
+		AggregateID:   event.AggregateID,
+		OccurredAt:    event.OccurredAt,
+		Data:          json.RawMessage(event.Payload),
+	})
+	if err != nil {
+		return err
+	}
+
+	err = r.redis.XAdd(ctx, &redis.XAddArgs{
+		Stream: r.stream,
+		MaxLen: r.maxLen,
+		Approx: r.maxLen > 0,
+		Values: map[string]interface{}{
+			"type":  event.EventType,
+			"event": string(envelope),
+		},
+	}).Err()
+	if err != nil {
+		r.logger.Warn("Failed to publish event, will retry",
+			zap.String("id", event.ID.String()),
+			zap.String("type", event.EventType),
+			zap.Error(err))
+	}
+	return err
+}
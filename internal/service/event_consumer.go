@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// EventHandler processes one event. Returning an error leaves the event
+// unacknowledged so it is delivered again.
+type EventHandler func(ctx context.Context, event model.Event) error
+
+// EventConsumer reads the event stream as a member of a consumer group, for
+// services that want to react to bookmark events. Delivery is at least once,
+// so handlers should be idempotent on Event.ID.
+type EventConsumer struct {
+	redis    *redis.Client
+	stream   string
+	group    string
+	consumer string
+	block    time.Duration
+	logger   *zap.Logger
+}
+
+func NewEventConsumer(redis *redis.Client, stream, group, consumer string, logger *zap.Logger) *EventConsumer {
+	return &EventConsumer{
+		redis:    redis,
+		stream:   stream,
+		group:    group,
+		consumer: consumer,
+		block:    5 * time.Second,
+		logger:   logger,
+	}
+}
+
+// Run consumes events until ctx is cancelled. It first replays events this
+// consumer received but did not acknowledge, then reads new ones; after a
+// handler failure it goes back to the unacknowledged events.
+func (c *EventConsumer) Run(ctx context.Context, handler EventHandler) error {
+	err := c.redis.XGroupCreateMkStream(ctx, c.stream, c.group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+
+	start := "0"
+	for ctx.Err() == nil {
+		streams, err := c.redis.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    c.group,
+			Consumer: c.consumer,
+			Streams:  []string{c.stream, start},
+			Count:    outboxBatchSize,
+			Block:    c.block,
+		}).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				continue
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			c.logger.Warn("Failed to read event stream", zap.String("stream", c.stream), zap.Error(err))
+			c.wait(ctx)
+			continue
+		}
+
+		messages := 0
+		failed := false
+		for _, stream := range streams {
+			for _, message := range stream.Messages {
+				messages++
+				if !c.handle(ctx, message, handler) {
+					failed = true
+				}
+			}
+		}
+
+		switch {
+		case failed:
+			start = "0"
+			c.wait(ctx)
+		case start == "0" && messages == 0:
+			start = ">"
+		}
+	}
+	return nil
+}
+
+// handle runs handler for one message and reports whether it was acknowledged.
+// Messages that cannot be decoded are acknowledged and dropped.
+func (c *EventConsumer) handle(ctx context.Context, message redis.XMessage, handler EventHandler) bool {
+	var event model.Event
+	raw, _ := message.Values["event"].(string)
+	if err := json.Unmarshal([]byte(raw), &event); err != nil {
+		c.logger.Error("Dropping undecodable event", zap.String("messageId", message.ID), zap.Error(err))
+		return c.ack(ctx, message.ID)
+	}
+
+	if err := handler(ctx, event); err != nil {
+		c.logger.Warn("Event handler failed, will retry",
+			zap.String("id", event.ID.String()),
+			zap.String("type", event.Type),
+			zap.Error(err))
+		return false
+	}
+	return c.ack(ctx, message.ID)
+}
+
+func (c *EventConsumer) ack(ctx context.Context, messageID string) bool {
+	if err := c.redis.XAck(ctx, c.stream, c.group, messageID).Err(); err != nil {
+		c.logger.Warn("Failed to acknowledge event", zap.String("messageId", messageID), zap.Error(err))
+		return false
+	}
+	return true
+}
+
+func (c *EventConsumer) wait(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(c.block):
+	}
+}
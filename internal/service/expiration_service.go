@@ -1,6 +1,7 @@
 package service
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,28 +18,49 @@ type ExpirationService interface {
 }
 
 type expirationService struct {
-	repo   repository.ExpirationRepository
-	logger *zap.Logger
+	repo       repository.ExpirationRepository
+	folderRepo repository.FolderRepository
+	activity   ActivityRecorder
+	logger     *zap.Logger
 }
 
-func NewExpirationService(repo repository.ExpirationRepository, logger *zap.Logger) ExpirationService {
-	return &expirationService{repo: repo, logger: logger}
+func NewExpirationService(
+	repo repository.ExpirationRepository,
+	folderRepo repository.FolderRepository,
+	activity ActivityRecorder,
+	logger *zap.Logger,
+) ExpirationService {
+	return &expirationService{repo: repo, folderRepo: folderRepo, activity: activity, logger: logger}
 }
 
 func (s *expirationService) Set(expiration *model.BookmarkExpiration) error {
+	if err := checkExpiration(s.folderRepo, expiration); err != nil {
+		return err
+	}
+
 	// Check if expiration already exists for this bookmark
 	existing, _ := s.repo.GetByBookmarkID(expiration.BookmarkID)
 	if existing != nil {
 		existing.ExpiresAt = expiration.ExpiresAt
 		existing.Action = expiration.Action
+		existing.TargetFolderID = expiration.TargetFolderID
 		existing.IsExpired = false
-		return s.repo.Update(existing)
+		existing.Attempts = 0
+		existing.NextAttemptAt = nil
+		existing.Error = ""
+		if err := s.repo.Update(existing); err != nil {
+			return err
+		}
+		*expiration = *existing
+		s.activity.Record(expiration.UserID, expiration.BookmarkID, model.ActivityExpirationSet, expirationDetails(expiration))
+		return nil
 	}
 
 	err := s.repo.Create(expiration)
 	if err != nil {
 		return err
 	}
+	s.activity.Record(expiration.UserID, expiration.BookmarkID, model.ActivityExpirationSet, expirationDetails(expiration))
 	s.logger.Info("Set expiration", zap.String("bookmarkId", expiration.BookmarkID.String()))
 	return nil
 }
@@ -48,7 +70,15 @@ func (s *expirationService) GetByBookmarkID(bookmarkID uuid.UUID) (*model.Bookma
 }
 
 func (s *expirationService) Remove(bookmarkID uuid.UUID) error {
-	return s.repo.Delete(bookmarkID)
+	expiration, err := s.repo.GetByBookmarkID(bookmarkID)
+	if err != nil {
+		return lookupError("expiration", err)
+	}
+	if err := s.repo.Delete(bookmarkID); err != nil {
+		return err
+	}
+	s.activity.Record(expiration.UserID, bookmarkID, model.ActivityExpirationRemoved, expirationDetails(expiration))
+	return nil
 }
 
 func (s *expirationService) GetExpiring(userID uuid.UUID) ([]model.BookmarkExpiration, error) {
@@ -56,3 +86,34 @@ func (s *expirationService) GetExpiring(userID uuid.UUID) ([]model.BookmarkExpir
 	before := time.Now().AddDate(0, 0, 7)
 	return s.repo.GetExpiring(userID, before)
 }
+
+// checkExpiration defaults the action to archive and checks that a move
+// targets one of the user's folders.
+func checkExpiration(folderRepo repository.FolderRepository, expiration *model.BookmarkExpiration) error {
+	if expiration.Action == "" {
+		expiration.Action = model.ExpirationActionArchive
+	}
+	if !expiration.Action.IsValid() {
+		return fmt.Errorf("unsupported expiration action: %s", expiration.Action)
+	}
+	if expiration.Action == model.ExpirationActionMove {
+		if expiration.TargetFolderID == nil {
+			return fmt.Errorf("targetFolderId is required for move action")
+		}
+		folder, err := folderRepo.GetByID(*expiration.TargetFolderID)
+		if err != nil || folder.UserID != expiration.UserID {
+			return fmt.Errorf("folder %w", ErrNotFound)
+		}
+	} else {
+		expiration.TargetFolderID = nil
+	}
+	return nil
+}
+
+func expirationDetails(expiration *model.BookmarkExpiration) map[string]interface{} {
+	return map[string]interface{}{
+		"action":         expiration.Action,
+		"expiresAt":      expiration.ExpiresAt,
+		"targetFolderId": expiration.TargetFolderID,
+	}
+}
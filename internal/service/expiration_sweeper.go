@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/repository"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	expirationBatchSize     = 100
+	expirationMaxRetryDelay = 6 * time.Hour
+)
+
+// ExpirationRetryPolicy bounds how often applying an expiration is retried.
+// Retries back off exponentially from RetryBaseDelay.
+type ExpirationRetryPolicy struct {
+	MaxAttempts    int
+	RetryBaseDelay time.Duration
+}
+
+// ExpirationSweeper finds expirations whose ExpiresAt has passed and applies
+// the configured action to the bookmark.
+type ExpirationSweeper struct {
+	periodicJob
+	repo            repository.ExpirationRepository
+	bookmarkService BookmarkService
+	collectionRepo  repository.CollectionRepository
+	activity        ActivityRecorder
+	notifier        Notifier
+	policy          ExpirationRetryPolicy
+	interval        time.Duration
+	now             Clock
+	logger          *zap.Logger
+}
+
+func NewExpirationSweeper(
+	repo repository.ExpirationRepository,
+	bookmarkService BookmarkService,
+	collectionRepo repository.CollectionRepository,
+	activity ActivityRecorder,
+	notifier Notifier,
+	policy ExpirationRetryPolicy,
+	interval time.Duration,
+	clock Clock,
+	logger *zap.Logger,
+) *ExpirationSweeper {
+	if clock == nil {
+		clock = time.Now
+	}
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 5
+	}
+	if policy.RetryBaseDelay <= 0 {
+		policy.RetryBaseDelay = time.Minute
+	}
+	return &ExpirationSweeper{
+		repo:            repo,
+		bookmarkService: bookmarkService,
+		collectionRepo:  collectionRepo,
+		activity:        activity,
+		notifier:        notifier,
+		policy:          policy,
+		interval:        interval,
+		now:             clock,
+		logger:          logger,
+	}
+}
+
+func (s *ExpirationSweeper) Start(ctx context.Context) {
+	s.logger.Info("Starting expiration sweeper", zap.Duration("interval", s.interval))
+	s.start(ctx, s.interval, func(ctx context.Context) { s.Sweep(ctx) })
+}
+
+func (s *ExpirationSweeper) Stop() {
+	s.stop()
+	s.logger.Info("Stopped expiration sweeper")
+}
+
+// Sweep processes every expiration due at the current clock time and returns
+// the number of expirations applied.
+func (s *ExpirationSweeper) Sweep(ctx context.Context) int {
+	now := s.now()
+	expirations, err := s.repo.GetDue(now, expirationBatchSize)
+	if err != nil {
+		s.logger.Error("Failed to load due expirations", zap.Error(err))
+		return 0
+	}
+
+	processed := 0
+	for _, expiration := range expirations {
+		if ctx.Err() != nil {
+			break
+		}
+
+		claimed, err := s.repo.MarkExpired(expiration.ID)
+		if err != nil {
+			s.logger.Error("Failed to claim expiration", zap.String("id", expiration.ID.String()), zap.Error(err))
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		expiration.IsExpired = true
+
+		if err := s.apply(ctx, expiration, now); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				// Bookmark is already gone; nothing left to expire
+				continue
+			}
+			s.fail(&expiration, now, err)
+			if err := s.repo.RecordFailure(&expiration); err != nil {
+				s.logger.Error("Failed to save expiration failure", zap.String("id", expiration.ID.String()), zap.Error(err))
+			}
+			continue
+		}
+
+		s.activity.Record(expiration.UserID, expiration.BookmarkID, model.ActivityExpired, expirationDetails(&expiration))
+		processed++
+	}
+
+	if processed > 0 {
+		s.logger.Info("Processed bookmark expirations", zap.Int("count", processed))
+	}
+	return processed
+}
+
+// fail records a failed attempt and schedules a retry, or gives up once
+// attempts run out, leaving the expiration expired.
+func (s *ExpirationSweeper) fail(expiration *model.BookmarkExpiration, now time.Time, err error) {
+	expiration.Attempts++
+	expiration.Error = truncate(err.Error(), 1000)
+	if expiration.Attempts >= s.policy.MaxAttempts {
+		expiration.NextAttemptAt = nil
+		s.logger.Warn("Failed to apply expiration, giving up",
+			zap.String("id", expiration.ID.String()),
+			zap.String("action", string(expiration.Action)),
+			zap.Int("attempts", expiration.Attempts),
+			zap.Error(err))
+		return
+	}
+
+	next := now.Add(s.retryDelay(expiration.Attempts))
+	expiration.IsExpired = false
+	expiration.NextAttemptAt = &next
+	s.logger.Warn("Failed to apply expiration, will retry",
+		zap.String("id", expiration.ID.String()),
+		zap.String("action", string(expiration.Action)),
+		zap.Int("attempts", expiration.Attempts),
+		zap.Time("nextAttemptAt", next),
+		zap.Error(err))
+}
+
+func (s *ExpirationSweeper) retryDelay(attempts int) time.Duration {
+	delay := s.policy.RetryBaseDelay
+	for i := 1; i < attempts && delay < expirationMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > expirationMaxRetryDelay {
+		delay = expirationMaxRetryDelay
+	}
+	return delay
+}
+
+func (s *ExpirationSweeper) apply(ctx context.Context, expiration model.BookmarkExpiration, now time.Time) error {
+	switch expiration.Action {
+	case model.ExpirationActionArchive, "":
+		return s.bookmarkService.SetArchived(expiration.BookmarkID, true)
+	case model.ExpirationActionDelete:
+		return s.bookmarkService.Delete(expiration.BookmarkID)
+	case model.ExpirationActionMove:
+		if expiration.TargetFolderID == nil {
+			return fmt.Errorf("move action has no target folder")
+		}
+		return s.bookmarkService.MoveToFolder(expiration.BookmarkID, expiration.TargetFolderID)
+	case model.ExpirationActionRemoveFromCollections:
+		return s.collectionRepo.RemoveBookmarkFromAll(expiration.BookmarkID)
+	case model.ExpirationActionNotify:
+		return s.notifier.Notify(ctx, model.Notification{
+			Type:       model.NotificationTypeExpiration,
+			UserID:     expiration.UserID,
+			BookmarkID: expiration.BookmarkID,
+			Message:    "Bookmark has expired",
+			Data:       expiration,
+			OccurredAt: now,
+		})
+	default:
+		return fmt.Errorf("unsupported expiration action: %s", expiration.Action)
+	}
+}
@@ -18,12 +18,13 @@ type FavoriteService interface {
 }
 
 type favoriteService struct {
-	repo   repository.FavoriteRepository
-	logger *zap.Logger
+	repo     repository.FavoriteRepository
+	activity ActivityRecorder
+	logger   *zap.Logger
 }
 
-func NewFavoriteService(repo repository.FavoriteRepository, logger *zap.Logger) FavoriteService {
-	return &favoriteService{repo: repo, logger: logger}
+func NewFavoriteService(repo repository.FavoriteRepository, activity ActivityRecorder, logger *zap.Logger) FavoriteService {
+	return &favoriteService{repo: repo, activity: activity, logger: logger}
 }
 
 func (s *favoriteService) AddFavorite(userID, bookmarkID uuid.UUID) error {
@@ -35,11 +36,19 @@ func (s *favoriteService) AddFavorite(userID, bookmarkID uuid.UUID) error {
 		UserID:     userID,
 		BookmarkID: bookmarkID,
 	}
-	return s.repo.Create(fav)
+	if err := s.repo.Create(fav); err != nil {
+		return err
+	}
+	s.activity.Record(userID, bookmarkID, model.ActivityFavorited, nil)
+	return nil
 }
 
 func (s *favoriteService) RemoveFavorite(userID, bookmarkID uuid.UUID) error {
-	return s.repo.Delete(userID, bookmarkID)
+	if err := s.repo.Delete(userID, bookmarkID); err != nil {
+		return err
+	}
+	s.activity.Record(userID, bookmarkID, model.ActivityUnfavorited, nil)
+	return nil
 }
 
 func (s *favoriteService) IsFavorite(userID, bookmarkID uuid.UUID) (bool, error) {
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrUnsupportedFeedFormat is returned for a feed format other than RSS,
+// Atom or JSON Feed.
+var ErrUnsupportedFeedFormat = errors.New("unsupported feed format")
+
+type FeedFormat string
+
+const (
+	FeedFormatRSS  FeedFormat = "rss"
+	FeedFormatAtom FeedFormat = "atom"
+	FeedFormatJSON FeedFormat = "json"
+)
+
+func (f FeedFormat) IsValid() bool {
+	switch f {
+	case FeedFormatRSS, FeedFormatAtom, FeedFormatJSON:
+		return true
+	}
+	return false
+}
+
+func (f FeedFormat) MediaType() string {
+	switch f {
+	case FeedFormatRSS:
+		return "application/rss+xml"
+	case FeedFormatAtom:
+		return "application/atom+xml"
+	}
+	return "application/feed+json"
+}
+
+func (f FeedFormat) ContentType() string {
+	return f.MediaType() + "; charset=utf-8"
+}
+
+// Feed is the newest bookmarks of a collection, ready to be written in any
+// FeedFormat.
+type Feed struct {
+	ID          uuid.UUID
+	Title       string
+	Description string
+	// URL is where the feed itself is served.
+	URL       string
+	UpdatedAt time.Time
+	Items     []FeedItem
+}
+
+type FeedItem struct {
+	ID       uuid.UUID
+	Title    string
+	URL      string
+	Summary  string
+	ImageURL string
+	// AddedAt is when the bookmark was put in the collection, which is when
+	// it appears in the feed.
+	AddedAt   time.Time
+	UpdatedAt time.Time
+}
+
+const (
+	atomNamespace  = "http://www.w3.org/2005/Atom"
+	mediaNamespace = "http://search.yahoo.com/mrss/"
+	jsonFeedV1_1   = "https://jsonfeed.org/version/1.1"
+)
+
+// WriteFeed writes feed to w as RSS 2.0, Atom 1.0 or JSON Feed 1.1.
+func WriteFeed(w io.Writer, feed *Feed, format FeedFormat) error {
+	switch format {
+	case FeedFormatRSS:
+		return writeXML(w, rssDocument(feed))
+	case FeedFormatAtom:
+		return writeXML(w, atomDocument(feed))
+	case FeedFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		return enc.Encode(jsonFeedDocument(feed))
+	}
+	return fmt.Errorf("%w %q", ErrUnsupportedFeedFormat, format)
+}
+
+func writeXML(w io.Writer, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+// feedItemID names an item independently of its URL, which may change.
+func feedItemID(id uuid.UUID) string {
+	return "urn:uuid:" + id.String()
+}
+
+// The XML namespaces of RSS extensions are written as literal prefixed names,
+// which encoding/xml leaves alone.
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Media   string     `xml:"xmlns:media,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Self          rssLink   `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssItem struct {
+	Title       string      `xml:"title"`
+	Link        string      `xml:"link"`
+	Description string      `xml:"description,omitempty"`
+	GUID        rssGUID     `xml:"guid"`
+	PubDate     string      `xml:"pubDate"`
+	Thumbnail   *mediaImage `xml:"media:thumbnail,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type mediaImage struct {
+	URL string `xml:"url,attr"`
+}
+
+func rssDocument(feed *Feed) *rss {
+	doc := &rss{
+		Version: "2.0",
+		Atom:    atomNamespace,
+		Media:   mediaNamespace,
+		Channel: rssChannel{
+			Title:         feed.Title,
+			Link:          feed.URL,
+			Description:   feed.Description,
+			Self:          rssLink{Href: feed.URL, Rel: "self", Type: FeedFormatRSS.MediaType()},
+			LastBuildDate: feed.UpdatedAt.UTC().Format(time.RFC1123Z),
+			Items:         make([]rssItem, 0, len(feed.Items)),
+		},
+	}
+	for _, item := range feed.Items {
+		entry := rssItem{
+			Title:       item.Title,
+			Link:        item.URL,
+			Description: item.Summary,
+			GUID:        rssGUID{Value: feedItemID(item.ID)},
+			PubDate:     item.AddedAt.UTC().Format(time.RFC1123Z),
+		}
+		if item.ImageURL != "" {
+			entry.Thumbnail = &mediaImage{URL: item.ImageURL}
+		}
+		doc.Channel.Items = append(doc.Channel.Items, entry)
+	}
+	return doc
+}
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Media    string      `xml:"xmlns:media,attr"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Author   atomPerson  `xml:"author"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Links     []atomLink  `xml:"link"`
+	Summary   string      `xml:"summary,omitempty"`
+	Published string      `xml:"published"`
+	Updated   string      `xml:"updated"`
+	Thumbnail *mediaImage `xml:"media:thumbnail,omitempty"`
+}
+
+func atomDocument(feed *Feed) *atomFeed {
+	doc := &atomFeed{
+		Media:    mediaNamespace,
+		ID:       feedItemID(feed.ID),
+		Title:    feed.Title,
+		Subtitle: feed.Description,
+		Updated:  feed.UpdatedAt.UTC().Format(time.RFC3339),
+		// Atom asks for an author; the collection speaks for its members
+		Author:  atomPerson{Name: feed.Title},
+		Links:   []atomLink{{Href: feed.URL, Rel: "self", Type: FeedFormatAtom.MediaType()}},
+		Entries: make([]atomEntry, 0, len(feed.Items)),
+	}
+	for _, item := range feed.Items {
+		entry := atomEntry{
+			ID:        feedItemID(item.ID),
+			Title:     item.Title,
+			Links:     []atomLink{{Href: item.URL, Rel: "alternate"}},
+			Summary:   item.Summary,
+			Published: item.AddedAt.UTC().Format(time.RFC3339),
+			Updated:   item.UpdatedAt.UTC().Format(time.RFC3339),
+		}
+		if item.ImageURL != "" {
+			entry.Thumbnail = &mediaImage{URL: item.ImageURL}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return doc
+}
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	FeedURL     string         `json:"feed_url"`
+	Description string         `json:"description,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string `json:"id"`
+	URL           string `json:"url"`
+	Title         string `json:"title"`
+	ContentText   string `json:"content_text"`
+	Image         string `json:"image,omitempty"`
+	DatePublished string `json:"date_published"`
+	DateModified  string `json:"date_modified"`
+}
+
+func jsonFeedDocument(feed *Feed) *jsonFeed {
+	doc := &jsonFeed{
+		Version:     jsonFeedV1_1,
+		Title:       feed.Title,
+		FeedURL:     feed.URL,
+		Description: feed.Description,
+		Items:       make([]jsonFeedItem, 0, len(feed.Items)),
+	}
+	for _, item := range feed.Items {
+		doc.Items = append(doc.Items, jsonFeedItem{
+			ID:            feedItemID(item.ID),
+			URL:           item.URL,
+			Title:         item.Title,
+			ContentText:   item.Summary,
+			Image:         item.ImageURL,
+			DatePublished: item.AddedAt.UTC().Format(time.RFC3339),
+			DateModified:  item.UpdatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	return doc
+}
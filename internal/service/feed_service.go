@@ -0,0 +1,118 @@
+package service
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+// maxFeedItems is how many of a collection's newest bookmarks its feed
+// carries. Readers remember older items themselves.
+const maxFeedItems = 50
+
+type FeedService interface {
+	// CollectionFeed builds the feed of the web bookmarks most recently added
+	// to the collection. feedURL is where the feed is served.
+	CollectionFeed(collectionID uuid.UUID, feedURL string) (*Feed, error)
+}
+
+type feedService struct {
+	collectionRepo repository.CollectionRepository
+	previewRepo    repository.PreviewRepository
+	logger         *zap.Logger
+}
+
+func NewFeedService(
+	collectionRepo repository.CollectionRepository,
+	previewRepo repository.PreviewRepository,
+	logger *zap.Logger,
+) FeedService {
+	return &feedService{
+		collectionRepo: collectionRepo,
+		previewRepo:    previewRepo,
+		logger:         logger,
+	}
+}
+
+func (s *feedService) CollectionFeed(collectionID uuid.UUID, feedURL string) (*Feed, error) {
+	collection, err := s.collectionRepo.GetByID(collectionID)
+	if err != nil {
+		return nil, lookupError("collection", err)
+	}
+	entries, err := s.collectionRepo.GetLatestExternalEntries(collectionID, maxFeedItems)
+	if err != nil {
+		return nil, err
+	}
+
+	feed := &Feed{
+		ID:          collection.ID,
+		Title:       collection.Name,
+		Description: collection.Description,
+		URL:         feedURL,
+		UpdatedAt:   collection.UpdatedAt,
+		Items:       make([]FeedItem, 0, len(entries)),
+	}
+	if feed.Description == "" {
+		feed.Description = "Bookmarks in " + collection.Name
+	}
+
+	previews := s.readyPreviews(entries)
+	for _, e := range entries {
+		item := FeedItem{
+			ID:        e.ID,
+			Title:     e.Title,
+			URL:       e.TargetURL,
+			Summary:   e.Description,
+			AddedAt:   e.AddedAt,
+			UpdatedAt: latest(e.AddedAt, e.UpdatedAt),
+		}
+		if p, ok := previews[e.ID]; ok {
+			if item.Title == "" {
+				item.Title = p.Title
+			}
+			if item.Summary == "" {
+				item.Summary = p.Description
+			}
+			item.ImageURL = p.ImageURL
+			item.UpdatedAt = latest(item.UpdatedAt, p.UpdatedAt)
+		}
+		if item.Title == "" {
+			item.Title = item.URL
+		}
+		feed.UpdatedAt = latest(feed.UpdatedAt, item.UpdatedAt)
+		feed.Items = append(feed.Items, item)
+	}
+	return feed, nil
+}
+
+// readyPreviews returns the fetched previews of entries by bookmark ID.
+// Previews only fill in summaries and images, so failing to load them is
+// only logged.
+func (s *feedService) readyPreviews(entries []repository.CollectionEntry) map[uuid.UUID]model.LinkPreview {
+	ids := make([]uuid.UUID, len(entries))
+	for i, e := range entries {
+		ids[i] = e.ID
+	}
+	previews, err := s.previewRepo.GetByBookmarkIDs(ids)
+	if err != nil {
+		s.logger.Warn("Failed to load previews for collection feed", zap.Error(err))
+	}
+
+	byBookmark := make(map[uuid.UUID]model.LinkPreview, len(previews))
+	for _, p := range previews {
+		if p.Status == model.PreviewStatusReady {
+			byBookmark[p.BookmarkID] = p
+		}
+	}
+	return byBookmark
+}
+
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
@@ -1,10 +1,22 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
+
 	"github.com/google/uuid"
 	"github.com/quckapp/bookmark-service/internal/model"
 	"github.com/quckapp/bookmark-service/internal/repository"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrFolderCycle    = errors.New("a folder cannot be moved into itself or one of its subfolders")
+	ErrFolderTooDeep  = errors.New("folders are nested too deeply")
+	ErrFolderNotEmpty = errors.New("folder is not empty")
 )
 
 type FolderService interface {
@@ -12,22 +24,62 @@ type FolderService interface {
 	GetByID(id uuid.UUID) (*model.BookmarkFolder, error)
 	GetByUser(userID uuid.UUID) ([]model.BookmarkFolder, error)
 	GetByUserAndWorkspace(userID, workspaceID uuid.UUID) ([]model.BookmarkFolder, error)
-	Update(folder *model.BookmarkFolder) error
-	Delete(id uuid.UUID) error
-	Reorder(items []model.ReorderItem) error
+	// GetTree returns the user's top-level folders with their subfolders
+	// nested inside, optionally limited to one workspace.
+	GetTree(userID uuid.UUID, workspaceID *uuid.UUID) ([]*model.FolderNode, error)
+	Update(id uuid.UUID, req *model.UpdateFolderRequest) (*model.BookmarkFolder, error)
+	// Move puts the folder under parentID, or at the top level when parentID
+	// is nil, keeping its position unless one is given.
+	Move(id uuid.UUID, parentID *uuid.UUID, position *int) (*model.BookmarkFolder, error)
+	Delete(id uuid.UUID, mode model.FolderDeleteMode) error
+	Reorder(userID uuid.UUID, items []model.ReorderItem) error
+	// MoveNextTo places the folder directly before or after anchorID, which
+	// must have the same parent.
+	MoveNextTo(id, anchorID uuid.UUID, after bool) error
 }
 
 type folderService struct {
-	repo   repository.FolderRepository
-	logger *zap.Logger
+	repo     repository.FolderRepository
+	activity ActivityRecorder
+	events   EventBus
+	tx       repository.Transactor
+	redis    *redis.Client
+	maxDepth int
+	logger   *zap.Logger
 }
 
-func NewFolderService(repo repository.FolderRepository, logger *zap.Logger) FolderService {
-	return &folderService{repo: repo, logger: logger}
+// NewFolderService allows folders to nest up to maxDepth levels, counting
+// the top level as one.
+func NewFolderService(
+	repo repository.FolderRepository,
+	activity ActivityRecorder,
+	events EventBus,
+	tx repository.Transactor,
+	redis *redis.Client,
+	maxDepth int,
+	logger *zap.Logger,
+) FolderService {
+	return &folderService{
+		repo:     repo,
+		activity: activity,
+		events:   events,
+		tx:       tx,
+		redis:    redis,
+		maxDepth: maxDepth,
+		logger:   logger,
+	}
 }
 
 func (s *folderService) Create(folder *model.BookmarkFolder) error {
-	err := s.repo.Create(folder)
+	err := s.tx.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if folder.ParentID != nil {
+			if err := s.checkPlacement(repo, folder, folder.ParentID); err != nil {
+				return err
+			}
+		}
+		return repo.Create(folder)
+	})
 	if err != nil {
 		return err
 	}
@@ -47,42 +99,380 @@ func (s *folderService) GetByUserAndWorkspace(userID, workspaceID uuid.UUID) ([]
 	return s.repo.GetByUserAndWorkspace(userID, workspaceID)
 }
 
-func (s *folderService) Update(folder *model.BookmarkFolder) error {
-	existing, err := s.repo.GetByID(folder.ID)
+func (s *folderService) Update(id uuid.UUID, req *model.UpdateFolderRequest) (*model.BookmarkFolder, error) {
+	existing, err := s.repo.GetByID(id)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	err = s.tx.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if !sameParent(existing.ParentID, req.ParentID) {
+			if err := s.checkPlacement(repo, existing, req.ParentID); err != nil {
+				return err
+			}
+		}
 
-	existing.Name = folder.Name
-	existing.Color = folder.Color
-	existing.Icon = folder.Icon
-	existing.Position = folder.Position
-	existing.ParentID = folder.ParentID
+		existing.Name = req.Name
+		existing.Color = req.Color
+		existing.Icon = req.Icon
+		if req.Position != nil {
+			existing.Position = *req.Position
+		}
+		existing.ParentID = req.ParentID
 
-	return s.repo.Update(existing)
+		return repo.Update(existing)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return existing, nil
 }
 
-func (s *folderService) Delete(id uuid.UUID) error {
-	err := s.repo.Delete(id)
+func (s *folderService) Move(id uuid.UUID, parentID *uuid.UUID, position *int) (*model.BookmarkFolder, error) {
+	folder, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, lookupError("folder", err)
+	}
+	if position != nil {
+		folder.Position = *position
+	}
+
+	err = s.tx.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if err := s.checkPlacement(repo, folder, parentID); err != nil {
+			return err
+		}
+		return repo.SetParent(id, parentID, folder.Position)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("Moved bookmark folder", zap.String("id", id.String()))
+	folder.ParentID = parentID
+	return folder, nil
+}
+
+func (s *folderService) Delete(id uuid.UUID, mode model.FolderDeleteMode) error {
+	if mode == "" {
+		mode = model.FolderDeleteMoveToParent
+	}
+	folder, err := s.repo.GetByID(id)
+	if err != nil {
+		return lookupError("folder", err)
+	}
+
+	// The folders and their bookmarks are read under lock so nothing can be
+	// created in or moved into the subtree before it is deleted
+	var bookmarks []model.Bookmark
+	err = s.tx.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		folders, err := repo.LockByUser(folder.UserID)
+		if err != nil {
+			return err
+		}
+		tree := newFolderTree(folders)
+		locked := tree.byID[id]
+		if locked == nil {
+			return fmt.Errorf("folder %w", ErrNotFound)
+		}
+		folder = locked
+
+		switch mode {
+		case model.FolderDeleteRefuse:
+			if len(tree.children[id]) > 0 {
+				return ErrFolderNotEmpty
+			}
+			if bookmarks, err = repo.LockBookmarks([]uuid.UUID{id}); err != nil {
+				return err
+			}
+			if len(bookmarks) > 0 {
+				return ErrFolderNotEmpty
+			}
+			return repo.Delete(id)
+
+		case model.FolderDeleteMoveToParent:
+			if bookmarks, err = repo.LockBookmarks([]uuid.UUID{id}); err != nil {
+				return err
+			}
+			if err := repo.MoveContents(id, folder.ParentID); err != nil {
+				return err
+			}
+			if err := repo.Delete(id); err != nil {
+				return err
+			}
+			for _, b := range bookmarks {
+				err := s.events.Publish(tx, model.EventBookmarkMoved, b.ID, model.BookmarkMovedEvent{
+					BookmarkID:   b.ID,
+					UserID:       b.UserID,
+					FromFolderID: b.FolderID,
+					ToFolderID:   folder.ParentID,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+
+		case model.FolderDeleteCascade:
+			ids := tree.subtree(id)
+			if bookmarks, err = repo.LockBookmarks(ids); err != nil {
+				return err
+			}
+			if err := repo.DeleteTree(ids); err != nil {
+				return err
+			}
+			for _, b := range bookmarks {
+				err := s.events.Publish(tx, model.EventBookmarkDeleted, b.ID, model.BookmarkDeletedEvent{
+					BookmarkID:  b.ID,
+					UserID:      b.UserID,
+					WorkspaceID: b.WorkspaceID,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+
+		default:
+			return fmt.Errorf("unsupported delete mode: %s", mode)
+		}
+	})
 	if err != nil {
 		return err
 	}
-	s.logger.Info("Deleted bookmark folder", zap.String("id", id.String()))
+
+	s.invalidateBookmarkCaches(folder.UserID, bookmarks)
+	for _, b := range bookmarks {
+		if mode == model.FolderDeleteCascade {
+			s.activity.Record(b.UserID, b.ID, model.ActivityDeleted, map[string]interface{}{
+				"title":         b.Title,
+				"deletedFolder": id,
+			})
+		} else {
+			s.activity.Record(b.UserID, b.ID, model.ActivityMoved, map[string]interface{}{
+				"fromFolderId":  b.FolderID,
+				"toFolderId":    folder.ParentID,
+				"deletedFolder": id,
+			})
+		}
+	}
+	s.logger.Info("Deleted bookmark folder",
+		zap.String("id", id.String()),
+		zap.String("mode", string(mode)),
+		zap.Int("bookmarks", len(bookmarks)))
 	return nil
 }
 
-func (s *folderService) Reorder(items []model.ReorderItem) error {
+func (s *folderService) GetTree(userID uuid.UUID, workspaceID *uuid.UUID) ([]*model.FolderNode, error) {
+	var folders []model.BookmarkFolder
+	var err error
+	if workspaceID != nil {
+		folders, err = s.repo.GetByUserAndWorkspace(userID, *workspaceID)
+	} else {
+		folders, err = s.repo.GetByUser(userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.repo.CountBookmarks(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := newFolderTree(folders)
+	placed := map[uuid.UUID]bool{}
+	var build func(folder *model.BookmarkFolder, depth int) *model.FolderNode
+	build = func(folder *model.BookmarkFolder, depth int) *model.FolderNode {
+		placed[folder.ID] = true
+		node := &model.FolderNode{
+			BookmarkFolder: *folder,
+			Depth:          depth,
+			BookmarkCount:  counts[folder.ID],
+			Children:       []*model.FolderNode{},
+		}
+		node.TotalBookmarkCount = node.BookmarkCount
+		for _, child := range tree.children[folder.ID] {
+			if placed[child.ID] {
+				continue
+			}
+			childNode := build(child, depth+1)
+			node.Children = append(node.Children, childNode)
+			node.TotalBookmarkCount += childNode.TotalBookmarkCount
+		}
+		return node
+	}
+
+	roots := []*model.FolderNode{}
+	for i := range folders {
+		folder := &folders[i]
+		if folder.ParentID == nil || tree.byID[*folder.ParentID] == nil {
+			roots = append(roots, build(folder, 1))
+		}
+	}
+	// Folders caught in a cycle have no top-level ancestor; list them at the
+	// top level rather than leaving them out
+	for i := range folders {
+		if !placed[folders[i].ID] {
+			roots = append(roots, build(&folders[i], 1))
+		}
+	}
+	return roots, nil
+}
+
+// Reorder sets the positions of the user's folders in one statement;
+// folders of other users are left out.
+func (s *folderService) Reorder(userID uuid.UUID, items []model.ReorderItem) error {
+	folders, err := s.repo.GetByUser(userID)
+	if err != nil {
+		return err
+	}
+	owned := make(map[uuid.UUID]bool, len(folders))
+	for _, folder := range folders {
+		owned[folder.ID] = true
+	}
+
+	positions := make(map[uuid.UUID]int, len(items))
 	for _, item := range items {
-		id, err := uuid.Parse(item.ID)
-		if err != nil {
-			continue
+		if id, err := uuid.Parse(item.ID); err == nil && owned[id] {
+			positions[id] = item.Position
 		}
-		folder, err := s.repo.GetByID(id)
-		if err != nil {
-			continue
+	}
+	return s.repo.SetPositions(positions)
+}
+
+func (s *folderService) MoveNextTo(id, anchorID uuid.UUID, after bool) error {
+	if id == anchorID {
+		return fmt.Errorf("%w: a folder cannot be moved next to itself", ErrInvalidMove)
+	}
+	folder, err := s.repo.GetByID(id)
+	if err != nil {
+		return lookupError("folder", err)
+	}
+	anchor, err := s.repo.GetByID(anchorID)
+	if err != nil {
+		return lookupError("folder", err)
+	}
+	if anchor.UserID != folder.UserID || anchor.WorkspaceID != folder.WorkspaceID || !sameParent(anchor.ParentID, folder.ParentID) {
+		return fmt.Errorf("%w: the folders have different parents", ErrInvalidMove)
+	}
+	return s.repo.MoveNextTo(folder, anchorID, after)
+}
+
+// checkPlacement checks that folder may live under parentID: the parent
+// must be one of the owner's folders in the same workspace, must not be the
+// folder or inside it, and the result must not nest deeper than maxDepth.
+// It locks the user's folders through repo, which must be bound to the
+// transaction that places the folder, so a concurrent move cannot undo the
+// check before that transaction commits.
+func (s *folderService) checkPlacement(repo repository.FolderRepository, folder *model.BookmarkFolder, parentID *uuid.UUID) error {
+	if parentID == nil {
+		return nil
+	}
+	folders, err := repo.LockByUser(folder.UserID)
+	if err != nil {
+		return err
+	}
+	tree := newFolderTree(folders)
+	parent := tree.byID[*parentID]
+	if parent == nil || parent.WorkspaceID != folder.WorkspaceID {
+		return fmt.Errorf("parent folder %w", ErrNotFound)
+	}
+	for _, id := range tree.subtree(folder.ID) {
+		if id == parent.ID {
+			return ErrFolderCycle
 		}
-		folder.Position = item.Position
-		s.repo.Update(folder)
+	}
+	if depth := tree.depth(parent.ID) + tree.height(folder.ID); s.maxDepth > 0 && depth > s.maxDepth {
+		return fmt.Errorf("%w: at most %d levels are allowed", ErrFolderTooDeep, s.maxDepth)
 	}
 	return nil
 }
+
+func (s *folderService) invalidateBookmarkCaches(userID uuid.UUID, bookmarks []model.Bookmark) {
+	keys := []string{userBookmarksCacheKey(userID)}
+	for _, b := range bookmarks {
+		keys = append(keys, bookmarkCacheKey(b.ID))
+	}
+	s.redis.Del(context.Background(), keys...)
+}
+
+func sameParent(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// folderTree indexes a user's folders by ID and by parent. Its walks stop
+// at folders they have already seen, so cycles left by older data cannot
+// make them loop.
+type folderTree struct {
+	byID     map[uuid.UUID]*model.BookmarkFolder
+	children map[uuid.UUID][]*model.BookmarkFolder
+}
+
+func newFolderTree(folders []model.BookmarkFolder) *folderTree {
+	t := &folderTree{
+		byID:     make(map[uuid.UUID]*model.BookmarkFolder, len(folders)),
+		children: map[uuid.UUID][]*model.BookmarkFolder{},
+	}
+	for i := range folders {
+		folder := &folders[i]
+		t.byID[folder.ID] = folder
+		if folder.ParentID != nil {
+			t.children[*folder.ParentID] = append(t.children[*folder.ParentID], folder)
+		}
+	}
+	return t
+}
+
+// subtree returns id followed by all of its descendants.
+func (t *folderTree) subtree(id uuid.UUID) []uuid.UUID {
+	ids := []uuid.UUID{id}
+	seen := map[uuid.UUID]bool{id: true}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range t.children[ids[i]] {
+			if !seen[child.ID] {
+				seen[child.ID] = true
+				ids = append(ids, child.ID)
+			}
+		}
+	}
+	return ids
+}
+
+// depth returns the level of id, where top-level folders are level one.
+func (t *folderTree) depth(id uuid.UUID) int {
+	depth := 0
+	seen := map[uuid.UUID]bool{}
+	for folder := t.byID[id]; folder != nil && !seen[folder.ID]; {
+		seen[folder.ID] = true
+		depth++
+		if folder.ParentID == nil {
+			break
+		}
+		folder = t.byID[*folder.ParentID]
+	}
+	return depth
+}
+
+// height returns the number of levels in id's subtree, counting id itself.
+func (t *folderTree) height(id uuid.UUID) int {
+	height := 0
+	level := []uuid.UUID{id}
+	seen := map[uuid.UUID]bool{id: true}
+	for len(level) > 0 {
+		height++
+		var next []uuid.UUID
+		for _, parentID := range level {
+			for _, child := range t.children[parentID] {
+				if !seen[child.ID] {
+					seen[child.ID] = true
+					next = append(next, child.ID)
+				}
+			}
+		}
+		level = next
+	}
+	return height
+}
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+// importProgressInterval limits how often a running job's progress is saved.
+const importProgressInterval = time.Second
+
+// ErrImportQueueFull is returned when too many imports are already waiting.
+var ErrImportQueueFull = errors.New("too many imports in progress, try again later")
+
+// ImportJobService runs large imports in the background and reports on them.
+type ImportJobService interface {
+	// Submit stores the upload and queues it; the returned job can be polled
+	// for progress and holds the ImportResult once it completes.
+	Submit(userID, workspaceID uuid.UUID, folderID *uuid.UUID, format string, mapping model.CSVColumnMapping, r io.Reader) (*model.ImportJob, error)
+	Get(userID, jobID uuid.UUID) (*model.ImportJob, error)
+	List(userID uuid.UUID, page, limit int) ([]model.ImportJob, int64, error)
+}
+
+type importTask struct {
+	job      *model.ImportJob
+	importer Importer
+	path     string
+	size     int64
+}
+
+// ImportJobRunner accepts import uploads and runs them on a pool of workers.
+type ImportJobRunner struct {
+	periodicJob
+	service    ImportService
+	repo       repository.ImportJobRepository
+	folderRepo repository.FolderRepository
+	workers    int
+	heartbeat  time.Duration
+	tempDir    string
+	now        Clock
+	logger     *zap.Logger
+
+	queue  chan importTask
+	mu     sync.Mutex
+	active map[uuid.UUID]bool
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewImportJobRunner runs imports on workers goroutines and queues up to
+// queueSize more. Uploads wait in tempDir until their job has run. Every
+// heartbeat the runner marks its jobs as alive; jobs that miss several
+// heartbeats, because their instance stopped, are failed.
+func NewImportJobRunner(
+	service ImportService,
+	repo repository.ImportJobRepository,
+	folderRepo repository.FolderRepository,
+	workers, queueSize int,
+	heartbeat time.Duration,
+	tempDir string,
+	clock Clock,
+	logger *zap.Logger,
+) *ImportJobRunner {
+	if workers <= 0 {
+		workers = 1
+	}
+	if queueSize <= 0 {
+		queueSize = 1
+	}
+	if tempDir == "" {
+		tempDir = os.TempDir()
+	}
+	if clock == nil {
+		clock = time.Now
+	}
+	return &ImportJobRunner{
+		service:    service,
+		repo:       repo,
+		folderRepo: folderRepo,
+		workers:    workers,
+		heartbeat:  heartbeat,
+		tempDir:    tempDir,
+		now:        clock,
+		logger:     logger,
+		queue:      make(chan importTask, queueSize),
+		active:     map[uuid.UUID]bool{},
+	}
+}
+
+func (r *ImportJobRunner) Start(ctx context.Context) {
+	r.logger.Info("Starting import job runner", zap.Int("workers", r.workers))
+
+	var workerCtx context.Context
+	workerCtx, r.cancel = context.WithCancel(ctx)
+	for i := 0; i < r.workers; i++ {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			for {
+				select {
+				case <-workerCtx.Done():
+					return
+				case task := <-r.queue:
+					r.run(workerCtx, task)
+				}
+			}
+		}()
+	}
+
+	r.start(ctx, r.heartbeat, r.beat)
+}
+
+// Stop interrupts running imports and fails the queued ones; what was
+// imported before that is kept.
+func (r *ImportJobRunner) Stop() {
+	r.stop()
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.wg.Wait()
+
+	for {
+		select {
+		case task := <-r.queue:
+			r.finish(task, nil, errors.New("interrupted by shutdown"))
+		default:
+			r.logger.Info("Stopped import job runner")
+			return
+		}
+	}
+}
+
+func (r *ImportJobRunner) Submit(userID, workspaceID uuid.UUID, folderID *uuid.UUID, format string, mapping model.CSVColumnMapping, body io.Reader) (*model.ImportJob, error) {
+	importer, err := NewImporter(format, mapping)
+	if err != nil {
+		return nil, err
+	}
+	if folderID != nil {
+		folder, err := r.folderRepo.GetByID(*folderID)
+		if err != nil || folder.UserID != userID {
+			return nil, fmt.Errorf("folder %w", ErrNotFound)
+		}
+	}
+
+	file, err := os.CreateTemp(r.tempDir, "bookmark-import-*")
+	if err != nil {
+		return nil, err
+	}
+	size, err := io.Copy(file, body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return nil, err
+	}
+
+	job := &model.ImportJob{
+		UserID:      userID,
+		WorkspaceID: workspaceID,
+		FolderID:    folderID,
+		Format:      format,
+		Status:      model.ImportJobQueued,
+	}
+	if err := r.repo.Create(job); err != nil {
+		os.Remove(file.Name())
+		return nil, err
+	}
+
+	task := importTask{job: job, importer: importer, path: file.Name(), size: size}
+	r.track(job.ID, true)
+	select {
+	case r.queue <- task:
+	default:
+		r.finish(task, nil, ErrImportQueueFull)
+		return nil, ErrImportQueueFull
+	}
+
+	r.logger.Info("Queued import job",
+		zap.String("jobId", job.ID.String()),
+		zap.String("format", format),
+		zap.Int64("bytes", size))
+	return job, nil
+}
+
+func (r *ImportJobRunner) Get(userID, jobID uuid.UUID) (*model.ImportJob, error) {
+	job, err := r.repo.GetByID(jobID)
+	if err != nil || job.UserID != userID {
+		return nil, fmt.Errorf("import job %w", ErrNotFound)
+	}
+	return job, nil
+}
+
+func (r *ImportJobRunner) List(userID uuid.UUID, page, limit int) ([]model.ImportJob, int64, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return r.repo.GetByUser(userID, limit, page*limit)
+}
+
+func (r *ImportJobRunner) run(ctx context.Context, task importTask) {
+	job := task.job
+	started := r.now()
+	job.Status = model.ImportJobRunning
+	job.StartedAt = &started
+	if err := r.repo.Update(job); err != nil {
+		r.logger.Error("Failed to start import job", zap.String("jobId", job.ID.String()), zap.Error(err))
+	}
+
+	file, err := os.Open(task.path)
+	if err != nil {
+		r.finish(task, nil, err)
+		return
+	}
+	defer file.Close()
+
+	counter := &progressReader{ctx: ctx, r: file}
+	lastSaved := started
+	result, err := r.service.Import(job.UserID, job.WorkspaceID, job.FolderID, task.importer, counter, func(result *model.ImportResult) {
+		job.Processed++
+		if now := r.now(); now.Sub(lastSaved) >= importProgressInterval {
+			lastSaved = now
+			job.Progress = percent(counter.n, task.size)
+			job.Imported, job.Failed = result.Imported, result.Failed
+			if err := r.repo.Update(job); err != nil {
+				r.logger.Warn("Failed to save import progress", zap.String("jobId", job.ID.String()), zap.Error(err))
+			}
+		}
+	})
+	if err != nil && ctx.Err() != nil {
+		err = errors.New("interrupted by shutdown")
+	}
+	r.finish(task, result, err)
+}
+
+// finish records the job's outcome and removes its upload.
+func (r *ImportJobRunner) finish(task importTask, result *model.ImportResult, err error) {
+	job := task.job
+	finished := r.now()
+	job.FinishedAt = &finished
+	job.Result = result
+	if result != nil {
+		job.Imported, job.Failed = result.Imported, result.Failed
+	}
+	if err != nil {
+		job.Status = model.ImportJobFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = model.ImportJobCompleted
+		job.Progress = 100
+	}
+	if updateErr := r.repo.Update(job); updateErr != nil {
+		r.logger.Error("Failed to save import job", zap.String("jobId", job.ID.String()), zap.Error(updateErr))
+	}
+
+	os.Remove(task.path)
+	r.track(job.ID, false)
+	r.logger.Info("Finished import job",
+		zap.String("jobId", job.ID.String()),
+		zap.String("status", string(job.Status)),
+		zap.Int("imported", job.Imported),
+		zap.Int("failed", job.Failed))
+}
+
+func (r *ImportJobRunner) track(jobID uuid.UUID, active bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if active {
+		r.active[jobID] = true
+	} else {
+		delete(r.active, jobID)
+	}
+}
+
+// beat keeps this instance's jobs alive and fails jobs whose instance
+// has stopped sending heartbeats.
+func (r *ImportJobRunner) beat(ctx context.Context) {
+	now := r.now()
+
+	r.mu.Lock()
+	ids := make([]uuid.UUID, 0, len(r.active))
+	for id := range r.active {
+		ids = append(ids, id)
+	}
+	r.mu.Unlock()
+
+	if err := r.repo.Touch(ids, now); err != nil {
+		r.logger.Error("Failed to touch import jobs", zap.Error(err))
+		return
+	}
+	failed, err := r.repo.FailStale(now.Add(-3*r.heartbeat), "interrupted: the import stopped responding")
+	if err != nil {
+		r.logger.Error("Failed to fail stale import jobs", zap.Error(err))
+	} else if failed > 0 {
+		r.logger.Warn("Failed stale import jobs", zap.Int64("count", failed))
+	}
+}
+
+func percent(n, total int64) int {
+	if total <= 0 {
+		return 0
+	}
+	p := int(n * 100 / total)
+	if p > 99 {
+		// 100 is reserved for completed jobs
+		p = 99
+	}
+	return p
+}
+
+// progressReader counts the bytes read and stops reading once ctx is done.
+type progressReader struct {
+	ctx context.Context
+	r   io.Reader
+	n   int64
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	if err := p.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := p.r.Read(b)
+	p.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,554 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/repository"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// maxImportErrors caps the per-item errors returned in an ImportResult so a
+// badly broken file does not produce an enormous response.
+const maxImportErrors = 100
+
+// ImportService moves bookmarks in and out of the file formats other
+// bookmark managers use.
+type ImportService interface {
+	ImportNetscape(userID, workspaceID uuid.UUID, folderID *uuid.UUID, r io.Reader) (*model.ImportResult, error)
+	ExportNetscape(userID uuid.UUID, workspaceID *uuid.UUID, w io.Writer) error
+	// Import reads r with importer, recreating its folder paths under
+	// folderID. progress, if set, is called after every bookmark.
+	Import(userID, workspaceID uuid.UUID, folderID *uuid.UUID, importer Importer, r io.Reader, progress func(result *model.ImportResult)) (*model.ImportResult, error)
+}
+
+type importService struct {
+	bookmarkRepo  repository.BookmarkRepository
+	folderRepo    repository.FolderRepository
+	tagRepo       repository.TagRepository
+	noteRepo      repository.NoteRepository
+	favoriteRepo  repository.FavoriteRepository
+	readLaterRepo repository.ReadLaterRepository
+	previews      PreviewService
+	rules         RuleEngine
+	activity      ActivityRecorder
+	events        EventBus
+	tx            repository.Transactor
+	redis         *redis.Client
+	logger        *zap.Logger
+}
+
+func NewImportService(
+	bookmarkRepo repository.BookmarkRepository,
+	folderRepo repository.FolderRepository,
+	tagRepo repository.TagRepository,
+	noteRepo repository.NoteRepository,
+	favoriteRepo repository.FavoriteRepository,
+	readLaterRepo repository.ReadLaterRepository,
+	previews PreviewService,
+	rules RuleEngine,
+	activity ActivityRecorder,
+	events EventBus,
+	tx repository.Transactor,
+	redis *redis.Client,
+	logger *zap.Logger,
+) ImportService {
+	return &importService{
+		bookmarkRepo:  bookmarkRepo,
+		folderRepo:    folderRepo,
+		tagRepo:       tagRepo,
+		noteRepo:      noteRepo,
+		favoriteRepo:  favoriteRepo,
+		readLaterRepo: readLaterRepo,
+		previews:      previews,
+		rules:         rules,
+		activity:      activity,
+		events:        events,
+		tx:            tx,
+		redis:         redis,
+		logger:        logger,
+	}
+}
+
+// ImportNetscape imports a browser bookmarks.html under folderID, or at the
+// top level when folderID is nil. Folders are matched by name so importing
+// the same file twice does not duplicate the hierarchy.
+func (s *importService) ImportNetscape(userID, workspaceID uuid.UUID, folderID *uuid.UUID, r io.Reader) (*model.ImportResult, error) {
+	im, err := s.newImporter(userID, workspaceID, folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	stack := []*uuid.UUID{folderID}
+	err = parseNetscape(r, netscapeVisitor{
+		enterFolder: func(name string, addedAt time.Time) error {
+			parent := stack[len(stack)-1]
+			id, err := im.folder(parent, name, addedAt)
+			if err != nil {
+				// Keep the folder's contents by importing them into the parent
+				im.fail(fmt.Sprintf("folder %q: %v", name, err))
+				id = parent
+			}
+			stack = append(stack, id)
+			return nil
+		},
+		leaveFolder: func() {
+			stack = stack[:len(stack)-1]
+		},
+		bookmark: func(b netscapeBookmark) error {
+			im.bookmark(importEntry{
+				FolderID:    stack[len(stack)-1],
+				Title:       b.Title,
+				URL:         b.URL,
+				Description: b.Description,
+				Tags:        b.Tags,
+				CreatedAt:   b.AddedAt,
+			})
+			return nil
+		},
+	})
+	if err != nil {
+		return im.result, fmt.Errorf("invalid bookmark file: %w", err)
+	}
+
+	s.logger.Info("Imported Netscape bookmarks",
+		zap.String("userId", userID.String()),
+		zap.Int("imported", im.result.Imported),
+		zap.Int("failed", im.result.Failed))
+	return im.result, nil
+}
+
+func (s *importService) Import(userID, workspaceID uuid.UUID, folderID *uuid.UUID, importer Importer, r io.Reader, progress func(result *model.ImportResult)) (*model.ImportResult, error) {
+	im, err := s.newImporter(userID, workspaceID, folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = importer.Parse(r, func(b ImportedBookmark) error {
+		parent := folderID
+		for _, name := range b.Folders {
+			id, err := im.folder(parent, name, time.Time{})
+			if err != nil {
+				im.fail(fmt.Sprintf("folder %q: %v", name, err))
+				break
+			}
+			parent = id
+		}
+		im.bookmark(importEntry{
+			FolderID:    parent,
+			Title:       b.Title,
+			URL:         b.URL,
+			Description: b.Description,
+			Note:        b.Note,
+			Tags:        b.Tags,
+			CreatedAt:   b.CreatedAt,
+			ReadLater:   b.ReadLater,
+			Favorite:    b.Favorite,
+		})
+		if progress != nil {
+			progress(im.result)
+		}
+		return nil
+	})
+	if err != nil {
+		return im.result, fmt.Errorf("invalid import file: %w", err)
+	}
+	return im.result, nil
+}
+
+// exportBatchSize is how many bookmarks an export reads at a time.
+const exportBatchSize = 500
+
+// ExportNetscape writes the user's bookmarks with a URL, nested by folder.
+// Bookmarks of internal types (messages, files, ...) have no URL a browser
+// could open and are left out. Bookmarks are read and written a batch at a
+// time, folder by folder, so exports of any size take little memory.
+func (s *importService) ExportNetscape(userID uuid.UUID, workspaceID *uuid.UUID, w io.Writer) error {
+	var folders []model.BookmarkFolder
+	var err error
+	if workspaceID != nil {
+		folders, err = s.folderRepo.GetByUserAndWorkspace(userID, *workspaceID)
+	} else {
+		folders, err = s.folderRepo.GetByUser(userID)
+	}
+	if err != nil {
+		return err
+	}
+	tags, err := s.tagRepo.GetByUser(userID)
+	if err != nil {
+		return err
+	}
+	ex := &netscapeExport{
+		service:     s,
+		userID:      userID,
+		workspaceID: workspaceID,
+		tagNames:    make(map[uuid.UUID]string, len(tags)),
+		children:    map[uuid.UUID][]model.BookmarkFolder{},
+		w:           newNetscapeWriter(w),
+	}
+	for _, t := range tags {
+		ex.tagNames[t.ID] = t.Name
+	}
+
+	parents := make(map[uuid.UUID]*uuid.UUID, len(folders))
+	known := make(map[uuid.UUID]bool, len(folders))
+	for _, f := range folders {
+		parents[f.ID] = f.ParentID
+		known[f.ID] = true
+	}
+	sort.SliceStable(folders, func(i, j int) bool { return folders[i].Position < folders[j].Position })
+	var top []model.BookmarkFolder
+	for _, f := range folders {
+		if f.ParentID != nil && known[*f.ParentID] && !inFolderCycle(parents, f.ID) {
+			ex.children[*f.ParentID] = append(ex.children[*f.ParentID], f)
+		} else {
+			top = append(top, f)
+		}
+	}
+
+	if err := ex.writeList(top, nil); err != nil {
+		return err
+	}
+	return ex.w.close()
+}
+
+// netscapeExport is the state of one ExportNetscape call.
+type netscapeExport struct {
+	service     *importService
+	userID      uuid.UUID
+	workspaceID *uuid.UUID
+	tagNames    map[uuid.UUID]string
+	children    map[uuid.UUID][]model.BookmarkFolder
+	w           *netscapeWriter
+}
+
+// writeList writes folders, each with its contents, followed by the
+// bookmarks in folderID.
+func (ex *netscapeExport) writeList(folders []model.BookmarkFolder, folderID *uuid.UUID) error {
+	for _, f := range folders {
+		ex.w.openFolder(f.Name, f.CreatedAt, f.UpdatedAt)
+		id := f.ID
+		if err := ex.writeList(ex.children[f.ID], &id); err != nil {
+			return err
+		}
+		ex.w.closeFolder()
+	}
+
+	return ex.service.bookmarkRepo.StreamForExport(ex.userID, ex.workspaceID, folderID, exportBatchSize, func(bookmarks []model.Bookmark) error {
+		tags, err := ex.bookmarkTags(bookmarks)
+		if err != nil {
+			return err
+		}
+		for _, b := range bookmarks {
+			ex.w.bookmark(netscapeBookmark{
+				Title:       b.Title,
+				URL:         b.TargetURL,
+				Description: b.Description,
+				Tags:        tags[b.ID],
+				AddedAt:     b.CreatedAt,
+				UpdatedAt:   b.UpdatedAt,
+			})
+		}
+		return nil
+	})
+}
+
+// bookmarkTags returns the tag names of a batch of bookmarks.
+func (ex *netscapeExport) bookmarkTags(bookmarks []model.Bookmark) (map[uuid.UUID][]string, error) {
+	ids := make([]uuid.UUID, len(bookmarks))
+	for i, b := range bookmarks {
+		ids[i] = b.ID
+	}
+	mappings, err := ex.service.tagRepo.GetMappingsByBookmarks(ids)
+	if err != nil {
+		return nil, err
+	}
+	byBookmark := map[uuid.UUID][]string{}
+	for _, m := range mappings {
+		if name, ok := ex.tagNames[m.TagID]; ok {
+			byBookmark[m.BookmarkID] = append(byBookmark[m.BookmarkID], name)
+		}
+	}
+	return byBookmark, nil
+}
+
+// inFolderCycle reports whether following id's parents leads back to id.
+// Such folders are exported at the top level instead of being dropped.
+func inFolderCycle(parents map[uuid.UUID]*uuid.UUID, id uuid.UUID) bool {
+	seen := map[uuid.UUID]bool{}
+	for cur := parents[id]; cur != nil; cur = parents[*cur] {
+		if *cur == id {
+			return true
+		}
+		if seen[*cur] {
+			// A cycle further up that does not include id; attach id normally
+			return false
+		}
+		seen[*cur] = true
+	}
+	return false
+}
+
+// importEntry is a bookmark read from an import file, already placed in a folder.
+type importEntry struct {
+	FolderID    *uuid.UUID
+	Title       string
+	URL         string
+	Description string
+	Note        string
+	Tags        []string
+	CreatedAt   time.Time
+	ReadLater   bool
+	Favorite    bool
+}
+
+type folderKey struct {
+	parent uuid.UUID
+	name   string
+}
+
+// importer creates the folders, tags and bookmarks of one import, reusing
+// folders and tags the user already has by name.
+type importer struct {
+	s           *importService
+	userID      uuid.UUID
+	workspaceID uuid.UUID
+	folders     map[folderKey]uuid.UUID
+	tags        map[string]uuid.UUID
+	rules       *RuleSet
+	result      *model.ImportResult
+}
+
+func (s *importService) newImporter(userID, workspaceID uuid.UUID, folderID *uuid.UUID) (*importer, error) {
+	if folderID != nil {
+		folder, err := s.folderRepo.GetByID(*folderID)
+		if err != nil || folder.UserID != userID {
+			return nil, fmt.Errorf("folder %w", ErrNotFound)
+		}
+	}
+
+	folders, err := s.folderRepo.GetByUserAndWorkspace(userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := s.tagRepo.GetByUserAndWorkspace(userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := s.rules.Load(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	im := &importer{
+		s:           s,
+		userID:      userID,
+		workspaceID: workspaceID,
+		folders:     make(map[folderKey]uuid.UUID, len(folders)),
+		tags:        make(map[string]uuid.UUID, len(tags)),
+		rules:       rules,
+		result:      &model.ImportResult{},
+	}
+	for _, f := range folders {
+		im.folders[folderKeyOf(f.ParentID, f.Name)] = f.ID
+	}
+	for _, t := range tags {
+		im.tags[strings.ToLower(t.Name)] = t.ID
+	}
+	return im, nil
+}
+
+func folderKeyOf(parent *uuid.UUID, name string) folderKey {
+	key := folderKey{name: strings.ToLower(strings.TrimSpace(name))}
+	if parent != nil {
+		key.parent = *parent
+	}
+	return key
+}
+
+// folder returns the ID of the named folder under parent, creating it if needed.
+func (im *importer) folder(parent *uuid.UUID, name string, createdAt time.Time) (*uuid.UUID, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "Untitled"
+	}
+	if len([]rune(name)) > 100 {
+		name = string([]rune(name)[:100])
+	}
+
+	key := folderKeyOf(parent, name)
+	if id, ok := im.folders[key]; ok {
+		return &id, nil
+	}
+
+	folder := &model.BookmarkFolder{
+		UserID:      im.userID,
+		WorkspaceID: im.workspaceID,
+		ParentID:    parent,
+		Name:        name,
+		CreatedAt:   createdAt,
+	}
+	if err := im.s.folderRepo.Create(folder); err != nil {
+		return nil, err
+	}
+	im.folders[key] = folder.ID
+	return &folder.ID, nil
+}
+
+// bookmark creates one external bookmark along with its tags, note, favorite
+// and read-later entry, runs the user's rules on it and records the outcome
+// in the import result. Like any new bookmark it publishes a created event,
+// queues a preview and is recorded in the activity log.
+func (im *importer) bookmark(entry importEntry) {
+	if entry.URL == "" {
+		im.fail(fmt.Sprintf("%q: missing URL", entry.Title))
+		return
+	}
+	if err := validateImportURL(entry.URL); err != nil {
+		im.fail(fmt.Sprintf("%q: %v", entry.URL, err))
+		return
+	}
+
+	title := strings.TrimSpace(entry.Title)
+	if title == "" {
+		title = entry.URL
+	}
+	if len([]rune(title)) > 255 {
+		title = string([]rune(title)[:255])
+	}
+
+	bookmark := &model.Bookmark{
+		UserID:      im.userID,
+		WorkspaceID: im.workspaceID,
+		FolderID:    entry.FolderID,
+		Type:        model.BookmarkTypeExternal,
+		Title:       title,
+		Description: entry.Description,
+		TargetID:    uuid.New(),
+		TargetURL:   entry.URL,
+		CreatedAt:   entry.CreatedAt,
+	}
+	outcome := im.s.rules.Prepare(im.rules, bookmark)
+	err := im.s.tx.Transaction(func(tx *gorm.DB) error {
+		if err := im.s.bookmarkRepo.WithTx(tx).Create(bookmark); err != nil {
+			return err
+		}
+		return im.s.events.Publish(tx, model.EventBookmarkCreated, bookmark.ID, bookmark)
+	})
+	if err != nil {
+		im.fail(fmt.Sprintf("%q: %v", entry.URL, err))
+		return
+	}
+	im.result.Imported++
+	im.s.redis.Del(context.Background(), userBookmarksCacheKey(im.userID))
+
+	// As with bookmarks created one at a time, a failure to queue the
+	// preview must not fail the import
+	if _, err := im.s.previews.Generate(bookmark.ID, bookmark.TargetURL); err != nil {
+		im.s.logger.Warn("Failed to queue preview", zap.String("id", bookmark.ID.String()), zap.Error(err))
+	}
+	details := map[string]interface{}{
+		"type":     bookmark.Type,
+		"title":    bookmark.Title,
+		"folderId": bookmark.FolderID,
+		"imported": true,
+	}
+	if outcome != nil {
+		details["ruleIds"] = outcome.RuleIDs
+	}
+	im.s.activity.Record(im.userID, bookmark.ID, model.ActivityCreated, details)
+
+	tagged := map[uuid.UUID]bool{}
+	for _, name := range entry.Tags {
+		tagID, err := im.tag(name)
+		if err == nil && tagged[tagID] {
+			continue
+		}
+		if err == nil {
+			tagged[tagID] = true
+			err = im.s.tagRepo.AddTagToBookmark(&model.BookmarkTagMapping{BookmarkID: bookmark.ID, TagID: tagID})
+		}
+		if err != nil {
+			im.s.logger.Warn("Failed to tag imported bookmark",
+				zap.String("bookmarkId", bookmark.ID.String()),
+				zap.String("tag", name),
+				zap.Error(err))
+		}
+	}
+
+	if note := strings.TrimSpace(entry.Note); note != "" {
+		err := im.s.noteRepo.Create(&model.BookmarkNote{BookmarkID: bookmark.ID, UserID: im.userID, Content: note})
+		im.warn(bookmark.ID, "note", err)
+	}
+	if entry.Favorite {
+		err := im.s.favoriteRepo.Create(&model.BookmarkFavorite{UserID: im.userID, BookmarkID: bookmark.ID})
+		im.warn(bookmark.ID, "favorite", err)
+	}
+	if entry.ReadLater {
+		err := im.s.readLaterRepo.Create(&model.ReadLaterItem{
+			UserID:     im.userID,
+			BookmarkID: bookmark.ID,
+			Status:     model.ReadLaterStatusUnread,
+		})
+		im.warn(bookmark.ID, "read-later item", err)
+	}
+	im.warn(bookmark.ID, "rule actions", im.s.rules.Apply(nil, bookmark, outcome))
+}
+
+// warn logs a failure to import something attached to a bookmark; the
+// bookmark itself still counts as imported.
+func (im *importer) warn(bookmarkID uuid.UUID, what string, err error) {
+	if err != nil {
+		im.s.logger.Warn("Failed to import "+what,
+			zap.String("bookmarkId", bookmarkID.String()),
+			zap.Error(err))
+	}
+}
+
+func (im *importer) tag(name string) (uuid.UUID, error) {
+	name = strings.TrimSpace(name)
+	if len([]rune(name)) > 50 {
+		name = string([]rune(name)[:50])
+	}
+	key := strings.ToLower(name)
+	if id, ok := im.tags[key]; ok {
+		return id, nil
+	}
+
+	tag := &model.BookmarkTag{UserID: im.userID, WorkspaceID: im.workspaceID, Name: name}
+	if err := im.s.tagRepo.Create(tag); err != nil {
+		return uuid.Nil, err
+	}
+	im.tags[key] = tag.ID
+	return tag.ID, nil
+}
+
+func (im *importer) fail(msg string) {
+	im.result.Failed++
+	if len(im.result.Errors) < maxImportErrors {
+		im.result.Errors = append(im.result.Errors, msg)
+	}
+}
+
+func validateImportURL(raw string) error {
+	if len(raw) > 500 {
+		return fmt.Errorf("URL longer than 500 characters")
+	}
+	scheme, _, ok := strings.Cut(raw, ":")
+	if !ok {
+		return fmt.Errorf("URL has no scheme")
+	}
+	switch strings.ToLower(scheme) {
+	case "javascript", "data", "place", "about", "chrome", "file":
+		return fmt.Errorf("unsupported URL scheme %q", scheme)
+	}
+	return nil
+}
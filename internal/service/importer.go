@@ -0,0 +1,126 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quckapp/bookmark-service/internal/model"
+)
+
+// ErrUnsupportedImportFormat is returned for an import format no Importer handles.
+var ErrUnsupportedImportFormat = errors.New("unsupported import format")
+
+// ImportedBookmark is one bookmark read from another service's export.
+type ImportedBookmark struct {
+	// Folders is the folder path below the import folder, outermost first.
+	Folders     []string
+	Title       string
+	URL         string
+	Description string
+	Note        string
+	Tags        []string
+	CreatedAt   time.Time
+	ReadLater   bool
+	Favorite    bool
+}
+
+// Importer parses one export format. Parse hands each bookmark to emit as
+// soon as it has been read, so files of any size can be imported.
+type Importer interface {
+	Parse(r io.Reader, emit func(b ImportedBookmark) error) error
+}
+
+// NewImporter returns the Importer for format. mapping is only used by the
+// generic CSV importer.
+func NewImporter(format string, mapping model.CSVColumnMapping) (Importer, error) {
+	switch format {
+	case model.ImportFormatNetscape:
+		return netscapeImporter{}, nil
+	case model.ImportFormatPocket:
+		return pocketImporter{}, nil
+	case model.ImportFormatRaindrop:
+		return newRaindropImporter(), nil
+	case model.ImportFormatPinboard:
+		return pinboardImporter{}, nil
+	case model.ImportFormatCSV:
+		return &csvImporter{mapping: mapping}, nil
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnsupportedImportFormat, format)
+}
+
+// netscapeImporter reads browser bookmark files; see parseNetscape.
+type netscapeImporter struct{}
+
+func (netscapeImporter) Parse(r io.Reader, emit func(b ImportedBookmark) error) error {
+	var folders []string
+	return parseNetscape(r, netscapeVisitor{
+		enterFolder: func(name string, addedAt time.Time) error {
+			folders = append(folders, name)
+			return nil
+		},
+		leaveFolder: func() {
+			folders = folders[:len(folders)-1]
+		},
+		bookmark: func(b netscapeBookmark) error {
+			return emit(ImportedBookmark{
+				Folders:     append([]string(nil), folders...),
+				Title:       b.Title,
+				URL:         b.URL,
+				Description: b.Description,
+				Tags:        b.Tags,
+				CreatedAt:   b.AddedAt,
+			})
+		},
+	})
+}
+
+// importTimeLayouts are tried in order for dates in CSV and JSON exports.
+var importTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"01/02/2006 15:04:05",
+	"01/02/2006",
+}
+
+// parseImportTime reads a date or a Unix timestamp, returning the zero time
+// for anything else so the record is stamped with the import time instead.
+func parseImportTime(value string) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return netscapeTime(value)
+	}
+	for _, layout := range importTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func parseImportBool(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "true", "yes", "y", "unread", "toread":
+		return true
+	}
+	return false
+}
+
+func splitImportList(value, sep string) []string {
+	var items []string
+	for _, item := range strings.Split(value, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// LinkCheckJob periodically checks the external bookmarks whose links are
+// due for a health check.
+type LinkCheckJob struct {
+	periodicJob
+	service  LinkCheckService
+	interval time.Duration
+	now      Clock
+	logger   *zap.Logger
+}
+
+func NewLinkCheckJob(service LinkCheckService, interval time.Duration, clock Clock, logger *zap.Logger) *LinkCheckJob {
+	if clock == nil {
+		clock = time.Now
+	}
+	return &LinkCheckJob{
+		service:  service,
+		interval: interval,
+		now:      clock,
+		logger:   logger,
+	}
+}
+
+func (j *LinkCheckJob) Start(ctx context.Context) {
+	j.logger.Info("Starting link checker", zap.Duration("interval", j.interval))
+	j.start(ctx, j.interval, j.run)
+}
+
+func (j *LinkCheckJob) Stop() {
+	j.stop()
+	j.logger.Info("Stopped link checker")
+}
+
+func (j *LinkCheckJob) run(ctx context.Context) {
+	checked, err := j.service.CheckDue(ctx, j.now())
+	if err != nil {
+		j.logger.Error("Failed to check links", zap.Error(err))
+		return
+	}
+	if checked > 0 {
+		j.logger.Info("Checked bookmark links", zap.Int("count", checked))
+	}
+}
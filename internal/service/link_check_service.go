@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	linkCheckBatchSize = 100
+	// linkCheckLease is how long a claimed check is reserved for one instance.
+	linkCheckLease = 15 * time.Minute
+)
+
+// ErrNoPermanentRedirect is returned when a bookmark's URL is not known to
+// redirect permanently elsewhere.
+var ErrNoPermanentRedirect = errors.New("bookmark url has no permanent redirect")
+
+type LinkCheckService interface {
+	GetByBookmark(bookmarkID uuid.UUID) (*model.LinkCheck, error)
+	// GetByUser lists the user's broken links, or with
+	// model.LinkCheckFilterRedirected the ones that moved permanently.
+	GetByUser(userID uuid.UUID, filter string, page, limit int) ([]model.BrokenLink, int64, error)
+	// FollowRedirect points the bookmark at the URL its link permanently
+	// redirects to, recording a version; userID is the user making the change.
+	FollowRedirect(bookmarkID, userID uuid.UUID) (*model.Bookmark, error)
+	// CheckDue checks a batch of external bookmarks that are due and returns
+	// how many were checked.
+	CheckDue(ctx context.Context, now time.Time) (int, error)
+}
+
+type LinkCheckPolicy struct {
+	Workers int
+	// RecheckInterval is how long a healthy link goes unchecked.
+	RecheckInterval time.Duration
+	// RetryBaseDelay is the wait after the first failure; it doubles with
+	// each further failure up to RecheckInterval.
+	RetryBaseDelay time.Duration
+	// FailureThreshold is the number of failures in a row after which a link
+	// is reported broken.
+	FailureThreshold int
+}
+
+type linkCheckService struct {
+	repo            repository.LinkCheckRepository
+	bulkRepo        repository.BulkRepository
+	bookmarkService BookmarkService
+	checker         LinkChecker
+	policy          LinkCheckPolicy
+	logger          *zap.Logger
+}
+
+func NewLinkCheckService(
+	repo repository.LinkCheckRepository,
+	bulkRepo repository.BulkRepository,
+	bookmarkService BookmarkService,
+	checker LinkChecker,
+	policy LinkCheckPolicy,
+	logger *zap.Logger,
+) LinkCheckService {
+	if policy.Workers <= 0 {
+		policy.Workers = 1
+	}
+	if policy.RecheckInterval <= 0 {
+		policy.RecheckInterval = 7 * 24 * time.Hour
+	}
+	if policy.RetryBaseDelay <= 0 {
+		policy.RetryBaseDelay = time.Hour
+	}
+	if policy.FailureThreshold <= 0 {
+		policy.FailureThreshold = 3
+	}
+	return &linkCheckService{
+		repo:            repo,
+		bulkRepo:        bulkRepo,
+		bookmarkService: bookmarkService,
+		checker:         checker,
+		policy:          policy,
+		logger:          logger,
+	}
+}
+
+func (s *linkCheckService) GetByBookmark(bookmarkID uuid.UUID) (*model.LinkCheck, error) {
+	check, err := s.repo.GetByBookmark(bookmarkID)
+	if err != nil {
+		return nil, lookupError("link check", err)
+	}
+	return check, nil
+}
+
+func (s *linkCheckService) GetByUser(userID uuid.UUID, filter string, page, limit int) ([]model.BrokenLink, int64, error) {
+	offset := page * limit
+	checks, total, err := s.repo.GetByUser(userID, filter, limit, offset)
+	if err != nil || len(checks) == 0 {
+		return []model.BrokenLink{}, total, err
+	}
+
+	ids := make([]uuid.UUID, len(checks))
+	for i, check := range checks {
+		ids[i] = check.BookmarkID
+	}
+	bookmarks, err := s.bulkRepo.GetBookmarks(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	byID := make(map[uuid.UUID]model.Bookmark, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		byID[bookmark.ID] = bookmark
+	}
+
+	links := make([]model.BrokenLink, 0, len(checks))
+	for _, check := range checks {
+		if bookmark, ok := byID[check.BookmarkID]; ok {
+			links = append(links, model.BrokenLink{Bookmark: bookmark, Check: check})
+		}
+	}
+	return links, total, nil
+}
+
+func (s *linkCheckService) FollowRedirect(bookmarkID, userID uuid.UUID) (*model.Bookmark, error) {
+	check, err := s.GetByBookmark(bookmarkID)
+	if err != nil {
+		return nil, err
+	}
+	bookmark, err := s.bookmarkService.GetByID(bookmarkID)
+	if err != nil {
+		return nil, lookupError("bookmark", err)
+	}
+	// A check of an older URL says nothing about the current one
+	if !check.PermanentRedirect || check.URL != bookmark.TargetURL {
+		return nil, ErrNoPermanentRedirect
+	}
+
+	target := check.RedirectURL
+	updated, err := s.bookmarkService.Update(bookmarkID, &model.UpdateBookmarkRequest{
+		Title:       bookmark.Title,
+		Description: bookmark.Description,
+		TargetURL:   &target,
+		Metadata:    bookmark.Metadata,
+		ChangeNote:  "Followed permanent redirect from " + truncate(check.URL, 200),
+	}, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	check.URL = target
+	check.RedirectURL = ""
+	check.PermanentRedirect = false
+	if err := s.repo.Update(check); err != nil {
+		s.logger.Warn("Failed to update link check", zap.String("bookmarkId", bookmarkID.String()), zap.Error(err))
+	}
+	return updated, nil
+}
+
+func (s *linkCheckService) CheckDue(ctx context.Context, now time.Time) (int, error) {
+	bookmarks, err := s.repo.GetDue(now, linkCheckBatchSize)
+	if err != nil || len(bookmarks) == 0 {
+		return 0, err
+	}
+	ids := make([]uuid.UUID, len(bookmarks))
+	for i, bookmark := range bookmarks {
+		ids[i] = bookmark.ID
+	}
+	existing, err := s.repo.GetByBookmarks(ids)
+	if err != nil {
+		return 0, err
+	}
+	checks := make(map[uuid.UUID]*model.LinkCheck, len(existing))
+	for i := range existing {
+		checks[existing[i].BookmarkID] = &existing[i]
+	}
+
+	jobs := make(chan *model.Bookmark)
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		checked int
+	)
+	for i := 0; i < s.policy.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for bookmark := range jobs {
+				check := checks[bookmark.ID]
+				if check == nil {
+					check = &model.LinkCheck{BookmarkID: bookmark.ID, UserID: bookmark.UserID, URL: bookmark.TargetURL}
+				}
+				if s.check(ctx, bookmark, check, now) {
+					mu.Lock()
+					checked++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	// Interleave hosts so one slow, rate-limited host does not hold up the rest
+	for _, bookmark := range interleaveByHost(bookmarks) {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- bookmark
+	}
+	close(jobs)
+	wg.Wait()
+	return checked, nil
+}
+
+// check claims and checks one link and reports whether it was checked.
+func (s *linkCheckService) check(ctx context.Context, bookmark *model.Bookmark, check *model.LinkCheck, now time.Time) bool {
+	claimed, err := s.repo.Claim(check, now, now.Add(linkCheckLease))
+	if err != nil {
+		s.logger.Error("Failed to claim link check", zap.String("bookmarkId", bookmark.ID.String()), zap.Error(err))
+		return false
+	}
+	if !claimed {
+		return false
+	}
+
+	result, checkErr := s.checker.Check(ctx, bookmark.TargetURL)
+	if ctx.Err() != nil {
+		// Shutting down; the lease expires and the link is checked again later
+		return false
+	}
+	applyLinkCheck(check, bookmark.TargetURL, result, checkErr, time.Now(), s.policy)
+	if err := s.repo.Update(check); err != nil {
+		s.logger.Error("Failed to save link check", zap.String("bookmarkId", bookmark.ID.String()), zap.Error(err))
+		return false
+	}
+	if check.IsBroken && check.ConsecutiveFailures == s.policy.FailureThreshold {
+		s.logger.Info("Link is broken",
+			zap.String("bookmarkId", bookmark.ID.String()),
+			zap.Int("statusCode", check.StatusCode),
+			zap.String("error", check.Error))
+	}
+	return true
+}
+
+// applyLinkCheck records the outcome of checking targetURL on check and
+// schedules the next check.
+func applyLinkCheck(check *model.LinkCheck, targetURL string, result *LinkCheckResult, checkErr error, now time.Time, policy LinkCheckPolicy) {
+	if check.URL != targetURL {
+		// The bookmark was pointed elsewhere; earlier failures no longer count
+		check.ConsecutiveFailures = 0
+		check.IsBroken = false
+	}
+	check.URL = targetURL
+	check.LastCheckedAt = &now
+	check.StatusCode = 0
+	check.RedirectURL = ""
+	check.PermanentRedirect = false
+	check.Error = ""
+
+	if checkErr != nil {
+		check.Error = truncate(checkErr.Error(), 255)
+	} else {
+		check.StatusCode = result.StatusCode
+		if result.FinalURL != "" && result.FinalURL != targetURL {
+			check.RedirectURL = truncate(result.FinalURL, 500)
+			check.PermanentRedirect = result.Permanent && result.StatusCode < 400 && len(result.FinalURL) <= 500
+		}
+	}
+
+	switch {
+	case checkErr == nil && result.StatusCode == http.StatusTooManyRequests:
+		// Rate limited by the site: not a sign the link is dead
+		check.NextCheckAt = now.Add(policy.RetryBaseDelay)
+	case checkErr != nil || result.StatusCode >= 400:
+		check.ConsecutiveFailures++
+		check.IsBroken = check.ConsecutiveFailures >= policy.FailureThreshold
+		check.NextCheckAt = now.Add(linkRetryDelay(check.ConsecutiveFailures, policy))
+	default:
+		check.ConsecutiveFailures = 0
+		check.IsBroken = false
+		check.NextCheckAt = now.Add(policy.RecheckInterval)
+	}
+}
+
+func linkRetryDelay(failures int, policy LinkCheckPolicy) time.Duration {
+	delay := policy.RetryBaseDelay
+	for i := 1; i < failures && delay < policy.RecheckInterval; i++ {
+		delay *= 2
+	}
+	if delay > policy.RecheckInterval {
+		delay = policy.RecheckInterval
+	}
+	return delay
+}
+
+// interleaveByHost orders bookmarks round-robin across their hosts, keeping
+// the order within each host.
+func interleaveByHost(bookmarks []model.Bookmark) []*model.Bookmark {
+	var hosts []string
+	byHost := map[string][]*model.Bookmark{}
+	for i := range bookmarks {
+		host := ""
+		if u, err := url.Parse(bookmarks[i].TargetURL); err == nil {
+			host = strings.ToLower(u.Host)
+		}
+		if _, seen := byHost[host]; !seen {
+			hosts = append(hosts, host)
+		}
+		byHost[host] = append(byHost[host], &bookmarks[i])
+	}
+
+	ordered := make([]*model.Bookmark, 0, len(bookmarks))
+	for len(ordered) < len(bookmarks) {
+		for _, host := range hosts {
+			if queue := byHost[host]; len(queue) > 0 {
+				ordered = append(ordered, queue[0])
+				byHost[host] = queue[1:]
+			}
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"go.uber.org/zap"
+)
+
+// memoryLinkCheckRepository keeps link checks in memory for CheckDue.
+type memoryLinkCheckRepository struct {
+	mu        sync.Mutex
+	bookmarks []model.Bookmark
+	checks    map[uuid.UUID]model.LinkCheck
+}
+
+func (r *memoryLinkCheckRepository) GetDue(now time.Time, limit int) ([]model.Bookmark, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var due []model.Bookmark
+	for _, b := range r.bookmarks {
+		check, ok := r.checks[b.ID]
+		if (!ok || !check.NextCheckAt.After(now)) && len(due) < limit {
+			due = append(due, b)
+		}
+	}
+	return due, nil
+}
+
+func (r *memoryLinkCheckRepository) GetByBookmark(bookmarkID uuid.UUID) (*model.LinkCheck, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	check, ok := r.checks[bookmarkID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &check, nil
+}
+
+func (r *memoryLinkCheckRepository) GetByBookmarks(bookmarkIDs []uuid.UUID) ([]model.LinkCheck, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var checks []model.LinkCheck
+	for _, id := range bookmarkIDs {
+		if check, ok := r.checks[id]; ok {
+			checks = append(checks, check)
+		}
+	}
+	return checks, nil
+}
+
+func (r *memoryLinkCheckRepository) GetByUser(userID uuid.UUID, filter string, limit, offset int) ([]model.LinkCheck, int64, error) {
+	return nil, 0, errors.New("not implemented")
+}
+
+func (r *memoryLinkCheckRepository) Claim(check *model.LinkCheck, now, leaseUntil time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if stored, ok := r.checks[check.BookmarkID]; ok && stored.NextCheckAt.After(now) {
+		return false, nil
+	}
+	if check.ID == uuid.Nil {
+		check.ID = uuid.New()
+	}
+	check.NextCheckAt = leaseUntil
+	r.checks[check.BookmarkID] = *check
+	return true, nil
+}
+
+func (r *memoryLinkCheckRepository) Update(check *model.LinkCheck) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[check.BookmarkID] = *check
+	return nil
+}
+
+func TestCheckDueSchedulesRetries(t *testing.T) {
+	status := http.StatusNotFound
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	bookmark := model.Bookmark{ID: uuid.New(), UserID: uuid.New(), Type: model.BookmarkTypeExternal, TargetURL: server.URL + "/page"}
+	repo := &memoryLinkCheckRepository{bookmarks: []model.Bookmark{bookmark}, checks: map[uuid.UUID]model.LinkCheck{}}
+	policy := LinkCheckPolicy{RecheckInterval: 24 * time.Hour, RetryBaseDelay: time.Hour, FailureThreshold: 2}
+	service := NewLinkCheckService(repo, nil, nil, newTestLinkChecker(5), policy, zap.NewNop())
+
+	// Each run is at the time the previous one scheduled
+	steps := []struct {
+		status   int
+		failures int
+		broken   bool
+		next     time.Duration
+	}{
+		{http.StatusNotFound, 1, false, time.Hour},
+		{http.StatusInternalServerError, 2, true, 2 * time.Hour},
+		{http.StatusNotFound, 3, true, 4 * time.Hour},
+		{http.StatusTooManyRequests, 3, true, time.Hour},
+		{http.StatusOK, 0, false, 24 * time.Hour},
+	}
+	now := time.Now()
+	for i, step := range steps {
+		mu.Lock()
+		status = step.status
+		mu.Unlock()
+
+		before := time.Now()
+		checked, err := service.CheckDue(context.Background(), now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		after := time.Now()
+		if checked != 1 {
+			t.Fatalf("step %d: checked %d links, want 1", i, checked)
+		}
+
+		check := repo.checks[bookmark.ID]
+		if check.StatusCode != step.status || check.ConsecutiveFailures != step.failures || check.IsBroken != step.broken {
+			t.Errorf("step %d: status %d, failures %d, broken %v; want %d, %d, %v",
+				i, check.StatusCode, check.ConsecutiveFailures, check.IsBroken, step.status, step.failures, step.broken)
+		}
+		if check.NextCheckAt.Before(before.Add(step.next)) || check.NextCheckAt.After(after.Add(step.next)) {
+			t.Errorf("step %d: next check in %v, want %v", i, check.NextCheckAt.Sub(before), step.next)
+		}
+
+		// Not due again until the scheduled time
+		if checked, _ := service.CheckDue(context.Background(), check.NextCheckAt.Add(-time.Second)); checked != 0 {
+			t.Errorf("step %d: checked %d links before they were due", i, checked)
+		}
+		now = check.NextCheckAt
+	}
+}
+
+func TestApplyLinkCheck(t *testing.T) {
+	policy := LinkCheckPolicy{RecheckInterval: 8 * time.Hour, RetryBaseDelay: time.Hour, FailureThreshold: 3}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	const target = "https://example.com/a"
+
+	tests := []struct {
+		name   string
+		check  model.LinkCheck
+		result *LinkCheckResult
+		err    error
+		want   model.LinkCheck
+	}{
+		{
+			name:   "healthy link resets failures",
+			check:  model.LinkCheck{URL: target, ConsecutiveFailures: 2},
+			result: &LinkCheckResult{StatusCode: 200},
+			want:   model.LinkCheck{URL: target, StatusCode: 200, NextCheckAt: now.Add(8 * time.Hour)},
+		},
+		{
+			name:  "network error counts as a failure",
+			check: model.LinkCheck{URL: target},
+			err:   errors.New("connection refused"),
+			want:  model.LinkCheck{URL: target, Error: "connection refused", ConsecutiveFailures: 1, NextCheckAt: now.Add(time.Hour)},
+		},
+		{
+			name:   "failures reach the threshold",
+			check:  model.LinkCheck{URL: target, ConsecutiveFailures: 2},
+			result: &LinkCheckResult{StatusCode: 503},
+			want:   model.LinkCheck{URL: target, StatusCode: 503, ConsecutiveFailures: 3, IsBroken: true, NextCheckAt: now.Add(4 * time.Hour)},
+		},
+		{
+			name:   "retry delay is capped by the recheck interval",
+			check:  model.LinkCheck{URL: target, ConsecutiveFailures: 9, IsBroken: true},
+			result: &LinkCheckResult{StatusCode: 404},
+			want:   model.LinkCheck{URL: target, StatusCode: 404, ConsecutiveFailures: 10, IsBroken: true, NextCheckAt: now.Add(8 * time.Hour)},
+		},
+		{
+			name:   "rate limiting is not a failure",
+			check:  model.LinkCheck{URL: target, ConsecutiveFailures: 1},
+			result: &LinkCheckResult{StatusCode: 429},
+			want:   model.LinkCheck{URL: target, StatusCode: 429, ConsecutiveFailures: 1, NextCheckAt: now.Add(time.Hour)},
+		},
+		{
+			name:   "a new url starts over",
+			check:  model.LinkCheck{URL: "https://example.com/old", ConsecutiveFailures: 5, IsBroken: true},
+			result: &LinkCheckResult{StatusCode: 404},
+			want:   model.LinkCheck{URL: target, StatusCode: 404, ConsecutiveFailures: 1, NextCheckAt: now.Add(time.Hour)},
+		},
+		{
+			name:   "permanent redirect",
+			check:  model.LinkCheck{URL: target},
+			result: &LinkCheckResult{StatusCode: 200, FinalURL: "https://example.com/b", Permanent: true},
+			want:   model.LinkCheck{URL: target, StatusCode: 200, RedirectURL: "https://example.com/b", PermanentRedirect: true, NextCheckAt: now.Add(8 * time.Hour)},
+		},
+		{
+			name:   "permanent redirect to a broken page",
+			check:  model.LinkCheck{URL: target},
+			result: &LinkCheckResult{StatusCode: 404, FinalURL: "https://example.com/b", Permanent: true},
+			want:   model.LinkCheck{URL: target, StatusCode: 404, RedirectURL: "https://example.com/b", ConsecutiveFailures: 1, NextCheckAt: now.Add(time.Hour)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := tt.check
+			applyLinkCheck(&check, target, tt.result, tt.err, now, policy)
+			tt.want.LastCheckedAt = &now
+			if check.StatusCode != tt.want.StatusCode || check.Error != tt.want.Error ||
+				check.ConsecutiveFailures != tt.want.ConsecutiveFailures || check.IsBroken != tt.want.IsBroken ||
+				check.RedirectURL != tt.want.RedirectURL || check.PermanentRedirect != tt.want.PermanentRedirect ||
+				check.URL != tt.want.URL || !check.NextCheckAt.Equal(tt.want.NextCheckAt) ||
+				check.LastCheckedAt == nil || !check.LastCheckedAt.Equal(now) {
+				t.Errorf("check =\n%+v\nwant\n%+v", check, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LinkCheckResult is what a link check saw at the end of the redirect chain.
+type LinkCheckResult struct {
+	StatusCode int
+	// FinalURL is the URL that answered when the request was redirected and
+	// empty otherwise.
+	FinalURL string
+	// Permanent is set when every redirect on the way was permanent.
+	Permanent bool
+}
+
+type LinkChecker interface {
+	// Check requests rawURL and follows its redirects. An error means no
+	// response was received; error statuses are reported in the result.
+	Check(ctx context.Context, rawURL string) (*LinkCheckResult, error)
+}
+
+type LinkCheckerConfig struct {
+	Timeout      time.Duration
+	MaxRedirects int
+	UserAgent    string
+	// HostInterval is the least time between two requests to one host.
+	HostInterval time.Duration
+	// AllowPrivateNetworks disables the SSRF guard. Only tests pointing at a
+	// local httptest server should set it.
+	AllowPrivateNetworks bool
+}
+
+type httpLinkChecker struct {
+	client  *http.Client
+	cfg     LinkCheckerConfig
+	limiter *hostLimiter
+}
+
+func NewLinkChecker(cfg LinkCheckerConfig) LinkChecker {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.MaxRedirects <= 0 {
+		cfg.MaxRedirects = 5
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = "QuckAppBookmarkBot/1.0"
+	}
+
+	client := &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: newGuardedTransport(cfg.Timeout, cfg.AllowPrivateNetworks),
+		// Redirects are followed by hand to see each status and rate limit each hop
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return &httpLinkChecker{client: client, cfg: cfg, limiter: newHostLimiter(cfg.HostInterval)}
+}
+
+func (c *httpLinkChecker) Check(ctx context.Context, rawURL string) (*LinkCheckResult, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if err := checkScheme(target); err != nil {
+		return nil, err
+	}
+
+	result, err := c.follow(ctx, http.MethodHead, target)
+	// Some servers reject HEAD, so error statuses are confirmed with a GET
+	if err == nil && result.StatusCode >= 400 && result.StatusCode != http.StatusTooManyRequests {
+		return c.follow(ctx, http.MethodGet, target)
+	}
+	return result, err
+}
+
+func (c *httpLinkChecker) follow(ctx context.Context, method string, target *url.URL) (*LinkCheckResult, error) {
+	result := &LinkCheckResult{Permanent: true}
+	for hops := 0; ; hops++ {
+		resp, err := c.do(ctx, method, target)
+		if err != nil {
+			return nil, err
+		}
+		// The body is not needed; read a little so the connection can be reused
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+		resp.Body.Close()
+
+		result.StatusCode = resp.StatusCode
+		location, err := resp.Location()
+		if !isRedirect(resp.StatusCode) || err != nil {
+			if hops == 0 {
+				result.Permanent = false
+			} else {
+				result.FinalURL = target.String()
+			}
+			return result, nil
+		}
+		if hops >= c.cfg.MaxRedirects {
+			return nil, fmt.Errorf("stopped after %d redirects", c.cfg.MaxRedirects)
+		}
+		if err := checkScheme(location); err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusMovedPermanently && resp.StatusCode != http.StatusPermanentRedirect {
+			result.Permanent = false
+		}
+		target = location
+	}
+}
+
+func (c *httpLinkChecker) do(ctx context.Context, method string, target *url.URL) (*http.Response, error) {
+	if err := c.limiter.wait(ctx, strings.ToLower(target.Host)); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.cfg.UserAgent)
+	return c.client.Do(req)
+}
+
+func isRedirect(status int) bool {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// hostLimiter spaces out requests to the same host by at least interval.
+type hostLimiter struct {
+	interval time.Duration
+	mu       sync.Mutex
+	next     map[string]time.Time
+}
+
+func newHostLimiter(interval time.Duration) *hostLimiter {
+	return &hostLimiter{interval: interval, next: map[string]time.Time{}}
+}
+
+// wait blocks until a request to host may be made and reserves that slot.
+func (l *hostLimiter) wait(ctx context.Context, host string) error {
+	if l.interval <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if len(l.next) > 1000 {
+		for h, at := range l.next {
+			if at.Before(now) {
+				delete(l.next, h)
+			}
+		}
+	}
+	at := l.next[host]
+	if at.Before(now) {
+		at = now
+	}
+	l.next[host] = at.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := at.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// newTestLinkChecker checks links on a local httptest server, which the SSRF
+// guard would otherwise refuse.
+func newTestLinkChecker(maxRedirects int) LinkChecker {
+	return NewLinkChecker(LinkCheckerConfig{MaxRedirects: maxRedirects, AllowPrivateNetworks: true})
+}
+
+// methodLog records the method and path of every request a server gets.
+type methodLog struct {
+	mu       sync.Mutex
+	requests []string
+}
+
+func (l *methodLog) add(r *http.Request) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.requests = append(l.requests, r.Method+" "+r.URL.Path)
+}
+
+func (l *methodLog) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return strings.Join(l.requests, ", ")
+}
+
+func TestLinkCheckerFallsBackToGet(t *testing.T) {
+	var log methodLog
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.add(r)
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	result, err := newTestLinkChecker(5).Check(context.Background(), server.URL+"/page")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.StatusCode != http.StatusOK {
+		t.Errorf("StatusCode = %d, want %d", result.StatusCode, http.StatusOK)
+	}
+	if got, want := log.String(), "HEAD /page, GET /page"; got != want {
+		t.Errorf("requests = %s, want %s", got, want)
+	}
+}
+
+func TestLinkCheckerTrustsHeadWhenRateLimited(t *testing.T) {
+	var log methodLog
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.add(r)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	result, err := newTestLinkChecker(5).Check(context.Background(), server.URL+"/page")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("StatusCode = %d, want %d", result.StatusCode, http.StatusTooManyRequests)
+	}
+	if got, want := log.String(), "HEAD /page"; got != want {
+		t.Errorf("requests = %s, want %s", got, want)
+	}
+}
+
+func TestLinkCheckerStatuses(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{"ok", http.StatusOK},
+		{"no content", http.StatusNoContent},
+		{"not found", http.StatusNotFound},
+		{"gone", http.StatusGone},
+		{"server error", http.StatusInternalServerError},
+		{"unavailable", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log methodLog
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				log.add(r)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			result, err := newTestLinkChecker(5).Check(context.Background(), server.URL+"/page")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.StatusCode != tt.status {
+				t.Errorf("StatusCode = %d, want %d", result.StatusCode, tt.status)
+			}
+			if result.FinalURL != "" || result.Permanent {
+				t.Errorf("unredirected result = %+v", result)
+			}
+			// Error statuses are confirmed with a GET
+			want := "HEAD /page"
+			if tt.status >= 400 {
+				want += ", GET /page"
+			}
+			if got := log.String(); got != want {
+				t.Errorf("requests = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestLinkCheckerRedirectChains(t *testing.T) {
+	tests := []struct {
+		name      string
+		chain     []int
+		final     int
+		permanent bool
+	}{
+		{"one permanent", []int{http.StatusMovedPermanently}, http.StatusOK, true},
+		{"all permanent", []int{http.StatusMovedPermanently, http.StatusPermanentRedirect}, http.StatusOK, true},
+		{"one temporary", []int{http.StatusFound}, http.StatusOK, false},
+		{"temporary on the way", []int{http.StatusMovedPermanently, http.StatusTemporaryRedirect, http.StatusPermanentRedirect}, http.StatusOK, false},
+		{"see other", []int{http.StatusSeeOther}, http.StatusOK, false},
+		{"ends broken", []int{http.StatusMovedPermanently}, http.StatusNotFound, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var hop int
+				fmt.Sscanf(r.URL.Path, "/hop/%d", &hop)
+				if hop < len(tt.chain) {
+					w.Header().Set("Location", fmt.Sprintf("/hop/%d", hop+1))
+					w.WriteHeader(tt.chain[hop])
+					return
+				}
+				w.WriteHeader(tt.final)
+			}))
+			defer server.Close()
+
+			result, err := newTestLinkChecker(5).Check(context.Background(), server.URL+"/hop/0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantURL := fmt.Sprintf("%s/hop/%d", server.URL, len(tt.chain))
+			if result.StatusCode != tt.final || result.FinalURL != wantURL || result.Permanent != tt.permanent {
+				t.Errorf("result = %+v, want status %d, final URL %s, permanent %v", result, tt.final, wantURL, tt.permanent)
+			}
+		})
+	}
+}
+
+func TestLinkCheckerRedirectLimit(t *testing.T) {
+	var log methodLog
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.add(r)
+		var hop int
+		fmt.Sscanf(r.URL.Path, "/hop/%d", &hop)
+		http.Redirect(w, r, fmt.Sprintf("/hop/%d", hop+1), http.StatusFound)
+	}))
+	defer server.Close()
+
+	_, err := newTestLinkChecker(3).Check(context.Background(), server.URL+"/hop/0")
+	if err == nil || !strings.Contains(err.Error(), "stopped after 3 redirects") {
+		t.Fatalf("err = %v, want the redirect limit", err)
+	}
+	if got, want := log.String(), "HEAD /hop/0, HEAD /hop/1, HEAD /hop/2, HEAD /hop/3"; got != want {
+		t.Errorf("requests = %s, want %s", got, want)
+	}
+}
+
+func TestLinkCheckerRejectsRedirectToOtherSchemes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "ftp://example.com/file")
+		w.WriteHeader(http.StatusMovedPermanently)
+	}))
+	defer server.Close()
+
+	_, err := newTestLinkChecker(5).Check(context.Background(), server.URL)
+	if !errors.Is(err, ErrURLNotAllowed) {
+		t.Errorf("err = %v, want ErrURLNotAllowed", err)
+	}
+}
+
+func TestLinkCheckerRefusesPrivateNetworks(t *testing.T) {
+	var requested atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested.Store(true)
+	}))
+	defer server.Close()
+
+	checker := NewLinkChecker(LinkCheckerConfig{})
+	_, err := checker.Check(context.Background(), server.URL)
+	if !errors.Is(err, ErrURLNotAllowed) {
+		t.Errorf("err = %v, want ErrURLNotAllowed", err)
+	}
+	if requested.Load() {
+		t.Error("the loopback server was requested")
+	}
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	xhtml "golang.org/x/net/html"
+)
+
+// netscapeBookmark is one <A> entry of a Netscape bookmark file.
+type netscapeBookmark struct {
+	Title       string
+	URL         string
+	Description string
+	Tags        []string
+	AddedAt     time.Time
+	UpdatedAt   time.Time
+}
+
+// netscapeVisitor receives the entries of a Netscape bookmark file in
+// document order. enterFolder and leaveFolder calls are balanced.
+type netscapeVisitor struct {
+	enterFolder func(name string, addedAt time.Time) error
+	leaveFolder func()
+	bookmark    func(b netscapeBookmark) error
+}
+
+// parseNetscape streams a Netscape Bookmark File (bookmarks.html) through v
+// without holding the document in memory. Folders are <H3> headings followed
+// by a nested <DL>; a <DD> after an <A> is that bookmark's description.
+func parseNetscape(r io.Reader, v netscapeVisitor) error {
+	const (
+		captureNone = iota
+		captureFolder
+		captureLink
+		captureDescription
+	)
+
+	z := xhtml.NewTokenizer(r)
+	capture := captureNone
+	var text strings.Builder
+	var folderName string
+	var folderAdded time.Time
+	folderPending := false
+	var pending *netscapeBookmark
+	var opened []bool // per <DL>: whether it opened a folder
+
+	flush := func() error {
+		if capture == captureDescription && pending != nil {
+			pending.Description = strings.TrimSpace(text.String())
+			capture = captureNone
+		}
+		if pending == nil {
+			return nil
+		}
+		b := *pending
+		pending = nil
+		return v.bookmark(b)
+	}
+
+	for {
+		switch z.Next() {
+		case xhtml.ErrorToken:
+			if errors.Is(z.Err(), io.EOF) {
+				if err := flush(); err != nil {
+					return err
+				}
+				for i := len(opened) - 1; i >= 0; i-- {
+					if opened[i] {
+						v.leaveFolder()
+					}
+				}
+				return nil
+			}
+			return z.Err()
+
+		case xhtml.TextToken:
+			if capture != captureNone {
+				text.Write(z.Text())
+			}
+
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			attrs := map[string]string{}
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				attrs[string(key)] = string(val)
+			}
+
+			switch string(name) {
+			case "h3":
+				if err := flush(); err != nil {
+					return err
+				}
+				capture = captureFolder
+				text.Reset()
+				folderAdded = netscapeTime(attrs["add_date"])
+			case "a":
+				if err := flush(); err != nil {
+					return err
+				}
+				capture = captureLink
+				text.Reset()
+				pending = &netscapeBookmark{
+					URL:       strings.TrimSpace(attrs["href"]),
+					Tags:      splitImportList(attrs["tags"], ","),
+					AddedAt:   netscapeTime(attrs["add_date"]),
+					UpdatedAt: netscapeTime(attrs["last_modified"]),
+				}
+			case "dd":
+				if pending != nil {
+					capture = captureDescription
+					text.Reset()
+				}
+			case "dt":
+				if err := flush(); err != nil {
+					return err
+				}
+			case "dl":
+				if err := flush(); err != nil {
+					return err
+				}
+				if folderPending {
+					if err := v.enterFolder(folderName, folderAdded); err != nil {
+						return err
+					}
+				}
+				opened = append(opened, folderPending)
+				folderPending = false
+			}
+
+		case xhtml.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "h3":
+				if capture == captureFolder {
+					folderName = strings.TrimSpace(text.String())
+					folderPending = true
+					capture = captureNone
+				}
+			case "a":
+				if capture == captureLink && pending != nil {
+					pending.Title = strings.TrimSpace(text.String())
+					capture = captureNone
+				}
+			case "dl":
+				if err := flush(); err != nil {
+					return err
+				}
+				folderPending = false
+				if n := len(opened); n > 0 {
+					if opened[n-1] {
+						v.leaveFolder()
+					}
+					opened = opened[:n-1]
+				}
+			}
+		}
+	}
+}
+
+func netscapeTime(value string) time.Time {
+	secs, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || secs <= 0 {
+		return time.Time{}
+	}
+	// Some browsers write microseconds rather than seconds
+	if secs > 1e11 {
+		secs /= 1e6
+	}
+	return time.Unix(secs, 0)
+}
+
+// netscapeWriter writes a Netscape Bookmark File as its entries come in,
+// so an export never holds more than a batch of bookmarks. openFolder and
+// closeFolder calls must be balanced before close.
+type netscapeWriter struct {
+	w     *bufio.Writer
+	depth int
+}
+
+func newNetscapeWriter(w io.Writer) *netscapeWriter {
+	nw := &netscapeWriter{w: bufio.NewWriter(w)}
+	nw.w.WriteString("<!DOCTYPE NETSCAPE-Bookmark-file-1>\n")
+	nw.w.WriteString("<!-- This is an automatically generated file.\n     It will be read and overwritten.\n     DO NOT EDIT! -->\n")
+	nw.w.WriteString(`<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">` + "\n")
+	nw.w.WriteString("<TITLE>Bookmarks</TITLE>\n<H1>Bookmarks</H1>\n")
+	nw.w.WriteString("<DL><p>\n")
+	return nw
+}
+
+func (nw *netscapeWriter) indent() string {
+	return strings.Repeat("    ", nw.depth)
+}
+
+func (nw *netscapeWriter) openFolder(name string, addedAt, updatedAt time.Time) {
+	fmt.Fprintf(nw.w, "%s    <DT><H3%s%s>%s</H3>\n", nw.indent(),
+		netscapeDateAttr("ADD_DATE", addedAt), netscapeDateAttr("LAST_MODIFIED", updatedAt),
+		html.EscapeString(name))
+	nw.depth++
+	fmt.Fprintf(nw.w, "%s<DL><p>\n", nw.indent())
+}
+
+func (nw *netscapeWriter) closeFolder() {
+	fmt.Fprintf(nw.w, "%s</DL><p>\n", nw.indent())
+	nw.depth--
+}
+
+func (nw *netscapeWriter) bookmark(b netscapeBookmark) {
+	indent := nw.indent()
+	tags := ""
+	if len(b.Tags) > 0 {
+		tags = fmt.Sprintf(` TAGS="%s"`, html.EscapeString(strings.Join(b.Tags, ",")))
+	}
+	fmt.Fprintf(nw.w, "%s    <DT><A HREF=\"%s\"%s%s%s>%s</A>\n", indent,
+		html.EscapeString(b.URL),
+		netscapeDateAttr("ADD_DATE", b.AddedAt), netscapeDateAttr("LAST_MODIFIED", b.UpdatedAt),
+		tags, html.EscapeString(b.Title))
+	if b.Description != "" {
+		fmt.Fprintf(nw.w, "%s    <DD>%s\n", indent, html.EscapeString(b.Description))
+	}
+}
+
+// close ends the top-level list and flushes what is buffered.
+func (nw *netscapeWriter) close() error {
+	nw.w.WriteString("</DL><p>\n")
+	return nw.w.Flush()
+}
+
+func netscapeDateAttr(name string, t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf(` %s="%d"`, name, t.Unix())
+}
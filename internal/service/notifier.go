@@ -0,0 +1,86 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// Notifier delivers user-facing notifications produced by background jobs.
+type Notifier interface {
+	Notify(ctx context.Context, notification model.Notification) error
+}
+
+type logNotifier struct {
+	logger *zap.Logger
+}
+
+func NewLogNotifier(logger *zap.Logger) Notifier {
+	return &logNotifier{logger: logger}
+}
+
+func (n *logNotifier) Notify(ctx context.Context, notification model.Notification) error {
+	n.logger.Info("Notification",
+		zap.String("type", notification.Type),
+		zap.String("userId", notification.UserID.String()),
+		zap.String("bookmarkId", notification.BookmarkID.String()),
+		zap.String("message", notification.Message))
+	return nil
+}
+
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string, timeout time.Duration) Notifier {
+	return &webhookNotifier{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (n *webhookNotifier) Notify(ctx context.Context, notification model.Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+type redisNotifier struct {
+	redis   *redis.Client
+	channel string
+}
+
+func NewRedisNotifier(redis *redis.Client, channel string) Notifier {
+	return &redisNotifier{redis: redis, channel: channel}
+}
+
+func (n *redisNotifier) Notify(ctx context.Context, notification model.Notification) error {
+	data, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	return n.redis.Publish(ctx, n.channel, data).Err()
+}
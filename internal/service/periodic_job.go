@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// minJobInterval is the shortest interval a periodic job runs at, so a zero
+// or negative setting does not spin or panic the ticker.
+const minJobInterval = time.Second
+
+// Clock returns the current time. Background jobs take one so tests can
+// control which rows are considered due.
+type Clock func() time.Time
+
+// periodicJob runs a function on a fixed interval until stopped. It is
+// embedded by the background workers to share start/stop handling.
+type periodicJob struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (j *periodicJob) start(ctx context.Context, interval time.Duration, fn func(ctx context.Context)) {
+	if interval < minJobInterval {
+		interval = minJobInterval
+	}
+	ctx, j.cancel = context.WithCancel(ctx)
+	j.done = make(chan struct{})
+
+	go func() {
+		defer close(j.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			fn(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// stop cancels the job and blocks until the current run has returned.
+func (j *periodicJob) stop() {
+	if j.cancel == nil {
+		return
+	}
+	j.cancel()
+	<-j.done
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// pinboardPost is one entry of Pinboard's JSON export (posts/all).
+type pinboardPost struct {
+	Href        string `json:"href"`
+	Description string `json:"description"`
+	Extended    string `json:"extended"`
+	Time        string `json:"time"`
+	ToRead      string `json:"toread"`
+	Tags        string `json:"tags"`
+}
+
+// pinboardImporter reads Pinboard's JSON export, an array of posts whose
+// "description" is the title and whose tags are space-separated. Posts
+// marked toread go on the read-later list. Pinboard has no folders.
+type pinboardImporter struct{}
+
+func (pinboardImporter) Parse(r io.Reader, emit func(b ImportedBookmark) error) error {
+	dec := json.NewDecoder(r)
+	if err := expectArray(dec); err != nil {
+		return err
+	}
+	for dec.More() {
+		var post pinboardPost
+		if err := dec.Decode(&post); err != nil {
+			return err
+		}
+		err := emit(ImportedBookmark{
+			Title:       post.Description,
+			URL:         strings.TrimSpace(post.Href),
+			Description: post.Extended,
+			Tags:        strings.Fields(post.Tags),
+			CreatedAt:   parseImportTime(post.Time),
+			ReadLater:   parseImportBool(post.ToRead),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+func expectArray(dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '[' {
+		return fmt.Errorf("expected a JSON array")
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+)
+
+// pocketImporter reads the ril_export.html file Pocket exports: an <h1>
+// heading per list ("Unread", "Read Archive") followed by a <ul> of links
+// carrying time_added and comma-separated tags attributes. Unread items go
+// on the read-later list. Pocket has no folders.
+type pocketImporter struct{}
+
+func (pocketImporter) Parse(r io.Reader, emit func(b ImportedBookmark) error) error {
+	z := xhtml.NewTokenizer(r)
+	var heading, text strings.Builder
+	inHeading := false
+	unread := false
+	var pending *ImportedBookmark
+
+	for {
+		switch z.Next() {
+		case xhtml.ErrorToken:
+			if errors.Is(z.Err(), io.EOF) {
+				return nil
+			}
+			return z.Err()
+
+		case xhtml.TextToken:
+			if inHeading {
+				heading.Write(z.Text())
+			} else if pending != nil {
+				text.Write(z.Text())
+			}
+
+		case xhtml.StartTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "h1":
+				inHeading = true
+				heading.Reset()
+			case "a":
+				attrs := map[string]string{}
+				for hasAttr {
+					var key, val []byte
+					key, val, hasAttr = z.TagAttr()
+					attrs[string(key)] = string(val)
+				}
+				text.Reset()
+				pending = &ImportedBookmark{
+					URL:       strings.TrimSpace(attrs["href"]),
+					Tags:      splitImportList(attrs["tags"], ","),
+					CreatedAt: netscapeTime(attrs["time_added"]),
+					ReadLater: unread,
+				}
+			}
+
+		case xhtml.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "h1":
+				inHeading = false
+				unread = strings.EqualFold(strings.TrimSpace(heading.String()), "unread")
+			case "a":
+				if pending != nil {
+					pending.Title = strings.TrimSpace(text.String())
+					b := *pending
+					pending = nil
+					if err := emit(b); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+}
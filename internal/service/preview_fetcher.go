@@ -0,0 +1,339 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/quckapp/bookmark-service/internal/model"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// ErrURLNotAllowed is returned when a preview URL uses an unsupported scheme
+// or resolves to a private, loopback or otherwise internal address.
+var ErrURLNotAllowed = errors.New("url not allowed")
+
+type PreviewFetcher interface {
+	Fetch(ctx context.Context, rawURL string) (*model.LinkPreview, error)
+}
+
+type PreviewFetcherConfig struct {
+	Timeout      time.Duration
+	MaxBodyBytes int64
+	MaxRedirects int
+	UserAgent    string
+	// AllowPrivateNetworks disables the SSRF guard. Only tests pointing at a
+	// local httptest server should set it.
+	AllowPrivateNetworks bool
+}
+
+type httpPreviewFetcher struct {
+	client *http.Client
+	cfg    PreviewFetcherConfig
+}
+
+func NewPreviewFetcher(cfg PreviewFetcherConfig) PreviewFetcher {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = 2 << 20
+	}
+	if cfg.MaxRedirects <= 0 {
+		cfg.MaxRedirects = 5
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = "QuckAppBookmarkBot/1.0"
+	}
+
+	client := &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: newGuardedTransport(cfg.Timeout, cfg.AllowPrivateNetworks),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= cfg.MaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", cfg.MaxRedirects)
+			}
+			return checkScheme(req.URL)
+		},
+	}
+
+	return &httpPreviewFetcher{client: client, cfg: cfg}
+}
+
+// newGuardedTransport returns a transport that refuses to connect to
+// non-public addresses unless allowPrivateNetworks is set.
+func newGuardedTransport(timeout time.Duration, allowPrivateNetworks bool) *http.Transport {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivateNetworks {
+		// Checked after DNS resolution so rebinding to an internal address is caught
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			addr, err := netip.ParseAddr(host)
+			if err != nil || !isPublicAddr(addr) {
+				return fmt.Errorf("%w: %s", ErrURLNotAllowed, host)
+			}
+			return nil
+		}
+	}
+
+	return &http.Transport{
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+}
+
+func (f *httpPreviewFetcher) Fetch(ctx context.Context, rawURL string) (*model.LinkPreview, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if err := checkScheme(target); err != nil {
+		return nil, err
+	}
+
+	resp, err := f.get(ctx, target.String(), "text/html,application/xhtml+xml;q=0.9,*/*;q=0.8")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	finalURL := resp.Request.URL
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+
+	preview := &model.LinkPreview{
+		URL:         rawURL,
+		ContentType: truncate(mediaType, 50),
+		SiteName:    truncate(finalURL.Hostname(), 100),
+		FetchedAt:   time.Now(),
+	}
+
+	if strings.HasPrefix(mediaType, "image/") {
+		preview.ImageURL = truncate(finalURL.String(), 500)
+		return preview, nil
+	}
+	if mediaType != "" && mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return preview, nil
+	}
+
+	body, err := charset.NewReader(io.LimitReader(resp.Body, f.cfg.MaxBodyBytes), resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+	meta := parsePageMeta(body)
+
+	preview.Title = truncate(firstNonEmpty(meta.props["og:title"], meta.props["twitter:title"], meta.title), 255)
+	preview.Description = firstNonEmpty(meta.props["og:description"], meta.props["twitter:description"], meta.props["description"])
+	preview.ImageURL = truncate(resolveURL(finalURL, firstNonEmpty(
+		meta.props["og:image"], meta.props["og:image:secure_url"], meta.props["og:image:url"],
+		meta.props["twitter:image"], meta.props["twitter:image:src"],
+	)), 500)
+	if siteName := meta.props["og:site_name"]; siteName != "" {
+		preview.SiteName = truncate(siteName, 100)
+	}
+	favicon := meta.favicon
+	if favicon == "" {
+		favicon = "/favicon.ico"
+	}
+	preview.FaviconURL = truncate(resolveURL(finalURL, favicon), 500)
+
+	if meta.oembed != "" && (preview.Title == "" || preview.ImageURL == "") {
+		f.applyOEmbed(ctx, preview, resolveURL(finalURL, meta.oembed))
+	}
+
+	return preview, nil
+}
+
+func (f *httpPreviewFetcher) get(ctx context.Context, rawURL, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.cfg.UserAgent)
+	req.Header.Set("Accept", accept)
+	return f.client.Do(req)
+}
+
+type oembedResponse struct {
+	Title        string `json:"title"`
+	ProviderName string `json:"provider_name"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	Type         string `json:"type"`
+}
+
+// applyOEmbed fills fields the page itself did not provide. Failures are
+// ignored since oEmbed only enriches an already usable preview.
+func (f *httpPreviewFetcher) applyOEmbed(ctx context.Context, preview *model.LinkPreview, endpoint string) {
+	resp, err := f.get(ctx, endpoint, "application/json")
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return
+	}
+
+	var oembed oembedResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, f.cfg.MaxBodyBytes)).Decode(&oembed); err != nil {
+		return
+	}
+
+	if preview.Title == "" {
+		preview.Title = truncate(oembed.Title, 255)
+	}
+	if preview.ImageURL == "" {
+		preview.ImageURL = truncate(oembed.ThumbnailURL, 500)
+	}
+	if oembed.ProviderName != "" {
+		preview.SiteName = truncate(oembed.ProviderName, 100)
+	}
+}
+
+type pageMeta struct {
+	title   string
+	props   map[string]string
+	favicon string
+	oembed  string
+}
+
+func parsePageMeta(r io.Reader) pageMeta {
+	meta := pageMeta{props: make(map[string]string)}
+	tokenizer := html.NewTokenizer(r)
+	inTitle := false
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return meta
+		case html.TextToken:
+			if inTitle && meta.title == "" {
+				meta.title = strings.TrimSpace(string(tokenizer.Text()))
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				return meta
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			attrs := map[string]string{}
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = tokenizer.TagAttr()
+				attrs[strings.ToLower(string(key))] = strings.TrimSpace(string(val))
+			}
+
+			switch string(name) {
+			case "title":
+				inTitle = true
+			case "body":
+				return meta
+			case "meta":
+				key := strings.ToLower(firstNonEmpty(attrs["property"], attrs["name"]))
+				if key != "" && attrs["content"] != "" {
+					if _, seen := meta.props[key]; !seen {
+						meta.props[key] = attrs["content"]
+					}
+				}
+			case "link":
+				rel := strings.ToLower(attrs["rel"])
+				switch {
+				case attrs["href"] == "":
+				case strings.Contains(rel, "icon") && meta.favicon == "":
+					meta.favicon = attrs["href"]
+				case rel == "alternate" && strings.EqualFold(attrs["type"], "application/json+oembed"):
+					meta.oembed = attrs["href"]
+				}
+			}
+		}
+	}
+}
+
+func checkScheme(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: unsupported scheme %q", ErrURLNotAllowed, u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("%w: missing host", ErrURLNotAllowed)
+	}
+	return nil
+}
+
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+func resolveURL(base *url.URL, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	return base.ResolveReference(parsed).String()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	s = s[:max]
+	for !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
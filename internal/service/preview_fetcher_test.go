@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// newTestPreviewFetcher fetches from a local httptest server, which the SSRF
+// guard would otherwise refuse.
+func newTestPreviewFetcher(maxBodyBytes int64) PreviewFetcher {
+	return NewPreviewFetcher(PreviewFetcherConfig{MaxBodyBytes: maxBodyBytes, AllowPrivateNetworks: true})
+}
+
+// servePage answers every request with body and the given content type.
+func servePage(contentType string, body []byte) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Write(body)
+	}))
+}
+
+func TestPreviewFetcherExtractsMetadata(t *testing.T) {
+	server := servePage("text/html; charset=utf-8", []byte(`<!doctype html>
+<html><head>
+<title>Plain title</title>
+<meta property="og:title" content=" Open Graph title ">
+<meta name="twitter:title" content="Twitter title">
+<meta name="description" content="A page about things">
+<meta property="og:image" content="/images/cover.png">
+<meta property="og:site_name" content="Example Site">
+<link rel="shortcut icon" href="/static/icon.png">
+</head><body><meta property="og:description" content="outside the head"></body></html>`))
+	defer server.Close()
+
+	preview, err := newTestPreviewFetcher(0).Fetch(context.Background(), server.URL+"/article")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"URL":         server.URL + "/article",
+		"ContentType": "text/html",
+		"Title":       "Open Graph title",
+		"Description": "A page about things",
+		"ImageURL":    server.URL + "/images/cover.png",
+		"FaviconURL":  server.URL + "/static/icon.png",
+		"SiteName":    "Example Site",
+	}
+	got := map[string]string{
+		"URL":         preview.URL,
+		"ContentType": preview.ContentType,
+		"Title":       preview.Title,
+		"Description": preview.Description,
+		"ImageURL":    preview.ImageURL,
+		"FaviconURL":  preview.FaviconURL,
+		"SiteName":    preview.SiteName,
+	}
+	for field, value := range want {
+		if got[field] != value {
+			t.Errorf("%s = %q, want %q", field, got[field], value)
+		}
+	}
+	if preview.FetchedAt.IsZero() {
+		t.Error("FetchedAt is not set")
+	}
+}
+
+func TestPreviewFetcherFallsBack(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><title> Only a title </title>
+<link rel="alternate" type="application/json+oembed" href="/oembed"></head></html>`)
+	})
+	mux.HandleFunc("/oembed", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"title":"oEmbed title","provider_name":"Provider","thumbnail_url":"https://cdn.example.com/thumb.jpg"}`)
+	})
+	mux.HandleFunc("/photo", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write([]byte{0xff, 0xd8, 0xff})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fetcher := newTestPreviewFetcher(0)
+
+	preview, err := fetcher.Fetch(context.Background(), server.URL+"/page")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Title != "Only a title" {
+		t.Errorf("Title = %q, want the <title> text", preview.Title)
+	}
+	if preview.ImageURL != "https://cdn.example.com/thumb.jpg" {
+		t.Errorf("ImageURL = %q, want the oEmbed thumbnail", preview.ImageURL)
+	}
+	if preview.SiteName != "Provider" {
+		t.Errorf("SiteName = %q, want the oEmbed provider", preview.SiteName)
+	}
+	if preview.FaviconURL != server.URL+"/favicon.ico" {
+		t.Errorf("FaviconURL = %q, want the default favicon", preview.FaviconURL)
+	}
+
+	preview, err = fetcher.Fetch(context.Background(), server.URL+"/photo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.ContentType != "image/jpeg" || preview.ImageURL != server.URL+"/photo" {
+		t.Errorf("image preview = %q %q, want the image itself", preview.ContentType, preview.ImageURL)
+	}
+}
+
+func TestPreviewFetcherCharsets(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+	}{
+		{
+			name:        "charset in the content type",
+			contentType: "text/html; charset=iso-8859-1",
+			body:        []byte("<html><head><title>Caf\xe9 cr\xe8me</title></head></html>"),
+		},
+		{
+			name:        "charset in a meta tag",
+			contentType: "text/html",
+			body:        []byte(`<html><head><meta charset="windows-1252"><title>Caf` + "\xe9 cr\xe8me</title></head></html>"),
+		},
+		{
+			name:        "utf-8",
+			contentType: "text/html; charset=utf-8",
+			body:        []byte("<html><head><title>Café crème</title></head></html>"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := servePage(tt.contentType, tt.body)
+			defer server.Close()
+
+			preview, err := newTestPreviewFetcher(0).Fetch(context.Background(), server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if preview.Title != "Café crème" {
+				t.Errorf("Title = %q, want %q", preview.Title, "Café crème")
+			}
+		})
+	}
+}
+
+func TestPreviewFetcherBodyLimit(t *testing.T) {
+	page := "<html><head><title>Early title</title><!--" + strings.Repeat("x", 4096) + `-->
+<meta property="og:title" content="Late title"></head></html>`
+	server := servePage("text/html; charset=utf-8", []byte(page))
+	defer server.Close()
+
+	preview, err := newTestPreviewFetcher(1024).Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Title != "Early title" {
+		t.Errorf("Title = %q, want only what fits in the body limit", preview.Title)
+	}
+
+	preview, err = newTestPreviewFetcher(int64(len(page))).Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Title != "Late title" {
+		t.Errorf("Title = %q, want the og:title once the page fits", preview.Title)
+	}
+}
+
+func TestPreviewFetcherRejects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	if _, err := newTestPreviewFetcher(0).Fetch(context.Background(), server.URL); err == nil || !strings.Contains(err.Error(), "unexpected status 404") {
+		t.Errorf("err = %v, want the 404 status", err)
+	}
+	if _, err := newTestPreviewFetcher(0).Fetch(context.Background(), "file:///etc/passwd"); !errors.Is(err, ErrURLNotAllowed) {
+		t.Errorf("err = %v, want ErrURLNotAllowed for a file url", err)
+	}
+}
+
+func TestPreviewFetcherRefusesPrivateNetworks(t *testing.T) {
+	var requested atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested.Store(true)
+	}))
+	defer server.Close()
+
+	fetcher := NewPreviewFetcher(PreviewFetcherConfig{})
+	for _, target := range []string{server.URL, strings.Replace(server.URL, "127.0.0.1", "localhost", 1)} {
+		if _, err := fetcher.Fetch(context.Background(), target); !errors.Is(err, ErrURLNotAllowed) {
+			t.Errorf("Fetch(%s) err = %v, want ErrURLNotAllowed", target, err)
+		}
+	}
+	if requested.Load() {
+		t.Error("the private server was contacted")
+	}
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	previewQueueKey = "preview:jobs"
+	previewRetryKey = "preview:retry"
+)
+
+type PreviewJob struct {
+	BookmarkID uuid.UUID `json:"bookmarkId"`
+	URL        string    `json:"url"`
+}
+
+// PreviewQueue holds preview fetch jobs. Jobs scheduled for later sit in a
+// sorted set keyed by due time until PromoteDue moves them onto the queue.
+type PreviewQueue interface {
+	Enqueue(ctx context.Context, job PreviewJob) error
+	EnqueueAt(ctx context.Context, job PreviewJob, at time.Time) error
+	Dequeue(ctx context.Context, timeout time.Duration) (*PreviewJob, error)
+	PromoteDue(ctx context.Context, now time.Time) (int, error)
+}
+
+type redisPreviewQueue struct {
+	redis *redis.Client
+}
+
+func NewRedisPreviewQueue(redis *redis.Client) PreviewQueue {
+	return &redisPreviewQueue{redis: redis}
+}
+
+func (q *redisPreviewQueue) Enqueue(ctx context.Context, job PreviewJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return q.redis.LPush(ctx, previewQueueKey, data).Err()
+}
+
+func (q *redisPreviewQueue) EnqueueAt(ctx context.Context, job PreviewJob, at time.Time) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return q.redis.ZAdd(ctx, previewRetryKey, redis.Z{Score: float64(at.Unix()), Member: string(data)}).Err()
+}
+
+// Dequeue blocks for up to timeout and returns nil when no job arrived.
+func (q *redisPreviewQueue) Dequeue(ctx context.Context, timeout time.Duration) (*PreviewJob, error) {
+	result, err := q.redis.BRPop(ctx, timeout, previewQueueKey).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var job PreviewJob
+	if err := json.Unmarshal([]byte(result[1]), &job); err != nil {
+		return nil, fmt.Errorf("invalid preview job: %w", err)
+	}
+	return &job, nil
+}
+
+func (q *redisPreviewQueue) PromoteDue(ctx context.Context, now time.Time) (int, error) {
+	members, err := q.redis.ZRangeByScore(ctx, previewRetryKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: fmt.Sprintf("%d", now.Unix()),
+	}).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	promoted := 0
+	for _, member := range members {
+		// Only the replica that removes the member pushes it
+		removed, err := q.redis.ZRem(ctx, previewRetryKey, member).Result()
+		if err != nil {
+			return promoted, err
+		}
+		if removed == 0 {
+			continue
+		}
+		if err := q.redis.LPush(ctx, previewQueueKey, member).Err(); err != nil {
+			return promoted, err
+		}
+		promoted++
+	}
+	return promoted, nil
+}
@@ -1,6 +1,10 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,24 +13,66 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	previewRefreshBatchSize = 100
+	previewMaxRetryDelay    = time.Hour
+)
+
 type PreviewService interface {
-	Create(preview *model.LinkPreview) error
+	Create(userID uuid.UUID, preview *model.LinkPreview) error
 	GetByBookmarkID(bookmarkID uuid.UUID) (*model.LinkPreview, error)
 	GetByURL(url string) (*model.LinkPreview, error)
 	Generate(bookmarkID uuid.UUID, url string) (*model.LinkPreview, error)
+	Process(ctx context.Context, job PreviewJob) error
+	RefreshStale(ctx context.Context, now time.Time) (int, error)
+}
+
+type PreviewPolicy struct {
+	MaxAttempts    int
+	RetryBaseDelay time.Duration
+	// RefreshTTL is how long a ready preview is kept before it is fetched again.
+	// Zero disables refreshing.
+	RefreshTTL time.Duration
+	// Lease is how long a preview may stay fetching, or pending without a
+	// retry due, before its job is taken as lost and queued again.
+	Lease time.Duration
 }
 
 type previewService struct {
-	repo repository.PreviewRepository
-	logger *zap.Logger
+	repo    repository.PreviewRepository
+	fetcher PreviewFetcher
+	queue   PreviewQueue
+	access  AccessService
+	policy  PreviewPolicy
+	logger  *zap.Logger
 }
 
-func NewPreviewService(repo repository.PreviewRepository, logger *zap.Logger) PreviewService {
-	return &previewService{repo: repo, logger: logger}
+func NewPreviewService(
+	repo repository.PreviewRepository,
+	fetcher PreviewFetcher,
+	queue PreviewQueue,
+	access AccessService,
+	policy PreviewPolicy,
+	logger *zap.Logger,
+) PreviewService {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 5
+	}
+	if policy.RetryBaseDelay <= 0 {
+		policy.RetryBaseDelay = 30 * time.Second
+	}
+	if policy.Lease <= 0 {
+		policy.Lease = 10 * time.Minute
+	}
+	return &previewService{repo: repo, fetcher: fetcher, queue: queue, access: access, policy: policy, logger: logger}
 }
 
-func (s *previewService) Create(preview *model.LinkPreview) error {
+func (s *previewService) Create(userID uuid.UUID, preview *model.LinkPreview) error {
+	if err := s.access.AuthorizeBookmark(userID, preview.BookmarkID); err != nil {
+		return err
+	}
 	preview.FetchedAt = time.Now()
+	preview.Status = model.PreviewStatusReady
 	return s.repo.Create(preview)
 }
 
@@ -38,25 +84,186 @@ func (s *previewService) GetByURL(url string) (*model.LinkPreview, error) {
 	return s.repo.GetByURL(url)
 }
 
-func (s *previewService) Generate(bookmarkID uuid.UUID, url string) (*model.LinkPreview, error) {
-	// Check if preview already exists
-	existing, _ := s.repo.GetByBookmarkID(bookmarkID)
-	if existing != nil {
-		existing.URL = url
-		existing.FetchedAt = time.Now()
-		err := s.repo.Update(existing)
-		return existing, err
+// Generate records a pending preview and queues it for the preview workers.
+func (s *previewService) Generate(bookmarkID uuid.UUID, rawURL string) (*model.LinkPreview, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid url", ErrURLNotAllowed)
+	}
+	if err := checkScheme(target); err != nil {
+		return nil, err
+	}
+
+	preview, _ := s.repo.GetByBookmarkID(bookmarkID)
+	if preview == nil {
+		preview = &model.LinkPreview{BookmarkID: bookmarkID, FetchedAt: time.Now()}
 	}
+	preview.URL = truncate(rawURL, 500)
+	preview.Status = model.PreviewStatusPending
+	preview.Error = ""
+	preview.Attempts = 0
+	preview.NextAttemptAt = nil
 
-	preview := &model.LinkPreview{
-		BookmarkID: bookmarkID,
-		URL:        url,
-		FetchedAt:  time.Now(),
+	if preview.ID == uuid.Nil {
+		err = s.repo.Create(preview)
+	} else {
+		err = s.repo.Update(preview)
 	}
-	err := s.repo.Create(preview)
 	if err != nil {
 		return nil, err
 	}
-	s.logger.Info("Generated preview", zap.String("bookmarkId", bookmarkID.String()))
+
+	if err := s.queue.Enqueue(context.Background(), PreviewJob{BookmarkID: bookmarkID, URL: preview.URL}); err != nil {
+		return nil, fmt.Errorf("failed to queue preview: %w", err)
+	}
+	s.logger.Info("Queued preview", zap.String("bookmarkId", bookmarkID.String()))
 	return preview, nil
 }
+
+// Process fetches the preview for a queued job. Fetch failures are recorded on
+// the preview and retried with exponential backoff until MaxAttempts is reached.
+func (s *previewService) Process(ctx context.Context, job PreviewJob) error {
+	claimed, err := s.repo.Claim(job.BookmarkID, job.URL)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		// Superseded by a newer Generate call, removed along with its
+		// bookmark, or already taken by another worker
+		return nil
+	}
+	preview, err := s.repo.GetByBookmarkID(job.BookmarkID)
+	if err != nil {
+		return nil
+	}
+
+	fetched, fetchErr := s.fetcher.Fetch(ctx, job.URL)
+	if fetchErr == nil {
+		applyFetchedPreview(preview, fetched)
+		preview.Status = model.PreviewStatusReady
+		preview.Error = ""
+		preview.Attempts = 0
+		preview.NextAttemptAt = nil
+		if saved, err := s.repo.SaveResult(preview, job.URL); err != nil || !saved {
+			return err
+		}
+		s.logger.Info("Generated preview", zap.String("bookmarkId", job.BookmarkID.String()))
+		return nil
+	}
+
+	preview.Attempts++
+	preview.Error = fetchErr.Error()
+	if errors.Is(fetchErr, ErrURLNotAllowed) || preview.Attempts >= s.policy.MaxAttempts {
+		preview.Status = model.PreviewStatusFailed
+		preview.NextAttemptAt = nil
+		if saved, err := s.repo.SaveResult(preview, job.URL); err != nil || !saved {
+			return err
+		}
+		s.logger.Warn("Preview failed",
+			zap.String("bookmarkId", job.BookmarkID.String()),
+			zap.Int("attempts", preview.Attempts),
+			zap.Error(fetchErr))
+		return nil
+	}
+
+	next := time.Now().Add(s.retryDelay(preview.Attempts))
+	preview.Status = model.PreviewStatusPending
+	preview.NextAttemptAt = &next
+	if saved, err := s.repo.SaveResult(preview, job.URL); err != nil || !saved {
+		return err
+	}
+	s.logger.Warn("Preview fetch failed, will retry",
+		zap.String("bookmarkId", job.BookmarkID.String()),
+		zap.Int("attempts", preview.Attempts),
+		zap.Time("nextAttemptAt", next),
+		zap.Error(fetchErr))
+	return s.queue.EnqueueAt(ctx, job, next)
+}
+
+// RefreshStale queues again the previews whose job was lost, because a
+// worker died holding it or the queue lost it, and ready previews whose
+// FetchedAt is older than RefreshTTL.
+func (s *previewService) RefreshStale(ctx context.Context, now time.Time) (int, error) {
+	queued, err := s.requeueStuck(ctx, now)
+	if err != nil || s.policy.RefreshTTL <= 0 {
+		return queued, err
+	}
+
+	previews, err := s.repo.GetStale(now.Add(-s.policy.RefreshTTL), previewRefreshBatchSize)
+	if err != nil {
+		return queued, err
+	}
+
+	for _, preview := range previews {
+		claimed, err := s.repo.MarkPending(preview.ID, model.PreviewStatusReady)
+		if err != nil {
+			return queued, err
+		}
+		if !claimed {
+			continue
+		}
+		if err := s.queue.Enqueue(ctx, PreviewJob{BookmarkID: preview.BookmarkID, URL: preview.URL}); err != nil {
+			return queued, err
+		}
+		queued++
+	}
+	return queued, nil
+}
+
+// requeueStuck queues previews that outlived their lease. A fetch that never
+// finished counts as a failed attempt, so a page that brings workers down
+// is given up on like any other failure.
+func (s *previewService) requeueStuck(ctx context.Context, now time.Time) (int, error) {
+	previews, err := s.repo.GetStuck(now.Add(-s.policy.Lease), previewRefreshBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for i := range previews {
+		preview := &previews[i]
+		status, attempts := model.PreviewStatusPending, preview.Attempts
+		if preview.Status == model.PreviewStatusFetching {
+			attempts++
+			if attempts >= s.policy.MaxAttempts {
+				status = model.PreviewStatusFailed
+			}
+		}
+		claimed, err := s.repo.Reclaim(preview, status, attempts)
+		if err != nil {
+			return queued, err
+		}
+		if !claimed || status == model.PreviewStatusFailed {
+			continue
+		}
+		if err := s.queue.Enqueue(ctx, PreviewJob{BookmarkID: preview.BookmarkID, URL: preview.URL}); err != nil {
+			return queued, err
+		}
+		s.logger.Warn("Requeued stuck preview",
+			zap.String("bookmarkId", preview.BookmarkID.String()),
+			zap.String("status", string(preview.Status)))
+		queued++
+	}
+	return queued, nil
+}
+
+func (s *previewService) retryDelay(attempts int) time.Duration {
+	delay := s.policy.RetryBaseDelay
+	for i := 1; i < attempts && delay < previewMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > previewMaxRetryDelay {
+		delay = previewMaxRetryDelay
+	}
+	return delay
+}
+
+func applyFetchedPreview(preview, fetched *model.LinkPreview) {
+	preview.Title = fetched.Title
+	preview.Description = fetched.Description
+	preview.ImageURL = fetched.ImageURL
+	preview.FaviconURL = fetched.FaviconURL
+	preview.SiteName = fetched.SiteName
+	preview.ContentType = fetched.ContentType
+	preview.FetchedAt = fetched.FetchedAt
+}
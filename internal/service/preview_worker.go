@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const previewDequeueTimeout = 5 * time.Second
+
+// PreviewWorker consumes the preview queue and periodically promotes retries
+// that are due and queues stale previews for refresh.
+type PreviewWorker struct {
+	periodicJob
+	service  PreviewService
+	queue    PreviewQueue
+	workers  int
+	interval time.Duration
+	now      Clock
+	logger   *zap.Logger
+
+	stopConsumers context.CancelFunc
+	consumers     sync.WaitGroup
+}
+
+func NewPreviewWorker(
+	service PreviewService,
+	queue PreviewQueue,
+	workers int,
+	interval time.Duration,
+	clock Clock,
+	logger *zap.Logger,
+) *PreviewWorker {
+	if workers <= 0 {
+		workers = 1
+	}
+	if clock == nil {
+		clock = time.Now
+	}
+	return &PreviewWorker{
+		service:  service,
+		queue:    queue,
+		workers:  workers,
+		interval: interval,
+		now:      clock,
+		logger:   logger,
+	}
+}
+
+func (w *PreviewWorker) Start(ctx context.Context) {
+	w.logger.Info("Starting preview worker", zap.Int("workers", w.workers), zap.Duration("interval", w.interval))
+
+	var consumerCtx context.Context
+	consumerCtx, w.stopConsumers = context.WithCancel(ctx)
+	for i := 0; i < w.workers; i++ {
+		w.consumers.Add(1)
+		go func() {
+			defer w.consumers.Done()
+			w.consume(consumerCtx)
+		}()
+	}
+
+	w.start(ctx, w.interval, w.schedule)
+}
+
+func (w *PreviewWorker) Stop() {
+	w.stop()
+	if w.stopConsumers != nil {
+		w.stopConsumers()
+	}
+	w.consumers.Wait()
+	w.logger.Info("Stopped preview worker")
+}
+
+func (w *PreviewWorker) consume(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := w.queue.Dequeue(ctx, previewDequeueTimeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			w.logger.Error("Failed to dequeue preview job", zap.Error(err))
+			time.Sleep(time.Second)
+			continue
+		}
+		if job == nil {
+			continue
+		}
+		if err := w.service.Process(ctx, *job); err != nil {
+			w.logger.Error("Failed to process preview job", zap.String("bookmarkId", job.BookmarkID.String()), zap.Error(err))
+		}
+	}
+}
+
+func (w *PreviewWorker) schedule(ctx context.Context) {
+	now := w.now()
+	if promoted, err := w.queue.PromoteDue(ctx, now); err != nil {
+		w.logger.Error("Failed to promote preview retries", zap.Error(err))
+	} else if promoted > 0 {
+		w.logger.Info("Promoted preview retries", zap.Int("count", promoted))
+	}
+
+	if queued, err := w.service.RefreshStale(ctx, now); err != nil {
+		w.logger.Error("Failed to queue stale previews", zap.Error(err))
+	} else if queued > 0 {
+		w.logger.Info("Queued stale previews for refresh", zap.Int("count", queued))
+	}
+}
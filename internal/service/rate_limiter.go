@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimiter counts requests per key in fixed windows.
+type RateLimiter interface {
+	// Allow counts a request against key and reports whether it is within
+	// the limit. When it is not, the duration says when the window resets.
+	Allow(ctx context.Context, key string) (bool, time.Duration, error)
+	// Blocked reports whether key has used up the current window, without
+	// counting a request.
+	Blocked(ctx context.Context, key string) (bool, time.Duration, error)
+}
+
+type redisRateLimiter struct {
+	redis  *redis.Client
+	prefix string
+	limit  int64
+	window time.Duration
+}
+
+// NewRedisRateLimiter allows limit requests per key in each window. Counters
+// live in Redis so every instance of the service shares them.
+func NewRedisRateLimiter(redis *redis.Client, prefix string, limit int, window time.Duration) RateLimiter {
+	if limit <= 0 {
+		limit = 60
+	}
+	if window <= 0 {
+		window = time.Minute
+	}
+	return &redisRateLimiter{redis: redis, prefix: prefix, limit: int64(limit), window: window}
+}
+
+func (l *redisRateLimiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	counterKey, reset := l.slot(key)
+	count, err := l.redis.Incr(ctx, counterKey).Result()
+	if err != nil {
+		return false, 0, err
+	}
+	if count == 1 {
+		l.redis.Expire(ctx, counterKey, l.window+time.Second)
+	}
+	if count <= l.limit {
+		return true, 0, nil
+	}
+	return false, reset, nil
+}
+
+func (l *redisRateLimiter) Blocked(ctx context.Context, key string) (bool, time.Duration, error) {
+	counterKey, reset := l.slot(key)
+	count, err := l.redis.Get(ctx, counterKey).Int64()
+	if err == redis.Nil {
+		return false, 0, nil
+	}
+	if err != nil {
+		return false, 0, err
+	}
+	if count < l.limit {
+		return false, 0, nil
+	}
+	return true, reset, nil
+}
+
+// slot returns the counter of key in the current window and how long until
+// the window ends.
+func (l *redisRateLimiter) slot(key string) (string, time.Duration) {
+	now := time.Now()
+	slot := now.UnixNano() / int64(l.window)
+	reset := time.Unix(0, (slot+1)*int64(l.window))
+	return l.prefix + ":" + key + ":" + strconv.FormatInt(slot, 10), reset.Sub(now)
+}
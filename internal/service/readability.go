@@ -0,0 +1,323 @@
+package service
+
+import (
+	"bytes"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ReadableContent is the main content of a page with the surrounding
+// navigation, ads and scripts taken out.
+type ReadableContent struct {
+	Title string
+	// HTML is a fragment that only uses a small set of harmless elements and
+	// attributes, with links and images made absolute.
+	HTML string
+	Text string
+}
+
+// droppedElements never hold readable content and are removed with their
+// children before scoring.
+var droppedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Aside: true,
+	atom.Form: true, atom.Button: true, atom.Input: true, atom.Select: true, atom.Textarea: true,
+	atom.Iframe: true, atom.Object: true, atom.Embed: true, atom.Canvas: true, atom.Svg: true,
+	atom.Link: true, atom.Meta: true,
+}
+
+var (
+	unlikelyCandidate = regexp.MustCompile(`(?i)banner|breadcrumb|comment|community|cookie|disqus|footer|header|menu|modal|newsletter|popup|promo|related|share|sidebar|social|sponsor|subscribe|\bads?\b`)
+	maybeCandidate    = regexp.MustCompile(`(?i)article|body|content|entry|main|page|post|story|text`)
+)
+
+// allowedElements maps the elements kept in archived HTML to the attributes
+// each may keep. Anything else is unwrapped to its children.
+var allowedElements = map[atom.Atom][]string{
+	atom.P: nil, atom.Br: nil, atom.Hr: nil, atom.Div: nil, atom.Span: nil,
+	atom.H1: nil, atom.H2: nil, atom.H3: nil, atom.H4: nil, atom.H5: nil, atom.H6: nil,
+	atom.Ul: nil, atom.Ol: nil, atom.Li: nil, atom.Dl: nil, atom.Dt: nil, atom.Dd: nil,
+	atom.Blockquote: nil, atom.Pre: nil, atom.Code: nil,
+	atom.Em: nil, atom.Strong: nil, atom.B: nil, atom.I: nil, atom.U: nil, atom.S: nil,
+	atom.Sub: nil, atom.Sup: nil, atom.Small: nil, atom.Mark: nil, atom.Cite: nil, atom.Q: nil,
+	atom.Figure: nil, atom.Figcaption: nil,
+	atom.Table: nil, atom.Thead: nil, atom.Tbody: nil, atom.Tfoot: nil, atom.Tr: nil, atom.Th: nil, atom.Td: nil,
+	atom.A:   {"href", "title"},
+	atom.Img: {"src", "alt", "title"},
+}
+
+// blockElements end a line in the plain text version.
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Br: true, atom.Div: true, atom.Li: true, atom.Tr: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Blockquote: true, atom.Pre: true, atom.Figure: true, atom.Figcaption: true,
+	atom.Dt: true, atom.Dd: true, atom.Hr: true, atom.Table: true,
+}
+
+// ExtractReadable finds the main content of an HTML document in the way
+// Readability does: paragraphs are scored by their length and punctuation,
+// the scores flow up to their containers and the best scoring container,
+// discounted by how much of it is link text, wins.
+func ExtractReadable(doc *html.Node, base *url.URL) *ReadableContent {
+	content := &ReadableContent{Title: strings.TrimSpace(nodeText(findElement(doc, atom.Title)))}
+	if body := findElement(doc, atom.Body); body != nil {
+		removeUnlikely(body)
+	}
+
+	root := bestCandidate(doc)
+	if root == nil {
+		root = firstNonNil(findElement(doc, atom.Article), findElement(doc, atom.Main), findElement(doc, atom.Body))
+	}
+	if root == nil {
+		return content
+	}
+
+	var out bytes.Buffer
+	for child := root.FirstChild; child != nil; child = child.NextSibling {
+		writeSanitized(&out, child, base)
+	}
+	content.HTML = strings.TrimSpace(out.String())
+
+	var text strings.Builder
+	writeText(&text, root)
+	content.Text = collapseBlankLines(text.String())
+	return content
+}
+
+func removeUnlikely(n *html.Node) {
+	for child := n.FirstChild; child != nil; {
+		next := child.NextSibling
+		if child.Type == html.CommentNode || child.Type == html.ElementNode && isUnlikely(child) {
+			n.RemoveChild(child)
+		} else {
+			removeUnlikely(child)
+		}
+		child = next
+	}
+}
+
+func isUnlikely(n *html.Node) bool {
+	if droppedElements[n.DataAtom] {
+		return true
+	}
+	if n.DataAtom == atom.Article || n.DataAtom == atom.Main || n.DataAtom == atom.Body {
+		return false
+	}
+	if attr(n, "hidden") != "" || attr(n, "aria-hidden") == "true" {
+		return true
+	}
+	match := attr(n, "class") + " " + attr(n, "id")
+	return unlikelyCandidate.MatchString(match) && !maybeCandidate.MatchString(match)
+}
+
+func bestCandidate(doc *html.Node) *html.Node {
+	scores := map[*html.Node]float64{}
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.P, atom.Pre, atom.Td, atom.Blockquote:
+				text := strings.TrimSpace(nodeText(n))
+				if len(text) >= 25 && n.Parent != nil {
+					score := 1 + float64(strings.Count(text, ",")) + float64(min(len(text)/100, 3))
+					scores[n.Parent] += score
+					if grand := n.Parent.Parent; grand != nil {
+						scores[grand] += score / 2
+					}
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			visit(child)
+		}
+	}
+	visit(doc)
+
+	var (
+		best      *html.Node
+		bestScore float64
+	)
+	for n, score := range scores {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		score *= 1 - linkDensity(n)
+		if best == nil || score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	return best
+}
+
+// linkDensity is the share of n's text that sits inside links.
+func linkDensity(n *html.Node) float64 {
+	total := len(nodeText(n))
+	if total == 0 {
+		return 0
+	}
+	linked := 0
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.A {
+			linked += len(nodeText(n))
+			return
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			visit(child)
+		}
+	}
+	visit(n)
+	return float64(linked) / float64(total)
+}
+
+func writeSanitized(out *bytes.Buffer, n *html.Node, base *url.URL) {
+	switch n.Type {
+	case html.TextNode:
+		out.WriteString(html.EscapeString(n.Data))
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	allowed, ok := allowedElements[n.DataAtom]
+	if !ok {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			writeSanitized(out, child, base)
+		}
+		return
+	}
+
+	var attrs []html.Attribute
+	for _, name := range allowed {
+		value := attr(n, name)
+		if value == "" {
+			continue
+		}
+		if name == "href" || name == "src" {
+			if value = absoluteHTTPURL(base, value); value == "" {
+				continue
+			}
+		}
+		attrs = append(attrs, html.Attribute{Key: name, Val: value})
+	}
+	if n.DataAtom == atom.Img && len(attrs) == 0 {
+		return
+	}
+	if n.DataAtom == atom.A {
+		attrs = append(attrs, html.Attribute{Key: "rel", Val: "noopener noreferrer nofollow"})
+	}
+
+	out.WriteString("<" + n.Data)
+	for _, a := range attrs {
+		out.WriteString(" " + a.Key + `="` + html.EscapeString(a.Val) + `"`)
+	}
+	out.WriteString(">")
+	if n.DataAtom == atom.Img || n.DataAtom == atom.Br || n.DataAtom == atom.Hr {
+		return
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		writeSanitized(out, child, base)
+	}
+	out.WriteString("</" + n.Data + ">")
+}
+
+// absoluteHTTPURL resolves ref against base and returns it only if it is an
+// http or https URL, so no javascript: or data: URL makes it into an archive.
+func absoluteHTTPURL(base *url.URL, ref string) string {
+	resolved := resolveURL(base, strings.TrimSpace(ref))
+	u, err := url.Parse(resolved)
+	if err != nil || checkScheme(u) != nil {
+		return ""
+	}
+	return resolved
+}
+
+func writeText(out *strings.Builder, n *html.Node) {
+	if n.Type == html.TextNode {
+		// Runs of whitespace become one space; lines are trimmed afterwards
+		fields := strings.Fields(n.Data)
+		if len(fields) == 0 {
+			if n.Data != "" {
+				out.WriteString(" ")
+			}
+			return
+		}
+		if strings.TrimLeft(n.Data, " \t\r\n") != n.Data {
+			out.WriteString(" ")
+		}
+		out.WriteString(strings.Join(fields, " "))
+		if strings.TrimRight(n.Data, " \t\r\n") != n.Data {
+			out.WriteString(" ")
+		}
+		return
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		writeText(out, child)
+	}
+	if n.Type == html.ElementNode && blockElements[n.DataAtom] {
+		out.WriteString("\n")
+	}
+}
+
+func collapseBlankLines(s string) string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n\n")
+}
+
+func nodeText(n *html.Node) string {
+	if n == nil {
+		return ""
+	}
+	var b strings.Builder
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			visit(child)
+		}
+	}
+	visit(n)
+	return b.String()
+}
+
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := findElement(child, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func firstNonNil(nodes ...*html.Node) *html.Node {
+	for _, n := range nodes {
+		if n != nil {
+			return n
+		}
+	}
+	return nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
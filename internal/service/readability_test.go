@@ -0,0 +1,77 @@
+package service
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestExtractReadableSanitizes(t *testing.T) {
+	page := `<!DOCTYPE html>
+<html><head><title> An article </title><script>alert("head")</script></head>
+<body>
+<nav><a href="/home">Home</a></nav>
+<article>
+<p onclick="alert('click')">The first paragraph of the article is long enough, with commas, to be scored as content.</p>
+<script>alert("body")</script>
+<p>Read <a href="javascript:alert('link')">this</a>, <a href="/next">the next part</a> or <a href="data:text/html,hi">that</a>.</p>
+<p><img src="javascript:alert('img')"><img src="/photo.jpg" alt="A photo" onerror="alert('error')"></p>
+<iframe src="https://ads.example.com/"></iframe>
+</article>
+</body></html>`
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, _ := url.Parse("https://example.com/articles/1")
+
+	content := ExtractReadable(doc, base)
+
+	if content.Title != "An article" {
+		t.Errorf("Title = %q, want %q", content.Title, "An article")
+	}
+	for _, unwanted := range []string{"<script", "alert(", "javascript:", "data:", "onclick", "onerror", "<iframe", "Home"} {
+		if strings.Contains(content.HTML, unwanted) {
+			t.Errorf("HTML contains %q:\n%s", unwanted, content.HTML)
+		}
+	}
+	for _, wanted := range []string{
+		`<a href="https://example.com/next" rel="noopener noreferrer nofollow">the next part</a>`,
+		`<img src="https://example.com/photo.jpg" alt="A photo">`,
+		`<a rel="noopener noreferrer nofollow">this</a>`,
+	} {
+		if !strings.Contains(content.HTML, wanted) {
+			t.Errorf("HTML does not contain %q:\n%s", wanted, content.HTML)
+		}
+	}
+	if strings.Contains(content.Text, "alert(") {
+		t.Errorf("Text contains script content:\n%s", content.Text)
+	}
+	if !strings.Contains(content.Text, "The first paragraph of the article") {
+		t.Errorf("Text is missing the article:\n%s", content.Text)
+	}
+}
+
+func TestAbsoluteHTTPURL(t *testing.T) {
+	base, _ := url.Parse("https://example.com/a/b")
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"c", "https://example.com/a/c"},
+		{"/c", "https://example.com/c"},
+		{"//cdn.example.com/x.png", "https://cdn.example.com/x.png"},
+		{"http://other.example.com/", "http://other.example.com/"},
+		{"javascript:alert(1)", ""},
+		{" JavaScript:alert(1)", ""},
+		{"data:image/png;base64,AAAA", ""},
+		{"vbscript:msgbox(1)", ""},
+	}
+	for _, tt := range tests {
+		if got := absoluteHTTPURL(base, tt.ref); got != tt.want {
+			t.Errorf("absoluteHTTPURL(%q) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
@@ -17,14 +17,18 @@ type ReadLaterService interface {
 
 type readLaterService struct {
 	repo   repository.ReadLaterRepository
+	access AccessService
 	logger *zap.Logger
 }
 
-func NewReadLaterService(repo repository.ReadLaterRepository, logger *zap.Logger) ReadLaterService {
-	return &readLaterService{repo: repo, logger: logger}
+func NewReadLaterService(repo repository.ReadLaterRepository, access AccessService, logger *zap.Logger) ReadLaterService {
+	return &readLaterService{repo: repo, access: access, logger: logger}
 }
 
 func (s *readLaterService) Add(item *model.ReadLaterItem) error {
+	if err := s.access.AuthorizeBookmark(item.UserID, item.BookmarkID); err != nil {
+		return err
+	}
 	if item.Status == "" {
 		item.Status = model.ReadLaterStatusUnread
 	}
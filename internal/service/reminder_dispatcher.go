@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	reminderBatchSize     = 100
+	reminderMaxRetryDelay = time.Hour
+)
+
+// ReminderRetryPolicy bounds how often delivering a reminder is retried.
+// Retries back off exponentially from RetryBaseDelay.
+type ReminderRetryPolicy struct {
+	MaxAttempts    int
+	RetryBaseDelay time.Duration
+}
+
+// ReminderDispatcher polls for pending reminders whose RemindAt has passed,
+// claims them and hands them to the configured Notifier.
+type ReminderDispatcher struct {
+	periodicJob
+	repo     repository.ReminderRepository
+	notifier Notifier
+	events   EventBus
+	policy   ReminderRetryPolicy
+	interval time.Duration
+	now      Clock
+	logger   *zap.Logger
+}
+
+func NewReminderDispatcher(
+	repo repository.ReminderRepository,
+	notifier Notifier,
+	events EventBus,
+	policy ReminderRetryPolicy,
+	interval time.Duration,
+	clock Clock,
+	logger *zap.Logger,
+) *ReminderDispatcher {
+	if clock == nil {
+		clock = time.Now
+	}
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 5
+	}
+	if policy.RetryBaseDelay <= 0 {
+		policy.RetryBaseDelay = time.Minute
+	}
+	return &ReminderDispatcher{
+		repo:     repo,
+		notifier: notifier,
+		events:   events,
+		policy:   policy,
+		interval: interval,
+		now:      clock,
+		logger:   logger,
+	}
+}
+
+func (d *ReminderDispatcher) Start(ctx context.Context) {
+	d.logger.Info("Starting reminder dispatcher", zap.Duration("interval", d.interval))
+	d.start(ctx, d.interval, func(ctx context.Context) { d.Dispatch(ctx) })
+}
+
+func (d *ReminderDispatcher) Stop() {
+	d.stop()
+	d.logger.Info("Stopped reminder dispatcher")
+}
+
+// Dispatch fires every reminder due at the current clock time and returns the
+// number of notifications delivered.
+func (d *ReminderDispatcher) Dispatch(ctx context.Context) int {
+	now := d.now()
+	reminders, err := d.repo.GetDueBefore(now, reminderBatchSize)
+	if err != nil {
+		d.logger.Error("Failed to load due reminders", zap.Error(err))
+		return 0
+	}
+
+	fired := 0
+	for _, reminder := range reminders {
+		if ctx.Err() != nil {
+			break
+		}
+
+		claimed, err := d.repo.MarkFired(reminder.ID, now)
+		if err != nil {
+			d.logger.Error("Failed to claim reminder", zap.String("id", reminder.ID.String()), zap.Error(err))
+			continue
+		}
+		if !claimed {
+			// Another replica fired it first
+			continue
+		}
+
+		reminder.Status = "fired"
+		reminder.FiredAt = &now
+		notification := model.Notification{
+			Type:       model.NotificationTypeReminder,
+			UserID:     reminder.UserID,
+			BookmarkID: reminder.BookmarkID,
+			Message:    reminder.Message,
+			Data:       reminder,
+			OccurredAt: now,
+		}
+
+		if err := d.notifier.Notify(ctx, notification); err != nil {
+			d.fail(&reminder, now, err)
+			if err := d.repo.RecordFailure(&reminder); err != nil {
+				d.logger.Error("Failed to save reminder delivery failure", zap.String("id", reminder.ID.String()), zap.Error(err))
+			}
+			continue
+		}
+		if err := d.events.Publish(nil, model.EventReminderFired, reminder.ID, reminder); err != nil {
+			d.logger.Warn("Failed to publish reminder event", zap.String("id", reminder.ID.String()), zap.Error(err))
+		}
+		fired++
+	}
+
+	if fired > 0 {
+		d.logger.Info("Fired bookmark reminders", zap.Int("count", fired))
+	}
+	return fired
+}
+
+// fail records a failed delivery and schedules a retry, or gives up once
+// attempts run out.
+func (d *ReminderDispatcher) fail(reminder *model.BookmarkReminder, now time.Time, err error) {
+	reminder.Attempts++
+	reminder.Error = truncate(err.Error(), 1000)
+	if reminder.Attempts >= d.policy.MaxAttempts {
+		reminder.Status = "failed"
+		reminder.NextAttemptAt = nil
+		d.logger.Warn("Failed to deliver reminder, giving up",
+			zap.String("id", reminder.ID.String()),
+			zap.Int("attempts", reminder.Attempts),
+			zap.Error(err))
+		return
+	}
+
+	next := now.Add(d.retryDelay(reminder.Attempts))
+	reminder.Status = "pending"
+	reminder.FiredAt = nil
+	reminder.NextAttemptAt = &next
+	d.logger.Warn("Failed to deliver reminder, will retry",
+		zap.String("id", reminder.ID.String()),
+		zap.Int("attempts", reminder.Attempts),
+		zap.Time("nextAttemptAt", next),
+		zap.Error(err))
+}
+
+func (d *ReminderDispatcher) retryDelay(attempts int) time.Duration {
+	delay := d.policy.RetryBaseDelay
+	for i := 1; i < attempts && delay < reminderMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > reminderMaxRetryDelay {
+		delay = reminderMaxRetryDelay
+	}
+	return delay
+}
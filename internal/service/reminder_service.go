@@ -23,12 +23,13 @@ type BookmarkReminderService interface {
 }
 
 type bookmarkReminderService struct {
-	repo   repository.ReminderRepository
-	logger *zap.Logger
+	repo     repository.ReminderRepository
+	activity ActivityRecorder
+	logger   *zap.Logger
 }
 
-func NewBookmarkReminderService(repo repository.ReminderRepository, logger *zap.Logger) BookmarkReminderService {
-	return &bookmarkReminderService{repo: repo, logger: logger}
+func NewBookmarkReminderService(repo repository.ReminderRepository, activity ActivityRecorder, logger *zap.Logger) BookmarkReminderService {
+	return &bookmarkReminderService{repo: repo, activity: activity, logger: logger}
 }
 
 func (s *bookmarkReminderService) Create(reminder *model.BookmarkReminder) error {
@@ -40,6 +41,7 @@ func (s *bookmarkReminderService) Create(reminder *model.BookmarkReminder) error
 	if err != nil {
 		return err
 	}
+	s.activity.Record(reminder.UserID, reminder.BookmarkID, model.ActivityReminderSet, reminderDetails(reminder))
 	s.logger.Info("Created bookmark reminder", zap.String("id", reminder.ID.String()))
 	return nil
 }
@@ -74,19 +76,29 @@ func (s *bookmarkReminderService) Update(id uuid.UUID, remindAt time.Time, messa
 	if message != "" {
 		reminder.Message = message
 	}
+	// A rescheduled reminder starts over with its deliveries
+	reminder.Attempts = 0
+	reminder.NextAttemptAt = nil
+	reminder.Error = ""
 
 	err = s.repo.Update(reminder)
 	if err != nil {
 		return nil, err
 	}
+	s.activity.Record(reminder.UserID, reminder.BookmarkID, model.ActivityReminderUpdated, reminderDetails(reminder))
 	return reminder, nil
 }
 
 func (s *bookmarkReminderService) Delete(id uuid.UUID) error {
-	err := s.repo.Delete(id)
+	reminder, err := s.repo.GetByID(id)
 	if err != nil {
 		return err
 	}
+	err = s.repo.Delete(id)
+	if err != nil {
+		return err
+	}
+	s.activity.Record(reminder.UserID, reminder.BookmarkID, model.ActivityReminderDeleted, map[string]interface{}{"reminderId": reminder.ID})
 	s.logger.Info("Deleted bookmark reminder", zap.String("id", id.String()))
 	return nil
 }
@@ -100,9 +112,21 @@ func (s *bookmarkReminderService) Cancel(id uuid.UUID) error {
 		return fmt.Errorf("can only cancel pending reminders")
 	}
 	reminder.Status = "cancelled"
-	return s.repo.Update(reminder)
+	if err := s.repo.Update(reminder); err != nil {
+		return err
+	}
+	s.activity.Record(reminder.UserID, reminder.BookmarkID, model.ActivityReminderCancelled, map[string]interface{}{"reminderId": reminder.ID})
+	return nil
 }
 
 func (s *bookmarkReminderService) CancelByBookmark(bookmarkID uuid.UUID) error {
 	return s.repo.CancelByBookmark(bookmarkID)
 }
+
+func reminderDetails(reminder *model.BookmarkReminder) map[string]interface{} {
+	return map[string]interface{}{
+		"reminderId": reminder.ID,
+		"remindAt":   reminder.RemindAt,
+		"message":    reminder.Message,
+	}
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/repository"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// RuleEngine runs a user's rules against bookmarks as they are created or
+// imported.
+type RuleEngine interface {
+	// Load returns the user's enabled rules, ready to match.
+	Load(userID uuid.UUID) (*RuleSet, error)
+	// Prepare matches a bookmark that is about to be saved and files it into
+	// the folder the rules pick. It returns nil when no rule matched.
+	Prepare(rules *RuleSet, bookmark *model.Bookmark) *model.RuleOutcome
+	// Apply writes the rest of outcome once the bookmark is saved: tags,
+	// collection entries, read-later entry and expiration. With a nil tx
+	// each write commits on its own.
+	Apply(tx *gorm.DB, bookmark *model.Bookmark, outcome *model.RuleOutcome) error
+}
+
+type ruleEngine struct {
+	repo       repository.RuleRepository
+	bulkRepo   repository.BulkRepository
+	folderRepo repository.FolderRepository
+	logger     *zap.Logger
+}
+
+func NewRuleEngine(
+	repo repository.RuleRepository,
+	bulkRepo repository.BulkRepository,
+	folderRepo repository.FolderRepository,
+	logger *zap.Logger,
+) RuleEngine {
+	return &ruleEngine{repo: repo, bulkRepo: bulkRepo, folderRepo: folderRepo, logger: logger}
+}
+
+func (e *ruleEngine) Load(userID uuid.UUID) (*RuleSet, error) {
+	rules, err := e.repo.GetEnabled(userID)
+	if err != nil {
+		return nil, err
+	}
+	return compileRules(rules, e.logger), nil
+}
+
+func (e *ruleEngine) Prepare(rules *RuleSet, bookmark *model.Bookmark) *model.RuleOutcome {
+	outcome := rules.Match(bookmark)
+	if outcome == nil || outcome.FolderID == nil {
+		return outcome
+	}
+	folder, err := e.folderRepo.GetByID(*outcome.FolderID)
+	if err != nil || !canFile(folder, bookmark) {
+		e.logger.Warn("Skipping rule folder", zap.String("folderId", outcome.FolderID.String()), zap.Error(err))
+		outcome.FolderID = nil
+		return outcome
+	}
+	bookmark.FolderID = outcome.FolderID
+	return outcome
+}
+
+func (e *ruleEngine) Apply(tx *gorm.DB, bookmark *model.Bookmark, outcome *model.RuleOutcome) error {
+	if outcome == nil {
+		return nil
+	}
+	repo := e.bulkRepo
+	if tx != nil {
+		repo = repo.WithTx(tx)
+	}
+	writes := newRuleWrites(bookmark.UserID, time.Now())
+	writes.add(bookmark, outcome, false)
+	return writes.write(repo, &model.RuleReapplyResult{})
+}
+
+// canFile reports whether a rule may file bookmark into folder: rules act
+// for the bookmark's owner and never move it to another workspace.
+func canFile(folder *model.BookmarkFolder, bookmark *model.Bookmark) bool {
+	return folder.UserID == bookmark.UserID && folder.WorkspaceID == bookmark.WorkspaceID
+}
+
+// RuleSet is a list of rules in the order they run, with their patterns
+// compiled.
+type RuleSet struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	rule     model.BookmarkRule
+	pattern  *regexp.Regexp
+	keywords []string
+	// broken rules have a pattern that no longer compiles and never match
+	broken bool
+}
+
+// compileRules prepares rules for matching. Rules are validated when they
+// are saved, so a pattern that fails to compile here only disables its rule.
+func compileRules(rules []model.BookmarkRule, logger *zap.Logger) *RuleSet {
+	set := &RuleSet{rules: make([]compiledRule, len(rules))}
+	for i, rule := range rules {
+		c := compiledRule{rule: rule}
+		if rule.Conditions.URLPattern != "" {
+			pattern, err := regexp.Compile(rule.Conditions.URLPattern)
+			if err != nil {
+				logger.Warn("Ignoring rule with invalid URL pattern", zap.String("ruleId", rule.ID.String()), zap.Error(err))
+				c.broken = true
+			}
+			c.pattern = pattern
+		}
+		for _, keyword := range rule.Conditions.TitleKeywords {
+			if keyword = strings.ToLower(strings.TrimSpace(keyword)); keyword != "" {
+				c.keywords = append(c.keywords, keyword)
+			}
+		}
+		set.rules[i] = c
+	}
+	return set
+}
+
+// Match returns what the rules do to bookmark, or nil when none matched. A
+// folder is only picked for bookmarks that are not in one.
+func (rs *RuleSet) Match(bookmark *model.Bookmark) *model.RuleOutcome {
+	var outcome *model.RuleOutcome
+	for i := range rs.rules {
+		c := &rs.rules[i]
+		if !c.matches(bookmark) {
+			continue
+		}
+		if outcome == nil {
+			outcome = &model.RuleOutcome{}
+		}
+		mergeRuleActions(outcome, &c.rule, bookmark)
+		if c.rule.StopProcessing {
+			break
+		}
+	}
+	return outcome
+}
+
+func (c *compiledRule) matches(bookmark *model.Bookmark) bool {
+	cond := c.rule.Conditions
+	switch {
+	case c.broken:
+		return false
+	case cond.WorkspaceID != nil && *cond.WorkspaceID != bookmark.WorkspaceID:
+		return false
+	case cond.Type != "" && cond.Type != bookmark.Type:
+		return false
+	case cond.Domain != "" && !inDomain(bookmark.TargetURL, cond.Domain):
+		return false
+	case c.pattern != nil && !c.pattern.MatchString(bookmark.TargetURL):
+		return false
+	}
+	if len(c.keywords) == 0 {
+		return true
+	}
+	title := strings.ToLower(bookmark.Title)
+	for _, keyword := range c.keywords {
+		if strings.Contains(title, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// inDomain reports whether rawURL's host is domain or one of its
+// subdomains, the same test the domain: search qualifier makes.
+func inDomain(rawURL, domain string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	for _, host := range []string{strings.ToLower(u.Hostname()), strings.ToLower(u.Host)} {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func mergeRuleActions(outcome *model.RuleOutcome, rule *model.BookmarkRule, bookmark *model.Bookmark) {
+	outcome.RuleIDs = append(outcome.RuleIDs, rule.ID)
+	actions := rule.Actions
+	if actions.FolderID != nil && outcome.FolderID == nil && bookmark.FolderID == nil {
+		outcome.FolderID = actions.FolderID
+	}
+	for _, tagID := range actions.TagIDs {
+		if !containsID(outcome.TagIDs, tagID) {
+			outcome.TagIDs = append(outcome.TagIDs, tagID)
+		}
+	}
+	if actions.CollectionID != nil && !containsID(outcome.CollectionIDs, *actions.CollectionID) {
+		outcome.CollectionIDs = append(outcome.CollectionIDs, *actions.CollectionID)
+	}
+	if actions.ReadLater != nil && outcome.ReadLater == nil {
+		outcome.ReadLater = actions.ReadLater
+	}
+	if actions.Expiration != nil && outcome.Expiration == nil {
+		outcome.Expiration = actions.Expiration
+	}
+}
+
+func containsID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleWrites gathers the outcomes for many bookmarks of one user so each
+// target is written with one statement.
+type ruleWrites struct {
+	userID uuid.UUID
+	// now is when the rules are applied, which expirations count from.
+	now time.Time
+	// overwriteExpirations replaces expirations bookmarks already have,
+	// which are otherwise kept.
+	overwriteExpirations bool
+	folders              map[uuid.UUID][]uuid.UUID
+	tags                 map[uuid.UUID][]uuid.UUID
+	collections          map[uuid.UUID][]uuid.UUID
+	readLater            map[int][]uuid.UUID
+	expirations          []model.BookmarkExpiration
+}
+
+func newRuleWrites(userID uuid.UUID, now time.Time) *ruleWrites {
+	return &ruleWrites{
+		userID:      userID,
+		now:         now,
+		folders:     map[uuid.UUID][]uuid.UUID{},
+		tags:        map[uuid.UUID][]uuid.UUID{},
+		collections: map[uuid.UUID][]uuid.UUID{},
+		readLater:   map[int][]uuid.UUID{},
+	}
+}
+
+// add queues outcome for bookmark. The folder is only queued when file is
+// set; new bookmarks are filed before they are inserted instead.
+func (w *ruleWrites) add(bookmark *model.Bookmark, outcome *model.RuleOutcome, file bool) {
+	id := bookmark.ID
+	if file && outcome.FolderID != nil {
+		w.folders[*outcome.FolderID] = append(w.folders[*outcome.FolderID], id)
+	}
+	for _, tagID := range outcome.TagIDs {
+		w.tags[tagID] = append(w.tags[tagID], id)
+	}
+	for _, collectionID := range outcome.CollectionIDs {
+		w.collections[collectionID] = append(w.collections[collectionID], id)
+	}
+	if outcome.ReadLater != nil {
+		w.readLater[outcome.ReadLater.Priority] = append(w.readLater[outcome.ReadLater.Priority], id)
+	}
+	if exp := outcome.Expiration; exp != nil {
+		w.expirations = append(w.expirations, model.BookmarkExpiration{
+			BookmarkID:     id,
+			UserID:         w.userID,
+			ExpiresAt:      w.now.AddDate(0, 0, exp.AfterDays),
+			Action:         exp.Action,
+			TargetFolderID: exp.TargetFolderID,
+		})
+	}
+}
+
+// write applies the queued actions with repo and adds what changed to result.
+func (w *ruleWrites) write(repo repository.BulkRepository, result *model.RuleReapplyResult) error {
+	for folderID, ids := range w.folders {
+		folderID := folderID
+		if err := repo.MoveBookmarks(ids, &folderID); err != nil {
+			return err
+		}
+		result.Filed += len(ids)
+	}
+	for tagID, ids := range w.tags {
+		added, err := repo.AddTags(ids, []uuid.UUID{tagID})
+		if err != nil {
+			return err
+		}
+		result.Tagged += added
+	}
+	for collectionID, ids := range w.collections {
+		added, err := repo.AddToCollection(collectionID, w.userID, ids)
+		if err != nil {
+			return err
+		}
+		result.AddedToCollections += added
+	}
+	for priority, ids := range w.readLater {
+		added, err := repo.AddToReadLater(w.userID, ids, priority)
+		if err != nil {
+			return err
+		}
+		result.AddedToReadLater += added
+	}
+	if w.overwriteExpirations {
+		if err := repo.SetExpirations(w.expirations); err != nil {
+			return err
+		}
+		result.ExpirationsSet += len(w.expirations)
+		return nil
+	}
+	added, err := repo.AddExpirations(w.expirations)
+	if err != nil {
+		return err
+	}
+	result.ExpirationsSet += added
+	return nil
+}
@@ -0,0 +1,406 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/repository"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// ruleBatchSize is how many bookmarks a dry run or re-apply loads at once.
+	ruleBatchSize = 500
+	// maxRulePatternLength bounds a rule's URL pattern.
+	maxRulePatternLength = 500
+)
+
+// ErrInvalidRule is wrapped by every reason a rule is rejected.
+var ErrInvalidRule = errors.New("invalid rule")
+
+type RuleService interface {
+	Create(rule *model.BookmarkRule) error
+	GetByID(id uuid.UUID) (*model.BookmarkRule, error)
+	GetByUser(userID uuid.UUID) ([]model.BookmarkRule, error)
+	Update(id uuid.UUID, req *model.UpdateRuleRequest) (*model.BookmarkRule, error)
+	Delete(id uuid.UUID) error
+	// Reorder runs the rules in ids first, in that order, followed by the
+	// user's other rules in their current order.
+	Reorder(userID uuid.UUID, ids []uuid.UUID) error
+	// DryRun reports which of the user's bookmarks the rules would change,
+	// without changing anything. rule, if set, is tried instead of the saved
+	// rules; ruleIDs picks saved rules, enabled or not; otherwise every
+	// enabled rule runs.
+	DryRun(userID uuid.UUID, rule *model.BookmarkRule, ruleIDs []uuid.UUID, workspaceID *uuid.UUID, page, limit int) (*model.RuleDryRunResult, error)
+	// Reapply runs the rules over the user's existing bookmarks, a batch at a
+	// time, each batch in its own transaction. Existing expirations are only
+	// replaced when overwriteExpirations is set.
+	Reapply(userID uuid.UUID, ruleIDs []uuid.UUID, workspaceID *uuid.UUID, overwriteExpirations bool) (*model.RuleReapplyResult, error)
+}
+
+type ruleService struct {
+	repo       repository.RuleRepository
+	bulkRepo   repository.BulkRepository
+	folderRepo repository.FolderRepository
+	access     AccessService
+	activity   ActivityRecorder
+	events     EventBus
+	tx         repository.Transactor
+	redis      *redis.Client
+	logger     *zap.Logger
+}
+
+func NewRuleService(
+	repo repository.RuleRepository,
+	bulkRepo repository.BulkRepository,
+	folderRepo repository.FolderRepository,
+	access AccessService,
+	activity ActivityRecorder,
+	events EventBus,
+	tx repository.Transactor,
+	redis *redis.Client,
+	logger *zap.Logger,
+) RuleService {
+	return &ruleService{
+		repo:       repo,
+		bulkRepo:   bulkRepo,
+		folderRepo: folderRepo,
+		access:     access,
+		activity:   activity,
+		events:     events,
+		tx:         tx,
+		redis:      redis,
+		logger:     logger,
+	}
+}
+
+func (s *ruleService) Create(rule *model.BookmarkRule) error {
+	if err := s.validate(rule); err != nil {
+		return err
+	}
+	position, err := s.repo.NextPosition(rule.UserID)
+	if err != nil {
+		return err
+	}
+	rule.Position = position
+	if err := s.repo.Create(rule); err != nil {
+		return err
+	}
+	s.logger.Info("Created rule", zap.String("id", rule.ID.String()))
+	return nil
+}
+
+func (s *ruleService) GetByID(id uuid.UUID) (*model.BookmarkRule, error) {
+	rule, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, lookupError("rule", err)
+	}
+	return rule, nil
+}
+
+func (s *ruleService) GetByUser(userID uuid.UUID) ([]model.BookmarkRule, error) {
+	return s.repo.GetByUser(userID)
+}
+
+func (s *ruleService) Update(id uuid.UUID, req *model.UpdateRuleRequest) (*model.BookmarkRule, error) {
+	existing, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != "" {
+		existing.Name = req.Name
+	}
+	if req.Enabled != nil {
+		existing.Enabled = *req.Enabled
+	}
+	if req.StopProcessing != nil {
+		existing.StopProcessing = *req.StopProcessing
+	}
+	if req.Conditions != nil {
+		existing.Conditions = *req.Conditions
+	}
+	if req.Actions != nil {
+		existing.Actions = *req.Actions
+	}
+	if err := s.validate(existing); err != nil {
+		return nil, err
+	}
+	err = s.repo.Update(existing)
+	return existing, err
+}
+
+func (s *ruleService) Delete(id uuid.UUID) error {
+	return s.repo.Delete(id)
+}
+
+func (s *ruleService) Reorder(userID uuid.UUID, ids []uuid.UUID) error {
+	rules, err := s.repo.GetByUser(userID)
+	if err != nil {
+		return err
+	}
+	owned := make(map[uuid.UUID]bool, len(rules))
+	for _, rule := range rules {
+		owned[rule.ID] = true
+	}
+
+	positions := make(map[uuid.UUID]int, len(rules))
+	for _, id := range ids {
+		if !owned[id] {
+			return fmt.Errorf("rule %s %w", id, ErrNotFound)
+		}
+		if _, ok := positions[id]; !ok {
+			positions[id] = len(positions)
+		}
+	}
+	for _, rule := range rules {
+		if _, ok := positions[rule.ID]; !ok {
+			positions[rule.ID] = len(positions)
+		}
+	}
+	return s.repo.SetPositions(positions)
+}
+
+func (s *ruleService) DryRun(userID uuid.UUID, rule *model.BookmarkRule, ruleIDs []uuid.UUID, workspaceID *uuid.UUID, page, limit int) (*model.RuleDryRunResult, error) {
+	var rules []model.BookmarkRule
+	if rule != nil {
+		if err := s.validate(rule); err != nil {
+			return nil, err
+		}
+		rules = []model.BookmarkRule{*rule}
+	} else {
+		var err error
+		if rules, err = s.selectRules(userID, ruleIDs); err != nil {
+			return nil, err
+		}
+	}
+	set := compileRules(rules, s.logger)
+
+	if page < 0 {
+		page = 0
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	offset := page * limit
+
+	result := &model.RuleDryRunResult{Matches: []model.RuleMatch{}}
+	err := s.repo.FindBookmarks(userID, workspaceID, ruleBatchSize, func(bookmarks []model.Bookmark) error {
+		for i := range bookmarks {
+			result.Scanned++
+			outcome := set.Match(&bookmarks[i])
+			if outcome == nil {
+				continue
+			}
+			if result.Matched >= offset && len(result.Matches) < limit {
+				result.Matches = append(result.Matches, model.RuleMatch{Bookmark: bookmarks[i], Outcome: *outcome})
+			}
+			result.Matched++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *ruleService) Reapply(userID uuid.UUID, ruleIDs []uuid.UUID, workspaceID *uuid.UUID, overwriteExpirations bool) (*model.RuleReapplyResult, error) {
+	rules, err := s.selectRules(userID, ruleIDs)
+	if err != nil {
+		return nil, err
+	}
+	result := &model.RuleReapplyResult{}
+	if len(rules) == 0 {
+		return result, nil
+	}
+	set := compileRules(rules, s.logger)
+
+	folders, err := s.folderRepo.GetByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	folderByID := make(map[uuid.UUID]*model.BookmarkFolder, len(folders))
+	for i := range folders {
+		folderByID[folders[i].ID] = &folders[i]
+	}
+
+	err = s.repo.FindBookmarks(userID, workspaceID, ruleBatchSize, func(bookmarks []model.Bookmark) error {
+		writes := newRuleWrites(userID, time.Now())
+		writes.overwriteExpirations = overwriteExpirations
+		matched := map[uuid.UUID]*model.RuleOutcome{}
+		for i := range bookmarks {
+			bookmark := &bookmarks[i]
+			result.Scanned++
+			outcome := set.Match(bookmark)
+			if outcome == nil {
+				continue
+			}
+			if outcome.FolderID != nil {
+				if folder := folderByID[*outcome.FolderID]; folder == nil || !canFile(folder, bookmark) {
+					outcome.FolderID = nil
+				}
+			}
+			result.Matched++
+			matched[bookmark.ID] = outcome
+			writes.add(bookmark, outcome, true)
+		}
+		if len(matched) == 0 {
+			return nil
+		}
+
+		err := s.tx.Transaction(func(tx *gorm.DB) error {
+			if err := writes.write(s.bulkRepo.WithTx(tx), result); err != nil {
+				return err
+			}
+			for i := range bookmarks {
+				outcome := matched[bookmarks[i].ID]
+				if outcome == nil || outcome.FolderID == nil {
+					continue
+				}
+				err := s.events.Publish(tx, model.EventBookmarkMoved, bookmarks[i].ID, model.BookmarkMovedEvent{
+					BookmarkID: bookmarks[i].ID,
+					UserID:     userID,
+					ToFolderID: outcome.FolderID,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		keys := []string{userBookmarksCacheKey(userID)}
+		for id, outcome := range matched {
+			keys = append(keys, bookmarkCacheKey(id))
+			if outcome.FolderID != nil {
+				s.activity.Record(userID, id, model.ActivityMoved, map[string]interface{}{
+					"toFolderId": outcome.FolderID,
+					"ruleIds":    outcome.RuleIDs,
+				})
+			}
+		}
+		s.redis.Del(context.Background(), keys...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Re-applied rules",
+		zap.String("userId", userID.String()),
+		zap.Int("scanned", result.Scanned),
+		zap.Int("matched", result.Matched))
+	return result, nil
+}
+
+// selectRules returns the user's enabled rules, or the rules in ids whether
+// enabled or not, in the order they run.
+func (s *ruleService) selectRules(userID uuid.UUID, ids []uuid.UUID) ([]model.BookmarkRule, error) {
+	if len(ids) == 0 {
+		return s.repo.GetEnabled(userID)
+	}
+	rules, err := s.repo.GetByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[uuid.UUID]bool, len(rules))
+	var selected []model.BookmarkRule
+	for _, rule := range rules {
+		if containsID(ids, rule.ID) {
+			found[rule.ID] = true
+			selected = append(selected, rule)
+		}
+	}
+	for _, id := range ids {
+		if !found[id] {
+			return nil, fmt.Errorf("rule %s %w", id, ErrNotFound)
+		}
+	}
+	return selected, nil
+}
+
+// validate normalises a rule and checks that its conditions are well formed
+// and that it only acts on folders, tags and collections its owner has.
+func (s *ruleService) validate(rule *model.BookmarkRule) error {
+	cond := &rule.Conditions
+	if cond.Domain != "" {
+		domain, ok := normalizeDomain(cond.Domain)
+		if !ok {
+			return fmt.Errorf("%w: invalid domain %q", ErrInvalidRule, cond.Domain)
+		}
+		cond.Domain = domain
+	}
+	if cond.URLPattern != "" {
+		if len(cond.URLPattern) > maxRulePatternLength {
+			return fmt.Errorf("%w: urlPattern is longer than %d characters", ErrInvalidRule, maxRulePatternLength)
+		}
+		if _, err := regexp.Compile(cond.URLPattern); err != nil {
+			return fmt.Errorf("%w: urlPattern: %v", ErrInvalidRule, err)
+		}
+	}
+	if cond.Type != "" && !isBookmarkType(string(cond.Type)) {
+		return fmt.Errorf("%w: unknown bookmark type %q", ErrInvalidRule, cond.Type)
+	}
+	keywords := cond.TitleKeywords[:0]
+	for _, keyword := range cond.TitleKeywords {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			keywords = append(keywords, keyword)
+		}
+	}
+	cond.TitleKeywords = keywords
+
+	actions := &rule.Actions
+	if actions.FolderID == nil && len(actions.TagIDs) == 0 && actions.CollectionID == nil &&
+		actions.ReadLater == nil && actions.Expiration == nil {
+		return fmt.Errorf("%w: at least one action is required", ErrInvalidRule)
+	}
+	if actions.FolderID != nil {
+		if err := s.access.AuthorizeFolder(rule.UserID, *actions.FolderID); err != nil {
+			return err
+		}
+	}
+	var tagIDs []uuid.UUID
+	for _, tagID := range actions.TagIDs {
+		if containsID(tagIDs, tagID) {
+			continue
+		}
+		if err := s.access.AuthorizeTag(rule.UserID, tagID); err != nil {
+			return err
+		}
+		tagIDs = append(tagIDs, tagID)
+	}
+	actions.TagIDs = tagIDs
+	if actions.CollectionID != nil {
+		if err := s.access.AuthorizeCollection(rule.UserID, *actions.CollectionID); err != nil {
+			return err
+		}
+	}
+	if exp := actions.Expiration; exp != nil {
+		if exp.AfterDays < 1 {
+			return fmt.Errorf("%w: expiration afterDays must be at least 1", ErrInvalidRule)
+		}
+		expiration := model.BookmarkExpiration{UserID: rule.UserID, Action: exp.Action, TargetFolderID: exp.TargetFolderID}
+		if err := checkExpiration(s.folderRepo, &expiration); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return err
+			}
+			return fmt.Errorf("%w: expiration: %v", ErrInvalidRule, err)
+		}
+		exp.Action, exp.TargetFolderID = expiration.Action, expiration.TargetFolderID
+	}
+	return nil
+}
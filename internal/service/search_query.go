@@ -0,0 +1,292 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/quckapp/bookmark-service/internal/model"
+)
+
+const searchDateLayout = "2006-01-02"
+
+var (
+	searchIsValues        = []string{"favorite", "archived", "shared"}
+	searchHasValues       = []string{"note", "comment", "reminder", "preview", "tag", "expiration", "archive"}
+	searchReadLaterValues = []string{"unread", "reading", "completed", "archived"}
+)
+
+// SearchQueryError reports why a search query could not be parsed. Pos is the
+// 1-based character offset of the offending token.
+type SearchQueryError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SearchQueryError) Error() string {
+	return fmt.Sprintf("invalid search query at position %d: %s", e.Pos, e.Msg)
+}
+
+// ParseSearchQuery parses a search expression such as
+//
+//	tag:design type:external folder:"Q3 Planning" created:>2026-01-01 -tag:old react
+//
+// Words and "quoted phrases" are matched against the full-text index; a
+// leading - negates a word or qualifier. Words with an unknown prefix, like
+// URLs, are kept as text.
+func ParseSearchQuery(input string) (*model.SearchQuery, error) {
+	p := &searchQueryParser{input: []rune(input)}
+	return p.parse()
+}
+
+type searchQueryParser struct {
+	input []rune
+	pos   int
+}
+
+func (p *searchQueryParser) parse() (*model.SearchQuery, error) {
+	query := &model.SearchQuery{}
+	var text []string
+
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.input) {
+			break
+		}
+
+		start := p.pos
+		negate := false
+		if p.input[p.pos] == '-' {
+			negate = true
+			p.pos++
+			if p.pos >= len(p.input) || unicode.IsSpace(p.input[p.pos]) {
+				return nil, p.errorAt(start, "expected a word or qualifier after '-'")
+			}
+		}
+
+		if p.input[p.pos] == '"' {
+			phraseStart := p.pos
+			phrase, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			if negate && strings.TrimSpace(phrase) == "" {
+				// Excluding everything that contains nothing would match no bookmark
+				return nil, p.errorAt(phraseStart, "empty phrase after '-'")
+			}
+			if negate {
+				query.Filters = append(query.Filters, model.SearchFilter{Field: model.SearchFieldText, Value: phrase, Negate: true})
+			} else if phrase != "" {
+				text = append(text, phrase)
+			}
+			continue
+		}
+
+		wordStart := p.pos
+		key := p.readWhile(func(r rune) bool { return !unicode.IsSpace(r) && r != ':' && r != '"' })
+		field := strings.ToLower(key)
+		if p.pos < len(p.input) && p.input[p.pos] == ':' && isSearchField(field) {
+			p.pos++
+			valueStart := p.pos
+			var value string
+			if p.pos < len(p.input) && p.input[p.pos] == '"' {
+				var err error
+				if value, err = p.quoted(); err != nil {
+					return nil, err
+				}
+			} else {
+				value = p.readWhile(func(r rune) bool { return !unicode.IsSpace(r) })
+			}
+			if strings.TrimSpace(value) == "" {
+				return nil, p.errorAt(valueStart, fmt.Sprintf("missing value for %s:", field))
+			}
+
+			filter, err := p.filter(field, value, valueStart)
+			if err != nil {
+				return nil, err
+			}
+			filter.Negate = negate
+			query.Filters = append(query.Filters, filter)
+			continue
+		}
+
+		// Not a qualifier: take the rest of the token as a plain word
+		p.pos = wordStart
+		word := p.readWhile(func(r rune) bool { return !unicode.IsSpace(r) })
+		if negate {
+			query.Filters = append(query.Filters, model.SearchFilter{Field: model.SearchFieldText, Value: word, Negate: true})
+		} else {
+			text = append(text, word)
+		}
+	}
+
+	query.Text = strings.Join(text, " ")
+	return query, nil
+}
+
+func (p *searchQueryParser) filter(field, value string, pos int) (model.SearchFilter, error) {
+	filter := model.SearchFilter{Field: field, Value: value}
+
+	switch field {
+	case model.SearchFieldType:
+		if !isBookmarkType(value) {
+			return filter, p.errorAt(pos, fmt.Sprintf("unknown bookmark type %q", value))
+		}
+	case model.SearchFieldIs:
+		filter.Value = strings.ToLower(value)
+		if !contains(searchIsValues, filter.Value) {
+			return filter, p.errorAt(pos, fmt.Sprintf("is: expects one of %s", strings.Join(searchIsValues, ", ")))
+		}
+	case model.SearchFieldHas:
+		filter.Value = strings.ToLower(value)
+		if !contains(searchHasValues, filter.Value) {
+			return filter, p.errorAt(pos, fmt.Sprintf("has: expects one of %s", strings.Join(searchHasValues, ", ")))
+		}
+	case model.SearchFieldReadLater:
+		filter.Value = strings.ToLower(value)
+		if !contains(searchReadLaterValues, filter.Value) {
+			return filter, p.errorAt(pos, fmt.Sprintf("readlater: expects one of %s", strings.Join(searchReadLaterValues, ", ")))
+		}
+	case model.SearchFieldDomain:
+		domain, ok := normalizeDomain(value)
+		if !ok {
+			return filter, p.errorAt(pos, fmt.Sprintf("invalid domain %q", value))
+		}
+		filter.Value = domain
+	case model.SearchFieldCreated, model.SearchFieldUpdated:
+		from, to, err := parseDateRange(value)
+		if err != nil {
+			return filter, p.errorAt(pos, err.Error())
+		}
+		filter.From, filter.To = from, to
+	}
+	return filter, nil
+}
+
+// normalizeDomain lowercases a domain and strips any scheme and trailing
+// slash; ok is false when what is left is not a bare host.
+func normalizeDomain(value string) (string, bool) {
+	domain := strings.ToLower(value)
+	if i := strings.Index(domain, "://"); i >= 0 {
+		domain = domain[i+3:]
+	}
+	domain = strings.TrimSuffix(domain, "/")
+	if domain == "" || strings.ContainsAny(domain, "/?#@") {
+		return "", false
+	}
+	return domain, true
+}
+
+// parseDateRange turns a date comparison into a half-open range of days:
+// 2026-01-01, >2026-01-01, >=, <, <= and 2026-01-01..2026-01-31 (inclusive).
+func parseDateRange(value string) (*time.Time, *time.Time, error) {
+	day := func(s string) (time.Time, error) {
+		t, err := time.Parse(searchDateLayout, s)
+		if err != nil {
+			return t, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", s)
+		}
+		return t, nil
+	}
+	next := func(t time.Time) *time.Time {
+		n := t.AddDate(0, 0, 1)
+		return &n
+	}
+
+	if from, to, ok := strings.Cut(value, ".."); ok {
+		start, err := day(from)
+		if err != nil {
+			return nil, nil, err
+		}
+		end, err := day(to)
+		if err != nil {
+			return nil, nil, err
+		}
+		if end.Before(start) {
+			return nil, nil, fmt.Errorf("date range %q ends before it starts", value)
+		}
+		return &start, next(end), nil
+	}
+
+	for _, op := range []string{">=", "<=", ">", "<"} {
+		if !strings.HasPrefix(value, op) {
+			continue
+		}
+		t, err := day(strings.TrimPrefix(value, op))
+		if err != nil {
+			return nil, nil, err
+		}
+		switch op {
+		case ">=":
+			return &t, nil, nil
+		case ">":
+			return next(t), nil, nil
+		case "<":
+			return nil, &t, nil
+		default:
+			return nil, next(t), nil
+		}
+	}
+
+	t, err := day(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &t, next(t), nil
+}
+
+// quoted reads a "double-quoted" string starting at the current position.
+func (p *searchQueryParser) quoted() (string, error) {
+	start := p.pos
+	p.pos++
+	value := p.readWhile(func(r rune) bool { return r != '"' })
+	if p.pos >= len(p.input) {
+		return "", p.errorAt(start, "unterminated quoted string")
+	}
+	p.pos++
+	return value, nil
+}
+
+func (p *searchQueryParser) readWhile(accept func(r rune) bool) string {
+	start := p.pos
+	for p.pos < len(p.input) && accept(p.input[p.pos]) {
+		p.pos++
+	}
+	return string(p.input[start:p.pos])
+}
+
+func (p *searchQueryParser) skipSpace() {
+	p.readWhile(unicode.IsSpace)
+}
+
+func (p *searchQueryParser) errorAt(pos int, msg string) error {
+	return &SearchQueryError{Pos: pos + 1, Msg: msg}
+}
+
+func isSearchField(field string) bool {
+	switch field {
+	case model.SearchFieldTag, model.SearchFieldType, model.SearchFieldFolder,
+		model.SearchFieldCreated, model.SearchFieldUpdated, model.SearchFieldIs,
+		model.SearchFieldHas, model.SearchFieldDomain, model.SearchFieldReadLater:
+		return true
+	}
+	return false
+}
+
+func isBookmarkType(value string) bool {
+	switch model.BookmarkType(value) {
+	case model.BookmarkTypeMessage, model.BookmarkTypeChannel, model.BookmarkTypeFile,
+		model.BookmarkTypeThread, model.BookmarkTypeExternal:
+		return true
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/quckapp/bookmark-service/internal/model"
+)
+
+func searchDay(value string) *time.Time {
+	t, err := time.Parse(searchDateLayout, value)
+	if err != nil {
+		panic(err)
+	}
+	return &t
+}
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  model.SearchQuery
+	}{
+		{
+			name:  "empty",
+			input: "   ",
+			want:  model.SearchQuery{},
+		},
+		{
+			name:  "words and quoted phrases",
+			input: `react  "design systems" hooks`,
+			want:  model.SearchQuery{Text: "react design systems hooks"},
+		},
+		{
+			name:  "negated word and phrase",
+			input: `react -spam -"old stuff"`,
+			want: model.SearchQuery{
+				Text: "react",
+				Filters: []model.SearchFilter{
+					{Field: model.SearchFieldText, Value: "spam", Negate: true},
+					{Field: model.SearchFieldText, Value: "old stuff", Negate: true},
+				},
+			},
+		},
+		{
+			name:  "tag and negated tag",
+			input: "tag:design -tag:old",
+			want: model.SearchQuery{Filters: []model.SearchFilter{
+				{Field: model.SearchFieldTag, Value: "design"},
+				{Field: model.SearchFieldTag, Value: "old", Negate: true},
+			}},
+		},
+		{
+			name:  "qualifier names are case insensitive",
+			input: "TAG:Design",
+			want: model.SearchQuery{Filters: []model.SearchFilter{
+				{Field: model.SearchFieldTag, Value: "Design"},
+			}},
+		},
+		{
+			name:  "quoted folder",
+			input: `folder:"Q3 Planning" notes`,
+			want: model.SearchQuery{
+				Text: "notes",
+				Filters: []model.SearchFilter{
+					{Field: model.SearchFieldFolder, Value: "Q3 Planning"},
+				},
+			},
+		},
+		{
+			name:  "type",
+			input: "type:external",
+			want: model.SearchQuery{Filters: []model.SearchFilter{
+				{Field: model.SearchFieldType, Value: "external"},
+			}},
+		},
+		{
+			name:  "is, has and readlater are lowercased",
+			input: "is:Favorite has:NOTE readlater:Unread",
+			want: model.SearchQuery{Filters: []model.SearchFilter{
+				{Field: model.SearchFieldIs, Value: "favorite"},
+				{Field: model.SearchFieldHas, Value: "note"},
+				{Field: model.SearchFieldReadLater, Value: "unread"},
+			}},
+		},
+		{
+			name:  "domain drops scheme and trailing slash",
+			input: "domain:HTTPS://Example.com/",
+			want: model.SearchQuery{Filters: []model.SearchFilter{
+				{Field: model.SearchFieldDomain, Value: "example.com"},
+			}},
+		},
+		{
+			name:  "single day",
+			input: "created:2026-01-01",
+			want: model.SearchQuery{Filters: []model.SearchFilter{
+				{Field: model.SearchFieldCreated, Value: "2026-01-01", From: searchDay("2026-01-01"), To: searchDay("2026-01-02")},
+			}},
+		},
+		{
+			name:  "after and from",
+			input: "created:>2026-01-01 updated:>=2026-01-01",
+			want: model.SearchQuery{Filters: []model.SearchFilter{
+				{Field: model.SearchFieldCreated, Value: ">2026-01-01", From: searchDay("2026-01-02")},
+				{Field: model.SearchFieldUpdated, Value: ">=2026-01-01", From: searchDay("2026-01-01")},
+			}},
+		},
+		{
+			name:  "before and up to",
+			input: "created:<2026-01-01 updated:<=2026-01-01",
+			want: model.SearchQuery{Filters: []model.SearchFilter{
+				{Field: model.SearchFieldCreated, Value: "<2026-01-01", To: searchDay("2026-01-01")},
+				{Field: model.SearchFieldUpdated, Value: "<=2026-01-01", To: searchDay("2026-01-02")},
+			}},
+		},
+		{
+			name:  "inclusive range",
+			input: "created:2026-01-01..2026-01-31",
+			want: model.SearchQuery{Filters: []model.SearchFilter{
+				{Field: model.SearchFieldCreated, Value: "2026-01-01..2026-01-31", From: searchDay("2026-01-01"), To: searchDay("2026-02-01")},
+			}},
+		},
+		{
+			name:  "unknown prefixes stay text",
+			input: "https://example.com/a foo:bar",
+			want:  model.SearchQuery{Text: "https://example.com/a foo:bar"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSearchQuery(tt.input)
+			if err != nil {
+				t.Fatalf("ParseSearchQuery(%q) returned error: %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("ParseSearchQuery(%q) =\n%+v\nwant\n%+v", tt.input, *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSearchQueryErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		pos   int
+	}{
+		{"dangling minus", "react -", 7},
+		{"minus before space", "react - hooks", 7},
+		{"unterminated phrase", `react "design`, 7},
+		{"negated empty phrase", `react -""`, 8},
+		{"negated blank phrase", `-"  " react`, 2},
+		{"unterminated qualifier value", `folder:"Q3`, 8},
+		{"missing value", "tag: react", 5},
+		{"unknown type", "type:bogus", 6},
+		{"unknown is", "is:pinned", 4},
+		{"unknown has", "has:attachment", 5},
+		{"unknown readlater", "readlater:later", 11},
+		{"domain with path", "domain:example.com/path", 8},
+		{"invalid date", "created:2026-13-01", 9},
+		{"invalid comparison date", "updated:>yesterday", 9},
+		{"invalid range end", "created:2026-01-01..soon", 9},
+		{"range ends before it starts", "created:2026-02-01..2026-01-01", 9},
+		{"position counts characters, not bytes", "café tag:", 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSearchQuery(tt.input)
+			var queryErr *SearchQueryError
+			if !errors.As(err, &queryErr) {
+				t.Fatalf("ParseSearchQuery(%q) error = %v, want a *SearchQueryError", tt.input, err)
+			}
+			if queryErr.Pos != tt.pos {
+				t.Errorf("ParseSearchQuery(%q) error at position %d, want %d (%s)", tt.input, queryErr.Pos, tt.pos, queryErr.Msg)
+			}
+		})
+	}
+}
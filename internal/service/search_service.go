@@ -0,0 +1,189 @@
+package service
+
+import (
+	"html"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+// snippetRadius is how many characters of context are kept on each side of
+// the first match in a highlight snippet.
+const snippetRadius = 60
+
+type SearchService interface {
+	// Search parses params.Query with ParseSearchQuery; a malformed query is
+	// reported as a *SearchQueryError.
+	Search(userID uuid.UUID, params model.BookmarkSearchParams) ([]model.SearchResult, int64, error)
+}
+
+type searchService struct {
+	repo   repository.SearchRepository
+	logger *zap.Logger
+}
+
+func NewSearchService(repo repository.SearchRepository, logger *zap.Logger) SearchService {
+	return &searchService{repo: repo, logger: logger}
+}
+
+func (s *searchService) Search(userID uuid.UUID, params model.BookmarkSearchParams) ([]model.SearchResult, int64, error) {
+	query, err := ParseSearchQuery(params.Query)
+	if err != nil {
+		return nil, 0, err
+	}
+	if params.Limit <= 0 {
+		params.Limit = 20
+	}
+	if params.Limit > 100 {
+		params.Limit = 100
+	}
+
+	results, total, err := s.repo.Search(userID, query, params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	terms := searchTerms(query.Text)
+	for i := range results {
+		results[i].Highlights = highlights(&results[i], terms)
+	}
+	return results, total, nil
+}
+
+// highlights returns an HTML snippet per field that contains a search term,
+// with the terms wrapped in <mark>. Everything else is HTML-escaped.
+func highlights(result *model.SearchResult, terms []string) map[string]string {
+	if len(terms) == 0 {
+		return nil
+	}
+
+	fields := []struct {
+		name string
+		text string
+	}{
+		{"title", result.Title},
+		{"description", result.Description},
+		{"targetUrl", result.TargetURL},
+		{"preview", result.PreviewText},
+		{"tags", result.TagText},
+		{"notes", result.NoteText},
+		{"comments", result.CommentText},
+		{"archive", result.ArchiveText},
+	}
+
+	out := map[string]string{}
+	for _, f := range fields {
+		if snippet, ok := highlight(f.text, terms); ok {
+			out[f.name] = snippet
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func highlight(text string, terms []string) (string, bool) {
+	runes := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	if len(lower) != len(runes) {
+		// Lower-casing changed the length, so offsets would not line up
+		lower = runes
+	}
+
+	type span struct{ start, end int }
+	var spans []span
+	for i := 0; i < len(lower); {
+		matched := 0
+		if i == 0 || !isWordRune(lower[i-1]) {
+			for _, term := range terms {
+				t := []rune(term)
+				if len(t) > matched && hasWordAt(lower, t, i) {
+					matched = len(t)
+				}
+			}
+		}
+		if matched > 0 {
+			spans = append(spans, span{i, i + matched})
+			i += matched
+			continue
+		}
+		i++
+	}
+	if len(spans) == 0 {
+		return "", false
+	}
+
+	from := spans[0].start - snippetRadius
+	if from < 0 {
+		from = 0
+	}
+	to := spans[0].end + snippetRadius
+	if to > len(runes) {
+		to = len(runes)
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	pos := from
+	for _, sp := range spans {
+		if sp.start >= to {
+			break
+		}
+		end := sp.end
+		if end > to {
+			end = to
+		}
+		b.WriteString(html.EscapeString(string(runes[pos:sp.start])))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(string(runes[sp.start:end])))
+		b.WriteString("</mark>")
+		pos = end
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:to])))
+	if to < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String(), true
+}
+
+// hasWordAt reports whether word occurs in s at the given offset and is not
+// followed by more of the same word, matching how FULLTEXT tokenizes.
+func hasWordAt(s, word []rune, at int) bool {
+	end := at + len(word)
+	if end > len(s) || (end < len(s) && isWordRune(s[end])) {
+		return false
+	}
+	for j, r := range word {
+		if s[at+j] != r {
+			return false
+		}
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r)
+}
+
+// searchTerms splits a query into the lower-case words to highlight.
+func searchTerms(query string) []string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !isWordRune(r)
+	})
+	seen := map[string]bool{}
+	terms := []string{}
+	for _, w := range words {
+		if !seen[w] {
+			seen[w] = true
+			terms = append(terms, w)
+		}
+	}
+	return terms
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/repository"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// maxPublicPageSize caps how many bookmarks one page of a shared collection
+// holds.
+const maxPublicPageSize = 50
+
+var (
+	// ErrShareLinkGone is returned when opening a link that was revoked or
+	// has expired.
+	ErrShareLinkGone = errors.New("share link is no longer available")
+	// ErrPasswordRequired is returned when opening a password protected link
+	// without the right password.
+	ErrPasswordRequired = errors.New("a valid password is required")
+	// ErrTooManyAttempts is returned while a link is locked after too many
+	// wrong passwords.
+	ErrTooManyAttempts = errors.New("too many wrong passwords, try again later")
+)
+
+type ShareLinkService interface {
+	// Create makes a new link to the collection. The returned link carries
+	// its token, which cannot be read back later.
+	Create(userID, collectionID uuid.UUID, req *model.CreateShareLinkRequest) (*model.CollectionShareLink, error)
+	List(collectionID uuid.UUID) ([]model.CollectionShareLink, error)
+	Revoke(collectionID, linkID uuid.UUID) error
+	// View opens the collection behind token for anyone holding it. Opening
+	// the first page counts as a view.
+	View(token, password string, page, limit int) (*model.PublicCollection, error)
+	// Open checks token and password like View does and returns the link
+	// without counting a view. Wrong passwords are counted per link, so
+	// guessing from many addresses locks the link all the same.
+	Open(token, password string) (*model.CollectionShareLink, error)
+}
+
+type shareLinkService struct {
+	repo           repository.ShareLinkRepository
+	collectionRepo repository.CollectionRepository
+	previewRepo    repository.PreviewRepository
+	// passwordFailures counts wrong passwords per link.
+	passwordFailures RateLimiter
+	logger           *zap.Logger
+}
+
+func NewShareLinkService(
+	repo repository.ShareLinkRepository,
+	collectionRepo repository.CollectionRepository,
+	previewRepo repository.PreviewRepository,
+	passwordFailures RateLimiter,
+	logger *zap.Logger,
+) ShareLinkService {
+	return &shareLinkService{
+		repo:             repo,
+		collectionRepo:   collectionRepo,
+		previewRepo:      previewRepo,
+		passwordFailures: passwordFailures,
+		logger:           logger,
+	}
+}
+
+func (s *shareLinkService) Create(userID, collectionID uuid.UUID, req *model.CreateShareLinkRequest) (*model.CollectionShareLink, error) {
+	if _, err := s.collectionRepo.GetByID(collectionID); err != nil {
+		return nil, lookupError("collection", err)
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expiry must be in the future", ErrInvalidShare)
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		return nil, err
+	}
+	link := &model.CollectionShareLink{
+		CollectionID: collectionID,
+		CreatedBy:    userID,
+		TokenHash:    sha256Hex([]byte(token)),
+		ExpiresAt:    req.ExpiresAt,
+	}
+	if req.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidShare, err)
+		}
+		link.PasswordHash = string(hash)
+		link.RequiresPassword = true
+	}
+
+	if err := s.repo.Create(link); err != nil {
+		return nil, err
+	}
+	link.Token = token
+	s.logger.Info("Created collection share link",
+		zap.String("collectionID", collectionID.String()),
+		zap.String("linkID", link.ID.String()))
+	return link, nil
+}
+
+func (s *shareLinkService) List(collectionID uuid.UUID) ([]model.CollectionShareLink, error) {
+	return s.repo.GetByCollection(collectionID)
+}
+
+func (s *shareLinkService) Revoke(collectionID, linkID uuid.UUID) error {
+	link, err := s.repo.GetByID(linkID)
+	if err != nil || link.CollectionID != collectionID {
+		return fmt.Errorf("share link %w", ErrNotFound)
+	}
+	revoked, err := s.repo.Revoke(linkID, time.Now())
+	if err != nil {
+		return err
+	}
+	if revoked {
+		s.logger.Info("Revoked collection share link", zap.String("linkID", linkID.String()))
+	}
+	return nil
+}
+
+func (s *shareLinkService) Open(token, password string) (*model.CollectionShareLink, error) {
+	link, err := s.repo.GetByTokenHash(sha256Hex([]byte(token)))
+	if err != nil {
+		return nil, lookupError("share link", err)
+	}
+	if !link.IsActive(time.Now()) {
+		return nil, ErrShareLinkGone
+	}
+	if link.RequiresPassword {
+		if err := s.checkPassword(link, password); err != nil {
+			return nil, err
+		}
+	}
+	return link, nil
+}
+
+// checkPassword compares password with the link's unless the link is locked
+// out. Like the request rate limit, it lets passwords through when the
+// failure counter cannot be reached.
+func (s *shareLinkService) checkPassword(link *model.CollectionShareLink, password string) error {
+	ctx := context.Background()
+	key := link.ID.String()
+	blocked, _, err := s.passwordFailures.Blocked(ctx, key)
+	if err != nil {
+		s.logger.Warn("Failed to read share link password failures", zap.String("linkID", key), zap.Error(err))
+	}
+	if blocked {
+		return ErrTooManyAttempts
+	}
+	if password != "" && bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)) == nil {
+		return nil
+	}
+	if password != "" {
+		if _, _, err := s.passwordFailures.Allow(ctx, key); err != nil {
+			s.logger.Warn("Failed to count share link password failure", zap.String("linkID", key), zap.Error(err))
+		}
+	}
+	return ErrPasswordRequired
+}
+
+func (s *shareLinkService) View(token, password string, page, limit int) (*model.PublicCollection, error) {
+	link, err := s.Open(token, password)
+	if err != nil {
+		return nil, err
+	}
+
+	collection, err := s.collectionRepo.GetByID(link.CollectionID)
+	if err != nil {
+		return nil, lookupError("collection", err)
+	}
+
+	if page < 0 {
+		page = 0
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > maxPublicPageSize {
+		limit = maxPublicPageSize
+	}
+	bookmarks, total, err := s.collectionRepo.GetExternalBookmarks(collection.ID, limit, page*limit)
+	if err != nil {
+		return nil, err
+	}
+
+	view := &model.PublicCollection{
+		Name:        collection.Name,
+		Description: collection.Description,
+		Color:       collection.Color,
+		Icon:        collection.Icon,
+		Bookmarks:   make([]model.PublicBookmark, 0, len(bookmarks)),
+		Total:       total,
+		Page:        page,
+		Limit:       limit,
+	}
+	previews := s.readyPreviews(bookmarks)
+	for _, b := range bookmarks {
+		view.Bookmarks = append(view.Bookmarks, model.PublicBookmark{
+			Title:       b.Title,
+			Description: b.Description,
+			URL:         b.TargetURL,
+			CreatedAt:   b.CreatedAt,
+			Preview:     previews[b.ID],
+		})
+	}
+
+	if page == 0 {
+		if err := s.repo.RecordView(link.ID, time.Now()); err != nil {
+			s.logger.Warn("Failed to record share link view", zap.String("linkID", link.ID.String()), zap.Error(err))
+		}
+	}
+	return view, nil
+}
+
+// readyPreviews returns the fetched previews of bookmarks by bookmark ID.
+// Previews are decoration, so failing to load them is only logged.
+func (s *shareLinkService) readyPreviews(bookmarks []model.Bookmark) map[uuid.UUID]*model.PublicPreview {
+	ids := make([]uuid.UUID, len(bookmarks))
+	for i, b := range bookmarks {
+		ids[i] = b.ID
+	}
+	previews, err := s.previewRepo.GetByBookmarkIDs(ids)
+	if err != nil {
+		s.logger.Warn("Failed to load previews for shared collection", zap.Error(err))
+	}
+
+	byBookmark := make(map[uuid.UUID]*model.PublicPreview, len(previews))
+	for _, p := range previews {
+		if p.Status != model.PreviewStatusReady {
+			continue
+		}
+		byBookmark[p.BookmarkID] = &model.PublicPreview{
+			Title:       p.Title,
+			Description: p.Description,
+			ImageURL:    p.ImageURL,
+			FaviconURL:  p.FaviconURL,
+			SiteName:    p.SiteName,
+		}
+	}
+	return byBookmark
+}
+
+// newShareToken returns 256 random bits, URL-safe encoded.
+func newShareToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
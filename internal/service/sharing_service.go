@@ -1,56 +1,155 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/quckapp/bookmark-service/internal/model"
 	"github.com/quckapp/bookmark-service/internal/repository"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidShare is wrapped when a share request is malformed.
+	ErrInvalidShare  = errors.New("invalid share")
+	ErrAlreadyShared = errors.New("already shared with this user")
+	ErrShareExpired  = errors.New("share has expired")
 )
 
 type SharingService interface {
-	ShareBookmark(shared *model.SharedBookmark) error
+	// Share shares a bookmark, folder or collection owned by shared.SharedBy.
+	Share(shared *model.SharedBookmark) error
 	GetSharedWithUser(userID uuid.UUID, page, limit int) ([]model.SharedBookmark, int64, error)
 	GetSharedByUser(userID uuid.UUID, page, limit int) ([]model.SharedBookmark, int64, error)
 	AcceptShare(id uuid.UUID) error
 	DeclineShare(id uuid.UUID) error
+	// UpdateShare changes the role or expiry of a share.
+	UpdateShare(id uuid.UUID, req *model.UpdateShareRequest) (*model.SharedBookmark, error)
+	// RevokeShare withdraws a share, whether or not it was accepted.
+	RevokeShare(id uuid.UUID) error
 	GetPendingCount(userID uuid.UUID) (int64, error)
 }
 
 type sharingService struct {
-	repo         repository.SharingRepository
-	bookmarkRepo repository.BookmarkRepository
-	logger       *zap.Logger
+	repo           repository.SharingRepository
+	bookmarkRepo   repository.BookmarkRepository
+	folderRepo     repository.FolderRepository
+	collectionRepo repository.CollectionRepository
+	activity       ActivityRecorder
+	events         EventBus
+	tx             repository.Transactor
+	logger         *zap.Logger
 }
 
-func NewSharingService(repo repository.SharingRepository, bookmarkRepo repository.BookmarkRepository, logger *zap.Logger) SharingService {
-	return &sharingService{repo: repo, bookmarkRepo: bookmarkRepo, logger: logger}
+func NewSharingService(
+	repo repository.SharingRepository,
+	bookmarkRepo repository.BookmarkRepository,
+	folderRepo repository.FolderRepository,
+	collectionRepo repository.CollectionRepository,
+	activity ActivityRecorder,
+	events EventBus,
+	tx repository.Transactor,
+	logger *zap.Logger,
+) SharingService {
+	return &sharingService{
+		repo:           repo,
+		bookmarkRepo:   bookmarkRepo,
+		folderRepo:     folderRepo,
+		collectionRepo: collectionRepo,
+		activity:       activity,
+		events:         events,
+		tx:             tx,
+		logger:         logger,
+	}
 }
 
-func (s *sharingService) ShareBookmark(shared *model.SharedBookmark) error {
-	// Verify bookmark exists
-	_, err := s.bookmarkRepo.GetByID(shared.BookmarkID)
+func (s *sharingService) Share(shared *model.SharedBookmark) error {
+	if shared.ResourceType == "" {
+		shared.ResourceType = model.ShareResourceBookmark
+	}
+	if shared.Role == "" {
+		shared.Role = model.ShareRoleViewer
+	}
+	if !shared.Role.IsValid() {
+		return fmt.Errorf("%w: unknown role %q", ErrInvalidShare, shared.Role)
+	}
+	if shared.SharedWith == uuid.Nil || shared.SharedWith == shared.SharedBy {
+		return fmt.Errorf("%w: cannot share with yourself", ErrInvalidShare)
+	}
+	if shared.ExpiresAt != nil && !shared.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf("%w: expiry must be in the future", ErrInvalidShare)
+	}
+
+	// Verify the resource exists and belongs to the sharer
+	ownerID, workspaceID, err := s.resource(shared.ResourceType, shared.ResourceID)
 	if err != nil {
-		return fmt.Errorf("bookmark not found")
+		return err
+	}
+	if ownerID != shared.SharedBy {
+		return ErrForbidden
+	}
+	shared.WorkspaceID = workspaceID
+	shared.BookmarkID = uuid.Nil
+	if shared.ResourceType == model.ShareResourceBookmark {
+		shared.BookmarkID = shared.ResourceID
 	}
 
 	// Check if already shared
-	alreadyShared, _ := s.repo.IsAlreadyShared(shared.BookmarkID, shared.SharedWith)
+	alreadyShared, _ := s.repo.IsAlreadyShared(shared.ResourceType, shared.ResourceID, shared.SharedWith)
 	if alreadyShared {
-		return fmt.Errorf("bookmark already shared with this user")
+		return fmt.Errorf("%s %w", shared.ResourceType, ErrAlreadyShared)
 	}
 
-	err = s.repo.Create(shared)
+	err = s.tx.Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).Create(shared); err != nil {
+			return err
+		}
+		return s.events.Publish(tx, model.EventShareCreated, shared.ID, shared)
+	})
 	if err != nil {
 		return err
 	}
-	s.logger.Info("Shared bookmark",
-		zap.String("bookmarkID", shared.BookmarkID.String()),
-		zap.String("sharedWith", shared.SharedWith.String()))
+	s.record(shared.SharedBy, shared, model.ActivityShared, map[string]interface{}{
+		"shareId":    shared.ID,
+		"sharedWith": shared.SharedWith,
+		"role":       shared.Role,
+	})
+	s.logger.Info("Shared resource",
+		zap.String("resourceType", string(shared.ResourceType)),
+		zap.String("resourceID", shared.ResourceID.String()),
+		zap.String("sharedWith", shared.SharedWith.String()),
+		zap.String("role", string(shared.Role)))
 	return nil
 }
 
+// resource returns the owner and workspace of a shareable resource.
+func (s *sharingService) resource(resourceType model.ShareResourceType, id uuid.UUID) (uuid.UUID, uuid.UUID, error) {
+	switch resourceType {
+	case model.ShareResourceBookmark:
+		bookmark, err := s.bookmarkRepo.GetByID(id)
+		if err != nil {
+			return uuid.Nil, uuid.Nil, lookupError("bookmark", err)
+		}
+		return bookmark.UserID, bookmark.WorkspaceID, nil
+	case model.ShareResourceFolder:
+		folder, err := s.folderRepo.GetByID(id)
+		if err != nil {
+			return uuid.Nil, uuid.Nil, lookupError("folder", err)
+		}
+		return folder.UserID, folder.WorkspaceID, nil
+	case model.ShareResourceCollection:
+		collection, err := s.collectionRepo.GetByID(id)
+		if err != nil {
+			return uuid.Nil, uuid.Nil, lookupError("collection", err)
+		}
+		return collection.UserID, collection.WorkspaceID, nil
+	}
+	return uuid.Nil, uuid.Nil, fmt.Errorf("%w: unknown resource type %q", ErrInvalidShare, resourceType)
+}
+
 func (s *sharingService) GetSharedWithUser(userID uuid.UUID, page, limit int) ([]model.SharedBookmark, int64, error) {
 	offset := page * limit
 	return s.repo.GetSharedWithUser(userID, limit, offset)
@@ -69,17 +168,112 @@ func (s *sharingService) AcceptShare(id uuid.UUID) error {
 	if share.IsAccepted {
 		return fmt.Errorf("already accepted")
 	}
-	return s.repo.Accept(id)
+	if share.ExpiresAt != nil && !share.ExpiresAt.After(time.Now()) {
+		return ErrShareExpired
+	}
+	err = s.tx.Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).Accept(id); err != nil {
+			return err
+		}
+		share.IsAccepted = true
+		return s.events.Publish(tx, model.EventShareAccepted, share.ID, share)
+	})
+	if err != nil {
+		return err
+	}
+	s.record(share.SharedWith, share, model.ActivityShareAccepted, map[string]interface{}{
+		"shareId":  share.ID,
+		"sharedBy": share.SharedBy,
+	})
+	return nil
 }
 
 func (s *sharingService) DeclineShare(id uuid.UUID) error {
-	_, err := s.repo.GetByID(id)
+	share, err := s.repo.GetByID(id)
 	if err != nil {
 		return fmt.Errorf("shared bookmark not found")
 	}
-	return s.repo.Delete(id)
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	s.record(share.SharedWith, share, model.ActivityShareDeclined, map[string]interface{}{
+		"shareId":  share.ID,
+		"sharedBy": share.SharedBy,
+	})
+	return nil
+}
+
+func (s *sharingService) UpdateShare(id uuid.UUID, req *model.UpdateShareRequest) (*model.SharedBookmark, error) {
+	share, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, lookupError("shared bookmark", err)
+	}
+
+	if req.Role != "" {
+		role := model.ShareRole(req.Role)
+		if !role.IsValid() {
+			return nil, fmt.Errorf("%w: unknown role %q", ErrInvalidShare, req.Role)
+		}
+		share.Role = role
+	}
+	switch {
+	case req.ClearExpiry:
+		share.ExpiresAt = nil
+	case req.ExpiresAt != nil:
+		if !req.ExpiresAt.After(time.Now()) {
+			return nil, fmt.Errorf("%w: expiry must be in the future", ErrInvalidShare)
+		}
+		share.ExpiresAt = req.ExpiresAt
+	}
+
+	err = s.tx.Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).Update(share); err != nil {
+			return err
+		}
+		return s.events.Publish(tx, model.EventShareUpdated, share.ID, share)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.record(share.SharedBy, share, model.ActivityShareUpdated, map[string]interface{}{
+		"shareId":   share.ID,
+		"role":      share.Role,
+		"expiresAt": share.ExpiresAt,
+	})
+	return share, nil
+}
+
+func (s *sharingService) RevokeShare(id uuid.UUID) error {
+	share, err := s.repo.GetByID(id)
+	if err != nil {
+		return lookupError("shared bookmark", err)
+	}
+	err = s.tx.Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).Delete(id); err != nil {
+			return err
+		}
+		return s.events.Publish(tx, model.EventShareRevoked, share.ID, share)
+	})
+	if err != nil {
+		return err
+	}
+	s.record(share.SharedBy, share, model.ActivityShareRevoked, map[string]interface{}{
+		"shareId":    share.ID,
+		"sharedWith": share.SharedWith,
+	})
+	s.logger.Info("Revoked share", zap.String("id", id.String()))
+	return nil
 }
 
 func (s *sharingService) GetPendingCount(userID uuid.UUID) (int64, error) {
 	return s.repo.GetPendingCount(userID)
 }
+
+// record adds a share change to the bookmark's activity log. The log is kept
+// per bookmark, so changes to folder and collection shares are not recorded.
+func (s *sharingService) record(userID uuid.UUID, share *model.SharedBookmark, action string, details map[string]interface{}) {
+	if share.ResourceType != model.ShareResourceBookmark && share.ResourceType != "" {
+		return
+	}
+	s.activity.Record(userID, share.BookmarkID, action, details)
+}
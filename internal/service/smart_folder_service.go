@@ -0,0 +1,218 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/quckapp/bookmark-service/internal/model"
+	"github.com/quckapp/bookmark-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+// maxSmartFolderPageSize caps how many bookmarks one page of a smart folder holds.
+const maxSmartFolderPageSize = 100
+
+// ErrInvalidSmartFolderFilter is wrapped by every reason a filter is rejected.
+var ErrInvalidSmartFolderFilter = errors.New("invalid smart folder filter")
+
+type SmartFolderService interface {
+	Create(folder *model.SmartFolder) error
+	GetByID(id uuid.UUID) (*model.SmartFolder, error)
+	GetByUser(userID uuid.UUID) ([]model.SmartFolder, error)
+	GetByUserAndWorkspace(userID, workspaceID uuid.UUID) ([]model.SmartFolder, error)
+	Update(id uuid.UUID, req *model.UpdateSmartFolderRequest) (*model.SmartFolder, error)
+	Delete(id uuid.UUID) error
+	// GetBookmarks evaluates the folder's filter against its owner's
+	// bookmarks in the folder's workspace.
+	GetBookmarks(id uuid.UUID, page, limit int) ([]model.SearchResult, int64, error)
+}
+
+type smartFolderService struct {
+	repo       repository.SmartFolderRepository
+	searchRepo repository.SearchRepository
+	clock      Clock
+	logger     *zap.Logger
+}
+
+func NewSmartFolderService(
+	repo repository.SmartFolderRepository,
+	searchRepo repository.SearchRepository,
+	clock Clock,
+	logger *zap.Logger,
+) SmartFolderService {
+	if clock == nil {
+		clock = time.Now
+	}
+	return &smartFolderService{repo: repo, searchRepo: searchRepo, clock: clock, logger: logger}
+}
+
+func (s *smartFolderService) Create(folder *model.SmartFolder) error {
+	if _, err := smartFolderQuery(folder.Filter, s.clock()); err != nil {
+		return err
+	}
+	err := s.repo.Create(folder)
+	if err != nil {
+		return err
+	}
+	s.logger.Info("Created smart folder", zap.String("id", folder.ID.String()))
+	return nil
+}
+
+func (s *smartFolderService) GetByID(id uuid.UUID) (*model.SmartFolder, error) {
+	folder, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, lookupError("smart folder", err)
+	}
+	return folder, nil
+}
+
+func (s *smartFolderService) GetByUser(userID uuid.UUID) ([]model.SmartFolder, error) {
+	return s.repo.GetByUser(userID)
+}
+
+func (s *smartFolderService) GetByUserAndWorkspace(userID, workspaceID uuid.UUID) ([]model.SmartFolder, error) {
+	return s.repo.GetByUserAndWorkspace(userID, workspaceID)
+}
+
+func (s *smartFolderService) Update(id uuid.UUID, req *model.UpdateSmartFolderRequest) (*model.SmartFolder, error) {
+	existing, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Filter != nil {
+		if _, err := smartFolderQuery(*req.Filter, s.clock()); err != nil {
+			return nil, err
+		}
+		existing.Filter = *req.Filter
+	}
+	if req.Name != "" {
+		existing.Name = req.Name
+	}
+	if req.Color != "" {
+		existing.Color = req.Color
+	}
+	if req.Icon != "" {
+		existing.Icon = req.Icon
+	}
+	if req.Sort != "" {
+		existing.Sort = req.Sort
+	}
+	if req.Position != nil {
+		existing.Position = *req.Position
+	}
+	err = s.repo.Update(existing)
+	return existing, err
+}
+
+func (s *smartFolderService) Delete(id uuid.UUID) error {
+	return s.repo.Delete(id)
+}
+
+func (s *smartFolderService) GetBookmarks(id uuid.UUID, page, limit int) ([]model.SearchResult, int64, error) {
+	folder, err := s.GetByID(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	query, err := smartFolderQuery(folder.Filter, s.clock())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if page < 0 {
+		page = 0
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > maxSmartFolderPageSize {
+		limit = maxSmartFolderPageSize
+	}
+	return s.searchRepo.Search(folder.UserID, query, model.BookmarkSearchParams{
+		WorkspaceID: folder.WorkspaceID.String(),
+		Sort:        folder.Sort,
+		Page:        page,
+		Limit:       limit,
+	})
+}
+
+// smartFolderQuery compiles a saved filter into a search query, resolving
+// CreatedWithinDays against now. Each field is validated the same way as the
+// qualifier it becomes.
+func smartFolderQuery(filter model.SmartFolderFilter, now time.Time) (*model.SearchQuery, error) {
+	query, err := ParseSearchQuery(filter.Query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: query: %v", ErrInvalidSmartFolderFilter, err)
+	}
+
+	p := &searchQueryParser{}
+	add := func(name, field, value string) error {
+		if strings.TrimSpace(value) == "" {
+			return fmt.Errorf("%w: %s must not be empty", ErrInvalidSmartFolderFilter, name)
+		}
+		f, err := p.filter(field, value, 0)
+		if err != nil {
+			var queryErr *SearchQueryError
+			if errors.As(err, &queryErr) {
+				return fmt.Errorf("%w: %s: %s", ErrInvalidSmartFolderFilter, name, strings.TrimPrefix(queryErr.Msg, field+": "))
+			}
+			return err
+		}
+		query.Filters = append(query.Filters, f)
+		return nil
+	}
+
+	if filter.Type != "" {
+		if err := add("type", model.SearchFieldType, filter.Type); err != nil {
+			return nil, err
+		}
+	}
+	for _, tag := range filter.Tags {
+		if err := add("tags", model.SearchFieldTag, tag); err != nil {
+			return nil, err
+		}
+	}
+	if filter.Domain != "" {
+		if err := add("domain", model.SearchFieldDomain, filter.Domain); err != nil {
+			return nil, err
+		}
+	}
+
+	var created string
+	switch {
+	case filter.CreatedFrom != "" && filter.CreatedTo != "":
+		created = filter.CreatedFrom + ".." + filter.CreatedTo
+	case filter.CreatedFrom != "":
+		created = ">=" + filter.CreatedFrom
+	case filter.CreatedTo != "":
+		created = "<=" + filter.CreatedTo
+	}
+	if created != "" {
+		if err := add("created", model.SearchFieldCreated, created); err != nil {
+			return nil, err
+		}
+	}
+	if filter.CreatedWithinDays < 0 {
+		return nil, fmt.Errorf("%w: createdWithinDays must not be negative", ErrInvalidSmartFolderFilter)
+	}
+	if filter.CreatedWithinDays > 0 {
+		from := now.AddDate(0, 0, -filter.CreatedWithinDays)
+		query.Filters = append(query.Filters, model.SearchFilter{Field: model.SearchFieldCreated, From: &from})
+	}
+
+	if filter.HasReminder != nil {
+		query.Filters = append(query.Filters, model.SearchFilter{
+			Field:  model.SearchFieldHas,
+			Value:  "reminder",
+			Negate: !*filter.HasReminder,
+		})
+	}
+	if filter.ReadLater != "" {
+		if err := add("readLater", model.SearchFieldReadLater, filter.ReadLater); err != nil {
+			return nil, err
+		}
+	}
+	return query, nil
+}
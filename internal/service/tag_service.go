@@ -16,21 +16,21 @@ type TagService interface {
 	GetUserTagsInWorkspace(userID, workspaceID uuid.UUID) ([]model.BookmarkTag, error)
 	UpdateTag(id uuid.UUID, req *model.UpdateTagRequest) (*model.BookmarkTag, error)
 	DeleteTag(id uuid.UUID) error
-	TagBookmark(bookmarkID uuid.UUID, tagIDs []uuid.UUID) error
-	UntagBookmark(bookmarkID, tagID uuid.UUID) error
+	TagBookmark(userID, bookmarkID uuid.UUID, tagIDs []uuid.UUID) error
+	UntagBookmark(userID, bookmarkID, tagID uuid.UUID) error
 	GetBookmarkTags(bookmarkID uuid.UUID) ([]model.BookmarkTag, error)
 	GetBookmarksByTag(tagID uuid.UUID, page, limit int) ([]model.Bookmark, int64, error)
-	ReplaceBookmarkTags(bookmarkID uuid.UUID, tagIDs []uuid.UUID) error
-	BulkTagBookmarks(bookmarkIDs []uuid.UUID, tagID uuid.UUID) error
+	ReplaceBookmarkTags(userID, bookmarkID uuid.UUID, tagIDs []uuid.UUID) error
 }
 
 type tagService struct {
-	repo   repository.TagRepository
-	logger *zap.Logger
+	repo     repository.TagRepository
+	activity ActivityRecorder
+	logger   *zap.Logger
 }
 
-func NewTagService(repo repository.TagRepository, logger *zap.Logger) TagService {
-	return &tagService{repo: repo, logger: logger}
+func NewTagService(repo repository.TagRepository, activity ActivityRecorder, logger *zap.Logger) TagService {
+	return &tagService{repo: repo, activity: activity, logger: logger}
 }
 
 func (s *tagService) CreateTag(tag *model.BookmarkTag) error {
@@ -83,24 +83,24 @@ func (s *tagService) DeleteTag(id uuid.UUID) error {
 	return nil
 }
 
-func (s *tagService) TagBookmark(bookmarkID uuid.UUID, tagIDs []uuid.UUID) error {
-	for _, tagID := range tagIDs {
-		mapping := &model.BookmarkTagMapping{
-			BookmarkID: bookmarkID,
-			TagID:      tagID,
-		}
-		if err := s.repo.AddTagToBookmark(mapping); err != nil {
-			s.logger.Warn("Failed to add tag to bookmark",
-				zap.String("bookmarkID", bookmarkID.String()),
-				zap.String("tagID", tagID.String()),
-				zap.Error(err))
-		}
+func (s *tagService) TagBookmark(userID, bookmarkID uuid.UUID, tagIDs []uuid.UUID) error {
+	if err := s.checkTags(userID, tagIDs); err != nil {
+		return err
+	}
+
+	added := s.addTags(bookmarkID, tagIDs)
+	if len(added) > 0 {
+		s.activity.Record(userID, bookmarkID, model.ActivityTagged, map[string]interface{}{"tagIds": added})
 	}
 	return nil
 }
 
-func (s *tagService) UntagBookmark(bookmarkID, tagID uuid.UUID) error {
-	return s.repo.RemoveTagFromBookmark(bookmarkID, tagID)
+func (s *tagService) UntagBookmark(userID, bookmarkID, tagID uuid.UUID) error {
+	if err := s.repo.RemoveTagFromBookmark(bookmarkID, tagID); err != nil {
+		return err
+	}
+	s.activity.Record(userID, bookmarkID, model.ActivityUntagged, map[string]interface{}{"tagId": tagID})
+	return nil
 }
 
 func (s *tagService) GetBookmarkTags(bookmarkID uuid.UUID) ([]model.BookmarkTag, error) {
@@ -112,13 +112,47 @@ func (s *tagService) GetBookmarksByTag(tagID uuid.UUID, page, limit int) ([]mode
 	return s.repo.GetBookmarksByTag(tagID, limit, offset)
 }
 
-func (s *tagService) ReplaceBookmarkTags(bookmarkID uuid.UUID, tagIDs []uuid.UUID) error {
+func (s *tagService) ReplaceBookmarkTags(userID, bookmarkID uuid.UUID, tagIDs []uuid.UUID) error {
+	if err := s.checkTags(userID, tagIDs); err != nil {
+		return err
+	}
 	if err := s.repo.RemoveAllTagsFromBookmark(bookmarkID); err != nil {
 		return err
 	}
-	return s.TagBookmark(bookmarkID, tagIDs)
+
+	added := s.addTags(bookmarkID, tagIDs)
+	s.activity.Record(userID, bookmarkID, model.ActivityTagsReplaced, map[string]interface{}{"tagIds": added})
+	return nil
 }
 
-func (s *tagService) BulkTagBookmarks(bookmarkIDs []uuid.UUID, tagID uuid.UUID) error {
-	return s.repo.BulkAddTagToBookmarks(bookmarkIDs, tagID)
+// addTags maps each tag to the bookmark and returns the tags that were added.
+func (s *tagService) addTags(bookmarkID uuid.UUID, tagIDs []uuid.UUID) []uuid.UUID {
+	added := []uuid.UUID{}
+	for _, tagID := range tagIDs {
+		mapping := &model.BookmarkTagMapping{
+			BookmarkID: bookmarkID,
+			TagID:      tagID,
+		}
+		if err := s.repo.AddTagToBookmark(mapping); err != nil {
+			s.logger.Warn("Failed to add tag to bookmark",
+				zap.String("bookmarkID", bookmarkID.String()),
+				zap.String("tagID", tagID.String()),
+				zap.Error(err))
+			continue
+		}
+		added = append(added, tagID)
+	}
+	return added
+}
+
+// checkTags ensures every tag exists and belongs to the user before any
+// mapping is written.
+func (s *tagService) checkTags(userID uuid.UUID, tagIDs []uuid.UUID) error {
+	for _, tagID := range tagIDs {
+		tag, err := s.repo.GetByID(tagID)
+		if err != nil || tag.UserID != userID {
+			return fmt.Errorf("tag %w", ErrNotFound)
+		}
+	}
+	return nil
 }
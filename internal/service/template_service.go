@@ -1,6 +1,8 @@
 package service
 
 import (
+	"fmt"
+
 	"github.com/google/uuid"
 	"github.com/quckapp/bookmark-service/internal/model"
 	"github.com/quckapp/bookmark-service/internal/repository"
@@ -19,14 +21,23 @@ type TemplateService interface {
 type templateService struct {
 	repo         repository.TemplateRepository
 	bookmarkRepo repository.BookmarkRepository
+	access       AccessService
 	logger       *zap.Logger
 }
 
-func NewTemplateService(repo repository.TemplateRepository, bookmarkRepo repository.BookmarkRepository, logger *zap.Logger) TemplateService {
-	return &templateService{repo: repo, bookmarkRepo: bookmarkRepo, logger: logger}
+func NewTemplateService(
+	repo repository.TemplateRepository,
+	bookmarkRepo repository.BookmarkRepository,
+	access AccessService,
+	logger *zap.Logger,
+) TemplateService {
+	return &templateService{repo: repo, bookmarkRepo: bookmarkRepo, access: access, logger: logger}
 }
 
 func (s *templateService) Create(template *model.BookmarkTemplate) error {
+	if err := s.checkFolder(template.UserID, template.FolderID); err != nil {
+		return err
+	}
 	err := s.repo.Create(template)
 	if err != nil {
 		return err
@@ -58,6 +69,9 @@ func (s *templateService) Update(id uuid.UUID, template *model.BookmarkTemplate)
 		existing.Type = template.Type
 	}
 	if template.FolderID != "" {
+		if err := s.checkFolder(existing.UserID, template.FolderID); err != nil {
+			return nil, err
+		}
 		existing.FolderID = template.FolderID
 	}
 	if template.TagIDs != "" {
@@ -106,3 +120,14 @@ func (s *templateService) Apply(templateID uuid.UUID, req *model.ApplyTemplateRe
 	s.logger.Info("Applied template", zap.String("templateId", templateID.String()))
 	return bookmark, nil
 }
+
+func (s *templateService) checkFolder(userID uuid.UUID, folderID string) error {
+	if folderID == "" {
+		return nil
+	}
+	id, err := uuid.Parse(folderID)
+	if err != nil {
+		return fmt.Errorf("folder %w", ErrNotFound)
+	}
+	return s.access.AuthorizeFolder(userID, id)
+}
@@ -1,27 +1,45 @@
 package service
 
 import (
+	"fmt"
+
 	"github.com/google/uuid"
 	"github.com/quckapp/bookmark-service/internal/model"
 	"github.com/quckapp/bookmark-service/internal/repository"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 type VersionService interface {
 	GetByBookmark(bookmarkID uuid.UUID, page, limit int) ([]model.BookmarkVersion, int64, error)
 	GetByID(id uuid.UUID) (*model.BookmarkVersion, error)
-	Restore(bookmarkID, versionID uuid.UUID) (*model.Bookmark, error)
-	CreateVersion(bookmark *model.Bookmark, userID uuid.UUID, changeNote string) error
+	Restore(bookmarkID, versionID, userID uuid.UUID) (*model.Bookmark, error)
+	// CreateVersion and RecordChange number versions inside tx, so they must
+	// run in the transaction that updates the bookmark.
+	CreateVersion(tx *gorm.DB, bookmark *model.Bookmark, userID uuid.UUID, changeNote string) error
+	RecordChange(tx *gorm.DB, before, after *model.Bookmark, userID uuid.UUID, changeNote string) error
+	Diff(bookmarkID, fromID, toID uuid.UUID) (*model.VersionDiff, error)
 }
 
 type versionService struct {
 	repo         repository.VersionRepository
 	bookmarkRepo repository.BookmarkRepository
+	retention    int
+	activity     ActivityRecorder
+	tx           repository.Transactor
 	logger       *zap.Logger
 }
 
-func NewVersionService(repo repository.VersionRepository, bookmarkRepo repository.BookmarkRepository, logger *zap.Logger) VersionService {
-	return &versionService{repo: repo, bookmarkRepo: bookmarkRepo, logger: logger}
+// NewVersionService keeps at most retention versions per bookmark; zero keeps all.
+func NewVersionService(
+	repo repository.VersionRepository,
+	bookmarkRepo repository.BookmarkRepository,
+	retention int,
+	activity ActivityRecorder,
+	tx repository.Transactor,
+	logger *zap.Logger,
+) VersionService {
+	return &versionService{repo: repo, bookmarkRepo: bookmarkRepo, retention: retention, activity: activity, tx: tx, logger: logger}
 }
 
 func (s *versionService) GetByBookmark(bookmarkID uuid.UUID, page, limit int) ([]model.BookmarkVersion, int64, error) {
@@ -33,30 +51,40 @@ func (s *versionService) GetByID(id uuid.UUID) (*model.BookmarkVersion, error) {
 	return s.repo.GetByID(id)
 }
 
-func (s *versionService) Restore(bookmarkID, versionID uuid.UUID) (*model.Bookmark, error) {
-	version, err := s.repo.GetByID(versionID)
-	if err != nil {
-		return nil, err
-	}
-
-	bookmark, err := s.bookmarkRepo.GetByID(bookmarkID)
+func (s *versionService) Restore(bookmarkID, versionID, userID uuid.UUID) (*model.Bookmark, error) {
+	version, err := s.getForBookmark(bookmarkID, versionID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Save current state as new version before restoring
-	s.CreateVersion(bookmark, bookmark.UserID, "Before restore")
+	var bookmark *model.Bookmark
+	changeNote := fmt.Sprintf("Restored from version %d", version.Version)
+	err = s.tx.Transaction(func(tx *gorm.DB) error {
+		repo := s.bookmarkRepo.WithTx(tx)
+		var err error
+		if bookmark, err = repo.GetForUpdate(bookmarkID); err != nil {
+			return err
+		}
+		before := *bookmark
 
-	// Restore from version
-	bookmark.Title = version.Title
-	bookmark.Description = version.Description
-	bookmark.TargetURL = version.TargetURL
-	bookmark.Metadata = version.Metadata
+		// Restore from version
+		bookmark.Title = version.Title
+		bookmark.Description = version.Description
+		bookmark.TargetURL = version.TargetURL
+		bookmark.Metadata = version.Metadata
 
-	err = s.bookmarkRepo.Update(bookmark)
+		if err := repo.Update(bookmark); err != nil {
+			return err
+		}
+		return s.RecordChange(tx, &before, bookmark, userID, changeNote)
+	})
 	if err != nil {
 		return nil, err
 	}
+	s.activity.Record(userID, bookmarkID, model.ActivityRestored, map[string]interface{}{
+		"versionId": versionID,
+		"version":   version.Version,
+	})
 
 	s.logger.Info("Restored bookmark from version",
 		zap.String("bookmarkId", bookmarkID.String()),
@@ -64,9 +92,40 @@ func (s *versionService) Restore(bookmarkID, versionID uuid.UUID) (*model.Bookma
 	return bookmark, nil
 }
 
-func (s *versionService) CreateVersion(bookmark *model.Bookmark, userID uuid.UUID, changeNote string) error {
-	latestVer, _ := s.repo.GetLatestVersion(bookmark.ID)
+func (s *versionService) CreateVersion(tx *gorm.DB, bookmark *model.Bookmark, userID uuid.UUID, changeNote string) error {
+	repo := s.repo.WithTx(tx)
+	latestVer, err := repo.LockLatestVersion(bookmark.ID)
+	if err != nil {
+		return err
+	}
+	return s.create(repo, bookmark, userID, latestVer+1, changeNote)
+}
+
+// RecordChange stores the state of after as a new version when a versioned
+// field differs from before. Each version holds the content after a change,
+// so the first recorded change also stores the original content.
+func (s *versionService) RecordChange(tx *gorm.DB, before, after *model.Bookmark, userID uuid.UUID, changeNote string) error {
+	if len(diffFields(versionOf(before), versionOf(after))) == 0 {
+		return nil
+	}
+
+	repo := s.repo.WithTx(tx)
+	latestVer, err := repo.LockLatestVersion(after.ID)
+	if err != nil {
+		return err
+	}
+	if latestVer == 0 {
+		if err := s.create(repo, before, before.UserID, 1, "Initial version"); err != nil {
+			return err
+		}
+		latestVer = 1
+	}
+	return s.create(repo, after, userID, latestVer+1, changeNote)
+}
 
+// create stores bookmark as version number and drops versions beyond the
+// retention limit.
+func (s *versionService) create(repo repository.VersionRepository, bookmark *model.Bookmark, userID uuid.UUID, number int, changeNote string) error {
 	version := &model.BookmarkVersion{
 		BookmarkID:  bookmark.ID,
 		UserID:      userID,
@@ -74,8 +133,70 @@ func (s *versionService) CreateVersion(bookmark *model.Bookmark, userID uuid.UUI
 		Description: bookmark.Description,
 		TargetURL:   bookmark.TargetURL,
 		Metadata:    bookmark.Metadata,
-		Version:     latestVer + 1,
+		Version:     number,
 		ChangeNote:  changeNote,
 	}
-	return s.repo.Create(version)
+	if err := repo.Create(version); err != nil {
+		return err
+	}
+
+	if s.retention > 0 && version.Version > s.retention {
+		return repo.DeleteUpTo(bookmark.ID, version.Version-s.retention)
+	}
+	return nil
+}
+
+func (s *versionService) Diff(bookmarkID, fromID, toID uuid.UUID) (*model.VersionDiff, error) {
+	from, err := s.getForBookmark(bookmarkID, fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.getForBookmark(bookmarkID, toID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.VersionDiff{
+		BookmarkID:  bookmarkID,
+		FromVersion: from.Version,
+		ToVersion:   to.Version,
+		Changes:     diffFields(from, to),
+	}, nil
+}
+
+func (s *versionService) getForBookmark(bookmarkID, versionID uuid.UUID) (*model.BookmarkVersion, error) {
+	version, err := s.repo.GetByID(versionID)
+	if err != nil || version.BookmarkID != bookmarkID {
+		return nil, fmt.Errorf("version %w", ErrNotFound)
+	}
+	return version, nil
+}
+
+func versionOf(bookmark *model.Bookmark) *model.BookmarkVersion {
+	return &model.BookmarkVersion{
+		Title:       bookmark.Title,
+		Description: bookmark.Description,
+		TargetURL:   bookmark.TargetURL,
+		Metadata:    bookmark.Metadata,
+	}
+}
+
+func diffFields(from, to *model.BookmarkVersion) []model.FieldChange {
+	fields := []struct {
+		name     string
+		from, to string
+	}{
+		{"title", from.Title, to.Title},
+		{"description", from.Description, to.Description},
+		{"targetUrl", from.TargetURL, to.TargetURL},
+		{"metadata", from.Metadata, to.Metadata},
+	}
+
+	changes := []model.FieldChange{}
+	for _, f := range fields {
+		if f.from != f.to {
+			changes = append(changes, model.FieldChange{Field: f.name, From: f.from, To: f.to})
+		}
+	}
+	return changes
 }